@@ -23,6 +23,246 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Returns a JSON Web Key Set with the RS256 and ES256 keys that verify access tokens, the kid header of a token names its key. HS256 secrets are never published.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Lists the public keys tokens are signed with.",
+                "responses": {
+                    "200": {
+                        "description": "Key set",
+                        "schema": {
+                            "$ref": "#/definitions/managers.JsonWebKeySet"
+                        }
+                    }
+                }
+            }
+        },
+        "/2fa/activate": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Checks a code of the authenticator app against the enrolled secret and returns ten recovery codes, they are shown only this once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "auth"
+                ],
+                "summary": "Turns on two-factor authentication.",
+                "parameters": [
+                    {
+                        "description": "Code of the authenticator app",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/auth.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/2fa/disable": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Needs a code of the authenticator app or a recovery code. Admins can not turn it off while the configuration requires it for them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "auth"
+                ],
+                "summary": "Turns off two-factor authentication.",
+                "parameters": [
+                    {
+                        "description": "Code of the authenticator app or a recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Disable status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Required for admins",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Creates a TOTP secret for the authenticated user and returns it with an otpauth URI to show as a QR code. The login only asks for codes once the enrolment is activated, enrolling again before that replaces the secret.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "auth"
+                ],
+                "summary": "Starts setting up two-factor authentication.",
+                "responses": {
+                    "200": {
+                        "description": "Secret and otpauth URI",
+                        "schema": {
+                            "$ref": "#/definitions/auth.TwoFactorEnrollment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Needs a code of the authenticator app or a recovery code, the earlier recovery codes stop working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "auth"
+                ],
+                "summary": "Replaces the recovery codes.",
+                "parameters": [
+                    {
+                        "description": "Code of the authenticator app or a recovery code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.TwoFactorCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/auth.RecoveryCodes"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/admin/languages": {
             "get": {
                 "security": [
@@ -372,14 +612,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/users": {
+        "/admin/templates": {
             "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Retrieves a list of users that match the specified filter criteria.",
+                "description": "Retrieves every stored version of the prompt templates that match the specified filter criteria.",
                 "consumes": [
                     "application/json"
                 ],
@@ -388,9 +628,9 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "admin",
-                    "users"
+                    "templates"
                 ],
-                "summary": "Reads users based on filter criteria.",
+                "summary": "Reads prompt templates based on filter criteria.",
                 "parameters": [
                     {
                         "type": "array",
@@ -398,12 +638,7 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "csv",
-                        "name": "courseIds",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "name": "emailQuery",
+                        "name": "languageIds",
                         "in": "query"
                     },
                     {
@@ -412,12 +647,7 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "csv",
-                        "name": "ids",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "name": "nameQuery",
+                        "name": "names",
                         "in": "query"
                     },
                     {
@@ -435,9 +665,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Filtered users",
+                        "description": "Filtered templates",
                         "schema": {
-                            "$ref": "#/definitions/pagination.PaginationResponse-entities_User"
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_PromptTemplate"
                         }
                     },
                     "400": {
@@ -453,38 +683,42 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/admin/users/{id}/makeadmin": {
-            "patch": {
+            },
+            "post": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Updates the role of a user to admin using their ID.",
+                "description": "Validates the text/template body and stores it as the next version of the named template for the language, or for every language without a variant when no language is given. The new version takes effect for the next prompts and messages.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "admin"
+                    "admin",
+                    "templates"
                 ],
-                "summary": "Promotes a user to admin status.",
+                "summary": "Stores a new version of a prompt template.",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Create Prompt Template Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/template.CreatePromptTemplateRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully promoted to admin"
+                        "description": "Created template version",
+                        "schema": {
+                            "$ref": "#/definitions/entities.PromptTemplate"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -499,31 +733,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api": {
-            "get": {
-                "description": "serve basic html",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "text/html"
-                ],
-                "tags": [
-                    "util"
-                ],
-                "summary": "Gets home html",
-                "responses": {
-                    "200": {
-                        "description": "OK"
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JwtAuth": []
                     }
-                }
-            }
-        },
-        "/api/healthcheck": {
-            "get": {
-                "description": "Get the status on Config, DB conn, Logging, Services",
+                ],
+                "description": "Stores the body as a new version of the template with the given ID, earlier versions are kept.",
                 "consumes": [
                     "application/json"
                 ],
@@ -531,24 +748,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "util"
+                    "admin",
+                    "templates"
+                ],
+                "summary": "Edits a prompt template.",
+                "parameters": [
+                    {
+                        "description": "Update Prompt Template Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/template.UpdatePromptTemplateRequest"
+                        }
+                    }
                 ],
-                "summary": "Healthcheck",
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "Created template version",
+                        "schema": {
+                            "$ref": "#/definitions/entities.PromptTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/contexts": {
-            "post": {
+        "/admin/templates/{id}": {
+            "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Accepts a payload to create a new context and associates it with the authenticated user if a user ID is not provided.",
+                "description": "Fetches a single stored version of a prompt template.",
                 "consumes": [
                     "application/json"
                 ],
@@ -556,27 +800,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "authorized",
-                    "contexts"
+                    "admin",
+                    "templates"
                 ],
-                "summary": "Creates a new context.",
+                "summary": "Retrieves a prompt template version by ID.",
                 "parameters": [
                     {
-                        "description": "Create Context Request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/context.CreateContextRequest"
-                        }
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Context ID response",
+                        "description": "Template version",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/entities.PromptTemplate"
                         }
                     },
                     "400": {
@@ -592,16 +833,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/contexts/{id}": {
+            },
             "delete": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Deletes the context associated with the provided ID. Only the owner of the context or authorized actions are permitted.",
+                "description": "Deletes a stored version, deleting the latest one puts the previous version or the built in default back in effect.",
                 "consumes": [
                     "application/json"
                 ],
@@ -609,14 +848,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "authorized",
-                    "contexts"
+                    "admin",
+                    "templates"
                 ],
-                "summary": "Deletes a context by ID.",
+                "summary": "Deletes a prompt template version by ID.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Context ID",
+                        "description": "Template ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -645,14 +884,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/documents": {
+        "/admin/usage": {
             "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Fetches a list of user documents that match the specified filter criteria.",
+                "description": "Sums the tokens spent per user and model within the period, the biggest spender first. The user ID filter narrows the report down to a single user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -660,42 +899,85 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "authorized",
-                    "documents"
+                    "admin",
+                    "usage"
                 ],
-                "summary": "Retrieves user documents based on filter criteria.",
+                "summary": "Reports the AI token usage of every user.",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "contextIds",
+                        "type": "string",
+                        "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "name": "extension",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userId",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Usage per user",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/usage.UsageSummary"
+                            }
+                        }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Retrieves a list of users that match the specified filter criteria.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin",
+                    "users"
+                ],
+                "summary": "Reads users based on filter criteria.",
+                "parameters": [
                     {
                         "type": "array",
                         "items": {
                             "type": "string"
                         },
                         "collectionFormat": "csv",
-                        "name": "ids",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "name": "location",
+                        "name": "courseIds",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "name": "name",
+                        "name": "emailQuery",
                         "in": "query"
                     },
                     {
@@ -704,7 +986,12 @@ const docTemplate = `{
                             "type": "string"
                         },
                         "collectionFormat": "csv",
-                        "name": "noteIds",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "nameQuery",
                         "in": "query"
                     },
                     {
@@ -718,22 +1005,13 @@ const docTemplate = `{
                         "name": "size",
                         "in": "query",
                         "required": true
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "userIds",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Filtered documents",
+                        "description": "Filtered users",
                         "schema": {
-                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Document"
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_User"
                         }
                     },
                     "400": {
@@ -749,43 +1027,38 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/users/{id}/makeadmin": {
+            "patch": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Creates a new document associated with the authenticated user, using multipart data for file upload.",
+                "description": "Updates the role of a user to admin using their ID.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
-                    "authorized",
-                    "documents"
+                    "admin"
                 ],
-                "summary": "Creates a user document from multipart data.",
+                "summary": "Promotes a user to admin status.",
                 "parameters": [
                     {
-                        "description": "Create Document Request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/document.CreateDocumentMultipartRequest"
-                        }
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Document ID",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                        "description": "Successfully promoted to admin"
                     },
                     "400": {
                         "description": "Bad Request",
@@ -802,42 +1075,79 @@ const docTemplate = `{
                 }
             }
         },
-        "/documents/bulk": {
-            "post": {
+        "/api": {
+            "get": {
+                "description": "serve basic html",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "util"
+                ],
+                "summary": "Gets home html",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/api/healthcheck": {
+            "get": {
+                "description": "Get the status on Config, DB conn, Logging, Services",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "util"
+                ],
+                "summary": "Healthcheck",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/attempts/{id}": {
+            "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Creates multiple documents for the authenticated user, using multipart data for file uploads.",
+                "description": "Fetches an attempt together with its graded answers, the expected answers and the feedback of the assistant. Only the user who made the attempt or authorized actions are permitted.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "authorized",
-                    "documents"
+                    "quizzes"
                 ],
-                "summary": "Creates multiple user documents from multipart data.",
+                "summary": "Retrieves a graded attempt by ID.",
                 "parameters": [
                     {
-                        "description": "Create Bulk Documents Request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/document.CreateDocumentsMultipartRequest"
-                        }
+                        "type": "integer",
+                        "description": "Attempt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Bulk Document IDs",
+                        "description": "Attempt details",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/entities.QuizAttempt"
                         }
                     },
                     "400": {
@@ -855,43 +1165,91 @@ const docTemplate = `{
                 }
             }
         },
-        "/documents/notes": {
+        "/cards": {
             "post": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Creates documents linked to a specific note, using multipart data for file uploads. The documents will be associated with the note ID.",
+                "description": "Adds a card written by hand to a deck, it is due for review right away. Only the owner of the deck or authorized actions are permitted.",
                 "consumes": [
-                    "multipart/form-data"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "authorized",
-                    "documents",
-                    "notes"
+                    "cards"
                 ],
-                "summary": "Creates note-related documents from multipart data.",
+                "summary": "Creates a new flashcard.",
                 "parameters": [
                     {
-                        "description": "Create Note Documents Request",
+                        "description": "Create Card Request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/document.CreateNoteDocumentsRequest"
+                            "$ref": "#/definitions/card.CreateCardRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Created document IDs",
+                        "description": "Created card",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/entities.Card"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Changes the front or back of a card, its schedule is kept. Only the owner of the card's deck or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "cards"
+                ],
+                "summary": "Updates a card.",
+                "parameters": [
+                    {
+                        "description": "Update Card Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/card.UpdateCardRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated card",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Card"
                         }
                     },
                     "400": {
@@ -909,14 +1267,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/documents/{id}": {
+        "/cards/{id}": {
             "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Fetches the details of a specific user document based on its ID. Only the owner of the document or authorized actions are permitted.",
+                "description": "Fetches a card together with its reviews. Only the owner of the card's deck or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -925,13 +1283,13 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "documents"
+                    "cards"
                 ],
-                "summary": "Retrieves a user document by ID.",
+                "summary": "Retrieves a card by ID.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Document ID",
+                        "description": "Card ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -939,9 +1297,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Document details",
+                        "description": "Card details",
                         "schema": {
-                            "$ref": "#/definitions/document.DocumentWrapped"
+                            "$ref": "#/definitions/entities.Card"
                         }
                     },
                     "400": {
@@ -964,7 +1322,7 @@ const docTemplate = `{
                         "JwtAuth": []
                     }
                 ],
-                "description": "Deletes a specific document for the authenticated user based on document ID.",
+                "description": "Deletes the card together with its reviews. Only the owner of the card's deck or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -973,13 +1331,13 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "documents"
+                    "cards"
                 ],
-                "summary": "Deletes a user document by ID.",
+                "summary": "Deletes a card by ID.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Document ID",
+                        "description": "Card ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -987,7 +1345,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Deletion status",
+                        "description": "Deletion success status",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1008,14 +1366,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/languages": {
-            "get": {
+        "/cards/{id}/review": {
+            "post": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Fetches a list of languages that match the specified filter criteria.",
+                "description": "Records how well the card was recalled, from 0 (forgotten) to 5 (perfect recall), and schedules its next review with SM-2. Only the owner of the card's deck or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1024,65 +1382,32 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "languages"
+                    "cards"
                 ],
-                "summary": "Retrieves languages based on filter criteria.",
+                "summary": "Records a review of a card.",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "name": "alpha2Code",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "courseIds",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "ids",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
-                    },
                     {
                         "type": "integer",
-                        "name": "size",
-                        "in": "query",
+                        "description": "Card ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "userIds",
-                        "in": "query"
+                        "description": "Review Card Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/card.ReviewCardRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Filtered languages",
+                        "description": "Rescheduled card",
                         "schema": {
-                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Language"
+                            "$ref": "#/definitions/entities.Card"
                         }
                     },
                     "400": {
@@ -1100,14 +1425,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/languages/{id}": {
+        "/citations/{id}": {
             "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Fetches the details of a specific language based on its ID.",
+                "description": "Fetches the part of the note or document that an assistant message cites, together with its offsets so it can be highlighted within the source. Only the owner of the citation's context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1116,13 +1441,13 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "languages"
+                    "citations"
                 ],
-                "summary": "Retrieves a language by ID.",
+                "summary": "Retrieves the cited snippet of a citation.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Language ID",
+                        "description": "Citation ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1130,9 +1455,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Language details",
+                        "description": "Cited snippet",
                         "schema": {
-                            "$ref": "#/definitions/entities.Language"
+                            "$ref": "#/definitions/citation.CitationSnippet"
                         }
                     },
                     "400": {
@@ -1150,9 +1475,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/login": {
+        "/contexts": {
             "post": {
-                "description": "Handles user login requests by validating credentials and returning a token.",
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Accepts a payload to create a new context and associates it with the authenticated user if a user ID is not provided.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1160,24 +1490,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "anon",
-                    "auth"
+                    "authorized",
+                    "contexts"
                 ],
-                "summary": "Authenticates a user and generates a token.",
+                "summary": "Creates a new context.",
                 "parameters": [
                     {
-                        "description": "Login Request",
+                        "description": "Create Context Request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/auth.LoginRequest"
+                            "$ref": "#/definitions/context.CreateContextRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Token response",
+                        "description": "Context ID response",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1198,14 +1528,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes": {
-            "get": {
+        "/contexts/{id}": {
+            "delete": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Fetches a list of notes that match the specified filter criteria. Notes will only be retrieved for the authorized user.",
+                "description": "Deletes the context associated with the provided ID. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1214,78 +1544,24 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "notes"
+                    "contexts"
                 ],
-                "summary": "Retrieves notes based on filter criteria.",
+                "summary": "Deletes a context by ID.",
                 "parameters": [
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "contexts",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "documents",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "ids",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "languages",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "name": "page",
-                        "in": "query",
-                        "required": true
-                    },
                     {
                         "type": "integer",
-                        "name": "size",
-                        "in": "query",
+                        "description": "Context ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "name": "users",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Filtered notes",
+                        "description": "Deletion success status",
                         "schema": {
-                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Note"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1301,14 +1577,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/contexts/{id}/messages": {
+            "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Accepts a payload to create a new note and associates it with the authenticated user if a user ID is not provided.",
+                "description": "Fetches the stored user and assistant messages of a context in chronological order. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1317,26 +1595,50 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "notes"
+                    "contexts",
+                    "messages"
                 ],
-                "summary": "Creates a new note.",
+                "summary": "Retrieves the conversation history of a context.",
                 "parameters": [
                     {
-                        "description": "Create Note Request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/note.CreateNoteRequest"
-                        }
-                    }
-                ],
-                "responses": {
+                        "type": "integer",
+                        "description": "Context ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "contextId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "roles",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
                     "200": {
-                        "description": "Note ID response",
+                        "description": "Context messages",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Message"
                         }
                     },
                     "400": {
@@ -1353,13 +1655,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Updates the details of a specific note based on the provided payload. Only the owner of the note or authorized actions are permitted.",
+                "description": "Sends the given message to the AI assistant within the context and returns the stored reply. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1368,25 +1670,34 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "notes"
+                    "contexts",
+                    "messages"
                 ],
-                "summary": "Updates a note.",
+                "summary": "Sends a message to the assistant of a context.",
                 "parameters": [
                     {
-                        "description": "Update Note Request",
+                        "type": "integer",
+                        "description": "Context ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create Message Request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/note.UpdateNoteRequest"
+                            "$ref": "#/definitions/prompt.CreateMessageRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated note details",
+                        "description": "Assistant reply",
                         "schema": {
-                            "$ref": "#/definitions/entities.Note"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1404,39 +1715,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/notes/{id}": {
+        "/contexts/{id}/messages/stream": {
             "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Fetches the details of a specific note based on its ID. Only the owner of the note or authorized actions are permitted.",
+                "description": "Sends the given message to the AI assistant within the context and relays the reply as \"delta\" events followed by a \"done\" event carrying the stored reply, or an \"error\" event. Closing the connection cancels the reply. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
                     "authorized",
-                    "notes"
+                    "contexts",
+                    "messages"
                 ],
-                "summary": "Retrieves a note by ID.",
+                "summary": "Streams the assistant reply to a message as Server-Sent Events.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Note ID",
+                        "description": "Context ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "contextId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "value",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Note details",
+                        "description": "Event stream of the reply",
                         "schema": {
-                            "$ref": "#/definitions/entities.Note"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -1452,14 +1774,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/contexts/{id}/model": {
+            "put": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Deletes the note associated with the provided ID. Only the owner of the note or authorized actions are permitted.",
+                "description": "Routes the later messages of the context to the given provider and model, empty values use the configured defaults. Notes and documents of the context are sent to the new model again while the conversation so far is dropped. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1468,21 +1792,30 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "notes"
+                    "contexts"
                 ],
-                "summary": "Deletes a note by ID.",
+                "summary": "Switches the AI provider and model of a context.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Note ID",
+                        "description": "Context ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Update Context Model Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/context.UpdateContextModelRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Deletion success status",
+                        "description": "Updated context",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1503,9 +1836,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
-            "post": {
-                "description": "Handles user creation requests by accepting a payload and returning the created user ID.",
+        "/contexts/{id}/prompts": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the prompts that were sent to the assistant for a context. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1513,27 +1851,55 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "anon",
-                    "users"
+                    "authorized",
+                    "contexts",
+                    "prompts"
                 ],
-                "summary": "Creates a new user.",
+                "summary": "Retrieves the prompts of a context.",
                 "parameters": [
                     {
-                        "description": "Create User Request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/user.CreateUserRequest"
-                        }
+                        "type": "integer",
+                        "description": "Context ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "contexts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User ID response",
+                        "description": "Context prompts",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Prompt"
                         }
                     },
                     "400": {
@@ -1551,14 +1917,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users": {
-            "patch": {
+        "/contexts/{id}/reset": {
+            "post": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Updates the details of a user based on the provided payload. Only the user themselves or authorized actions are permitted.",
+                "description": "A soft reset drops the conversation so far but keeps the notes and documents the assistant was given. A hard reset also makes the assistant forget every note and document of the context until they are changed again. Stored messages are kept in both modes. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1567,25 +1933,33 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "users"
+                    "contexts"
                 ],
-                "summary": "Updates user information.",
+                "summary": "Resets the assistant conversation of a context.",
                 "parameters": [
                     {
-                        "description": "Update User Request",
+                        "type": "integer",
+                        "description": "Context ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reset Context Request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/user.UpdateUserRequest"
+                            "$ref": "#/definitions/context.ResetContextRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated user details",
+                        "description": "Reset success status",
                         "schema": {
-                            "$ref": "#/definitions/entities.User"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1603,39 +1977,40 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/{id}": {
+        "/contexts/{id}/ws": {
             "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Fetches details of a user based on the provided ID. Only the user themselves or authorized actions are permitted.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Upgrades to a websocket where the client sends {\"type\":\"message\",\"value\":\"...\"} or {\"type\":\"typing\"} and receives context events such as streamed reply deltas, typing/status updates and prompt notifications. Every open tab of the owner receives the same events. The JWT can be given as the \"token\" query parameter since browsers cannot set headers on websockets. Only the owner of the context or authorized actions are permitted.",
                 "tags": [
                     "authorized",
-                    "users"
+                    "contexts",
+                    "messages"
                 ],
-                "summary": "Retrieves a user by ID.",
+                "summary": "Opens a websocket chat channel for a context.",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Context ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "JWT when the Authorization header cannot be set",
+                        "name": "token",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User details",
+                    "101": {
+                        "description": "Switching protocols",
                         "schema": {
-                            "$ref": "#/definitions/entities.User"
+                            "$ref": "#/definitions/event.ContextEvent"
                         }
                     },
                     "400": {
@@ -1651,14 +2026,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/decks": {
+            "get": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Deletes the user associated with the provided ID. Only the user themselves or authorized actions are permitted.",
+                "description": "Fetches the decks of the authenticated user that match the specified filter criteria.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1667,24 +2044,60 @@ const docTemplate = `{
                 ],
                 "tags": [
                     "authorized",
-                    "users"
+                    "decks"
                 ],
-                "summary": "Deletes a user by ID.",
+                "summary": "Retrieves decks based on filter criteria.",
                 "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "contexts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "users",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Deletion success status",
+                        "description": "Filtered decks",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Deck"
                         }
                     },
                     "400": {
@@ -1700,46 +2113,254 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users/{id}/make-non-admin/{role}": {
-            "patch": {
+            },
+            "post": {
                 "security": [
                     {
                         "JwtAuth": []
                     }
                 ],
-                "description": "Updates a user’s role to a non-admin role using their ID and the new role value. Only the user themselves or authorized actions are permitted.",
+                "description": "Creates an empty deck within a context of the authenticated user. Only the owner of the context or authorized actions are permitted.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
                     "authorized",
-                    "users"
+                    "decks"
                 ],
-                "summary": "Changes a user’s role to a non-admin role.",
+                "summary": "Creates a new flashcard deck.",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
+                        "description": "Create Deck Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deck.CreateDeckRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created deck",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Deck"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Renames a deck. Only the owner of the deck or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "decks"
+                ],
+                "summary": "Updates a deck.",
+                "parameters": [
+                    {
+                        "description": "Update Deck Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deck.UpdateDeckRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated deck",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Deck"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/decks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches a deck together with its cards. Only the owner of the deck or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "decks"
+                ],
+                "summary": "Retrieves a deck by ID.",
+                "parameters": [
                     {
                         "type": "integer",
-                        "description": "New role ID",
-                        "name": "role",
+                        "description": "Deck ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully changed role"
+                        "description": "Deck details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Deck"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Deletes the deck together with its cards and their reviews. Only the owner of the deck or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "decks"
+                ],
+                "summary": "Deletes a deck by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Deck ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion success status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/decks/{id}/due": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the cards of the deck whose next review is due, the most overdue first. Only the owner of the deck or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "decks",
+                    "cards"
+                ],
+                "summary": "Retrieves the cards of a deck that are due for review.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Deck ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Due cards",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Card"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1755,147 +2376,4254 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "auth.LoginRequest": {
+        },
+        "/decks/{id}/generate": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Asks the assistant of the deck's context to write flashcards about a note or document of the same context and adds them to the deck. Only the owner of the deck and the source or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "decks",
+                    "cards"
+                ],
+                "summary": "Generates flashcards from a note or document.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Deck ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Generate Cards Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deck.GenerateCardsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Generated cards",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Token budget used up",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Assistant did not answer with cards",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches a list of user documents that match the specified filter criteria.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "documents"
+                ],
+                "summary": "Retrieves user documents based on filter criteria.",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "contextIds",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "extension",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "location",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "noteIds",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "userIds",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Filtered documents",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Document"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Creates a new document associated with the authenticated user, using multipart data for file upload.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "documents"
+                ],
+                "summary": "Creates a user document from multipart data.",
+                "parameters": [
+                    {
+                        "description": "Create Document Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/document.CreateDocumentMultipartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/bulk": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Creates multiple documents for the authenticated user, using multipart data for file uploads.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "documents"
+                ],
+                "summary": "Creates multiple user documents from multipart data.",
+                "parameters": [
+                    {
+                        "description": "Create Bulk Documents Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/document.CreateDocumentsMultipartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Bulk Document IDs",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/notes": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Creates documents linked to a specific note, using multipart data for file uploads. The documents will be associated with the note ID.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "documents",
+                    "notes"
+                ],
+                "summary": "Creates note-related documents from multipart data.",
+                "parameters": [
+                    {
+                        "description": "Create Note Documents Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/document.CreateNoteDocumentsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created document IDs",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the details of a specific user document based on its ID. Only the owner of the document or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "documents"
+                ],
+                "summary": "Retrieves a user document by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document details",
+                        "schema": {
+                            "$ref": "#/definitions/document.DocumentWrapped"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Deletes a specific document for the authenticated user based on document ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "documents"
+                ],
+                "summary": "Deletes a user document by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/documents/{id}/thumbnail": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Streams the JPEG thumbnail generated when the image was uploaded, turned upright according to its EXIF orientation.",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "authorized",
+                    "documents"
+                ],
+                "summary": "Retrieves the thumbnail of an image document.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Thumbnail",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/email/verify": {
+            "post": {
+                "description": "Uses up the token of the verification mail sent on registration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Confirms the email address of a user.",
+                "parameters": [
+                    {
+                        "description": "Verify Email Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verification status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/email/verify/resend": {
+            "post": {
+                "description": "Replaces the earlier verification link with a new one. The answer is the same whether or not an account has the email, so it can not be used to find accounts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Sends the verification mail again.",
+                "parameters": [
+                    {
+                        "description": "Resend Verification Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ResendVerificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Mail could not be delivered",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/equations": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the equations of the authenticated user that match the specified filter criteria.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "equations"
+                ],
+                "summary": "Retrieves equations based on filter criteria.",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "contexts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "notes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "users",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Filtered equations",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Equation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Validates the LaTeX of an equation, renders it to MathML and plain text and gives it to the assistant of its context. The equation can be placed in a note of the context. Only the owner of the context and the note or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "equations"
+                ],
+                "summary": "Creates a new equation.",
+                "parameters": [
+                    {
+                        "description": "Create Equation Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/equation.CreateEquationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created equation",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Changes the LaTeX or label of an equation, new LaTeX is validated and rendered again. Only the owner of the equation or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "equations"
+                ],
+                "summary": "Updates an equation.",
+                "parameters": [
+                    {
+                        "description": "Update Equation Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/equation.UpdateEquationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated equation",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Equation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/equations/render": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Validates the given LaTeX and returns its MathML and plain text forms, for previews while an equation is written.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "equations"
+                ],
+                "summary": "Renders LaTeX without saving it.",
+                "parameters": [
+                    {
+                        "description": "Render Equation Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/equation.RenderEquationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rendered equation",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/equations/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches an equation with its LaTeX source, MathML and plain text forms. Only the owner of the equation or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "equations"
+                ],
+                "summary": "Retrieves an equation by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Equation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Equation details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Equation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Deletes the equation and lets the assistant of its context forget it. Only the owner of the equation or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "equations"
+                ],
+                "summary": "Deletes an equation by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Equation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion success status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/languages": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches a list of languages that match the specified filter criteria.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "languages"
+                ],
+                "summary": "Retrieves languages based on filter criteria.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "name": "alpha2Code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "courseIds",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "userIds",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Filtered languages",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Language"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/languages/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the details of a specific language based on its ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "languages"
+                ],
+                "summary": "Retrieves a language by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Language ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Language details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Language"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Handles user login requests by validating credentials and returning a token. Users with two-factor authentication get a challenge token instead, which is sent with their code to /login/2fa.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Authenticates a user and generates a token.",
+                "parameters": [
+                    {
+                        "description": "Login Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token pair or two-factor challenge",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login/2fa": {
+            "post": {
+                "description": "Exchanges the challenge token of the login and a code of the authenticator app or a recovery code for a token pair. The challenge is valid for five minutes and for one code, after a wrong code the login starts over. Too many wrong codes lock the second factor for a while.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Finishes a login with two-factor authentication.",
+                "parameters": [
+                    {
+                        "description": "Two-Factor Login Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.TwoFactorLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Revokes the access token of the request and every refresh token of its session.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "auth"
+                ],
+                "summary": "Ends the current session.",
+                "responses": {
+                    "200": {
+                        "description": "Logout status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout/all": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Revokes the refresh tokens of all sessions of the user and the access tokens issued with them, including the one of the request.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "auth"
+                ],
+                "summary": "Ends every session of the authenticated user.",
+                "responses": {
+                    "200": {
+                        "description": "Logout status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches a list of notes that match the specified filter criteria. Notes will only be retrieved for the authorized user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "notes"
+                ],
+                "summary": "Retrieves notes based on filter criteria.",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "contexts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "documents",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "languages",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "users",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Filtered notes",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Accepts a payload to create a new note and associates it with the authenticated user if a user ID is not provided.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "notes"
+                ],
+                "summary": "Creates a new note.",
+                "parameters": [
+                    {
+                        "description": "Create Note Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/note.CreateNoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Note ID response",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Updates the details of a specific note based on the provided payload. Only the owner of the note or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "notes"
+                ],
+                "summary": "Updates a note.",
+                "parameters": [
+                    {
+                        "description": "Update Note Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/note.UpdateNoteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated note details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/notes/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the details of a specific note based on its ID. Only the owner of the note or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "notes"
+                ],
+                "summary": "Retrieves a note by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Note details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Note"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Deletes the note associated with the provided ID. Only the owner of the note or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "notes"
+                ],
+                "summary": "Deletes a note by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Note ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion success status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/providers": {
+            "get": {
+                "description": "Returns the names of the configured providers, each one is logged in with at /oidc/{provider}/login.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Lists the OpenID Connect providers users can log in with.",
+                "responses": {
+                    "200": {
+                        "description": "Provider names",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/{provider}/callback": {
+            "get": {
+                "description": "Redeems the code the provider sent back and logs in the user of the identity. An identity seen for the first time is linked to the user with its email, or a new user is created, as long as the provider verified the email. Users with two-factor authentication get a challenge token, which is sent with their code to /login/2fa.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Finishes a login at an OpenID Connect provider.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State of the login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Error sent by the provider",
+                        "name": "error",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token pair or two-factor challenge",
+                        "schema": {
+                            "$ref": "#/definitions/auth.LoginResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Email not verified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Provider unreachable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/oidc/{provider}/login": {
+            "get": {
+                "description": "Redirects to the provider with an authorization code request secured by PKCE. The provider sends the user back to the configured redirect url, which has to lead to /oidc/{provider}/callback. The login has to be finished within ten minutes.",
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Starts a login at an OpenID Connect provider.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown provider",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Provider unreachable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "Sends a link that is valid for an hour and can be used once, earlier links stop working. The answer is the same whether or not an account has the email, so it can not be used to find accounts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Mails a password reset link.",
+                "parameters": [
+                    {
+                        "description": "Forgot Password Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Request status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Mail could not be delivered",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "The new password has to pass the password policy, the token stays valid when it does not. Every session of the user is ended.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Sets a new password with the token of a reset mail.",
+                "parameters": [
+                    {
+                        "description": "Reset Password Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reset status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/prompts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the details of a specific prompt based on its ID. Only the owner of the prompt's context or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "prompts"
+                ],
+                "summary": "Retrieves a prompt by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Prompt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Prompt details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Prompt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Deletes the prompt associated with the provided ID. Only the owner of the prompt's context or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "prompts"
+                ],
+                "summary": "Deletes a prompt by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Prompt ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion success status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the quizzes of the authenticated user that match the specified filter criteria.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "quizzes"
+                ],
+                "summary": "Retrieves quizzes based on filter criteria.",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "contexts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "users",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Filtered quizzes",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Asks the assistant of the context to write multiple choice and short answer questions about the notes and documents of the context. Answers stay hidden until an attempt is graded. Only the owner of the context or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "quizzes"
+                ],
+                "summary": "Generates a quiz from the material of a context.",
+                "parameters": [
+                    {
+                        "description": "Generate Quiz Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/quiz.GenerateQuizRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Generated quiz",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Token budget used up",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Assistant did not answer with questions",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches a quiz together with its questions, without their answers. Only the owner of the quiz or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "quizzes"
+                ],
+                "summary": "Retrieves a quiz by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quiz details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.Quiz"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Deletes the quiz together with its questions and attempts. Only the owner of the quiz or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "quizzes"
+                ],
+                "summary": "Deletes a quiz by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion success status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/quizzes/{id}/attempts": {
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Grades the given answers, multiple choice answers against the correct option and short answers with the assistant of the quiz's context, and records the score. Only the owner of the quiz or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "quizzes"
+                ],
+                "summary": "Submits and grades an attempt at a quiz.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Quiz ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Submit Attempt Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/quiz.SubmitAttemptRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Graded attempt",
+                        "schema": {
+                            "$ref": "#/definitions/entities.QuizAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Token budget used up",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "502": {
+                        "description": "Assistant did not answer with a grade",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Handles user creation requests by accepting a payload and returning the created user ID. The email has to be a bare address and the password has to pass the password policy, a mail with a verification link is sent to the address.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "users"
+                ],
+                "summary": "Creates a new user.",
+                "parameters": [
+                    {
+                        "description": "Create User Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User ID response",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Email is taken",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Rotates the refresh token, the given one can not be used again. Using a refresh token a second time ends its session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "anon",
+                    "auth"
+                ],
+                "summary": "Exchanges a refresh token for a new token pair.",
+                "parameters": [
+                    {
+                        "description": "Refresh Token Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token response",
+                        "schema": {
+                            "$ref": "#/definitions/auth.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "patch": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Updates the details of a user based on the provided payload. Only the user themselves or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users"
+                ],
+                "summary": "Updates user information.",
+                "parameters": [
+                    {
+                        "description": "Update User Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated user details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches details of a user based on the provided ID. Only the user themselves or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users"
+                ],
+                "summary": "Retrieves a user by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User details",
+                        "schema": {
+                            "$ref": "#/definitions/entities.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Deletes the user associated with the provided ID. Only the user themselves or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users"
+                ],
+                "summary": "Deletes a user by ID.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion success status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Returns name, prefix, scopes and last use of every key, the secrets can not be read again. Only the user themselves is permitted and API keys can not manage keys.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users",
+                    "auth"
+                ],
+                "summary": "Lists the API keys of a user.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API keys",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entities.ApiKey"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Returns the key once, it is sent as \"Authorization: ApiKey \u003ckey\u003e\". Scopes are a resource and an access like \"notes:write\" or \"documents:read\", writing does not include reading. The resources are notes, documents, equations, contexts, prompts, citations, decks, cards, quizzes, attempts and languages.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users",
+                    "auth"
+                ],
+                "summary": "Creates an API key for scripts and integrations.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create API Key Request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/user.CreateApiKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Created key",
+                        "schema": {
+                            "$ref": "#/definitions/auth.CreatedApiKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/api-keys/{keyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "The key stops working right away. Only the user themselves is permitted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users",
+                    "auth"
+                ],
+                "summary": "Revokes an API key.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "API Key ID",
+                        "name": "keyId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deletion success status",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/attempts": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Fetches the graded attempts of the user, oldest first, optionally only those of the given quizzes. Only the user themselves or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users",
+                    "quizzes"
+                ],
+                "summary": "Retrieves the quiz score history of a user.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "name": "page",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "name": "quizzes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "name": "size",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Score history",
+                        "schema": {
+                            "$ref": "#/definitions/pagination.PaginationResponse-entities_QuizAttempt"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/make-non-admin/{role}": {
+            "patch": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Updates a user’s role to a non-admin role using their ID and the new role value. Only the user themselves or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "authorized",
+                    "users"
+                ],
+                "summary": "Changes a user’s role to a non-admin role.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "New role ID",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully changed role"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/usage": {
+            "get": {
+                "security": [
+                    {
+                        "JwtAuth": []
+                    }
+                ],
+                "description": "Sums the tokens spent by the user per model within the period, together with the budget of their role and how much of it is used today and this month. Only the user themselves or authorized actions are permitted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "authorized",
+                    "users",
+                    "usage"
+                ],
+                "summary": "Retrieves the AI token usage of a user.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User usage",
+                        "schema": {
+                            "$ref": "#/definitions/usage.UserUsage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.CreatedApiKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginRequest": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "ChallengeExpiresIn": {
+                    "description": "Seconds left to send the code with the challenge, named apart from ExpiresIn of the tokens as both share the login response",
+                    "type": "integer"
+                },
+                "ChallengeToken": {
+                    "type": "string"
+                },
+                "ExpiresIn": {
+                    "description": "Seconds until the access token expires",
+                    "type": "integer"
+                },
+                "RefreshToken": {
+                    "type": "string"
+                },
+                "Token": {
+                    "type": "string"
+                },
+                "TwoFactorRequired": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "auth.RecoveryCodes": {
+            "type": "object",
+            "properties": {
+                "codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "auth.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refreshToken"
+            ],
+            "properties": {
+                "refreshToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ResendVerificationRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.TokenPair": {
+            "type": "object",
+            "properties": {
+                "ExpiresIn": {
+                    "description": "Seconds until the access token expires",
+                    "type": "integer"
+                },
+                "RefreshToken": {
+                    "type": "string"
+                },
+                "Token": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.TwoFactorEnrollment": {
+            "type": "object",
+            "properties": {
+                "otpauthUri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.TwoFactorLoginRequest": {
+            "type": "object",
+            "required": [
+                "challengeToken",
+                "code"
+            ],
+            "properties": {
+                "challengeToken": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "auth.VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "card.CreateCardRequest": {
+            "type": "object",
+            "properties": {
+                "back": {
+                    "type": "string"
+                },
+                "deckId": {
+                    "type": "string"
+                },
+                "front": {
+                    "type": "string"
+                }
+            }
+        },
+        "card.ReviewCardRequest": {
+            "type": "object",
+            "properties": {
+                "grade": {
+                    "type": "integer"
+                }
+            }
+        },
+        "card.UpdateCardRequest": {
+            "type": "object",
+            "properties": {
+                "back": {
+                    "type": "string"
+                },
+                "front": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "citation.CitationSnippet": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "endOffset": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "messageId": {
+                    "type": "string"
+                },
+                "number": {
+                    "description": "Number of the excerpt as the assistant saw it, \"[1]\" is 1",
+                    "type": "integer"
+                },
+                "section": {
+                    "type": "string"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "sourceId": {
+                    "type": "string"
+                },
+                "sourceType": {
+                    "$ref": "#/definitions/entities.SourceType"
+                },
+                "startOffset": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "context.CreateContextRequest": {
+            "type": "object",
+            "properties": {
+                "languageID": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "context.ResetContextRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string"
+                }
+            }
+        },
+        "context.UpdateContextModelRequest": {
+            "type": "object",
+            "properties": {
+                "model": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
+        "deck.CreateDeckRequest": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "deck.GenerateCardsRequest": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "sourceId": {
+                    "type": "string"
+                },
+                "sourceType": {
+                    "type": "string"
+                }
+            }
+        },
+        "deck.UpdateDeckRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "document.CreateDocumentMultipartRequest": {
+            "type": "object"
+        },
+        "document.CreateDocumentsMultipartRequest": {
+            "type": "object"
+        },
+        "document.CreateNoteDocumentsRequest": {
+            "type": "object"
+        },
+        "document.DocumentWrapped": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "$ref": "#/definitions/entities.DocumentImage"
+                },
+                "isReadableByAll": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "noteId": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.DocumentSection"
+                    }
+                },
+                "thumbnailPath": {
+                    "description": "Only set for images",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.ApiKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.AttemptAnswer": {
+            "type": "object",
+            "properties": {
+                "attemptId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "expected": {
+                    "description": "Correct option or answer of the question, shown once the answer is graded",
+                    "type": "string"
+                },
+                "feedback": {
+                    "description": "Why a short answer was graded the way it was",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isCorrect": {
+                    "type": "boolean"
+                },
+                "questionId": {
+                    "type": "string"
+                },
+                "selectedOption": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Card": {
+            "type": "object",
+            "properties": {
+                "back": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deckId": {
+                    "type": "string"
+                },
+                "dueAt": {
+                    "type": "string"
+                },
+                "easeFactor": {
+                    "type": "number"
+                },
+                "front": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "intervalDays": {
+                    "type": "integer"
+                },
+                "lastReviewedAt": {
+                    "type": "string"
+                },
+                "repetitions": {
+                    "description": "Scheduling state, a new card is due right away",
+                    "type": "integer"
+                },
+                "reviews": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.CardReview"
+                    }
+                },
+                "sourceId": {
+                    "type": "string"
+                },
+                "sourceType": {
+                    "description": "Note or document the card was generated from, empty for cards written by hand",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entities.SourceType"
+                        }
+                    ]
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.CardReview": {
+            "type": "object",
+            "properties": {
+                "cardId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "easeFactor": {
+                    "type": "number"
+                },
+                "grade": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "intervalDays": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Citation": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "endOffset": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "messageId": {
+                    "type": "string"
+                },
+                "number": {
+                    "description": "Number of the excerpt as the assistant saw it, \"[1]\" is 1",
+                    "type": "integer"
+                },
+                "section": {
+                    "type": "string"
+                },
+                "sourceId": {
+                    "type": "string"
+                },
+                "sourceType": {
+                    "$ref": "#/definitions/entities.SourceType"
+                },
+                "startOffset": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Context": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Document"
+                    }
+                },
+                "externalId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "languageId": {
+                    "type": "string"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Message"
+                    }
+                },
+                "model": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Note"
+                    }
+                },
+                "prompts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Prompt"
+                    }
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Deck": {
+            "type": "object",
+            "properties": {
+                "cards": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Card"
+                    }
+                },
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Document": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "image": {
+                    "$ref": "#/definitions/entities.DocumentImage"
+                },
+                "isReadableByAll": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "noteId": {
+                    "type": "string"
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.DocumentSection"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.DocumentImage": {
+            "type": "object",
+            "properties": {
+                "cameraMake": {
+                    "type": "string"
+                },
+                "cameraModel": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "documentId": {
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "mimeType": {
+                    "type": "string"
+                },
+                "orientation": {
+                    "type": "integer"
+                },
+                "takenAt": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.DocumentSection": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "documentId": {
+                    "type": "string"
+                },
+                "endOffset": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "startOffset": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Equation": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "latex": {
+                    "type": "string"
+                },
+                "mathml": {
+                    "description": "Rendered from Latex whenever it is saved",
+                    "type": "string"
+                },
+                "noteId": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Language": {
+            "type": "object",
+            "properties": {
+                "alpha2Code": {
+                    "type": "string"
+                },
+                "alpha3Code": {
+                    "type": "string"
+                },
+                "contexts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Context"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Note"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.User"
+                    }
+                }
+            }
+        },
+        "entities.Message": {
+            "type": "object",
+            "properties": {
+                "citations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Citation"
+                    }
+                },
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isCompacted": {
+                    "description": "Compacted turns stay in the history but are no longer part of the conversation the assistant sees",
+                    "type": "boolean"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "promptTokens": {
+                    "type": "integer"
+                },
+                "role": {
+                    "$ref": "#/definitions/entities.MessageRole"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.MessageRole": {
+            "type": "string",
+            "enum": [
+                "user",
+                "assistant"
+            ],
+            "x-enum-varnames": [
+                "UserMessage",
+                "AssistantMessage"
+            ]
+        },
+        "entities.Note": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Document"
+                    }
+                },
+                "equations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Equation"
+                    }
+                },
+                "header": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "languageId": {
+                    "type": "string"
+                },
+                "payload": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Password": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Prompt": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "entityId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isSummary": {
+                    "description": "Summary of the compacted turns of the context, there is at most one per context",
+                    "type": "boolean"
+                },
+                "subject": {
+                    "description": "What the assistant was told to remember, so it can be told to forget the same thing",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.PromptTemplate": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "languageId": {
+                    "description": "Empty for the template used by every language without a variant of its own",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.Question": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/entities.QuestionKind"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "options": {
+                    "description": "Only multiple choice questions have options",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "quizId": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.QuestionKind": {
+            "type": "string",
+            "enum": [
+                "multipleChoice",
+                "shortAnswer"
+            ],
+            "x-enum-varnames": [
+                "MultipleChoiceQuestion",
+                "ShortAnswerQuestion"
+            ]
+        },
+        "entities.Quiz": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "questions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Question"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.QuizAttempt": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.AttemptAnswer"
+                    }
+                },
+                "correctCount": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "questionCount": {
+                    "type": "integer"
+                },
+                "quizId": {
+                    "type": "string"
+                },
+                "score": {
+                    "description": "Percentage of the questions answered correctly",
+                    "type": "number"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Role": {
+            "type": "integer",
+            "enum": [
+                1,
+                2
+            ],
+            "x-enum-varnames": [
+                "Admin",
+                "Customer"
+            ]
+        },
+        "entities.SourceType": {
+            "type": "string",
+            "enum": [
+                "note",
+                "document"
+            ],
+            "x-enum-varnames": [
+                "NoteSource",
+                "DocumentSource"
+            ]
+        },
+        "entities.User": {
+            "type": "object",
+            "properties": {
+                "contexts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Context"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "documents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Document"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "emailVerifiedAt": {
+                    "description": "Nil until the user opens the link of the verification mail",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "languages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Language"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "notes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Note"
+                    }
+                },
+                "password": {
+                    "$ref": "#/definitions/entities.Password"
+                },
+                "role": {
+                    "$ref": "#/definitions/entities.Role"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "equation.CreateEquationRequest": {
+            "type": "object",
+            "properties": {
+                "contextId": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "latex": {
+                    "type": "string"
+                },
+                "noteId": {
+                    "type": "string"
+                },
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "equation.RenderEquationRequest": {
+            "type": "object",
+            "properties": {
+                "latex": {
+                    "type": "string"
+                }
+            }
+        },
+        "equation.UpdateEquationRequest": {
             "type": "object",
             "properties": {
-                "password": {
+                "id": {
                     "type": "string"
                 },
-                "username": {
+                "label": {
+                    "type": "string"
+                },
+                "latex": {
                     "type": "string"
                 }
             }
         },
-        "context.CreateContextRequest": {
+        "event.ContextEvent": {
+            "description": "Event pushed to every open websocket of a context",
             "type": "object",
             "properties": {
-                "languageID": {
+                "contextId": {
                     "type": "string"
                 },
-                "userID": {
-                    "type": "string"
+                "payload": {},
+                "type": {
+                    "$ref": "#/definitions/event.EventType"
                 }
             }
         },
-        "document.CreateDocumentMultipartRequest": {
-            "type": "object"
-        },
-        "document.CreateDocumentsMultipartRequest": {
-            "type": "object"
-        },
-        "document.CreateNoteDocumentsRequest": {
-            "type": "object"
+        "event.EventType": {
+            "type": "string",
+            "enum": [
+                "delta",
+                "message",
+                "typing",
+                "status",
+                "promptAdded",
+                "promptUpdated",
+                "promptRemoved",
+                "contextReset",
+                "error"
+            ],
+            "x-enum-varnames": [
+                "Delta",
+                "Message",
+                "Typing",
+                "Status",
+                "PromptAdded",
+                "PromptUpdated",
+                "PromptRemoved",
+                "ContextReset",
+                "Error"
+            ]
         },
-        "document.DocumentWrapped": {
+        "language.CreateLanguageRequest": {
             "type": "object",
             "properties": {
-                "contextId": {
-                    "type": "string"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "extension": {
+                "alpha2Code": {
                     "type": "string"
                 },
-                "id": {
+                "alpha3Code": {
                     "type": "string"
                 },
-                "isReadableByAll": {
-                    "type": "boolean"
-                },
-                "location": {
+                "icon": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
-                },
-                "noteId": {
+                }
+            }
+        },
+        "language.UpdateLanguageRequest": {
+            "type": "object",
+            "properties": {
+                "alpha2code": {
                     "type": "string"
                 },
-                "path": {
+                "alpha3code": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "id": {
                     "type": "string"
                 },
-                "userId": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "entities.Context": {
+        "managers.JsonWebKey": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "alg": {
                     "type": "string"
                 },
-                "documents": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entities.Document"
-                    }
+                "crv": {
+                    "type": "string"
                 },
-                "externalId": {
+                "e": {
                     "type": "string"
                 },
-                "id": {
+                "kid": {
                     "type": "string"
                 },
-                "languageId": {
+                "kty": {
                     "type": "string"
                 },
-                "notes": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entities.Note"
-                    }
+                "n": {
+                    "type": "string"
                 },
-                "prompts": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entities.Prompt"
-                    }
+                "use": {
+                    "type": "string"
                 },
-                "updatedAt": {
+                "x": {
                     "type": "string"
                 },
-                "userId": {
+                "y": {
                     "type": "string"
                 }
             }
         },
-        "entities.Document": {
+        "managers.JsonWebKeySet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/managers.JsonWebKey"
+                    }
+                }
+            }
+        },
+        "note.CreateNoteRequest": {
             "type": "object",
             "properties": {
                 "contextId": {
                     "type": "string"
                 },
-                "createdAt": {
+                "header": {
                     "type": "string"
                 },
-                "extension": {
+                "languageId": {
                     "type": "string"
                 },
-                "id": {
+                "payload": {
                     "type": "string"
                 },
-                "isReadableByAll": {
-                    "type": "boolean"
-                },
-                "location": {
+                "userId": {
+                    "type": "string"
+                }
+            }
+        },
+        "note.UpdateNoteRequest": {
+            "type": "object",
+            "properties": {
+                "header": {
                     "type": "string"
                 },
-                "name": {
+                "id": {
                     "type": "string"
                 },
-                "noteId": {
+                "languageId": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "payload": {
                     "type": "string"
                 },
                 "userId": {
@@ -1903,236 +6631,281 @@ const docTemplate = `{
                 }
             }
         },
-        "entities.Language": {
+        "pagination.PaginationResponse-entities_Card": {
             "type": "object",
             "properties": {
-                "alpha2Code": {
-                    "type": "string"
-                },
-                "alpha3Code": {
-                    "type": "string"
-                },
-                "contexts": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Context"
+                        "$ref": "#/definitions/entities.Card"
                     }
                 },
-                "createdAt": {
-                    "type": "string"
-                },
-                "icon": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "size": {
+                    "type": "integer"
                 },
-                "notes": {
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.PaginationResponse-entities_Deck": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Note"
+                        "$ref": "#/definitions/entities.Deck"
                     }
                 },
-                "updatedAt": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "users": {
+                "size": {
+                    "type": "integer"
+                },
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.PaginationResponse-entities_Document": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.User"
+                        "$ref": "#/definitions/entities.Document"
                     }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "totalCount": {
+                    "type": "integer"
                 }
             }
         },
-        "entities.Note": {
+        "pagination.PaginationResponse-entities_Equation": {
             "type": "object",
             "properties": {
-                "contextId": {
-                    "type": "string"
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Equation"
+                    }
                 },
-                "createdAt": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "documents": {
+                "size": {
+                    "type": "integer"
+                },
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.PaginationResponse-entities_Language": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Document"
+                        "$ref": "#/definitions/entities.Language"
                     }
                 },
-                "header": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string"
+                "size": {
+                    "type": "integer"
                 },
-                "languageId": {
-                    "type": "string"
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.PaginationResponse-entities_Message": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Message"
+                    }
                 },
-                "payload": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "updatedAt": {
-                    "type": "string"
+                "size": {
+                    "type": "integer"
                 },
-                "userId": {
-                    "type": "string"
+                "totalCount": {
+                    "type": "integer"
                 }
             }
         },
-        "entities.Password": {
+        "pagination.PaginationResponse-entities_Note": {
             "type": "object",
             "properties": {
-                "createdAt": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Note"
+                    }
                 },
-                "password": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "updatedAt": {
-                    "type": "string"
+                "size": {
+                    "type": "integer"
                 },
-                "userId": {
-                    "type": "string"
+                "totalCount": {
+                    "type": "integer"
                 }
             }
         },
-        "entities.Prompt": {
+        "pagination.PaginationResponse-entities_Prompt": {
             "type": "object",
             "properties": {
-                "contextId": {
-                    "type": "string"
-                },
-                "createdAt": {
-                    "type": "string"
-                },
-                "entityId": {
-                    "type": "string"
+                "content": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Prompt"
+                    }
                 },
-                "id": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "updatedAt": {
-                    "type": "string"
+                "size": {
+                    "type": "integer"
                 },
-                "value": {
-                    "type": "string"
+                "totalCount": {
+                    "type": "integer"
                 }
             }
         },
-        "entities.Role": {
-            "type": "integer",
-            "enum": [
-                1,
-                2
-            ],
-            "x-enum-varnames": [
-                "Admin",
-                "Customer"
-            ]
-        },
-        "entities.User": {
+        "pagination.PaginationResponse-entities_PromptTemplate": {
             "type": "object",
             "properties": {
-                "contexts": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Context"
+                        "$ref": "#/definitions/entities.PromptTemplate"
                     }
                 },
-                "createdAt": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "documents": {
+                "size": {
+                    "type": "integer"
+                },
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.PaginationResponse-entities_Quiz": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Document"
+                        "$ref": "#/definitions/entities.Quiz"
                     }
                 },
-                "email": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "id": {
-                    "type": "string"
+                "size": {
+                    "type": "integer"
                 },
-                "languages": {
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.PaginationResponse-entities_QuizAttempt": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Language"
+                        "$ref": "#/definitions/entities.QuizAttempt"
                     }
                 },
-                "name": {
-                    "type": "string"
+                "page": {
+                    "type": "integer"
                 },
-                "notes": {
+                "size": {
+                    "type": "integer"
+                },
+                "totalCount": {
+                    "type": "integer"
+                }
+            }
+        },
+        "pagination.PaginationResponse-entities_User": {
+            "type": "object",
+            "properties": {
+                "content": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Note"
+                        "$ref": "#/definitions/entities.User"
                     }
                 },
-                "password": {
-                    "$ref": "#/definitions/entities.Password"
+                "page": {
+                    "type": "integer"
                 },
-                "role": {
-                    "$ref": "#/definitions/entities.Role"
+                "size": {
+                    "type": "integer"
                 },
-                "updatedAt": {
-                    "type": "string"
+                "totalCount": {
+                    "type": "integer"
                 }
             }
         },
-        "language.CreateLanguageRequest": {
+        "prompt.CreateMessageRequest": {
             "type": "object",
             "properties": {
-                "alpha2Code": {
-                    "type": "string"
-                },
-                "alpha3Code": {
-                    "type": "string"
-                },
-                "icon": {
+                "contextId": {
                     "type": "string"
                 },
-                "name": {
+                "value": {
                     "type": "string"
                 }
             }
         },
-        "language.UpdateLanguageRequest": {
+        "quiz.AnswerRequest": {
             "type": "object",
             "properties": {
-                "alpha2code": {
-                    "type": "string"
-                },
-                "alpha3code": {
+                "questionId": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
+                "selectedOption": {
+                    "type": "integer"
                 },
-                "name": {
+                "text": {
                     "type": "string"
                 }
             }
         },
-        "note.CreateNoteRequest": {
+        "quiz.GenerateQuizRequest": {
             "type": "object",
             "properties": {
                 "contextId": {
                     "type": "string"
                 },
-                "header": {
-                    "type": "string"
-                },
-                "languageId": {
-                    "type": "string"
+                "questionCount": {
+                    "type": "integer"
                 },
-                "payload": {
+                "title": {
                     "type": "string"
                 },
                 "userId": {
@@ -2140,114 +6913,161 @@ const docTemplate = `{
                 }
             }
         },
-        "note.UpdateNoteRequest": {
+        "quiz.SubmitAttemptRequest": {
             "type": "object",
             "properties": {
-                "header": {
-                    "type": "string"
-                },
-                "id": {
+                "answers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/quiz.AnswerRequest"
+                    }
+                }
+            }
+        },
+        "template.CreatePromptTemplateRequest": {
+            "type": "object",
+            "required": [
+                "body",
+                "name"
+            ],
+            "properties": {
+                "body": {
                     "type": "string"
                 },
                 "languageId": {
                     "type": "string"
                 },
-                "payload": {
-                    "type": "string"
-                },
-                "userId": {
+                "name": {
                     "type": "string"
                 }
             }
         },
-        "operation.Operation": {
-            "type": "integer",
-            "enum": [
-                0,
-                1
+        "template.UpdatePromptTemplateRequest": {
+            "type": "object",
+            "required": [
+                "body",
+                "id"
             ],
-            "x-enum-varnames": [
-                "Insert",
-                "Delete"
-            ]
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
         },
-        "pagination.PaginationResponse-entities_Document": {
+        "usage.ModelUsage": {
             "type": "object",
             "properties": {
-                "content": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entities.Document"
-                    }
+                "calls": {
+                    "type": "integer"
                 },
-                "page": {
+                "completionTokens": {
                     "type": "integer"
                 },
-                "size": {
+                "model": {
+                    "type": "string"
+                },
+                "promptTokens": {
                     "type": "integer"
                 },
-                "totalCount": {
+                "totalTokens": {
                     "type": "integer"
                 }
             }
         },
-        "pagination.PaginationResponse-entities_Language": {
+        "usage.UsageSummary": {
             "type": "object",
             "properties": {
-                "content": {
+                "calls": {
+                    "type": "integer"
+                },
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "models": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Language"
+                        "$ref": "#/definitions/usage.ModelUsage"
                     }
                 },
-                "page": {
+                "promptTokens": {
                     "type": "integer"
                 },
-                "size": {
-                    "type": "integer"
+                "to": {
+                    "type": "string"
                 },
-                "totalCount": {
+                "totalTokens": {
                     "type": "integer"
+                },
+                "userId": {
+                    "type": "string"
                 }
             }
         },
-        "pagination.PaginationResponse-entities_Note": {
+        "usage.UserUsage": {
             "type": "object",
             "properties": {
-                "content": {
+                "calls": {
+                    "type": "integer"
+                },
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "dailyLimit": {
+                    "type": "integer"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "models": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Note"
+                        "$ref": "#/definitions/usage.ModelUsage"
                     }
                 },
-                "page": {
+                "monthlyLimit": {
                     "type": "integer"
                 },
-                "size": {
+                "promptTokens": {
                     "type": "integer"
                 },
-                "totalCount": {
+                "to": {
+                    "type": "string"
+                },
+                "totalTokens": {
+                    "type": "integer"
+                },
+                "usedThisMonth": {
+                    "type": "integer"
+                },
+                "usedToday": {
                     "type": "integer"
+                },
+                "userId": {
+                    "type": "string"
                 }
             }
         },
-        "pagination.PaginationResponse-entities_User": {
+        "user.CreateApiKeyRequest": {
             "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
             "properties": {
-                "content": {
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.User"
+                        "type": "string"
                     }
-                },
-                "page": {
-                    "type": "integer"
-                },
-                "size": {
-                    "type": "integer"
-                },
-                "totalCount": {
-                    "type": "integer"
                 }
             }
         },
@@ -2271,36 +7091,8 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
-                "languageOps": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "properties": {
-                            "op": {
-                                "$ref": "#/definitions/operation.Operation"
-                            },
-                            "val": {
-                                "$ref": "#/definitions/entities.Language"
-                            }
-                        }
-                    }
-                },
                 "name": {
                     "type": "string"
-                },
-                "noteOps": {
-                    "type": "array",
-                    "items": {
-                        "type": "object",
-                        "properties": {
-                            "op": {
-                                "$ref": "#/definitions/operation.Operation"
-                            },
-                            "val": {
-                                "$ref": "#/definitions/entities.Note"
-                            }
-                        }
-                    }
                 }
             }
         }
@@ -3,8 +3,10 @@ package handlers
 import (
 	"echo-api/models/dtos/requests/language"
 	"echo-api/models/dtos/requests/note"
+	"echo-api/models/dtos/requests/template"
 	"echo-api/models/dtos/requests/user"
 	_ "echo-api/models/dtos/responses/pagination"
+	_ "echo-api/models/dtos/responses/usage"
 	"echo-api/services"
 	"echo-api/util"
 	"errors"
@@ -18,10 +20,12 @@ type AdminHandlers struct {
 	userService     *services.UserService
 	noteService     *services.NoteService
 	languageService *services.LanguageService
+	usageService    *services.UsageService
+	templateService *services.PromptTemplateService
 }
 
-func InitializeAdminHandlers(logger *util.Logger, us *services.UserService, ns *services.NoteService, ls *services.LanguageService) *AdminHandlers {
-	return &AdminHandlers{logger: logger, userService: us, noteService: ns, languageService: ls}
+func InitializeAdminHandlers(logger *util.Logger, us *services.UserService, ns *services.NoteService, ls *services.LanguageService, uss *services.UsageService, pts *services.PromptTemplateService) *AdminHandlers {
+	return &AdminHandlers{logger: logger, userService: us, noteService: ns, languageService: ls, usageService: uss, templateService: pts}
 }
 
 func (h *AdminHandlers) ConfigureRoutes(api *gin.RouterGroup) {
@@ -33,6 +37,12 @@ func (h *AdminHandlers) ConfigureRoutes(api *gin.RouterGroup) {
 	api.POST("/languages", h.CreateLanguage)
 	api.PATCH("/languages", h.UpdateLanguage)
 	api.DELETE("/languages/:id", h.DeleteLanguage)
+	api.GET("/usage", h.ReadUsageReport)
+	api.GET("/templates", h.ReadPromptTemplateWithFilter)
+	api.GET("/templates/:id", h.ReadPromptTemplateWithID)
+	api.POST("/templates", h.CreatePromptTemplate)
+	api.PATCH("/templates", h.UpdatePromptTemplate)
+	api.DELETE("/templates/:id", h.DeletePromptTemplate)
 }
 
 // @BasePath /admin
@@ -253,3 +263,193 @@ func (h *AdminHandlers) UpdateLanguage(c *gin.Context) {
 
 	c.JSON(http.StatusOK, language)
 }
+
+// ReadUsageReport godoc
+// @Summary Reports the AI token usage of every user.
+// @Schemes
+// @Description Sums the tokens spent per user and model within the period, the biggest spender first. The user ID filter narrows the report down to a single user.
+// @Security JwtAuth
+// @Tags admin, usage
+// @Accept json
+// @Produce json
+// @Param filter query user.FilterUsageRequest false "Period, defaults to the current month"
+// @Param userId query string false "User ID"
+// @Success 200 {array} usage.UsageSummary "Usage per user"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/usage [get]
+func (h *AdminHandlers) ReadUsageReport(c *gin.Context) {
+	var request user.FilterUsageRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.UserID = c.Query("userId")
+
+	report, err := h.usageService.Report(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ReadPromptTemplateWithFilter godoc
+// @Summary Reads prompt templates based on filter criteria.
+// @Schemes
+// @Description Retrieves every stored version of the prompt templates that match the specified filter criteria.
+// @Security JwtAuth
+// @Tags admin, templates
+// @Accept json
+// @Produce json
+// @Param filter query template.FilterPromptTemplatesRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.PromptTemplate] "Filtered templates"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/templates [get]
+func (h *AdminHandlers) ReadPromptTemplateWithFilter(c *gin.Context) {
+	var request template.FilterPromptTemplatesRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	templates, err := h.templateService.FilterAll(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// ReadPromptTemplateWithID godoc
+// @Summary Retrieves a prompt template version by ID.
+// @Schemes
+// @Description Fetches a single stored version of a prompt template.
+// @Security JwtAuth
+// @Tags admin, templates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} entities.PromptTemplate "Template version"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/templates/{id} [get]
+func (h *AdminHandlers) ReadPromptTemplateWithID(c *gin.Context) {
+	id := c.Param("id")
+
+	template, err := h.templateService.GetOne(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// CreatePromptTemplate godoc
+// @Summary Stores a new version of a prompt template.
+// @Schemes
+// @Description Validates the text/template body and stores it as the next version of the named template for the language, or for every language without a variant when no language is given. The new version takes effect for the next prompts and messages.
+// @Security JwtAuth
+// @Tags admin, templates
+// @Accept json
+// @Produce json
+// @Param request body template.CreatePromptTemplateRequest true "Create Prompt Template Request"
+// @Success 200 {object} entities.PromptTemplate "Created template version"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/templates [post]
+func (h *AdminHandlers) CreatePromptTemplate(c *gin.Context) {
+	var request template.CreatePromptTemplateRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.templateService.CreateOne(request)
+	if err != nil {
+		h.abortOnTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdatePromptTemplate godoc
+// @Summary Edits a prompt template.
+// @Schemes
+// @Description Stores the body as a new version of the template with the given ID, earlier versions are kept.
+// @Security JwtAuth
+// @Tags admin, templates
+// @Accept json
+// @Produce json
+// @Param request body template.UpdatePromptTemplateRequest true "Update Prompt Template Request"
+// @Success 200 {object} entities.PromptTemplate "Created template version"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/templates [patch]
+func (h *AdminHandlers) UpdatePromptTemplate(c *gin.Context) {
+	var request template.UpdatePromptTemplateRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	template, err := h.templateService.UpdateOne(request)
+	if err != nil {
+		h.abortOnTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// DeletePromptTemplate godoc
+// @Summary Deletes a prompt template version by ID.
+// @Schemes
+// @Description Deletes a stored version, deleting the latest one puts the previous version or the built in default back in effect.
+// @Security JwtAuth
+// @Tags admin, templates
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Success 200 {object} map[string]interface{} "Deletion success status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /admin/templates/{id} [delete]
+func (h *AdminHandlers) DeletePromptTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	ok, err := h.templateService.DeleteOne(id)
+	if err != nil || !ok {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
+}
+
+func (h *AdminHandlers) abortOnTemplateError(c *gin.Context, err error) {
+	h.logger.Err(err)
+	switch err.Error() {
+	case "templateErrorUnknownName", "templateErrorInvalidBody":
+		c.AbortWithError(http.StatusBadRequest, err)
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
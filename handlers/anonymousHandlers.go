@@ -1,28 +1,45 @@
 package handlers
 
 import (
+	_ "echo-api/managers"
 	"echo-api/models/dtos/requests/auth"
 	"echo-api/models/dtos/requests/user"
+	_ "echo-api/models/dtos/responses/auth"
 	"echo-api/services"
 	"echo-api/util"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AnonymousHandlers struct {
-	logger      *util.Logger
-	authService *services.AuthService
-	userService *services.UserService
+	logger         *util.Logger
+	authService    *services.AuthService
+	userService    *services.UserService
+	accountService *services.AccountService
+	oidcService    *services.OidcService
 }
 
-func InitializeAnonymousHandlers(logger *util.Logger, us *services.UserService, as *services.AuthService) *AnonymousHandlers {
-	return &AnonymousHandlers{logger: logger, userService: us, authService: as}
+func InitializeAnonymousHandlers(logger *util.Logger, us *services.UserService, as *services.AuthService, acs *services.AccountService, os *services.OidcService) *AnonymousHandlers {
+	return &AnonymousHandlers{logger: logger, userService: us, authService: as, accountService: acs, oidcService: os}
 }
 
 func (h *AnonymousHandlers) ConfigureRoutes(api *gin.RouterGroup) {
 	api.POST("/login", h.Login)
+	api.POST("/login/2fa", h.LoginWithSecondFactor)
+	api.GET("/oidc/providers", h.ReadOidcProviders)
+	api.GET("/oidc/:provider/login", h.BeginOidcLogin)
+	api.GET("/oidc/:provider/callback", h.CompleteOidcLogin)
 	api.POST("/register", h.CreateUser)
+	api.POST("/token/refresh", h.RefreshToken)
+	api.GET("/.well-known/jwks.json", h.ReadJwks)
+	api.POST("/email/verify", h.VerifyEmail)
+	api.POST("/email/verify/resend", h.ResendEmailVerification)
+	api.POST("/password/forgot", h.ForgotPassword)
+	api.POST("/password/reset", h.ResetPassword)
 }
 
 // @BasePath
@@ -30,12 +47,12 @@ func (h *AnonymousHandlers) ConfigureRoutes(api *gin.RouterGroup) {
 // Login godoc
 // @Summary Authenticates a user and generates a token.
 // @Schemes
-// @Description Handles user login requests by validating credentials and returning a token.
+// @Description Handles user login requests by validating credentials and returning a token. Users with two-factor authentication get a challenge token instead, which is sent with their code to /login/2fa.
 // @Tags anon, auth
 // @Accept json
 // @Produce json
 // @Param request body auth.LoginRequest true "Login Request"
-// @Success 200 {object} map[string]interface{} "Token response"
+// @Success 200 {object} auth.LoginResponse "Token pair or two-factor challenge"
 // @Failure 400 {object} string "Bad Request"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /login [post]
@@ -48,7 +65,7 @@ func (h *AnonymousHandlers) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.Login(request)
+	tokens, err := h.authService.Login(request)
 	if err != nil {
 		h.logger.Err(err)
 		if err.Error() != "passwordIncorrect" {
@@ -59,19 +76,181 @@ func (h *AnonymousHandlers) Login(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]any{"Token": token})
+	c.JSON(http.StatusOK, tokens)
+}
+
+// LoginWithSecondFactor godoc
+// @Summary Finishes a login with two-factor authentication.
+// @Schemes
+// @Description Exchanges the challenge token of the login and a code of the authenticator app or a recovery code for a token pair. The challenge is valid for five minutes and for one code, after a wrong code the login starts over. Too many wrong codes lock the second factor for a while.
+// @Tags anon, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorLoginRequest true "Two-Factor Login Request"
+// @Success 200 {object} auth.TokenPair "Token response"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 429 {object} string "Too many wrong codes"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /login/2fa [post]
+func (h *AnonymousHandlers) LoginWithSecondFactor(c *gin.Context) {
+	var request auth.TwoFactorLoginRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	tokens, err := h.authService.LoginWithSecondFactor(request)
+	if err != nil {
+		msg := h.logger.Err(err)
+		switch err.Error() {
+		case "tokenErrorChallengeInvalid", "twoFactorErrorInvalidCode", "twoFactorErrorNotEnrolled":
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{"error": msg})
+		case "twoFactorErrorLocked":
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, map[string]any{"error": msg})
+		default:
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// ReadOidcProviders godoc
+// @Summary Lists the OpenID Connect providers users can log in with.
+// @Schemes
+// @Description Returns the names of the configured providers, each one is logged in with at /oidc/{provider}/login.
+// @Tags anon, auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Provider names"
+// @Router /oidc/providers [get]
+func (h *AnonymousHandlers) ReadOidcProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, map[string]any{"providers": h.oidcService.Providers()})
+}
+
+// BeginOidcLogin godoc
+// @Summary Starts a login at an OpenID Connect provider.
+// @Schemes
+// @Description Redirects to the provider with an authorization code request secured by PKCE. The provider sends the user back to the configured redirect url, which has to lead to /oidc/{provider}/callback. The login has to be finished within ten minutes.
+// @Tags anon, auth
+// @Param provider path string true "Provider name"
+// @Success 302 {object} string "Redirect to the provider"
+// @Failure 404 {object} string "Unknown provider"
+// @Failure 502 {object} string "Provider unreachable"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /oidc/{provider}/login [get]
+func (h *AnonymousHandlers) BeginOidcLogin(c *gin.Context) {
+	url, err := h.oidcService.Begin(c.Param("provider"))
+	if err != nil {
+		h.abortOnOidcError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// CompleteOidcLogin godoc
+// @Summary Finishes a login at an OpenID Connect provider.
+// @Schemes
+// @Description Redeems the code the provider sent back and logs in the user of the identity. An identity seen for the first time is linked to the user with its email, or a new user is created, as long as the provider verified the email. Users with two-factor authentication get a challenge token, which is sent with their code to /login/2fa.
+// @Tags anon, auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string false "Authorization code"
+// @Param state query string true "State of the login"
+// @Param error query string false "Error sent by the provider"
+// @Success 200 {object} auth.LoginResponse "Token pair or two-factor challenge"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Email not verified"
+// @Failure 404 {object} string "Unknown provider"
+// @Failure 502 {object} string "Provider unreachable"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /oidc/{provider}/callback [get]
+func (h *AnonymousHandlers) CompleteOidcLogin(c *gin.Context) {
+	if c.Query("error") != "" {
+		h.logger.Debug().Msg(fmt.Sprintf("AnonymousHandlers_CompleteOidcLogin provider answered with: %s", c.Query("error")))
+		h.abortOnOidcError(c, errors.New("oidcErrorDenied"))
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.AbortWithStatusJSON(http.StatusBadRequest, map[string]any{"error": "Code and state are required"})
+		return
+	}
+
+	res, err := h.authService.LoginWithOidc(c.Param("provider"), code, state)
+	if err != nil {
+		h.abortOnOidcError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// RefreshToken godoc
+// @Summary Exchanges a refresh token for a new token pair.
+// @Schemes
+// @Description Rotates the refresh token, the given one can not be used again. Using a refresh token a second time ends its session.
+// @Tags anon, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.RefreshTokenRequest true "Refresh Token Request"
+// @Success 200 {object} auth.TokenPair "Token response"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /token/refresh [post]
+func (h *AnonymousHandlers) RefreshToken(c *gin.Context) {
+	var request auth.RefreshTokenRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	tokens, err := h.authService.Refresh(request)
+	if err != nil {
+		msg := h.logger.Err(err)
+		switch err.Error() {
+		case "tokenErrorRefreshInvalid", "tokenErrorRefreshExpired", "tokenErrorRefreshReused":
+			c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{"error": msg})
+		default:
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// ReadJwks godoc
+// @Summary Lists the public keys tokens are signed with.
+// @Schemes
+// @Description Returns a JSON Web Key Set with the RS256 and ES256 keys that verify access tokens, the kid header of a token names its key. HS256 secrets are never published.
+// @Tags anon, auth
+// @Produce json
+// @Success 200 {object} managers.JsonWebKeySet "Key set"
+// @Router /.well-known/jwks.json [get]
+func (h *AnonymousHandlers) ReadJwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.PublicKeys())
 }
 
 // CreateUser godoc
 // @Summary Creates a new user.
 // @Schemes
-// @Description Handles user creation requests by accepting a payload and returning the created user ID.
+// @Description Handles user creation requests by accepting a payload and returning the created user ID. The email has to be a bare address and the password has to pass the password policy, a mail with a verification link is sent to the address.
 // @Tags anon, users
 // @Accept json
 // @Produce json
 // @Param request body user.CreateUserRequest true "Create User Request"
 // @Success 200 {object} map[string]interface{} "User ID response"
 // @Failure 400 {object} string "Bad Request"
+// @Failure 409 {object} string "Email is taken"
 // @Failure 500 {object} string "Internal Server Error"
 // @Router /register [post]
 func (h *AnonymousHandlers) CreateUser(c *gin.Context) {
@@ -85,10 +264,162 @@ func (h *AnonymousHandlers) CreateUser(c *gin.Context) {
 
 	id, err := h.userService.CreateOne(request)
 	if err != nil {
-		h.logger.Err(err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		h.abortOnAccountError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, map[string]any{"id": id})
 }
+
+// VerifyEmail godoc
+// @Summary Confirms the email address of a user.
+// @Schemes
+// @Description Uses up the token of the verification mail sent on registration.
+// @Tags anon, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.VerifyEmailRequest true "Verify Email Request"
+// @Success 200 {object} map[string]interface{} "Verification status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /email/verify [post]
+func (h *AnonymousHandlers) VerifyEmail(c *gin.Context) {
+	var request auth.VerifyEmailRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.accountService.VerifyEmail(request)
+	if err != nil {
+		h.abortOnAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
+// ResendEmailVerification godoc
+// @Summary Sends the verification mail again.
+// @Schemes
+// @Description Replaces the earlier verification link with a new one. The answer is the same whether or not an account has the email, so it can not be used to find accounts.
+// @Tags anon, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ResendVerificationRequest true "Resend Verification Request"
+// @Success 200 {object} map[string]interface{} "Request status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 503 {object} string "Mail could not be delivered"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /email/verify/resend [post]
+func (h *AnonymousHandlers) ResendEmailVerification(c *gin.Context) {
+	var request auth.ResendVerificationRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.accountService.ResendEmailVerification(request)
+	if err != nil {
+		h.abortOnAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
+// ForgotPassword godoc
+// @Summary Mails a password reset link.
+// @Schemes
+// @Description Sends a link that is valid for an hour and can be used once, earlier links stop working. The answer is the same whether or not an account has the email, so it can not be used to find accounts.
+// @Tags anon, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ForgotPasswordRequest true "Forgot Password Request"
+// @Success 200 {object} map[string]interface{} "Request status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 503 {object} string "Mail could not be delivered"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /password/forgot [post]
+func (h *AnonymousHandlers) ForgotPassword(c *gin.Context) {
+	var request auth.ForgotPasswordRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.accountService.ForgotPassword(request)
+	if err != nil {
+		h.abortOnAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
+// ResetPassword godoc
+// @Summary Sets a new password with the token of a reset mail.
+// @Schemes
+// @Description The new password has to pass the password policy, the token stays valid when it does not. Every session of the user is ended.
+// @Tags anon, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.ResetPasswordRequest true "Reset Password Request"
+// @Success 200 {object} map[string]interface{} "Reset status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /password/reset [post]
+func (h *AnonymousHandlers) ResetPassword(c *gin.Context) {
+	var request auth.ResetPasswordRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	err = h.accountService.ResetPassword(request)
+	if err != nil {
+		h.abortOnAccountError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
+func (h *AnonymousHandlers) abortOnOidcError(c *gin.Context, err error) {
+	msg := h.logger.Err(err)
+	switch err.Error() {
+	case "oidcErrorUnknownProvider":
+		c.AbortWithStatusJSON(http.StatusNotFound, map[string]any{"error": msg})
+	case "oidcErrorDenied", "oidcErrorStateInvalid", "oidcErrorExchangeFailed", "oidcErrorInvalidIdToken":
+		c.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{"error": msg})
+	case "oidcErrorEmailMissing", "oidcErrorEmailNotVerified", "oidcErrorAccountNotVerified", "userErrorInvalidEmail":
+		c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{"error": msg})
+	case "oidcErrorProviderUnreachable":
+		c.AbortWithStatusJSON(http.StatusBadGateway, map[string]any{"error": msg})
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// The message tells the user what to change, like which rule their password breaks
+func (h *AnonymousHandlers) abortOnAccountError(c *gin.Context, err error) {
+	msg := h.logger.Err(err)
+	switch {
+	case strings.HasPrefix(err.Error(), "passwordError"), strings.HasPrefix(err.Error(), "accountError"), err.Error() == "userErrorInvalidEmail":
+		c.AbortWithStatusJSON(http.StatusBadRequest, map[string]any{"error": msg})
+	case err.Error() == "userErrorEmailTaken":
+		c.AbortWithStatusJSON(http.StatusConflict, map[string]any{"error": msg})
+	case err.Error() == "mailErrorDeliveryFailed":
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, map[string]any{"error": msg})
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
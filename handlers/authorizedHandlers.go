@@ -1,43 +1,89 @@
 package handlers
 
 import (
+	gocontext "context"
+	"echo-api/models/dtos/requests/auth"
+	"echo-api/models/dtos/requests/card"
 	"echo-api/models/dtos/requests/context"
+	"echo-api/models/dtos/requests/deck"
 	"echo-api/models/dtos/requests/document"
+	"echo-api/models/dtos/requests/equation"
 	"echo-api/models/dtos/requests/language"
+	"echo-api/models/dtos/requests/message"
 	"echo-api/models/dtos/requests/note"
 	"echo-api/models/dtos/requests/prompt"
+	"echo-api/models/dtos/requests/quiz"
 	"echo-api/models/dtos/requests/user"
+	_ "echo-api/models/dtos/responses/auth"
+	_ "echo-api/models/dtos/responses/citation"
+	documentResponse "echo-api/models/dtos/responses/document"
+	"echo-api/models/dtos/responses/event"
 	_ "echo-api/models/dtos/responses/pagination"
+	_ "echo-api/models/dtos/responses/usage"
+	"echo-api/models/entities"
 	"echo-api/services"
 	"echo-api/util"
 	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
+const webSocketPingPeriod = 30 * time.Second
+const webSocketPongWait = 60 * time.Second
+const webSocketWriteWait = 10 * time.Second
+
+// Messages waiting for their reply on one connection, more are refused until the queue drains
+const webSocketQueuedMessages = 1
+
 type AuthorizedHandlers struct {
-	logger          *util.Logger
-	authService     *services.AuthService
-	userService     *services.UserService
-	noteService     *services.NoteService
-	languageService *services.LanguageService
-	documentService *services.DocumentService
-	contextService  *services.ContextService
-	promptService   *services.PromptService
+	logger           *util.Logger
+	authService      *services.AuthService
+	userService      *services.UserService
+	noteService      *services.NoteService
+	languageService  *services.LanguageService
+	documentService  *services.DocumentService
+	contextService   *services.ContextService
+	promptService    *services.PromptService
+	hubService       *services.HubService
+	citationService  *services.CitationService
+	usageService     *services.UsageService
+	deckService      *services.DeckService
+	cardService      *services.CardService
+	quizService      *services.QuizService
+	equationService  *services.EquationService
+	twoFactorService *services.TwoFactorService
+	apiKeyService    *services.ApiKeyService
+	upgrader         websocket.Upgrader
 }
 
-func InitializeAuthorizedHandlers(logger *util.Logger, us *services.UserService, as *services.AuthService, ns *services.NoteService, ls *services.LanguageService, ds *services.DocumentService, cs *services.ContextService, ps *services.PromptService) *AuthorizedHandlers {
-	return &AuthorizedHandlers{logger: logger, userService: us, authService: as, noteService: ns, languageService: ls, documentService: ds, contextService: cs, promptService: ps}
+func InitializeAuthorizedHandlers(logger *util.Logger, us *services.UserService, as *services.AuthService, ns *services.NoteService, ls *services.LanguageService, ds *services.DocumentService, cs *services.ContextService, ps *services.PromptService, hs *services.HubService, cis *services.CitationService, uss *services.UsageService, dks *services.DeckService, cds *services.CardService, qs *services.QuizService, es *services.EquationService, tfs *services.TwoFactorService, aks *services.ApiKeyService) *AuthorizedHandlers {
+	// Origins are not restricted, same as the CORS middleware
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	return &AuthorizedHandlers{logger: logger, userService: us, authService: as, noteService: ns, languageService: ls, documentService: ds, contextService: cs, promptService: ps, hubService: hs, citationService: cis, usageService: uss, deckService: dks, cardService: cds, quizService: qs, equationService: es, twoFactorService: tfs, apiKeyService: aks, upgrader: upgrader}
 }
 
 func (h *AuthorizedHandlers) ConfigureRoutes(api *gin.RouterGroup) {
+	api.POST("/logout", h.Logout)
+	api.POST("/logout/all", h.LogoutAll)
+	api.POST("/2fa/enroll", h.EnrollTwoFactor)
+	api.POST("/2fa/activate", h.ActivateTwoFactor)
+	api.POST("/2fa/disable", h.DisableTwoFactor)
+	api.POST("/2fa/recovery-codes", h.RegenerateRecoveryCodes)
 	api.GET("/users/:id", h.ReadUserWithID)
 	api.PATCH("/users", h.UpdateUser)
 	api.DELETE("users/:id", h.DeleteUser)
+	api.GET("/users/:id/usage", h.ReadUserUsage)
+	api.GET("/users/:id/attempts", h.ReadUserAttempts)
 	api.PATCH("/users/:id/:role", h.MakeUserNonAdmin)
+	api.GET("/users/:id/api-keys", h.ReadUserApiKeys)
+	api.POST("/users/:id/api-keys", h.CreateUserApiKey)
+	api.DELETE("/users/:id/api-keys/:keyId", h.DeleteUserApiKey)
 
 	api.POST("/notes", h.CreateNote)
 	api.GET("/notes/:id", h.ReadNoteWithID)
@@ -46,17 +92,59 @@ func (h *AuthorizedHandlers) ConfigureRoutes(api *gin.RouterGroup) {
 	api.PATCH("/notes/document", h.CreateNoteDocuments)
 	api.DELETE("/notes/:id", h.DeleteNote)
 
+	api.POST("/equations", h.CreateEquation)
+	api.POST("/equations/render", h.RenderEquation)
+	api.GET("/equations/:id", h.ReadEquationWithID)
+	api.GET("/equations", h.ReadEquationWithFilter)
+	api.PATCH("/equations", h.UpdateEquation)
+	api.DELETE("/equations/:id", h.DeleteEquation)
+
 	api.GET("/languages/:id", h.ReadLanguageWithID)
 	api.GET("/languages", h.ReadLanguageWithFilter)
 
 	api.POST("/documents", h.CreateUserDocument)
 	api.POST("/documents/bulk", h.CreateUserDocumentBulk)
 	api.GET("/documents/:id", h.ReadUserDocumentWithID)
+	api.GET("/documents/:id/thumbnail", h.ReadDocumentThumbnail)
 	api.GET("/documents", h.ReadUserDocumentWithFilter)
 	api.DELETE("/documents/:id", h.DeleteDocument)
 
 	api.POST("/contexts", h.CreateContext)
 	api.POST("/contexts/:id", h.DeleteContext)
+	api.PUT("/contexts/:id/model", h.UpdateContextModel)
+	api.POST("/contexts/:id/reset", h.ResetContext)
+	api.GET("/contexts/:id/messages", h.ReadContextMessages)
+	api.POST("/contexts/:id/messages", h.CreateContextMessage)
+	api.GET("/contexts/:id/messages/stream", h.StreamContextMessage)
+	api.GET("/contexts/:id/prompts", h.ReadContextPrompts)
+	api.GET("/contexts/:id/ws", h.ContextWebSocket)
+
+	api.GET("/prompts/:id", h.ReadPromptWithID)
+	api.DELETE("/prompts/:id", h.DeletePrompt)
+
+	api.GET("/citations/:id", h.ReadCitationSnippet)
+
+	api.POST("/decks", h.CreateDeck)
+	api.GET("/decks/:id", h.ReadDeckWithID)
+	api.GET("/decks", h.ReadDeckWithFilter)
+	api.PATCH("/decks", h.UpdateDeck)
+	api.DELETE("/decks/:id", h.DeleteDeck)
+	api.POST("/decks/:id/generate", h.GenerateDeckCards)
+	api.GET("/decks/:id/due", h.ReadDueCards)
+
+	api.POST("/cards", h.CreateCard)
+	api.GET("/cards/:id", h.ReadCardWithID)
+	api.PATCH("/cards", h.UpdateCard)
+	api.DELETE("/cards/:id", h.DeleteCard)
+	api.POST("/cards/:id/review", h.ReviewCard)
+
+	api.POST("/quizzes", h.GenerateQuiz)
+	api.GET("/quizzes/:id", h.ReadQuizWithID)
+	api.GET("/quizzes", h.ReadQuizWithFilter)
+	api.DELETE("/quizzes/:id", h.DeleteQuiz)
+	api.POST("/quizzes/:id/attempts", h.SubmitQuizAttempt)
+
+	api.GET("/attempts/:id", h.ReadAttemptWithID)
 }
 
 // @BasePath /admin
@@ -90,6 +178,44 @@ func (h *AuthorizedHandlers) ReadUserWithID(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// ReadUserUsage godoc
+// @Summary Retrieves the AI token usage of a user.
+// @Schemes
+// @Description Sums the tokens spent by the user per model within the period, together with the budget of their role and how much of it is used today and this month. Only the user themselves or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, users, usage
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param filter query user.FilterUsageRequest false "Period, defaults to the current month"
+// @Success 200 {object} usage.UserUsage "User usage"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id}/usage [get]
+func (h *AuthorizedHandlers) ReadUserUsage(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "User") {
+		return
+	}
+	var request user.FilterUsageRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.UserID = id
+
+	res, err := h.usageService.GetUserUsage(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
 // DeleteUser godoc
 // @Summary Deletes a user by ID.
 // @Schemes
@@ -191,6 +317,98 @@ func (h *AuthorizedHandlers) MakeUserNonAdmin(c *gin.Context) {
 	c.String(http.StatusOK, "")
 }
 
+// ReadUserApiKeys godoc
+// @Summary Lists the API keys of a user.
+// @Schemes
+// @Description Returns name, prefix, scopes and last use of every key, the secrets can not be read again. Only the user themselves is permitted and API keys can not manage keys.
+// @Security JwtAuth
+// @Tags authorized, users, auth
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {array} entities.ApiKey "API keys"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id}/api-keys [get]
+func (h *AuthorizedHandlers) ReadUserApiKeys(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "User") {
+		return
+	}
+
+	keys, err := h.apiKeyService.GetAll(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateUserApiKey godoc
+// @Summary Creates an API key for scripts and integrations.
+// @Schemes
+// @Description Returns the key once, it is sent as "Authorization: ApiKey <key>". Scopes are a resource and an access like "notes:write" or "documents:read", writing does not include reading. The resources are notes, documents, equations, contexts, prompts, citations, decks, cards, quizzes, attempts and languages.
+// @Security JwtAuth
+// @Tags authorized, users, auth
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body user.CreateApiKeyRequest true "Create API Key Request"
+// @Success 200 {object} auth.CreatedApiKey "Created key"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id}/api-keys [post]
+func (h *AuthorizedHandlers) CreateUserApiKey(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "User") {
+		return
+	}
+	var request user.CreateApiKeyRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.UserID = id
+
+	res, err := h.apiKeyService.Create(request)
+	if err != nil {
+		h.abortOnApiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// DeleteUserApiKey godoc
+// @Summary Revokes an API key.
+// @Schemes
+// @Description The key stops working right away. Only the user themselves is permitted.
+// @Security JwtAuth
+// @Tags authorized, users, auth
+// @Produce json
+// @Param id path int true "User ID"
+// @Param keyId path int true "API Key ID"
+// @Success 200 {object} map[string]interface{} "Deletion success status"
+// @Failure 404 {object} string "Not Found"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id}/api-keys/{keyId} [delete]
+func (h *AuthorizedHandlers) DeleteUserApiKey(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "User") {
+		return
+	}
+
+	err := h.apiKeyService.Revoke(id, c.Param("keyId"))
+	if err != nil {
+		h.abortOnApiKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
 // ReadNoteWithID godoc
 // @Summary Retrieves a note by ID.
 // @Schemes
@@ -280,6 +498,9 @@ func (h *AuthorizedHandlers) CreateNote(c *gin.Context) {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
+	if request.ContextID != "" && !h.isUserActingOnSelf(c, request.ContextID, "Context") {
+		return
+	}
 	userID, err := h.getUserIDFromJwt(c)
 	if err != nil {
 		h.logger.Err(err)
@@ -297,6 +518,7 @@ func (h *AuthorizedHandlers) CreateNote(c *gin.Context) {
 	_, err = h.sendPrompt(note.ContextID, note.ID, note)
 	if err != nil {
 		c.JSON(http.StatusOK, map[string]any{"note": note, "aiError": err.Error()})
+		return
 	}
 	c.JSON(http.StatusOK, map[string]any{"note": note})
 }
@@ -331,6 +553,7 @@ func (h *AuthorizedHandlers) DeleteNote(c *gin.Context) {
 	err = h.deletePrompt("", id)
 	if err != nil {
 		c.JSON(http.StatusOK, map[string]any{"isOk": ok, "aiError": err.Error()})
+		return
 	}
 	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
 }
@@ -371,6 +594,7 @@ func (h *AuthorizedHandlers) UpdateNote(c *gin.Context) {
 	_, err = h.updatePrompt(note.ContextID, note.ID, note)
 	if err != nil {
 		c.JSON(http.StatusOK, map[string]any{"value": note, "aiError": err.Error()})
+		return
 	}
 	c.JSON(http.StatusOK, note)
 }
@@ -463,6 +687,41 @@ func (h *AuthorizedHandlers) ReadUserDocumentWithID(c *gin.Context) {
 	c.JSON(http.StatusOK, document)
 }
 
+// ReadDocumentThumbnail godoc
+// @Summary Retrieves the thumbnail of an image document.
+// @Schemes
+// @Description Streams the JPEG thumbnail generated when the image was uploaded, turned upright according to its EXIF orientation.
+// @Security JwtAuth
+// @Tags authorized, documents
+// @Produce jpeg
+// @Param id path string true "Document ID"
+// @Success 200 {file} binary "Thumbnail"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /documents/{id}/thumbnail [get]
+func (h *AuthorizedHandlers) ReadDocumentThumbnail(c *gin.Context) {
+	id := c.Param("id")
+
+	if !h.isUserActingOnSelf(c, id, "Document") {
+		return
+	}
+
+	f, err := h.documentService.GetThumbnail(id)
+	if err != nil {
+		h.abortOnDocumentError(c, err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, info.Size(), "image/jpeg", f, nil)
+}
+
 // ReadUserDocumentWithFilter godoc
 // @Summary Retrieves user documents based on filter criteria.
 // @Schemes
@@ -523,6 +782,9 @@ func (h *AuthorizedHandlers) CreateUserDocument(c *gin.Context) {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
+	if !h.isUserActingOnSelf(c, request.ContextID, "Context") {
+		return
+	}
 	userID, err := h.getUserIDFromJwt(c)
 	if err != nil {
 		h.logger.Err(err)
@@ -534,14 +796,14 @@ func (h *AuthorizedHandlers) CreateUserDocument(c *gin.Context) {
 
 	doc, err := h.documentService.CreateOneFromMultipart(request)
 	if err != nil {
-		h.logger.Err(err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		h.abortOnDocumentError(c, err)
 		return
 	}
 
-	_, err = h.sendPrompt(doc.ContextID, doc.ID, doc)
+	_, err = h.sendDocumentPrompt(doc)
 	if err != nil {
 		c.JSON(http.StatusOK, map[string]any{"doc": doc, "aiError": err.Error()})
+		return
 	}
 	c.JSON(http.StatusOK, map[string]any{"doc": doc})
 }
@@ -567,6 +829,9 @@ func (h *AuthorizedHandlers) CreateUserDocumentBulk(c *gin.Context) {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
+	if !h.isUserActingOnSelf(c, request.ContextID, "Context") {
+		return
+	}
 	userID, err := h.getUserIDFromJwt(c)
 	if err != nil {
 		h.logger.Err(err)
@@ -578,15 +843,14 @@ func (h *AuthorizedHandlers) CreateUserDocumentBulk(c *gin.Context) {
 
 	docs, err := h.documentService.CreateBulkFromMultipart(request)
 	if err != nil {
-		h.logger.Err(err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		h.abortOnDocumentError(c, err)
 		return
 	}
 
 	ids := make([]string, len(docs))
 	errs := make(map[string]string)
 	for i, doc := range docs {
-		_, err = h.sendPrompt(doc.ContextID, doc.ID, doc)
+		_, err = h.sendDocumentPrompt(doc)
 		if err != nil {
 			errs[doc.ID] = err.Error()
 		}
@@ -630,6 +894,7 @@ func (h *AuthorizedHandlers) DeleteDocument(c *gin.Context) {
 	err = h.deletePrompt("", id)
 	if err != nil {
 		c.JSON(http.StatusOK, map[string]any{"isOk": ok, "aiError": err.Error()})
+		return
 	}
 	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
 }
@@ -655,6 +920,9 @@ func (h *AuthorizedHandlers) CreateNoteDocuments(c *gin.Context) {
 		c.AbortWithError(http.StatusBadRequest, err)
 		return
 	}
+	if !h.isUserActingOnSelf(c, request.ContextID, "Context") || !h.isUserActingOnSelf(c, request.NoteID, "Note") {
+		return
+	}
 	userID, err := h.getUserIDFromJwt(c)
 	if err != nil {
 		h.logger.Err(err)
@@ -668,15 +936,14 @@ func (h *AuthorizedHandlers) CreateNoteDocuments(c *gin.Context) {
 
 	docs, err := h.documentService.CreateBulkFromMultipart(request.CreateDocumentsMultipartRequest)
 	if err != nil {
-		h.logger.Err(err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		h.abortOnDocumentError(c, err)
 		return
 	}
 
 	ids := make([]string, len(docs))
 	errs := make(map[string]string)
 	for i, doc := range docs {
-		_, err = h.sendPrompt(doc.ContextID, doc.ID, doc)
+		_, err = h.sendDocumentPrompt(doc)
 		if err != nil {
 			errs[doc.ID] = err.Error()
 		}
@@ -721,14 +988,98 @@ func (h *AuthorizedHandlers) CreateContext(c *gin.Context) {
 
 	context, err := h.contextService.CreateOne(request)
 	if err != nil {
-		h.logger.Err(err)
-		c.AbortWithStatus(http.StatusInternalServerError)
+		h.abortOnAiError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, map[string]any{"context": context})
 }
 
+// UpdateContextModel godoc
+// @Summary Switches the AI provider and model of a context.
+// @Schemes
+// @Description Routes the later messages of the context to the given provider and model, empty values use the configured defaults. Notes and documents of the context are sent to the new model again while the conversation so far is dropped. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, contexts
+// @Accept json
+// @Produce json
+// @Param id path int true "Context ID"
+// @Param request body context.UpdateContextModelRequest true "Update Context Model Request"
+// @Success 200 {object} map[string]interface{} "Updated context"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /contexts/{id}/model [put]
+func (h *AuthorizedHandlers) UpdateContextModel(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Context") {
+		return
+	}
+	var request context.UpdateContextModelRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.ID = id
+
+	updated, err := h.contextService.UpdateModel(request)
+	if err != nil {
+		h.abortOnAiError(c, err)
+		return
+	}
+	err = h.promptService.ReplayPrompts(id)
+	if err != nil {
+		h.abortOnAiError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"context": updated})
+}
+
+// ResetContext godoc
+// @Summary Resets the assistant conversation of a context.
+// @Schemes
+// @Description A soft reset drops the conversation so far but keeps the notes and documents the assistant was given. A hard reset also makes the assistant forget every note and document of the context until they are changed again. Stored messages are kept in both modes. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, contexts
+// @Accept json
+// @Produce json
+// @Param id path int true "Context ID"
+// @Param request body context.ResetContextRequest true "Reset Context Request"
+// @Success 200 {object} map[string]interface{} "Reset success status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /contexts/{id}/reset [post]
+func (h *AuthorizedHandlers) ResetContext(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Context") {
+		return
+	}
+	var request context.ResetContextRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.ID = id
+
+	err = h.promptService.ResetContext(request)
+	if err != nil {
+		if err.Error() == "argumentErrorUnknownResetMode" {
+			h.logger.Err(err)
+			c.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		h.abortOnAiError(c, err)
+		return
+	}
+	h.hubService.Publish(id, event.ContextReset, request)
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
 // DeleteContext godoc
 // @Summary Deletes a context by ID.
 // @Schemes
@@ -759,62 +1110,1625 @@ func (h *AuthorizedHandlers) DeleteContext(c *gin.Context) {
 	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
 }
 
-func (h *AuthorizedHandlers) getUserIDFromJwt(c *gin.Context) (string, error) {
-	parts := strings.Split(c.Request.Header.Get("Authorization"), " ")
-	id, err := h.authService.GetUserIDFromToken(parts[len(parts)-1])
+// ReadContextMessages godoc
+// @Summary Retrieves the conversation history of a context.
+// @Schemes
+// @Description Fetches the stored user and assistant messages of a context in chronological order. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, contexts, messages
+// @Accept json
+// @Produce json
+// @Param id path int true "Context ID"
+// @Param filter query message.FilterMessagesRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.Message] "Context messages"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /contexts/{id}/messages [get]
+func (h *AuthorizedHandlers) ReadContextMessages(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Context") {
+		return
+	}
+	var request message.FilterMessagesRequest
+	err := c.ShouldBindQuery(&request)
 	if err != nil {
-		return "", err
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
 	}
+	request.ContextID = id
 
-	return id, nil
-}
-
-func (h *AuthorizedHandlers) isUserActingOnSelf(c *gin.Context, entityID string, entityName string) bool {
-	userID, err := h.getUserIDFromJwt(c)
+	messages, err := h.promptService.FilterMessages(request)
 	if err != nil {
 		h.logger.Err(err)
 		c.AbortWithStatus(http.StatusInternalServerError)
-		return false
+		return
 	}
-	var ok bool
-	switch strings.ToLower(entityName) {
-	case "context":
-		ok, err = h.contextService.CheckIfBelongsToUser(entityID, userID)
-	case "document":
-		ok, err = h.documentService.CheckIfBelongsToUser(entityID, userID)
-	case "user":
-		err = nil
-		ok = entityID == userID
-	case "note":
-		ok, err = h.noteService.CheckIfBelongsToUser(entityID, userID)
-	default:
-		return true
+
+	c.JSON(http.StatusOK, messages)
+}
+
+// CreateContextMessage godoc
+// @Summary Sends a message to the assistant of a context.
+// @Schemes
+// @Description Sends the given message to the AI assistant within the context and returns the stored reply. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, contexts, messages
+// @Accept json
+// @Produce json
+// @Param id path int true "Context ID"
+// @Param request body prompt.CreateMessageRequest true "Create Message Request"
+// @Success 200 {object} map[string]interface{} "Assistant reply"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /contexts/{id}/messages [post]
+func (h *AuthorizedHandlers) CreateContextMessage(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Context") {
+		return
 	}
+	var request prompt.CreateMessageRequest
+	err := c.ShouldBind(&request)
 	if err != nil {
 		h.logger.Err(err)
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return false
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
 	}
-	if !ok {
-		h.logger.Err(errors.New("authorizationErrorUnauthorizedForContent"))
-		c.AbortWithStatus(http.StatusInternalServerError)
-		return false
+	request.ContextID = id
+
+	reply, err := h.promptService.GenerateAndSendMessage(request)
+	if err != nil {
+		h.abortOnAiError(c, err)
+		return
 	}
+	h.hubService.Publish(id, event.Message, reply)
 
-	return true
+	c.JSON(http.StatusOK, map[string]any{"message": reply})
 }
 
-func (h *AuthorizedHandlers) sendPrompt(contextID string, entityID string, val any) (string, error) {
-
-	req := prompt.CreatePromptRequest{
-		ContextID: contextID,
-		Value:     val,
-		EntityID:  entityID,
-	}
+// StreamContextMessage godoc
+// @Summary Streams the assistant reply to a message as Server-Sent Events.
+// @Schemes
+// @Description Sends the given message to the AI assistant within the context and relays the reply as "delta" events followed by a "done" event carrying the stored reply, or an "error" event. Closing the connection cancels the reply. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, contexts, messages
+// @Accept json
+// @Produce text/event-stream
+// @Param id path int true "Context ID"
+// @Param request query prompt.CreateMessageRequest true "Create Message Request"
+// @Success 200 {string} string "Event stream of the reply"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /contexts/{id}/messages/stream [get]
+func (h *AuthorizedHandlers) StreamContextMessage(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Context") {
+		return
+	}
+	var request prompt.CreateMessageRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.ContextID = id
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ctx := c.Request.Context()
+	reply, err := h.promptService.GenerateAndStreamMessage(ctx, request, func(delta string) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.SSEvent("delta", map[string]any{"content": delta})
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		// Nothing is streamed before the budget is checked, so the status can still tell why
+		if !c.Writer.Written() {
+			h.abortOnAiError(c, err)
+			return
+		}
+		msg := h.logger.Err(err)
+		if ctx.Err() == nil {
+			c.SSEvent("error", map[string]any{"error": msg})
+			c.Writer.Flush()
+		}
+		return
+	}
+
+	h.hubService.Publish(id, event.Message, reply)
+
+	c.SSEvent("done", map[string]any{"message": reply})
+	c.Writer.Flush()
+}
+
+// ContextWebSocket godoc
+// @Summary Opens a websocket chat channel for a context.
+// @Schemes
+// @Description Upgrades to a websocket where the client sends {"type":"message","value":"..."} or {"type":"typing"} and receives context events such as streamed reply deltas, typing/status updates and prompt notifications. Every open tab of the owner receives the same events. The JWT can be given as the "token" query parameter since browsers cannot set headers on websockets. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, contexts, messages
+// @Param id path int true "Context ID"
+// @Param token query string false "JWT when the Authorization header cannot be set"
+// @Success 101 {object} event.ContextEvent "Switching protocols"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /contexts/{id}/ws [get]
+func (h *AuthorizedHandlers) ContextWebSocket(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Context") {
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrader has already answered the request with an error status
+		h.logger.Err(err)
+		return
+	}
+	sub := h.hubService.Subscribe(id, userID)
+	defer h.hubService.Unsubscribe(sub)
+
+	ctx, cancel := gocontext.WithCancel(c.Request.Context())
+	defer cancel()
+	go h.writeWebSocketEvents(conn, sub)
+	h.readWebSocketMessages(ctx, conn, id)
+}
+
+// ReadContextPrompts godoc
+// @Summary Retrieves the prompts of a context.
+// @Schemes
+// @Description Fetches the prompts that were sent to the assistant for a context. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, contexts, prompts
+// @Accept json
+// @Produce json
+// @Param id path int true "Context ID"
+// @Param filter query prompt.FilterPromptsRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.Prompt] "Context prompts"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /contexts/{id}/prompts [get]
+func (h *AuthorizedHandlers) ReadContextPrompts(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Context") {
+		return
+	}
+	var request prompt.FilterPromptsRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.ContextIDs = &[]string{id}
+
+	prompts, err := h.promptService.FilterAll(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, prompts)
+}
+
+// ReadPromptWithID godoc
+// @Summary Retrieves a prompt by ID.
+// @Schemes
+// @Description Fetches the details of a specific prompt based on its ID. Only the owner of the prompt's context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, prompts
+// @Accept json
+// @Produce json
+// @Param id path int true "Prompt ID"
+// @Success 200 {object} entities.Prompt "Prompt details"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /prompts/{id} [get]
+func (h *AuthorizedHandlers) ReadPromptWithID(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Prompt") {
+		return
+	}
+
+	prompt, err := h.promptService.GetOne(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// DeletePrompt godoc
+// @Summary Deletes a prompt by ID.
+// @Schemes
+// @Description Deletes the prompt associated with the provided ID. Only the owner of the prompt's context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, prompts
+// @Accept json
+// @Produce json
+// @Param id path int true "Prompt ID"
+// @Success 200 {object} map[string]interface{} "Deletion success status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /prompts/{id} [delete]
+func (h *AuthorizedHandlers) DeletePrompt(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Prompt") {
+		return
+	}
+
+	ok, err := h.promptService.DeleteAndSend(id)
+	if err != nil || !ok {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
+}
+
+// Logout godoc
+// @Summary Ends the current session.
+// @Schemes
+// @Description Revokes the access token of the request and every refresh token of its session.
+// @Security JwtAuth
+// @Tags authorized, auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logout status"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /logout [post]
+func (h *AuthorizedHandlers) Logout(c *gin.Context) {
+	claims := c.MustGet("claims").(jwt.MapClaims)
+
+	err := h.authService.Logout(claims)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
+// LogoutAll godoc
+// @Summary Ends every session of the authenticated user.
+// @Schemes
+// @Description Revokes the refresh tokens of all sessions of the user and the access tokens issued with them, including the one of the request.
+// @Security JwtAuth
+// @Tags authorized, auth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logout status"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /logout/all [post]
+func (h *AuthorizedHandlers) LogoutAll(c *gin.Context) {
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	err = h.authService.LogoutAll(userID)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
+// EnrollTwoFactor godoc
+// @Summary Starts setting up two-factor authentication.
+// @Schemes
+// @Description Creates a TOTP secret for the authenticated user and returns it with an otpauth URI to show as a QR code. The login only asks for codes once the enrolment is activated, enrolling again before that replaces the secret.
+// @Security JwtAuth
+// @Tags authorized, auth
+// @Produce json
+// @Success 200 {object} auth.TwoFactorEnrollment "Secret and otpauth URI"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /2fa/enroll [post]
+func (h *AuthorizedHandlers) EnrollTwoFactor(c *gin.Context) {
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := h.twoFactorService.Enroll(userID)
+	if err != nil {
+		h.abortOnTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ActivateTwoFactor godoc
+// @Summary Turns on two-factor authentication.
+// @Schemes
+// @Description Checks a code of the authenticator app against the enrolled secret and returns ten recovery codes, they are shown only this once.
+// @Security JwtAuth
+// @Tags authorized, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorCodeRequest true "Code of the authenticator app"
+// @Success 200 {object} auth.RecoveryCodes "Recovery codes"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 429 {object} string "Too many wrong codes"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /2fa/activate [post]
+func (h *AuthorizedHandlers) ActivateTwoFactor(c *gin.Context) {
+	var request auth.TwoFactorCodeRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := h.twoFactorService.Activate(userID, request.Code)
+	if err != nil {
+		h.abortOnTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// DisableTwoFactor godoc
+// @Summary Turns off two-factor authentication.
+// @Schemes
+// @Description Needs a code of the authenticator app or a recovery code. Admins can not turn it off while the configuration requires it for them.
+// @Security JwtAuth
+// @Tags authorized, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorCodeRequest true "Code of the authenticator app or a recovery code"
+// @Success 200 {object} map[string]interface{} "Disable status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 403 {object} string "Required for admins"
+// @Failure 429 {object} string "Too many wrong codes"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /2fa/disable [post]
+func (h *AuthorizedHandlers) DisableTwoFactor(c *gin.Context) {
+	var request auth.TwoFactorCodeRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	err = h.twoFactorService.Disable(userID, request.Code)
+	if err != nil {
+		h.abortOnTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": true})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replaces the recovery codes.
+// @Schemes
+// @Description Needs a code of the authenticator app or a recovery code, the earlier recovery codes stop working.
+// @Security JwtAuth
+// @Tags authorized, auth
+// @Accept json
+// @Produce json
+// @Param request body auth.TwoFactorCodeRequest true "Code of the authenticator app or a recovery code"
+// @Success 200 {object} auth.RecoveryCodes "Recovery codes"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 429 {object} string "Too many wrong codes"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /2fa/recovery-codes [post]
+func (h *AuthorizedHandlers) RegenerateRecoveryCodes(c *gin.Context) {
+	var request auth.TwoFactorCodeRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	res, err := h.twoFactorService.RegenerateRecoveryCodes(userID, request.Code)
+	if err != nil {
+		h.abortOnTwoFactorError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *AuthorizedHandlers) getUserIDFromJwt(c *gin.Context) (string, error) {
+	if claims, ok := c.Get("claims"); ok {
+		if id, ok := claims.(jwt.MapClaims)["userID"].(string); ok {
+			return id, nil
+		}
+	}
+	parts := strings.Split(c.Request.Header.Get("Authorization"), " ")
+	id, err := h.authService.GetUserIDFromToken(parts[len(parts)-1])
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// ReadCitationSnippet godoc
+// @Summary Retrieves the cited snippet of a citation.
+// @Schemes
+// @Description Fetches the part of the note or document that an assistant message cites, together with its offsets so it can be highlighted within the source. Only the owner of the citation's context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, citations
+// @Accept json
+// @Produce json
+// @Param id path int true "Citation ID"
+// @Success 200 {object} citation.CitationSnippet "Cited snippet"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /citations/{id} [get]
+func (h *AuthorizedHandlers) ReadCitationSnippet(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Citation") {
+		return
+	}
+
+	snippet, err := h.citationService.GetSnippet(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, snippet)
+}
+
+// CreateEquation godoc
+// @Summary Creates a new equation.
+// @Schemes
+// @Description Validates the LaTeX of an equation, renders it to MathML and plain text and gives it to the assistant of its context. The equation can be placed in a note of the context. Only the owner of the context and the note or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, equations
+// @Accept json
+// @Produce json
+// @Param request body equation.CreateEquationRequest true "Create Equation Request"
+// @Success 200 {object} map[string]interface{} "Created equation"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /equations [post]
+func (h *AuthorizedHandlers) CreateEquation(c *gin.Context) {
+	var request equation.CreateEquationRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if request.NoteID != nil && *request.NoteID != "" && !h.isUserActingOnSelf(c, *request.NoteID, "Note") {
+		return
+	}
+	if request.ContextID != "" && !h.isUserActingOnSelf(c, request.ContextID, "Context") {
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	request.UserID = &userID
+
+	created, err := h.equationService.CreateOne(request)
+	if err != nil {
+		h.abortOnEquationError(c, err)
+		return
+	}
+	_, err = h.sendPrompt(created.ContextID, created.ID, created)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{"equation": created, "aiError": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"equation": created})
+}
+
+// ReadEquationWithID godoc
+// @Summary Retrieves an equation by ID.
+// @Schemes
+// @Description Fetches an equation with its LaTeX source, MathML and plain text forms. Only the owner of the equation or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, equations
+// @Accept json
+// @Produce json
+// @Param id path int true "Equation ID"
+// @Success 200 {object} entities.Equation "Equation details"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /equations/{id} [get]
+func (h *AuthorizedHandlers) ReadEquationWithID(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Equation") {
+		return
+	}
+
+	found, err := h.equationService.GetOne(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, found)
+}
+
+// ReadEquationWithFilter godoc
+// @Summary Retrieves equations based on filter criteria.
+// @Schemes
+// @Description Fetches the equations of the authenticated user that match the specified filter criteria.
+// @Security JwtAuth
+// @Tags authorized, equations
+// @Accept json
+// @Produce json
+// @Param filter query equation.FilterEquationsRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.Equation] "Filtered equations"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /equations [get]
+func (h *AuthorizedHandlers) ReadEquationWithFilter(c *gin.Context) {
+	var request equation.FilterEquationsRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	id, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	request.UserIDs = &[]string{id}
+
+	equations, err := h.equationService.FilterAll(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, equations)
+}
+
+// UpdateEquation godoc
+// @Summary Updates an equation.
+// @Schemes
+// @Description Changes the LaTeX or label of an equation, new LaTeX is validated and rendered again. Only the owner of the equation or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, equations
+// @Accept json
+// @Produce json
+// @Param request body equation.UpdateEquationRequest true "Update Equation Request"
+// @Success 200 {object} entities.Equation "Updated equation"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /equations [patch]
+func (h *AuthorizedHandlers) UpdateEquation(c *gin.Context) {
+	var request equation.UpdateEquationRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !h.isUserActingOnSelf(c, request.ID, "Equation") {
+		return
+	}
+
+	updated, err := h.equationService.UpdateOne(request)
+	if err != nil {
+		h.abortOnEquationError(c, err)
+		return
+	}
+
+	_, err = h.updatePrompt(updated.ContextID, updated.ID, updated)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{"value": updated, "aiError": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteEquation godoc
+// @Summary Deletes an equation by ID.
+// @Schemes
+// @Description Deletes the equation and lets the assistant of its context forget it. Only the owner of the equation or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, equations
+// @Accept json
+// @Produce json
+// @Param id path int true "Equation ID"
+// @Success 200 {object} map[string]interface{} "Deletion success status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /equations/{id} [delete]
+func (h *AuthorizedHandlers) DeleteEquation(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Equation") {
+		return
+	}
+
+	ok, err := h.equationService.DeleteOne(id)
+	if err != nil || !ok {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	err = h.deletePrompt("", id)
+	if err != nil {
+		c.JSON(http.StatusOK, map[string]any{"isOk": ok, "aiError": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
+}
+
+// RenderEquation godoc
+// @Summary Renders LaTeX without saving it.
+// @Schemes
+// @Description Validates the given LaTeX and returns its MathML and plain text forms, for previews while an equation is written.
+// @Security JwtAuth
+// @Tags authorized, equations
+// @Accept json
+// @Produce json
+// @Param request body equation.RenderEquationRequest true "Render Equation Request"
+// @Success 200 {object} map[string]interface{} "Rendered equation"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /equations/render [post]
+func (h *AuthorizedHandlers) RenderEquation(c *gin.Context) {
+	var request equation.RenderEquationRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	rendered, err := h.equationService.Render(request)
+	if err != nil {
+		h.abortOnEquationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"latex": rendered.Latex, "mathml": rendered.MathML, "text": rendered.Text})
+}
+
+// CreateDeck godoc
+// @Summary Creates a new flashcard deck.
+// @Schemes
+// @Description Creates an empty deck within a context of the authenticated user. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, decks
+// @Accept json
+// @Produce json
+// @Param request body deck.CreateDeckRequest true "Create Deck Request"
+// @Success 200 {object} entities.Deck "Created deck"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /decks [post]
+func (h *AuthorizedHandlers) CreateDeck(c *gin.Context) {
+	var request deck.CreateDeckRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !h.isUserActingOnSelf(c, request.ContextID, "Context") {
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	request.UserID = &userID
+
+	created, err := h.deckService.CreateOne(request)
+	if err != nil {
+		h.abortOnStudyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+// ReadDeckWithID godoc
+// @Summary Retrieves a deck by ID.
+// @Schemes
+// @Description Fetches a deck together with its cards. Only the owner of the deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, decks
+// @Accept json
+// @Produce json
+// @Param id path int true "Deck ID"
+// @Success 200 {object} entities.Deck "Deck details"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /decks/{id} [get]
+func (h *AuthorizedHandlers) ReadDeckWithID(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Deck") {
+		return
+	}
+
+	found, err := h.deckService.GetOne(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, found)
+}
+
+// ReadDeckWithFilter godoc
+// @Summary Retrieves decks based on filter criteria.
+// @Schemes
+// @Description Fetches the decks of the authenticated user that match the specified filter criteria.
+// @Security JwtAuth
+// @Tags authorized, decks
+// @Accept json
+// @Produce json
+// @Param filter query deck.FilterDecksRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.Deck] "Filtered decks"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /decks [get]
+func (h *AuthorizedHandlers) ReadDeckWithFilter(c *gin.Context) {
+	var request deck.FilterDecksRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	id, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	request.UserIDs = &[]string{id}
+
+	decks, err := h.deckService.FilterAll(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, decks)
+}
+
+// UpdateDeck godoc
+// @Summary Updates a deck.
+// @Schemes
+// @Description Renames a deck. Only the owner of the deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, decks
+// @Accept json
+// @Produce json
+// @Param request body deck.UpdateDeckRequest true "Update Deck Request"
+// @Success 200 {object} entities.Deck "Updated deck"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /decks [patch]
+func (h *AuthorizedHandlers) UpdateDeck(c *gin.Context) {
+	var request deck.UpdateDeckRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !h.isUserActingOnSelf(c, request.ID, "Deck") {
+		return
+	}
+
+	updated, err := h.deckService.UpdateOne(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteDeck godoc
+// @Summary Deletes a deck by ID.
+// @Schemes
+// @Description Deletes the deck together with its cards and their reviews. Only the owner of the deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, decks
+// @Accept json
+// @Produce json
+// @Param id path int true "Deck ID"
+// @Success 200 {object} map[string]interface{} "Deletion success status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /decks/{id} [delete]
+func (h *AuthorizedHandlers) DeleteDeck(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Deck") {
+		return
+	}
+
+	ok, err := h.deckService.DeleteOne(id)
+	if err != nil || !ok {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
+}
+
+// GenerateDeckCards godoc
+// @Summary Generates flashcards from a note or document.
+// @Schemes
+// @Description Asks the assistant of the deck's context to write flashcards about a note or document of the same context and adds them to the deck. Only the owner of the deck and the source or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, decks, cards
+// @Accept json
+// @Produce json
+// @Param id path int true "Deck ID"
+// @Param request body deck.GenerateCardsRequest true "Generate Cards Request"
+// @Success 200 {object} map[string]interface{} "Generated cards"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 429 {object} string "Token budget used up"
+// @Failure 500 {object} string "Internal Server Error"
+// @Failure 502 {object} string "Assistant did not answer with cards"
+// @Router /decks/{id}/generate [post]
+func (h *AuthorizedHandlers) GenerateDeckCards(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Deck") {
+		return
+	}
+	var request deck.GenerateCardsRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.DeckID = id
+
+	found, err := h.deckService.GetOne(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	var source any
+	var sourceContextID string
+	switch entities.SourceType(strings.ToLower(request.SourceType)) {
+	case entities.NoteSource:
+		if !h.isUserActingOnSelf(c, request.SourceID, "Note") {
+			return
+		}
+		var n entities.Note
+		n, err = h.noteService.GetOne(request.SourceID)
+		source, sourceContextID = n, n.ContextID
+	case entities.DocumentSource:
+		if !h.isUserActingOnSelf(c, request.SourceID, "Document") {
+			return
+		}
+		var d documentResponse.DocumentWrapped
+		d, err = h.documentService.GetOne(request.SourceID)
+		source, sourceContextID = d.Document, d.ContextID
+	default:
+		err = errors.New("argumentErrorUnknownSourceType")
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	if sourceContextID != found.ContextID {
+		err = errors.New("argumentErrorSourceOutsideContext")
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	cards, err := h.cardService.GenerateFromSource(found, source, request.Count)
+	if err != nil {
+		h.abortOnAiError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"cards": cards})
+}
+
+// ReadDueCards godoc
+// @Summary Retrieves the cards of a deck that are due for review.
+// @Schemes
+// @Description Fetches the cards of the deck whose next review is due, the most overdue first. Only the owner of the deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, decks, cards
+// @Accept json
+// @Produce json
+// @Param id path int true "Deck ID"
+// @Param filter query deck.FilterDueCardsRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.Card] "Due cards"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /decks/{id}/due [get]
+func (h *AuthorizedHandlers) ReadDueCards(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Deck") {
+		return
+	}
+	var request deck.FilterDueCardsRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.DeckID = id
+
+	cards, err := h.cardService.FilterDue(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, cards)
+}
+
+// CreateCard godoc
+// @Summary Creates a new flashcard.
+// @Schemes
+// @Description Adds a card written by hand to a deck, it is due for review right away. Only the owner of the deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, cards
+// @Accept json
+// @Produce json
+// @Param request body card.CreateCardRequest true "Create Card Request"
+// @Success 200 {object} entities.Card "Created card"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /cards [post]
+func (h *AuthorizedHandlers) CreateCard(c *gin.Context) {
+	var request card.CreateCardRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !h.isUserActingOnSelf(c, request.DeckID, "Deck") {
+		return
+	}
+
+	created, err := h.cardService.CreateOne(request)
+	if err != nil {
+		h.abortOnStudyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+// ReadCardWithID godoc
+// @Summary Retrieves a card by ID.
+// @Schemes
+// @Description Fetches a card together with its reviews. Only the owner of the card's deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, cards
+// @Accept json
+// @Produce json
+// @Param id path int true "Card ID"
+// @Success 200 {object} entities.Card "Card details"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /cards/{id} [get]
+func (h *AuthorizedHandlers) ReadCardWithID(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Card") {
+		return
+	}
+
+	found, err := h.cardService.GetOne(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, found)
+}
+
+// UpdateCard godoc
+// @Summary Updates a card.
+// @Schemes
+// @Description Changes the front or back of a card, its schedule is kept. Only the owner of the card's deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, cards
+// @Accept json
+// @Produce json
+// @Param request body card.UpdateCardRequest true "Update Card Request"
+// @Success 200 {object} entities.Card "Updated card"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /cards [patch]
+func (h *AuthorizedHandlers) UpdateCard(c *gin.Context) {
+	var request card.UpdateCardRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !h.isUserActingOnSelf(c, request.ID, "Card") {
+		return
+	}
+
+	updated, err := h.cardService.UpdateOne(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCard godoc
+// @Summary Deletes a card by ID.
+// @Schemes
+// @Description Deletes the card together with its reviews. Only the owner of the card's deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, cards
+// @Accept json
+// @Produce json
+// @Param id path int true "Card ID"
+// @Success 200 {object} map[string]interface{} "Deletion success status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /cards/{id} [delete]
+func (h *AuthorizedHandlers) DeleteCard(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Card") {
+		return
+	}
+
+	ok, err := h.cardService.DeleteOne(id)
+	if err != nil || !ok {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
+}
+
+// ReviewCard godoc
+// @Summary Records a review of a card.
+// @Schemes
+// @Description Records how well the card was recalled, from 0 (forgotten) to 5 (perfect recall), and schedules its next review with SM-2. Only the owner of the card's deck or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, cards
+// @Accept json
+// @Produce json
+// @Param id path int true "Card ID"
+// @Param request body card.ReviewCardRequest true "Review Card Request"
+// @Success 200 {object} entities.Card "Rescheduled card"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /cards/{id}/review [post]
+func (h *AuthorizedHandlers) ReviewCard(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Card") {
+		return
+	}
+	var request card.ReviewCardRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.ID = id
+
+	reviewed, err := h.cardService.Review(request)
+	if err != nil {
+		h.abortOnStudyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reviewed)
+}
+
+// GenerateQuiz godoc
+// @Summary Generates a quiz from the material of a context.
+// @Schemes
+// @Description Asks the assistant of the context to write multiple choice and short answer questions about the notes and documents of the context. Answers stay hidden until an attempt is graded. Only the owner of the context or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, quizzes
+// @Accept json
+// @Produce json
+// @Param request body quiz.GenerateQuizRequest true "Generate Quiz Request"
+// @Success 200 {object} entities.Quiz "Generated quiz"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 429 {object} string "Token budget used up"
+// @Failure 500 {object} string "Internal Server Error"
+// @Failure 502 {object} string "Assistant did not answer with questions"
+// @Router /quizzes [post]
+func (h *AuthorizedHandlers) GenerateQuiz(c *gin.Context) {
+	var request quiz.GenerateQuizRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	if !h.isUserActingOnSelf(c, request.ContextID, "Context") {
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	request.UserID = &userID
+
+	created, err := h.quizService.Generate(request)
+	if err != nil {
+		h.abortOnQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+// ReadQuizWithID godoc
+// @Summary Retrieves a quiz by ID.
+// @Schemes
+// @Description Fetches a quiz together with its questions, without their answers. Only the owner of the quiz or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, quizzes
+// @Accept json
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Success 200 {object} entities.Quiz "Quiz details"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /quizzes/{id} [get]
+func (h *AuthorizedHandlers) ReadQuizWithID(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Quiz") {
+		return
+	}
+
+	found, err := h.quizService.GetOne(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, found)
+}
+
+// ReadQuizWithFilter godoc
+// @Summary Retrieves quizzes based on filter criteria.
+// @Schemes
+// @Description Fetches the quizzes of the authenticated user that match the specified filter criteria.
+// @Security JwtAuth
+// @Tags authorized, quizzes
+// @Accept json
+// @Produce json
+// @Param filter query quiz.FilterQuizzesRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.Quiz] "Filtered quizzes"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /quizzes [get]
+func (h *AuthorizedHandlers) ReadQuizWithFilter(c *gin.Context) {
+	var request quiz.FilterQuizzesRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	id, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	request.UserIDs = &[]string{id}
+
+	quizzes, err := h.quizService.FilterAll(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, quizzes)
+}
+
+// DeleteQuiz godoc
+// @Summary Deletes a quiz by ID.
+// @Schemes
+// @Description Deletes the quiz together with its questions and attempts. Only the owner of the quiz or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, quizzes
+// @Accept json
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Success 200 {object} map[string]interface{} "Deletion success status"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /quizzes/{id} [delete]
+func (h *AuthorizedHandlers) DeleteQuiz(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Quiz") {
+		return
+	}
+
+	ok, err := h.quizService.DeleteOne(id)
+	if err != nil || !ok {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]any{"isOk": ok})
+}
+
+// SubmitQuizAttempt godoc
+// @Summary Submits and grades an attempt at a quiz.
+// @Schemes
+// @Description Grades the given answers, multiple choice answers against the correct option and short answers with the assistant of the quiz's context, and records the score. Only the owner of the quiz or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, quizzes
+// @Accept json
+// @Produce json
+// @Param id path int true "Quiz ID"
+// @Param request body quiz.SubmitAttemptRequest true "Submit Attempt Request"
+// @Success 200 {object} entities.QuizAttempt "Graded attempt"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 429 {object} string "Token budget used up"
+// @Failure 500 {object} string "Internal Server Error"
+// @Failure 502 {object} string "Assistant did not answer with a grade"
+// @Router /quizzes/{id}/attempts [post]
+func (h *AuthorizedHandlers) SubmitQuizAttempt(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Quiz") {
+		return
+	}
+	var request quiz.SubmitAttemptRequest
+	err := c.ShouldBind(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	request.QuizID = id
+	request.UserID = userID
+
+	attempt, err := h.quizService.SubmitAttempt(request)
+	if err != nil {
+		h.abortOnQuizError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempt)
+}
+
+// ReadUserAttempts godoc
+// @Summary Retrieves the quiz score history of a user.
+// @Schemes
+// @Description Fetches the graded attempts of the user, oldest first, optionally only those of the given quizzes. Only the user themselves or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, users, quizzes
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param filter query quiz.FilterAttemptsRequest true "Filter parameters"
+// @Success 200 {object} pagination.PaginationResponse[entities.QuizAttempt] "Score history"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /users/{id}/attempts [get]
+func (h *AuthorizedHandlers) ReadUserAttempts(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "User") {
+		return
+	}
+	var request quiz.FilterAttemptsRequest
+	err := c.ShouldBindQuery(&request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	request.UserID = id
+
+	attempts, err := h.quizService.FilterAttempts(request)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// ReadAttemptWithID godoc
+// @Summary Retrieves a graded attempt by ID.
+// @Schemes
+// @Description Fetches an attempt together with its graded answers, the expected answers and the feedback of the assistant. Only the user who made the attempt or authorized actions are permitted.
+// @Security JwtAuth
+// @Tags authorized, quizzes
+// @Accept json
+// @Produce json
+// @Param id path int true "Attempt ID"
+// @Success 200 {object} entities.QuizAttempt "Attempt details"
+// @Failure 400 {object} string "Bad Request"
+// @Failure 500 {object} string "Internal Server Error"
+// @Router /attempts/{id} [get]
+func (h *AuthorizedHandlers) ReadAttemptWithID(c *gin.Context) {
+	id := c.Param("id")
+	if !h.isUserActingOnSelf(c, id, "Attempt") {
+		return
+	}
+
+	found, err := h.quizService.GetAttempt(id)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.JSON(http.StatusOK, found)
+}
+
+func (h *AuthorizedHandlers) isUserActingOnSelf(c *gin.Context, entityID string, entityName string) bool {
+	userID, err := h.getUserIDFromJwt(c)
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return false
+	}
+	var ok bool
+	switch strings.ToLower(entityName) {
+	case "context":
+		ok, err = h.contextService.CheckIfBelongsToUser(entityID, userID)
+	case "document":
+		ok, err = h.documentService.CheckIfBelongsToUser(entityID, userID)
+	case "user":
+		err = nil
+		ok = entityID == userID
+	case "note":
+		ok, err = h.noteService.CheckIfBelongsToUser(entityID, userID)
+	case "prompt":
+		var p entities.Prompt
+		p, err = h.promptService.GetOne(entityID)
+		if err == nil {
+			ok, err = h.contextService.CheckIfBelongsToUser(p.ContextID, userID)
+		}
+	case "citation":
+		var ci entities.Citation
+		ci, err = h.citationService.GetOne(entityID)
+		if err == nil {
+			ok, err = h.contextService.CheckIfBelongsToUser(ci.ContextID, userID)
+		}
+	case "equation":
+		ok, err = h.equationService.CheckIfBelongsToUser(entityID, userID)
+	case "deck":
+		ok, err = h.deckService.CheckIfBelongsToUser(entityID, userID)
+	case "card":
+		var cd entities.Card
+		cd, err = h.cardService.GetOne(entityID)
+		if err == nil {
+			ok, err = h.deckService.CheckIfBelongsToUser(cd.DeckID, userID)
+		}
+	case "quiz":
+		ok, err = h.quizService.CheckIfBelongsToUser(entityID, userID)
+	case "attempt":
+		ok, err = h.quizService.CheckIfAttemptBelongsToUser(entityID, userID)
+	default:
+		return true
+	}
+	if err != nil {
+		h.logger.Err(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return false
+	}
+	if !ok {
+		h.logger.Err(errors.New("authorizationErrorUnauthorizedForContent"))
+		c.AbortWithStatus(http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+func (h *AuthorizedHandlers) abortOnTwoFactorError(c *gin.Context, err error) {
+	msg := h.logger.Err(err)
+	switch err.Error() {
+	case "twoFactorErrorInvalidCode", "twoFactorErrorNotEnrolled", "twoFactorErrorAlreadyEnabled":
+		c.AbortWithStatusJSON(http.StatusBadRequest, map[string]any{"error": msg})
+	case "twoFactorErrorRequired":
+		c.AbortWithStatusJSON(http.StatusForbidden, map[string]any{"error": msg})
+	case "twoFactorErrorLocked":
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, map[string]any{"error": msg})
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+func (h *AuthorizedHandlers) abortOnApiKeyError(c *gin.Context, err error) {
+	msg := h.logger.Err(err)
+	switch err.Error() {
+	case "apiKeyErrorNameRequired", "apiKeyErrorInvalidScope", "apiKeyErrorLimitReached":
+		c.AbortWithStatusJSON(http.StatusBadRequest, map[string]any{"error": msg})
+	case "apiKeyErrorNotFound":
+		c.AbortWithStatusJSON(http.StatusNotFound, map[string]any{"error": msg})
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// Rejected uploads are the caller's fault, everything else is ours
+func (h *AuthorizedHandlers) abortOnDocumentError(c *gin.Context, err error) {
+	h.logger.Err(err)
+	switch err.Error() {
+	case "argumentErrorUnsupportedExtension", "documentErrorTooLarge", "extractionErrorInvalidDocument", "extractionErrorInvalidEncoding",
+		"imageErrorInvalidImage", "imageErrorTooLarge", "imageErrorNoThumbnail":
+		c.AbortWithError(http.StatusBadRequest, err)
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+func (h *AuthorizedHandlers) abortOnEquationError(c *gin.Context, err error) {
+	h.logger.Err(err)
+	if strings.HasPrefix(err.Error(), "equationError") {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	switch err.Error() {
+	case "argumentErrorIDMissing", "argumentErrorSourceOutsideContext":
+		c.AbortWithError(http.StatusBadRequest, err)
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+func (h *AuthorizedHandlers) abortOnStudyError(c *gin.Context, err error) {
+	h.logger.Err(err)
+	switch err.Error() {
+	case "argumentErrorMissing", "argumentErrorIDMissing", "argumentErrorInvalidGrade":
+		c.AbortWithError(http.StatusBadRequest, err)
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// Quizzes can fail on the request, on the material of the context or on the assistant
+func (h *AuthorizedHandlers) abortOnQuizError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "argumentErrorIDMissing", "argumentErrorUnknownQuestion", "quizErrorNoMaterial":
+		h.logger.Err(err)
+		c.AbortWithError(http.StatusBadRequest, err)
+	default:
+		h.abortOnAiError(c, err)
+	}
+}
+
+func (h *AuthorizedHandlers) abortOnAiError(c *gin.Context, err error) {
+	msg := h.logger.Err(err)
+	switch err.Error() {
+	case "aiErrorUnknownProvider", "aiErrorUnknownModel":
+		c.AbortWithError(http.StatusBadRequest, err)
+	case "budgetErrorDailyExceeded", "budgetErrorMonthlyExceeded":
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, map[string]any{"error": msg})
+	case "flashcardErrorInvalidReply", "quizErrorInvalidReply":
+		c.AbortWithStatusJSON(http.StatusBadGateway, map[string]any{"error": msg})
+	default:
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+func (h *AuthorizedHandlers) sendPrompt(contextID string, entityID string, val any) (string, error) {
+
+	req := prompt.CreatePromptRequest{
+		ContextID: contextID,
+		Value:     val,
+		EntityID:  entityID,
+	}
+	p, err := h.promptService.GenerateAndSendPrompt(req)
+	if err != nil {
+		return "", err
+	}
+	h.hubService.Publish(contextID, event.PromptAdded, p)
+	return p.ID, nil
+}
+
+// Images are given to the prompt as well, the prompt service drops them for models that can not look at them
+func (h *AuthorizedHandlers) sendDocumentPrompt(doc entities.Document) (string, error) {
+	images, err := h.documentService.GetImageAttachments(doc)
+	if err != nil {
+		return "", err
+	}
+	req := prompt.CreatePromptRequest{
+		ContextID: doc.ContextID,
+		Value:     doc,
+		EntityID:  doc.ID,
+		Images:    images,
+	}
 	p, err := h.promptService.GenerateAndSendPrompt(req)
 	if err != nil {
 		return "", err
 	}
+	h.hubService.Publish(doc.ContextID, event.PromptAdded, p)
 	return p.ID, nil
 }
 
@@ -828,6 +2742,7 @@ func (h *AuthorizedHandlers) updatePrompt(contextID string, entityID string, val
 	if err != nil {
 		return "", err
 	}
+	h.hubService.Publish(contextID, event.PromptUpdated, p)
 	return p.ID, nil
 }
 
@@ -838,8 +2753,103 @@ func (h *AuthorizedHandlers) deletePrompt(contextID string, entityID string) err
 	}
 	found, err := h.promptService.FindByEntityAndContext(req)
 	if err != nil {
+		// Nothing to forget after a hard reset
+		if err.Error() == "notFoundError" {
+			return nil
+		}
 		return err
 	}
 	_, err = h.promptService.DeleteAndSend(found.ID)
-	return err
+	if err != nil {
+		return err
+	}
+	h.hubService.Publish(found.ContextID, event.PromptRemoved, found)
+	return nil
+}
+
+// Only this goroutine writes to the connection since websocket connections support a single writer
+func (h *AuthorizedHandlers) writeWebSocketEvents(conn *websocket.Conn, sub *services.Subscription) {
+	ticker := time.NewTicker(webSocketPingPeriod)
+	defer ticker.Stop()
+	defer conn.Close()
+	for {
+		select {
+		case e, ok := <-sub.Events:
+			conn.SetWriteDeadline(time.Now().Add(webSocketWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			err := conn.WriteJSON(e)
+			if err != nil {
+				h.logger.Error().Err(err).Msg("AuthorizedHandlers_writeWebSocketEvents could not write to the websocket")
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(webSocketWriteWait))
+			err := conn.WriteMessage(websocket.PingMessage, nil)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Reads client messages until the connection closes. Replies are written one at a time by a worker of the connection,
+// so a new message or a close is never blocked by them and replies never interleave
+func (h *AuthorizedHandlers) readWebSocketMessages(ctx gocontext.Context, conn *websocket.Conn, contextID string) {
+	queue := make(chan string, webSocketQueuedMessages)
+	defer close(queue)
+	go func() {
+		for value := range queue {
+			h.replyOverWebSocket(ctx, contextID, value)
+		}
+	}()
+	conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(webSocketPongWait))
+	})
+	for {
+		var request message.WebSocketMessageRequest
+		err := conn.ReadJSON(&request)
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.logger.Error().Err(err).Msg("AuthorizedHandlers_readWebSocketMessages websocket closed unexpectedly")
+			}
+			return
+		}
+
+		switch event.EventType(request.Type) {
+		case event.Message:
+			select {
+			case queue <- request.Value:
+			default:
+				h.hubService.Publish(contextID, event.Error, map[string]any{"error": h.logger.Err(errors.New("argumentErrorReplyInProgress"))})
+			}
+		case event.Typing:
+			h.hubService.Publish(contextID, event.Typing, map[string]any{"actor": "user"})
+		default:
+			h.hubService.Publish(contextID, event.Error, map[string]any{"error": h.logger.Err(errors.New("argumentErrorUnknownEventType"))})
+		}
+	}
+}
+
+func (h *AuthorizedHandlers) replyOverWebSocket(ctx gocontext.Context, contextID string, value string) {
+	h.hubService.Publish(contextID, event.Message, entities.Message{ContextID: contextID, Role: entities.UserMessage, Content: value})
+	h.hubService.Publish(contextID, event.Status, map[string]any{"status": "thinking"})
+	req := prompt.CreateMessageRequest{ContextID: contextID, Value: value}
+	reply, err := h.promptService.GenerateAndStreamMessage(ctx, req, func(delta string) error {
+		h.hubService.Publish(contextID, event.Delta, map[string]any{"content": delta})
+		return ctx.Err()
+	})
+	if err != nil {
+		msg := h.logger.Err(err)
+		if ctx.Err() == nil {
+			h.hubService.Publish(contextID, event.Error, map[string]any{"error": msg})
+		}
+		return
+	}
+
+	h.hubService.Publish(contextID, event.Message, reply)
+	h.hubService.Publish(contextID, event.Status, map[string]any{"status": "idle"})
 }
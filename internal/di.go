@@ -7,7 +7,9 @@ import (
 	"echo-api/models/entities"
 	"echo-api/services"
 	"echo-api/util"
+	"errors"
 	"os"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
@@ -18,9 +20,22 @@ var configuration *util.Configuration
 var db *gorm.DB
 var logger *util.Logger
 var hasher managers.HashingManager
+var passwordHasher managers.PasswordHashingManager
+var signingKeyManager managers.SigningKeyManager
+var passwordPolicyManager managers.PasswordPolicyManager
+var mailManager managers.MailManager
+var totpManager managers.TotpManager
+var oidcManager managers.OidcManager
 var fileManager managers.FileManager
 var promptManager managers.PromptGenManager
-var aiCommunicationManager managers.AiCommunicationManager
+var aiProviderRegistry managers.AiProviderRegistry
+var chunkingManager managers.ChunkingManager
+var embeddingManager managers.EmbeddingManager
+var extractionManager managers.ExtractionManager
+var schedulingManager managers.SchedulingManager
+var equationManager managers.EquationManager
+var imageManager managers.ImageManager
+var ocrManager managers.OcrManager
 
 var noteRepository *util.GormRepository[entities.Note]
 var userRepository *util.GormRepository[entities.User]
@@ -28,14 +43,51 @@ var documentRepository *util.GormRepository[entities.Document]
 var languageRepository *util.GormRepository[entities.Language]
 var contextRepository *util.GormRepository[entities.Context]
 var promptRepository *util.GormRepository[entities.Prompt]
+var messageRepository *util.GormRepository[entities.Message]
+var citationRepository *util.GormRepository[entities.Citation]
+var chunkRepository *util.GormRepository[entities.Chunk]
+var cachedEmbeddingRepository *util.GormRepository[entities.CachedEmbedding]
+var usageRecordRepository *util.GormRepository[entities.UsageRecord]
+var promptTemplateRepository *util.GormRepository[entities.PromptTemplate]
+var deckRepository *util.GormRepository[entities.Deck]
+var cardRepository *util.GormRepository[entities.Card]
+var cardReviewRepository *util.GormRepository[entities.CardReview]
+var quizRepository *util.GormRepository[entities.Quiz]
+var questionRepository *util.GormRepository[entities.Question]
+var quizAttemptRepository *util.GormRepository[entities.QuizAttempt]
+var attemptAnswerRepository *util.GormRepository[entities.AttemptAnswer]
+var equationRepository *util.GormRepository[entities.Equation]
+var refreshTokenRepository *util.GormRepository[entities.RefreshToken]
+var revokedTokenRepository *util.GormRepository[entities.RevokedToken]
+var accountTokenRepository *util.GormRepository[entities.AccountToken]
+var passwordRepository *util.GormRepository[entities.Password]
+var twoFactorRepository *util.GormRepository[entities.TwoFactor]
+var recoveryCodeRepository *util.GormRepository[entities.RecoveryCode]
+var oidcLoginStateRepository *util.GormRepository[entities.OidcLoginState]
+var externalIdentityRepository *util.GormRepository[entities.ExternalIdentity]
+var apiKeyRepository *util.GormRepository[entities.ApiKey]
 
 var authService *services.AuthService
+var tokenService *services.TokenService
+var accountService *services.AccountService
+var twoFactorService *services.TwoFactorService
+var oidcService *services.OidcService
+var apiKeyService *services.ApiKeyService
 var documentService *services.DocumentService
 var languageService *services.LanguageService
 var noteService *services.NoteService
 var userService *services.UserService
 var contextService *services.ContextService
 var promptService *services.PromptService
+var hubService *services.HubService
+var retrievalService *services.RetrievalService
+var citationService *services.CitationService
+var usageService *services.UsageService
+var promptTemplateService *services.PromptTemplateService
+var deckService *services.DeckService
+var cardService *services.CardService
+var quizService *services.QuizService
+var equationService *services.EquationService
 
 var utilHandlers *handlers.UtilHandlers
 var anonymousHandlers *handlers.AnonymousHandlers
@@ -56,11 +108,48 @@ func InjectDeps() error {
 		return err
 	}
 
+	// Passwords stored before Argon2id were keyed BLAKE3 hashes, they are upgraded on the next login
+	passwordHasher = implementations.NewArgon2idHashingManager(configuration, hasher)
+
+	signingKeyManager, err = implementations.NewConfiguredSigningKeyManager(configuration, logger)
+	if err != nil {
+		return err
+	}
+
+	passwordPolicyManager = implementations.NewConfiguredPasswordPolicyManager(configuration)
+
+	mailManager, err = newMailManager()
+	if err != nil {
+		return err
+	}
+
+	totpManager = implementations.NewRfc6238TotpManager(configuration.Title)
+
+	oidcManager = implementations.NewConfiguredOidcManager(configuration, logger)
+
 	fileManager = implementations.NewOnServerFileManager("~/FileSaveLoc", configuration.SaveLocations)
 
-	promptManager = implementations.NewLocalPromptGenManager(fileManager)
+	extractionManager = implementations.NewLocalExtractionManager()
+
+	chunkingManager = implementations.NewLocalChunkingManager(configuration.RetrievalChunkSize)
 
-	aiCommunicationManager = implementations.NewOpenAiCommunicationManager(configuration)
+	schedulingManager = implementations.NewSm2SchedulingManager()
+
+	equationManager = implementations.NewLocalEquationManager()
+
+	imageManager = implementations.NewLocalImageManager(configuration.ThumbnailSize)
+
+	ocrManager, err = newOcrManager()
+	if err != nil {
+		return err
+	}
+
+	embeddingManager, err = newEmbeddingManager()
+	if err != nil {
+		return err
+	}
+
+	aiProviderRegistry = implementations.NewConfiguredAiProviderRegistry(configuration, logger)
 
 	db, err = gorm.Open(postgres.Open(configuration.DbConnectionString), &gorm.Config{})
 	if err != nil {
@@ -76,38 +165,151 @@ func InjectDeps() error {
 
 	configureServices()
 
+	err = promptTemplateService.SeedDefaults()
+	if err != nil {
+		return err
+	}
+
 	initializeHandlers()
 
 	return nil
 }
 
+func newEmbeddingManager() (managers.EmbeddingManager, error) {
+	switch strings.ToLower(configuration.EmbeddingProvider) {
+	case "", "local":
+		return implementations.NewLocalEmbeddingManager(), nil
+	case "openai":
+		return implementations.NewOpenAiEmbeddingManager(configuration, logger), nil
+	case "ollama":
+		return implementations.NewOllamaEmbeddingManager(configuration, logger), nil
+	default:
+		return nil, errors.New("configErrorUnknownEmbeddingProvider")
+	}
+}
+
+// A missing Tesseract binary only disables OCR, images are still accepted
+func newOcrManager() (managers.OcrManager, error) {
+	switch strings.ToLower(configuration.OcrProvider) {
+	case "", "none":
+		return implementations.NewNoopOcrManager(), nil
+	case "tesseract":
+		m := implementations.NewTesseractOcrManager(configuration.OcrBinary, configuration.OcrLanguages, logger)
+		if !m.IsAvailable() {
+			logger.Warn().Msg("Tesseract was not found, text in images will not be recognized")
+			return implementations.NewNoopOcrManager(), nil
+		}
+		return m, nil
+	default:
+		return nil, errors.New("configErrorUnknownOcrProvider")
+	}
+}
+
+// Mails are only logged until SMTP is configured, which is fine for development but leaks reset links anywhere else
+func newMailManager() (managers.MailManager, error) {
+	switch strings.ToLower(configuration.MailProvider) {
+	case "", "log":
+		logger.Warn().Msg("No mail provider is configured, mails are written to the log")
+		return implementations.NewLogMailManager(logger), nil
+	case "smtp":
+		if configuration.SmtpHost == "" || configuration.MailFrom == "" {
+			return nil, errors.New("configErrorMailNotConfigured")
+		}
+		return implementations.NewSmtpMailManager(configuration, logger), nil
+	default:
+		return nil, errors.New("configErrorUnknownMailProvider")
+	}
+}
+
 func initializeRepositories() {
-	noteRepository = util.NewGormRepository[entities.Note](db, []string{"Documents"})
-	documentRepository = util.NewGormRepository[entities.Document](db, []string{})
+	noteRepository = util.NewGormRepository[entities.Note](db, []string{"Documents", "Equations"})
+	documentRepository = util.NewGormRepository[entities.Document](db, []string{"Image"})
 	languageRepository = util.NewGormRepository[entities.Language](db, []string{"Notes", "Contexts"})
 	userRepository = util.NewGormRepository[entities.User](db, []string{"Contexts", "Documents", "Notes", "Languages"})
 	contextRepository = util.NewGormRepository[entities.Context](db, []string{"Notes", "Prompts", "Documents"})
 	promptRepository = util.NewGormRepository[entities.Prompt](db, []string{})
+	messageRepository = util.NewGormRepository[entities.Message](db, []string{"Citations"})
+	citationRepository = util.NewGormRepository[entities.Citation](db, []string{})
+	chunkRepository = util.NewGormRepository[entities.Chunk](db, []string{})
+	cachedEmbeddingRepository = util.NewGormRepository[entities.CachedEmbedding](db, []string{})
+	usageRecordRepository = util.NewGormRepository[entities.UsageRecord](db, []string{})
+	promptTemplateRepository = util.NewGormRepository[entities.PromptTemplate](db, []string{})
+	deckRepository = util.NewGormRepository[entities.Deck](db, []string{"Cards"})
+	cardRepository = util.NewGormRepository[entities.Card](db, []string{"Reviews"})
+	cardReviewRepository = util.NewGormRepository[entities.CardReview](db, []string{})
+	quizRepository = util.NewGormRepository[entities.Quiz](db, []string{})
+	questionRepository = util.NewGormRepository[entities.Question](db, []string{})
+	quizAttemptRepository = util.NewGormRepository[entities.QuizAttempt](db, []string{})
+	attemptAnswerRepository = util.NewGormRepository[entities.AttemptAnswer](db, []string{})
+	equationRepository = util.NewGormRepository[entities.Equation](db, []string{})
+	refreshTokenRepository = util.NewGormRepository[entities.RefreshToken](db, []string{})
+	revokedTokenRepository = util.NewGormRepository[entities.RevokedToken](db, []string{})
+	accountTokenRepository = util.NewGormRepository[entities.AccountToken](db, []string{})
+	passwordRepository = util.NewGormRepository[entities.Password](db, []string{})
+	twoFactorRepository = util.NewGormRepository[entities.TwoFactor](db, []string{})
+	recoveryCodeRepository = util.NewGormRepository[entities.RecoveryCode](db, []string{})
+	oidcLoginStateRepository = util.NewGormRepository[entities.OidcLoginState](db, []string{})
+	externalIdentityRepository = util.NewGormRepository[entities.ExternalIdentity](db, []string{})
+	apiKeyRepository = util.NewGormRepository[entities.ApiKey](db, []string{})
 }
 
 func configureServices() {
-	authService = services.NewAuthService(db, hasher, logger, configuration.GetSecretKey())
-	documentService = services.NewDocumentService(documentRepository, logger, fileManager)
+	tokenService = services.NewTokenService(refreshTokenRepository, revokedTokenRepository, userRepository, logger, signingKeyManager, configuration.AccessTokenMinutes, configuration.RefreshTokenDays)
+	twoFactorService = services.NewTwoFactorService(twoFactorRepository, recoveryCodeRepository, userRepository, logger, totpManager, passwordHasher, configuration.RequireAdminTwoFactor)
+	oidcService = services.NewOidcService(oidcLoginStateRepository, externalIdentityRepository, userRepository, logger, oidcManager)
+	apiKeyService = services.NewApiKeyService(apiKeyRepository, userRepository, logger)
+	authService = services.NewAuthService(db, passwordHasher, logger, tokenService, twoFactorService, oidcService, apiKeyService)
+	documentService = services.NewDocumentService(documentRepository, logger, fileManager, extractionManager, imageManager, ocrManager, configuration.AcceptedExtensions)
 	languageService = services.NewLanguageService(languageRepository, logger)
 	noteService = services.NewNoteService(noteRepository, logger)
-	userService = services.NewUserService(userRepository, logger, hasher)
-	contextService = services.NewContextService(contextRepository, logger)
-	promptService = services.NewPromptService(promptRepository, logger, promptManager, aiCommunicationManager)
+	equationService = services.NewEquationService(equationRepository, noteRepository, logger, equationManager)
+	accountService = services.NewAccountService(accountTokenRepository, userRepository, passwordRepository, logger, mailManager, passwordPolicyManager, passwordHasher, tokenService, configuration.PublicUrl)
+	userService = services.NewUserService(userRepository, logger, passwordHasher, passwordPolicyManager, accountService)
+	contextService = services.NewContextService(contextRepository, logger, aiProviderRegistry)
+	hubService = services.NewHubService(logger)
+	citationService = services.NewCitationService(citationRepository, noteRepository, documentRepository, logger)
+	retrievalService = services.NewRetrievalService(chunkRepository, cachedEmbeddingRepository, logger, chunkingManager, embeddingManager, configuration.RetrievalTopK)
+	promptTemplateService = services.NewPromptTemplateService(promptTemplateRepository, logger)
+	promptManager = implementations.NewLocalPromptGenManager(promptTemplateService)
+	usageService = services.NewUsageService(usageRecordRepository, userRepository, logger, configuration.TokenBudgets)
+	promptService = services.NewPromptService(promptRepository, messageRepository, contextRepository, logger, promptManager, aiProviderRegistry, retrievalService, usageService, configuration.AiContextWindowTokens)
+	deckService = services.NewDeckService(deckRepository, logger)
+	cardService = services.NewCardService(cardRepository, cardReviewRepository, logger, schedulingManager, promptService)
+	quizService = services.NewQuizService(quizRepository, questionRepository, quizAttemptRepository, attemptAnswerRepository, noteRepository, documentRepository, logger, promptService)
 }
 
 func DoMigrationsIfExists() error {
 	err := db.AutoMigrate(
 		&entities.User{},
 		&entities.Document{},
+		&entities.DocumentSection{},
+		&entities.DocumentImage{},
 		&entities.Note{},
+		&entities.Equation{},
 		&entities.Context{},
 		&entities.Prompt{},
+		&entities.Message{},
+		&entities.Citation{},
+		&entities.Chunk{},
+		&entities.CachedEmbedding{},
+		&entities.UsageRecord{},
+		&entities.PromptTemplate{},
 		&entities.Password{},
+		&entities.RefreshToken{},
+		&entities.RevokedToken{},
+		&entities.AccountToken{},
+		&entities.TwoFactor{},
+		&entities.RecoveryCode{},
+		&entities.OidcLoginState{},
+		&entities.ExternalIdentity{},
+		&entities.ApiKey{},
+		&entities.Deck{},
+		&entities.Card{},
+		&entities.CardReview{},
+		&entities.Quiz{},
+		&entities.Question{},
+		&entities.QuizAttempt{},
+		&entities.AttemptAnswer{},
 	)
 	if err != nil {
 		return err
@@ -122,9 +324,9 @@ func DoMigrationsIfExists() error {
 
 func initializeHandlers() {
 	utilHandlers = handlers.InitializeUtilHandlers(configuration)
-	anonymousHandlers = handlers.InitializeAnonymousHandlers(logger, userService, authService)
-	authorizedHandlers = handlers.InitializeAuthorizedHandlers(logger, userService, authService, noteService, languageService, documentService, contextService, promptService)
-	adminHandlers = handlers.InitializeAdminHandlers(logger, userService, noteService, languageService)
+	anonymousHandlers = handlers.InitializeAnonymousHandlers(logger, userService, authService, accountService, oidcService)
+	authorizedHandlers = handlers.InitializeAuthorizedHandlers(logger, userService, authService, noteService, languageService, documentService, contextService, promptService, hubService, citationService, usageService, deckService, cardService, quizService, equationService, twoFactorService, apiKeyService)
+	adminHandlers = handlers.InitializeAdminHandlers(logger, userService, noteService, languageService, usageService, promptTemplateService)
 }
 
 func MapEnpoints(g *gin.Engine) {
@@ -165,6 +367,22 @@ func GetAuthService() *services.AuthService {
 	return authService
 }
 
+func GetAccountService() *services.AccountService {
+	return accountService
+}
+
+func GetTwoFactorService() *services.TwoFactorService {
+	return twoFactorService
+}
+
+func GetOidcService() *services.OidcService {
+	return oidcService
+}
+
+func GetApiKeyService() *services.ApiKeyService {
+	return apiKeyService
+}
+
 func GetDocumentService() *services.DocumentService {
 	return documentService
 }
@@ -186,3 +404,39 @@ func GetContextService() *services.ContextService {
 func GetPromptService() *services.PromptService {
 	return promptService
 }
+
+func GetHubService() *services.HubService {
+	return hubService
+}
+
+func GetRetrievalService() *services.RetrievalService {
+	return retrievalService
+}
+
+func GetCitationService() *services.CitationService {
+	return citationService
+}
+
+func GetUsageService() *services.UsageService {
+	return usageService
+}
+
+func GetPromptTemplateService() *services.PromptTemplateService {
+	return promptTemplateService
+}
+
+func GetDeckService() *services.DeckService {
+	return deckService
+}
+
+func GetCardService() *services.CardService {
+	return cardService
+}
+
+func GetQuizService() *services.QuizService {
+	return quizService
+}
+
+func GetEquationService() *services.EquationService {
+	return equationService
+}
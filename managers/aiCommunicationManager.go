@@ -1,8 +1,37 @@
 package managers
 
+import "context"
+
 type AiCommunicationManager interface {
-	SendPrompt(string, string) (string, error)
+	SendPrompt(string, string) (Completion, error)
+	SendMessage(string, string) (Completion, error)
+	StreamMessage(context.Context, string, string, func(string) error) (Completion, error)
 	ResetContext(string, bool) error
 	DeleteContext(string, bool) error
-	CreateContext(string, bool) error
+	// Starts the conversation with the given system prompt, an empty one starts it without
+	CreateContext(string, string) error
+	HasContext(string) bool
+	// Answers a single message outside of any conversation
+	Complete(string) (Completion, error)
+	// Replaces the turns of the conversation that are not prompts with the given summary prompt, the last given number of them are kept
+	CompactContext(string, string, int) error
+}
+
+// Implemented by managers that can give images to their model, SupportsImages tells whether the configured model accepts them
+type ImageAiCommunicationManager interface {
+	SupportsImages() bool
+	SendPromptWithImages(string, string, []ImageAttachment) (Completion, error)
+}
+
+type Completion struct {
+	Content          string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Image given to a model that can look at it
+type ImageAttachment struct {
+	MimeType string
+	Data     []byte
 }
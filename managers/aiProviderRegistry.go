@@ -0,0 +1,5 @@
+package managers
+
+type AiProviderRegistry interface {
+	Get(provider string, model string) (AiCommunicationManager, error)
+}
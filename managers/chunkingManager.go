@@ -0,0 +1,12 @@
+package managers
+
+type ChunkingManager interface {
+	Split(string) []TextChunk
+}
+
+// Offsets are byte offsets of the chunk within the split text
+type TextChunk struct {
+	Content     string
+	StartOffset int
+	EndOffset   int
+}
@@ -0,0 +1,7 @@
+package managers
+
+type EmbeddingManager interface {
+	Embed([]string) ([][]float32, error)
+	// Identifies the vector space, vectors of different models are never compared
+	Model() string
+}
@@ -0,0 +1,15 @@
+package managers
+
+// Reads the LaTeX math of an equation, an equation that can not be rendered is not valid
+type EquationManager interface {
+	Render(string) (RenderedEquation, error)
+}
+
+type RenderedEquation struct {
+	// Source without surrounding math delimiters such as $$ or \[ \]
+	Latex string
+	// Presentation MathML for clients that can not render LaTeX
+	MathML string
+	// Plain text form for the assistant, e.g. "x = (-b ± sqrt(b^2 - 4ac))/(2a)"
+	Text string
+}
@@ -0,0 +1,18 @@
+package managers
+
+type ExtractionManager interface {
+	Extract([]byte, string) (ExtractedText, error)
+	Supports(string) bool
+}
+
+type ExtractedText struct {
+	Text     string
+	Sections []TextSection
+}
+
+// Sections are pages for paged formats and headings for the rest, offsets are byte offsets within Text
+type TextSection struct {
+	Title       string
+	StartOffset int
+	EndOffset   int
+}
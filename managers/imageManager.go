@@ -0,0 +1,29 @@
+package managers
+
+import "time"
+
+// Reads photos and screenshots, the text in them is left to an OcrManager
+type ImageManager interface {
+	Supports(string) bool
+	Process([]byte, string) (ProcessedImage, error)
+}
+
+type ProcessedImage struct {
+	MimeType string
+	Width    int
+	Height   int
+	// JPEG that fits in a square of the configured size, turned upright according to the EXIF orientation
+	Thumbnail []byte
+	Metadata  ImageMetadata
+}
+
+// Taken from EXIF, missing values are left empty
+type ImageMetadata struct {
+	TakenAt     *time.Time
+	CameraMake  string
+	CameraModel string
+	// EXIF orientation from 1 (upright) to 8
+	Orientation int
+	Latitude    *float64
+	Longitude   *float64
+}
@@ -0,0 +1,224 @@
+package implementations
+
+import (
+	"context"
+	"echo-api/managers"
+	"echo-api/util"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const anthropicVersion = "2023-06-01"
+const defaultAnthropicMaxTokens = 1024
+
+// Talks to an Anthropic Messages style "/v1/messages" endpoint, where system prompts travel outside of the messages
+type anthropicChatAdapter struct {
+	baseUrl   string
+	apiKey    string
+	maxTokens int
+	logger    *util.Logger
+	client    *http.Client
+}
+
+type anthropicRequest struct {
+	Model     string `json:"model"`
+	MaxTokens int    `json:"max_tokens"`
+	System    string `json:"system,omitempty"`
+	Messages  []any  `json:"messages"`
+	Stream    bool   `json:"stream,omitempty"`
+}
+
+// Messages with images send their content as blocks, images come before the text as the provider recommends
+type anthropicBlocksMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicContentBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *anthropicImageSource `json:"source,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+type anthropicStreamEvent struct {
+	Type    string             `json:"type"`
+	Message *anthropicResponse `json:"message"`
+	Delta   struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Usage *anthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewAnthropicCommunicationManager(provider util.AiProvider, model string, logger *util.Logger) *ChatCommunicationManager {
+	maxTokens := provider.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+	adapter := &anthropicChatAdapter{
+		baseUrl:   provider.BaseUrl,
+		apiKey:    provider.ApiKey,
+		maxTokens: maxTokens,
+		logger:    logger,
+		client:    &http.Client{Timeout: getProviderTimeout(provider)},
+	}
+	return newChatCommunicationManager(adapter, model, provider.SupportsImages)
+}
+
+func (a *anthropicChatAdapter) complete(ctx context.Context, model string, messages []chatMessage) (managers.Completion, error) {
+	resp, err := a.postMessages(ctx, a.newRequest(model, messages, false))
+	if err != nil {
+		return managers.Completion{}, err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("AnthropicCommunicationManager_complete could not read the provider response")
+		return managers.Completion{}, errors.New("aiErrorProviderInvalidResponse")
+	}
+	var message anthropicResponse
+	err = json.Unmarshal(payload, &message)
+	if err != nil || len(message.Content) == 0 {
+		a.logger.Error().Err(err).Msg(fmt.Sprintf("AnthropicCommunicationManager_complete got an unexpected body: %s", string(payload)))
+		return managers.Completion{}, errors.New("aiErrorProviderInvalidResponse")
+	}
+
+	var sb strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	return managers.Completion{
+		Content:          sb.String(),
+		Model:            message.Model,
+		PromptTokens:     message.Usage.InputTokens,
+		CompletionTokens: message.Usage.OutputTokens,
+	}, nil
+}
+
+// Reads the "data: {...}" server-sent events until "message_stop", usage is split between "message_start" and "message_delta"
+func (a *anthropicChatAdapter) stream(ctx context.Context, model string, messages []chatMessage, onDelta func(string) error) (managers.Completion, error) {
+	var res managers.Completion
+	resp, err := a.postMessages(ctx, a.newRequest(model, messages, true))
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	err = scanProviderStream(ctx, resp.Body, a.logger, "AnthropicCommunicationManager_stream", func(line string) (bool, error) {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return false, nil
+		}
+		var event anthropicStreamEvent
+		err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event)
+		if err != nil {
+			a.logger.Error().Err(err).Msg(fmt.Sprintf("AnthropicCommunicationManager_stream got an unexpected event: %s", data))
+			return true, errors.New("aiErrorProviderInvalidResponse")
+		}
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				res.Model = event.Message.Model
+				res.PromptTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				return false, nil
+			}
+			sb.WriteString(event.Delta.Text)
+			err = onDelta(event.Delta.Text)
+			if err != nil {
+				return true, err
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				res.CompletionTokens = event.Usage.OutputTokens
+			}
+		case "message_stop":
+			return true, nil
+		case "error":
+			a.logger.Error().Msg(fmt.Sprintf("AnthropicCommunicationManager_stream provider sent an error: %s", data))
+			if event.Error != nil && event.Error.Type == "rate_limit_error" {
+				return true, errors.New("aiErrorProviderRateLimited")
+			}
+			return true, errors.New("aiErrorProviderRejected")
+		}
+		return false, nil
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Content = sb.String()
+
+	return res, nil
+}
+
+func (a *anthropicChatAdapter) newRequest(model string, messages []chatMessage, stream bool) anthropicRequest {
+	request := anthropicRequest{Model: model, MaxTokens: a.maxTokens, Messages: make([]any, 0, len(messages)), Stream: stream}
+	system := make([]string, 0)
+	for _, m := range messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		if len(m.images) > 0 {
+			request.Messages = append(request.Messages, toAnthropicBlocksMessage(m))
+			continue
+		}
+		request.Messages = append(request.Messages, m)
+	}
+	request.System = strings.Join(system, "\n\n")
+	return request
+}
+
+func toAnthropicBlocksMessage(m chatMessage) anthropicBlocksMessage {
+	blocks := make([]anthropicContentBlock, 0, len(m.images)+1)
+	for _, image := range m.images {
+		source := &anthropicImageSource{Type: "base64", MediaType: image.MimeType, Data: base64.StdEncoding.EncodeToString(image.Data)}
+		blocks = append(blocks, anthropicContentBlock{Type: "image", Source: source})
+	}
+	blocks = append(blocks, anthropicContentBlock{Type: "text", Text: m.Content})
+	return anthropicBlocksMessage{Role: m.Role, Content: blocks}
+}
+
+func (a *anthropicChatAdapter) postMessages(ctx context.Context, request anthropicRequest) (*http.Response, error) {
+	if a.baseUrl == "" {
+		return nil, errors.New("aiErrorNotConfigured")
+	}
+	url := strings.TrimRight(a.baseUrl, "/") + "/v1/messages"
+	headers := map[string]string{"x-api-key": a.apiKey, "anthropic-version": anthropicVersion}
+	return postToProvider(ctx, a.client, a.logger, "AnthropicCommunicationManager_postMessages", url, "", request, headers)
+}
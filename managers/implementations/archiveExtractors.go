@@ -0,0 +1,216 @@
+package implementations
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Office documents and epubs are zip archives of xml files
+
+// A small archive can inflate to gigabytes, entries past this size are refused rather than read into memory
+const maxZipEntryBytes = 64 << 20
+
+func extractDocx(content []byte, b *textBuilder) error {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return errors.New("extractionErrorInvalidDocument")
+	}
+	document, err := readZipEntry(archive, "word/document.xml")
+	if err != nil {
+		return err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	var paragraph strings.Builder
+	isHeading := false
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.New("extractionErrorInvalidDocument")
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				isHeading = false
+			case "pStyle":
+				style := strings.ToLower(xmlAttr(t, "val"))
+				isHeading = strings.HasPrefix(style, "heading") || style == "title"
+			case "t":
+				inText = true
+			case "tab", "br", "cr":
+				paragraph.WriteString(" ")
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				writeXmlParagraph(b, paragraph.String(), isHeading)
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+	return nil
+}
+
+func extractOdt(content []byte, b *textBuilder) error {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return errors.New("extractionErrorInvalidDocument")
+	}
+	document, err := readZipEntry(archive, "content.xml")
+	if err != nil {
+		return err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(document))
+	var paragraph strings.Builder
+	// Paragraphs may be nested, e.g. inside notes, so only the outermost one is written
+	depth := 0
+	isHeading := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.New("extractionErrorInvalidDocument")
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p", "h":
+				if depth == 0 {
+					paragraph.Reset()
+					isHeading = t.Name.Local == "h"
+				}
+				depth++
+			case "s", "tab", "line-break":
+				paragraph.WriteString(" ")
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" || t.Name.Local == "h" {
+				depth--
+				if depth == 0 {
+					writeXmlParagraph(b, paragraph.String(), isHeading)
+				}
+			}
+		case xml.CharData:
+			if depth > 0 {
+				paragraph.Write(t)
+			}
+		}
+	}
+	return nil
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Items []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// Chapters are read in spine order, which is the reading order of the book
+func extractEpub(content []byte, b *textBuilder) error {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return errors.New("extractionErrorInvalidDocument")
+	}
+	containerXml, err := readZipEntry(archive, "META-INF/container.xml")
+	if err != nil {
+		return err
+	}
+	var container epubContainer
+	err = xml.Unmarshal(containerXml, &container)
+	if err != nil || len(container.Rootfiles) == 0 {
+		return errors.New("extractionErrorInvalidDocument")
+	}
+	packagePath := container.Rootfiles[0].FullPath
+	packageXml, err := readZipEntry(archive, packagePath)
+	if err != nil {
+		return err
+	}
+	var pkg epubPackage
+	err = xml.Unmarshal(packageXml, &pkg)
+	if err != nil {
+		return errors.New("extractionErrorInvalidDocument")
+	}
+
+	hrefs := make(map[string]string, len(pkg.Items))
+	for _, item := range pkg.Items {
+		hrefs[item.ID] = item.Href
+	}
+	for _, itemRef := range pkg.Spine {
+		href, ok := hrefs[itemRef.IDRef]
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		chapter, err := readZipEntry(archive, path.Join(path.Dir(packagePath), href))
+		if err != nil {
+			return err
+		}
+		err = extractHtml(chapter, b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeXmlParagraph(b *textBuilder, paragraph string, isHeading bool) {
+	if isHeading {
+		title := strings.Join(strings.Fields(paragraph), " ")
+		if title != "" {
+			b.startSection(title)
+		}
+	}
+	b.writeParagraph(paragraph)
+}
+
+func readZipEntry(archive *zip.Reader, name string) ([]byte, error) {
+	f, err := archive.Open(name)
+	if err != nil {
+		return nil, errors.New("extractionErrorInvalidDocument")
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, maxZipEntryBytes+1))
+	if err != nil || len(content) > maxZipEntryBytes {
+		return nil, errors.New("extractionErrorInvalidDocument")
+	}
+	return content, nil
+}
+
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
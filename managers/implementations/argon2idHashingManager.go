@@ -0,0 +1,114 @@
+package implementations
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"echo-api/managers"
+	"echo-api/util"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+const (
+	defaultArgon2MemoryKiB   = 64 * 1024
+	defaultArgon2Iterations  = 3
+	defaultArgon2Parallelism = 2
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+)
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+/* This implementation stores passwords as Argon2id PHC strings, "$argon2id$v=19$m=65536,t=3,p=2$salt$hash", with a random salt per password.
+ * Hashes that are not PHC strings were made by the legacy manager and are verified with it, NeedsRehash reports them for an upgrade.
+ */
+type Argon2idHashingManager struct {
+	params argon2Params
+	legacy managers.HashingManager
+}
+
+func NewArgon2idHashingManager(configuration *util.Configuration, legacy managers.HashingManager) *Argon2idHashingManager {
+	params := argon2Params{memory: defaultArgon2MemoryKiB, iterations: defaultArgon2Iterations, parallelism: defaultArgon2Parallelism}
+	if configuration.Argon2MemoryKiB > 0 {
+		params.memory = uint32(configuration.Argon2MemoryKiB)
+	}
+	if configuration.Argon2Iterations > 0 {
+		params.iterations = uint32(configuration.Argon2Iterations)
+	}
+	if configuration.Argon2Parallelism > 0 {
+		params.parallelism = uint8(min(configuration.Argon2Parallelism, 255))
+	}
+	return &Argon2idHashingManager{params: params, legacy: legacy}
+}
+
+func (h *Argon2idHashingManager) GetHash(s string) (string, error) {
+	salt := make([]byte, argon2SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(s), salt, h.params.iterations, h.params.memory, h.params.parallelism, argon2KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.params.memory, h.params.iterations, h.params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Settings are read from the hash, so passwords hashed before the settings changed keep working
+func (h *Argon2idHashingManager) Verify(hashed string, new string) (bool, error) {
+	if !strings.HasPrefix(hashed, argon2idPrefix) {
+		if h.legacy == nil {
+			return false, nil
+		}
+		return h.legacy.Verify(hashed, new)
+	}
+	params, salt, key, err := parseArgon2idHash(hashed)
+	if err != nil {
+		return false, err
+	}
+	res := argon2.IDKey([]byte(new), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(res, key) == 1, nil
+}
+
+func (h *Argon2idHashingManager) NeedsRehash(hashed string) bool {
+	if !strings.HasPrefix(hashed, argon2idPrefix) {
+		return true
+	}
+	params, _, _, err := parseArgon2idHash(hashed)
+	return err != nil || params != h.params
+}
+
+func parseArgon2idHash(hashed string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("hashingErrorInvalidHash")
+	}
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("hashingErrorInvalidHash")
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil || params.memory == 0 || params.iterations == 0 || params.parallelism == 0 {
+		return params, nil, nil, errors.New("hashingErrorInvalidHash")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errors.New("hashingErrorInvalidHash")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("hashingErrorInvalidHash")
+	}
+	return params, salt, key, nil
+}
@@ -3,12 +3,15 @@ package implementations
 import (
 	"echo-api/util"
 	"encoding/hex"
+	"sync"
 
 	"github.com/zeebo/blake3"
 )
 
 type Blake3HashingManager struct {
 	hasher *blake3.Hasher
+	// Hasher keeps state between Write and Sum, so it is shared by one caller at a time
+	mutex sync.Mutex
 }
 
 func NewBlake3HashingManager(configuration *util.Configuration) (*Blake3HashingManager, error) {
@@ -22,6 +25,8 @@ func NewBlake3HashingManager(configuration *util.Configuration) (*Blake3HashingM
 }
 
 func (h *Blake3HashingManager) GetHash(s string) (string, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 	count, err := h.hasher.Write([]byte(s))
 	if err != nil || count == 0 {
 		return "", err
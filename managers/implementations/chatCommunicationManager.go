@@ -0,0 +1,228 @@
+package implementations
+
+import (
+	"context"
+	"echo-api/managers"
+	"errors"
+	"sync"
+)
+
+// Adapters translate a conversation to the wire format of a provider
+type chatAdapter interface {
+	complete(ctx context.Context, model string, messages []chatMessage) (managers.Completion, error)
+	stream(ctx context.Context, model string, messages []chatMessage, onDelta func(string) error) (managers.Completion, error)
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Prompt turns survive a soft reset
+	isPrompt bool
+	// Summary turns are replaced by the next compaction
+	isSummary bool
+	// Only sent by adapters of models that can look at images
+	images []managers.ImageAttachment
+}
+
+/* This implementation keeps the conversation of every context in memory and sends it to a single model through a chatAdapter.
+ * After a restart a context starts again from the initial prompt.
+ */
+type ChatCommunicationManager struct {
+	adapter        chatAdapter
+	model          string
+	supportsImages bool
+	mutex          sync.Mutex
+	conversations  map[string][]chatMessage
+}
+
+func newChatCommunicationManager(adapter chatAdapter, model string, supportsImages bool) *ChatCommunicationManager {
+	return &ChatCommunicationManager{adapter: adapter, model: model, supportsImages: supportsImages, conversations: make(map[string][]chatMessage)}
+}
+
+func (cm *ChatCommunicationManager) SendPrompt(contextID string, msg string) (managers.Completion, error) {
+	return cm.send(contextID, msg, true, nil)
+}
+
+func (cm *ChatCommunicationManager) SendMessage(contextID string, msg string) (managers.Completion, error) {
+	return cm.send(contextID, msg, false, nil)
+}
+
+func (cm *ChatCommunicationManager) SupportsImages() bool {
+	return cm.supportsImages
+}
+
+// Images stay in the conversation with the prompt, so the model can look at them again for later messages
+func (cm *ChatCommunicationManager) SendPromptWithImages(contextID string, msg string, images []managers.ImageAttachment) (managers.Completion, error) {
+	if !cm.supportsImages {
+		images = nil
+	}
+	return cm.send(contextID, msg, true, images)
+}
+
+func (cm *ChatCommunicationManager) send(contextID string, msg string, isPrompt bool, images []managers.ImageAttachment) (managers.Completion, error) {
+	if cm.model == "" {
+		return managers.Completion{}, errors.New("aiErrorNotConfigured")
+	}
+	history := cm.getOrCreateConversation(contextID)
+	turn := chatMessage{Role: "user", Content: msg, isPrompt: isPrompt, images: images}
+	messages := append(history, turn)
+
+	res, err := cm.adapter.complete(context.Background(), cm.model, messages)
+	if err != nil {
+		return managers.Completion{}, err
+	}
+	cm.appendToConversation(contextID, turn, chatMessage{Role: "assistant", Content: res.Content, isPrompt: isPrompt})
+
+	if res.Model == "" {
+		res.Model = cm.model
+	}
+	return res, nil
+}
+
+func (cm *ChatCommunicationManager) Complete(msg string) (managers.Completion, error) {
+	if cm.model == "" {
+		return managers.Completion{}, errors.New("aiErrorNotConfigured")
+	}
+	res, err := cm.adapter.complete(context.Background(), cm.model, []chatMessage{{Role: "user", Content: msg}})
+	if err != nil {
+		return managers.Completion{}, err
+	}
+
+	if res.Model == "" {
+		res.Model = cm.model
+	}
+	return res, nil
+}
+
+func (cm *ChatCommunicationManager) StreamMessage(ctx context.Context, contextID string, msg string, onDelta func(string) error) (managers.Completion, error) {
+	if cm.model == "" {
+		return managers.Completion{}, errors.New("aiErrorNotConfigured")
+	}
+	history := cm.getOrCreateConversation(contextID)
+	messages := append(history, chatMessage{Role: "user", Content: msg})
+
+	res, err := cm.adapter.stream(ctx, cm.model, messages, onDelta)
+	if err != nil {
+		return managers.Completion{}, err
+	}
+	cm.appendToConversation(contextID, chatMessage{Role: "user", Content: msg}, chatMessage{Role: "assistant", Content: res.Content})
+
+	if res.Model == "" {
+		res.Model = cm.model
+	}
+	return res, nil
+}
+
+// Soft reset keeps the system prompt and remembered material, hard reset keeps only the system prompt
+func (cm *ChatCommunicationManager) ResetContext(contextID string, isSoftReset bool) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	conversation, ok := cm.conversations[contextID]
+	if !ok {
+		cm.conversations[contextID] = newConversation(defaultInitialPrompt())
+		return nil
+	}
+
+	kept := make([]chatMessage, 0, len(conversation))
+	for _, turn := range conversation {
+		if turn.Role == "system" {
+			kept = append(kept, turn)
+		} else if isSoftReset && turn.isPrompt {
+			kept = append(kept, turn)
+		}
+	}
+	cm.conversations[contextID] = kept
+
+	return nil
+}
+
+// The summary is answered like any other prompt so user and assistant turns keep alternating
+func (cm *ChatCommunicationManager) CompactContext(contextID string, summary string, keep int) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	conversation := cm.conversations[contextID]
+
+	messageCount := 0
+	for _, turn := range conversation {
+		if turn.Role != "system" && !turn.isPrompt {
+			messageCount++
+		}
+	}
+	kept := make([]chatMessage, 0, len(conversation))
+	recent := make([]chatMessage, 0, keep)
+	for _, turn := range conversation {
+		switch {
+		case turn.isSummary:
+		case turn.Role == "system" || turn.isPrompt:
+			kept = append(kept, turn)
+		case messageCount <= keep:
+			recent = append(recent, turn)
+			messageCount--
+		default:
+			messageCount--
+		}
+	}
+	kept = append(kept,
+		chatMessage{Role: "user", Content: summary, isPrompt: true, isSummary: true},
+		chatMessage{Role: "assistant", Content: "done", isPrompt: true, isSummary: true},
+	)
+	cm.conversations[contextID] = append(kept, recent...)
+
+	return nil
+}
+
+func (cm *ChatCommunicationManager) DeleteContext(contextID string, ignoreMissing bool) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	if _, ok := cm.conversations[contextID]; !ok && !ignoreMissing {
+		return errors.New("aiErrorContextNotFound")
+	}
+	delete(cm.conversations, contextID)
+
+	return nil
+}
+
+func (cm *ChatCommunicationManager) CreateContext(contextID string, initialPrompt string) error {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.conversations[contextID] = newConversation(initialPrompt)
+
+	return nil
+}
+
+func (cm *ChatCommunicationManager) HasContext(contextID string) bool {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	_, ok := cm.conversations[contextID]
+	return ok
+}
+
+func (cm *ChatCommunicationManager) getOrCreateConversation(contextID string) []chatMessage {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	conversation, ok := cm.conversations[contextID]
+	if !ok {
+		conversation = newConversation(defaultInitialPrompt())
+		cm.conversations[contextID] = conversation
+	}
+
+	return append(make([]chatMessage, 0, len(conversation)+1), conversation...)
+}
+
+func (cm *ChatCommunicationManager) appendToConversation(contextID string, turns ...chatMessage) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+	cm.conversations[contextID] = append(cm.conversations[contextID], turns...)
+}
+
+func newConversation(initialPrompt string) []chatMessage {
+	if initialPrompt == "" {
+		return make([]chatMessage, 0)
+	}
+	return []chatMessage{{Role: "system", Content: initialPrompt}}
+}
+
+// Used when a conversation is started without CreateContext
+func defaultInitialPrompt() string {
+	return managers.DefaultTemplates[managers.Initial]
+}
@@ -0,0 +1,90 @@
+package implementations
+
+import (
+	"echo-api/managers"
+	"echo-api/util"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+/* This implementation builds a communication manager for every provider and model pair named in configuration.
+ * Managers are created on first use and shared afterwards, so conversations of contexts on the same model live together.
+ */
+type ConfiguredAiProviderRegistry struct {
+	providers       map[string]util.AiProvider
+	defaultProvider string
+	enabled         bool
+	logger          *util.Logger
+	echo            *EchoCommunicationManager
+	mutex           sync.Mutex
+	managers        map[string]managers.AiCommunicationManager
+}
+
+func NewConfiguredAiProviderRegistry(c *util.Configuration, logger *util.Logger) *ConfiguredAiProviderRegistry {
+	providers := make(map[string]util.AiProvider)
+	for _, p := range c.GetAiProviders() {
+		providers[p.Name] = p
+	}
+	return &ConfiguredAiProviderRegistry{
+		providers:       providers,
+		defaultProvider: c.GetDefaultAiProvider(),
+		enabled:         c.IsAiAssistantEnabled,
+		logger:          logger,
+		echo:            NewEchoCommunicationManager(),
+		managers:        make(map[string]managers.AiCommunicationManager),
+	}
+}
+
+// Empty provider or model falls back to the configured defaults
+func (r *ConfiguredAiProviderRegistry) Get(provider string, model string) (managers.AiCommunicationManager, error) {
+	if !r.enabled {
+		return r.echo, nil
+	}
+	p, model, err := r.resolve(provider, model)
+	if err != nil {
+		return nil, err
+	}
+
+	key := p.Name + "/" + model
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if m, ok := r.managers[key]; ok {
+		return m, nil
+	}
+	r.logger.Debug().Msg(fmt.Sprintf("ConfiguredAiProviderRegistry_Get creating manager for %s", key))
+	var m managers.AiCommunicationManager
+	switch strings.ToLower(p.Type) {
+	case "", "openai":
+		m = NewOpenAiCommunicationManager(p, model, r.logger)
+	case "anthropic":
+		m = NewAnthropicCommunicationManager(p, model, r.logger)
+	case "ollama":
+		m = NewOllamaCommunicationManager(p, model, r.logger)
+	default:
+		return nil, errors.New("aiErrorUnknownProviderType")
+	}
+	r.managers[key] = m
+
+	return m, nil
+}
+
+// Returns the configured provider and the model that a context asking for them would be routed to
+func (r *ConfiguredAiProviderRegistry) resolve(provider string, model string) (util.AiProvider, string, error) {
+	if provider == "" {
+		provider = r.defaultProvider
+	}
+	p, ok := r.providers[provider]
+	if !ok {
+		return util.AiProvider{}, "", errors.New("aiErrorUnknownProvider")
+	}
+	if model == "" {
+		model = p.DefaultModel
+	}
+	if len(p.Models) > 0 && !slices.Contains(p.Models, model) && model != p.DefaultModel {
+		return util.AiProvider{}, "", errors.New("aiErrorUnknownModel")
+	}
+	return p, model, nil
+}
@@ -0,0 +1,339 @@
+package implementations
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"echo-api/managers"
+	"echo-api/util"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const oidcTimeout = 15 * time.Second
+
+// Responses of providers are small, anything larger is not what was asked for
+const maxOidcResponseBytes = 1 << 20
+
+type oidcDiscovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JwksUri               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+type oidcTokenResponse struct {
+	IdToken string `json:"id_token"`
+}
+
+type oidcProviderState struct {
+	discovery *oidcDiscovery
+	keys      map[string]any
+}
+
+/* This implementation reads the endpoints of every provider named in configuration from its discovery document on first use.
+ * ID tokens have to be signed with RS256 or ES256 by a key of the provider's JWKS, which is fetched again when a token names an unknown key.
+ */
+type ConfiguredOidcManager struct {
+	providers map[string]util.OidcProvider
+	logger    *util.Logger
+	client    *http.Client
+	mutex     sync.Mutex
+	states    map[string]*oidcProviderState
+}
+
+func NewConfiguredOidcManager(c *util.Configuration, logger *util.Logger) *ConfiguredOidcManager {
+	providers := make(map[string]util.OidcProvider)
+	for _, p := range c.OidcProviders {
+		providers[p.Name] = p
+	}
+	return &ConfiguredOidcManager{
+		providers: providers,
+		logger:    logger,
+		client:    &http.Client{Timeout: oidcTimeout},
+		states:    make(map[string]*oidcProviderState),
+	}
+}
+
+func (m *ConfiguredOidcManager) Providers() []string {
+	res := make([]string, 0, len(m.providers))
+	for name := range m.providers {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (m *ConfiguredOidcManager) AuthorizationUrl(provider string, state string, nonce string, codeChallenge string) (string, error) {
+	p, ok := m.providers[provider]
+	if !ok {
+		return "", errors.New("oidcErrorUnknownProvider")
+	}
+	discovery, err := m.discover(p)
+	if err != nil {
+		return "", err
+	}
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	} else if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", p.RedirectUrl)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+func (m *ConfiguredOidcManager) Exchange(provider string, code string, codeVerifier string, nonce string) (managers.OidcIdentity, error) {
+	p, ok := m.providers[provider]
+	if !ok {
+		return managers.OidcIdentity{}, errors.New("oidcErrorUnknownProvider")
+	}
+	discovery, err := m.discover(p)
+	if err != nil {
+		return managers.OidcIdentity{}, err
+	}
+	idToken, err := m.redeemCode(p, discovery, code, codeVerifier)
+	if err != nil {
+		return managers.OidcIdentity{}, err
+	}
+	claims, err := m.verifyIdToken(p, discovery, idToken, nonce)
+	if err != nil {
+		return managers.OidcIdentity{}, err
+	}
+
+	identity := managers.OidcIdentity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	if identity.Name == "" {
+		identity.Name, _ = claims["preferred_username"].(string)
+	}
+	// Some providers send the flag as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	identity.EmailVerified = identity.EmailVerified || p.TrustEmails
+	if identity.Subject == "" {
+		return managers.OidcIdentity{}, errors.New("oidcErrorInvalidIdToken")
+	}
+	return identity, nil
+}
+
+// Secrets go in a basic authorization header unless the provider only takes them in the body
+func (m *ConfiguredOidcManager) redeemCode(p util.OidcProvider, discovery *oidcDiscovery, code string, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectUrl)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.ClientID)
+	postSecret := p.ClientSecret != "" && slices.Contains(discovery.TokenAuthMethods, "client_secret_post") && !slices.Contains(discovery.TokenAuthMethods, "client_secret_basic")
+	if postSecret {
+		form.Set("client_secret", p.ClientSecret)
+	}
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" && !postSecret {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	var token oidcTokenResponse
+	err = m.doJson(req, &token)
+	if err != nil {
+		m.logger.Error().Err(err).Msg(fmt.Sprintf("ConfiguredOidcManager_redeemCode could not redeem the code at %s", p.Name))
+		return "", errors.New("oidcErrorExchangeFailed")
+	}
+	if token.IdToken == "" {
+		return "", errors.New("oidcErrorInvalidIdToken")
+	}
+	return token.IdToken, nil
+}
+
+// The nonce ties the token to the login that was started here, so a token taken from another login is refused
+func (m *ConfiguredOidcManager) verifyIdToken(p util.OidcProvider, discovery *oidcDiscovery, idToken string, nonce string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.Alg() {
+		case "RS256", "ES256":
+		default:
+			return nil, errors.New("oidcErrorInvalidIdToken")
+		}
+		kid, _ := token.Header["kid"].(string)
+		return m.keyOf(p, discovery, kid)
+	})
+	if err != nil {
+		m.logger.Debug().Msg(fmt.Sprintf("ConfiguredOidcManager_verifyIdToken rejected the token of %s: %s", p.Name, err.Error()))
+		return nil, errors.New("oidcErrorInvalidIdToken")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("oidcErrorInvalidIdToken")
+	}
+	if claims["iss"] != discovery.Issuer || claims["nonce"] != nonce || !hasAudience(claims["aud"], p.ClientID) {
+		return nil, errors.New("oidcErrorInvalidIdToken")
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != p.ClientID {
+		return nil, errors.New("oidcErrorInvalidIdToken")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("oidcErrorInvalidIdToken")
+	}
+	return claims, nil
+}
+
+func hasAudience(aud any, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []any:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *ConfiguredOidcManager) discover(p util.OidcProvider) (*oidcDiscovery, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if state, ok := m.states[p.Name]; ok {
+		return state.discovery, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(p.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery oidcDiscovery
+	err = m.doJson(req, &discovery)
+	if err != nil {
+		m.logger.Error().Err(err).Msg(fmt.Sprintf("ConfiguredOidcManager_discover could not read the discovery document of %s", p.Name))
+		return nil, errors.New("oidcErrorProviderUnreachable")
+	}
+	// The document has to be about the configured issuer, or tokens of another issuer would be taken
+	if strings.TrimRight(discovery.Issuer, "/") != strings.TrimRight(p.Issuer, "/") || discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksUri == "" {
+		m.logger.Error().Msg(fmt.Sprintf("ConfiguredOidcManager_discover got an invalid discovery document from %s", p.Name))
+		return nil, errors.New("oidcErrorProviderUnreachable")
+	}
+	m.states[p.Name] = &oidcProviderState{discovery: &discovery, keys: make(map[string]any)}
+	return &discovery, nil
+}
+
+// Keys are fetched again when the kid is unknown, as providers rotate them
+func (m *ConfiguredOidcManager) keyOf(p util.OidcProvider, discovery *oidcDiscovery, kid string) (any, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	state := m.states[p.Name]
+	if key, ok := state.keys[kid]; ok {
+		return key, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, discovery.JwksUri, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set managers.JsonWebKeySet
+	err = m.doJson(req, &set)
+	if err != nil {
+		m.logger.Error().Err(err).Msg(fmt.Sprintf("ConfiguredOidcManager_keyOf could not read the keys of %s", p.Name))
+		return nil, errors.New("oidcErrorProviderUnreachable")
+	}
+	state.keys = make(map[string]any)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := publicKeyOf(jwk)
+		if err != nil {
+			continue
+		}
+		state.keys[jwk.KeyID] = key
+	}
+	key, ok := state.keys[kid]
+	if !ok {
+		return nil, errors.New("tokenErrorUnknownKey")
+	}
+	return key, nil
+}
+
+func publicKeyOf(jwk managers.JsonWebKey) (any, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) > 4 {
+			return nil, errors.New("oidcErrorInvalidKey")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, errors.New("oidcErrorInvalidKey")
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("oidcErrorInvalidKey")
+		}
+		return key, nil
+	}
+	return nil, errors.New("oidcErrorInvalidKey")
+}
+
+func (m *ConfiguredOidcManager) doJson(req *http.Request, target any) error {
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxOidcResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d: %s", req.URL.Host, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, target)
+}
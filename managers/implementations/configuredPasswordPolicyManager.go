@@ -0,0 +1,88 @@
+package implementations
+
+import (
+	"echo-api/util"
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const defaultPasswordMinLength = 10
+
+// Configuration can make the policy stricter but not weaker than this
+const lowestPasswordMinLength = 8
+
+// Hashing cost grows with the length, nobody types more than this
+const passwordMaxLength = 256
+
+// Passwords this long are taken as passphrases and do not need mixed characters
+const passphraseLength = 20
+
+// Parts of the email and name shorter than this are too common to reject passwords for
+const minPersonalPartLength = 4
+
+/* This implementation asks for a minimum length and at least three of lowercase, uppercase, digits and symbols, unless the password is a long passphrase.
+ * Passwords that contain the email or a part of the name of the user are rejected, as those are the first guesses of an attacker.
+ */
+type ConfiguredPasswordPolicyManager struct {
+	minLength int
+}
+
+func NewConfiguredPasswordPolicyManager(c *util.Configuration) *ConfiguredPasswordPolicyManager {
+	minLength := c.PasswordMinLength
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+	return &ConfiguredPasswordPolicyManager{minLength: max(minLength, lowestPasswordMinLength)}
+}
+
+func (m *ConfiguredPasswordPolicyManager) Check(password string, personal ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < m.minLength {
+		return errors.New("passwordErrorTooShort")
+	}
+	if length > passwordMaxLength {
+		return errors.New("passwordErrorTooLong")
+	}
+	if length < passphraseLength && characterClasses(password) < 3 {
+		return errors.New("passwordErrorTooWeak")
+	}
+	lowered := strings.ToLower(password)
+	for _, part := range personalParts(personal) {
+		if strings.Contains(lowered, part) {
+			return errors.New("passwordErrorContainsPersonalInfo")
+		}
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+// Splits emails at the @ and names at spaces, so "jane.doe@mail.com" gives "jane.doe" and "mail.com"
+func personalParts(personal []string) []string {
+	res := make([]string, 0)
+	for _, p := range personal {
+		for _, part := range strings.FieldsFunc(strings.ToLower(p), func(r rune) bool { return r == '@' || unicode.IsSpace(r) }) {
+			if utf8.RuneCountInString(part) >= minPersonalPartLength {
+				res = append(res, part)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,220 @@
+package implementations
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"echo-api/managers"
+	"echo-api/util"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+)
+
+const minSigningSecretLength = 32
+const minRsaKeyBits = 2048
+
+/* This implementation loads HS256 secrets and RS256 or ES256 PEM keys named in configuration.
+ * Rotating means adding a new key, making it the active one and removing the old one once the tokens it signed have expired.
+ * Without any configured key a random secret is generated, tokens then do not survive a restart and are not shared between instances.
+ */
+type ConfiguredSigningKeyManager struct {
+	keys   map[string]managers.SigningKey
+	active string
+}
+
+func NewConfiguredSigningKeyManager(c *util.Configuration, logger *util.Logger) (*ConfiguredSigningKeyManager, error) {
+	m := &ConfiguredSigningKeyManager{keys: make(map[string]managers.SigningKey), active: c.GetActiveSigningKey()}
+	for _, kc := range c.GetSigningKeys() {
+		if kc.ID == "" {
+			return nil, errors.New("configErrorInvalidSigningKey")
+		}
+		if _, ok := m.keys[kc.ID]; ok {
+			return nil, errors.New("configErrorDuplicateSigningKey")
+		}
+		key, err := loadSigningKey(kc)
+		if err != nil {
+			logger.Error().Err(err).Msg(fmt.Sprintf("ConfiguredSigningKeyManager could not load the signing key: %s", kc.ID))
+			return nil, err
+		}
+		m.keys[kc.ID] = key
+	}
+	if len(m.keys) == 0 {
+		logger.Warn().Msg("No signing keys are configured, tokens are signed with a random key that is lost on restart")
+		key, err := newEphemeralSigningKey()
+		if err != nil {
+			return nil, err
+		}
+		m.keys[key.ID] = key
+		m.active = key.ID
+	}
+
+	active, ok := m.keys[m.active]
+	if !ok {
+		return nil, errors.New("configErrorUnknownSigningKey")
+	}
+	if active.SignKey == nil {
+		return nil, errors.New("configErrorSigningKeyCannotSign")
+	}
+	return m, nil
+}
+
+func (m *ConfiguredSigningKeyManager) ActiveKey() managers.SigningKey {
+	return m.keys[m.active]
+}
+
+func (m *ConfiguredSigningKeyManager) GetKey(kid string) (managers.SigningKey, error) {
+	key, ok := m.keys[kid]
+	if !ok {
+		return managers.SigningKey{}, errors.New("tokenErrorUnknownKey")
+	}
+	return key, nil
+}
+
+// Ordered by key id so the set only changes when the keys do
+func (m *ConfiguredSigningKeyManager) PublicKeys() managers.JsonWebKeySet {
+	res := managers.JsonWebKeySet{Keys: make([]managers.JsonWebKey, 0, len(m.keys))}
+	for _, key := range m.keys {
+		jwk := managers.JsonWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+		switch pub := key.VerifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			jwk.KeyType = "EC"
+			jwk.Curve = pub.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, 32)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, 32)))
+		default:
+			continue
+		}
+		res.Keys = append(res.Keys, jwk)
+	}
+	slices.SortFunc(res.Keys, func(a managers.JsonWebKey, b managers.JsonWebKey) int {
+		return strings.Compare(a.KeyID, b.KeyID)
+	})
+	return res
+}
+
+// Algorithm is taken from the key when it is not configured, a secret without one is HS256
+func loadSigningKey(kc util.SigningKeyConfig) (managers.SigningKey, error) {
+	algorithm := strings.ToUpper(kc.Algorithm)
+	if algorithm == "" && kc.KeyFile == "" {
+		algorithm = "HS256"
+	}
+	if algorithm == "HS256" {
+		return loadSigningSecret(kc)
+	}
+
+	content, err := os.ReadFile(kc.KeyFile)
+	if err != nil {
+		return managers.SigningKey{}, err
+	}
+	signKey, verifyKey, err := parsePemKey(content)
+	if err != nil {
+		return managers.SigningKey{}, err
+	}
+	var keyAlgorithm string
+	switch pub := verifyKey.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRsaKeyBits {
+			return managers.SigningKey{}, errors.New("configErrorWeakSigningKey")
+		}
+		keyAlgorithm = "RS256"
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return managers.SigningKey{}, errors.New("configErrorInvalidSigningKey")
+		}
+		keyAlgorithm = "ES256"
+	default:
+		return managers.SigningKey{}, errors.New("configErrorInvalidSigningKey")
+	}
+	if algorithm != "" && algorithm != keyAlgorithm {
+		return managers.SigningKey{}, errors.New("configErrorInvalidSigningKey")
+	}
+	return managers.SigningKey{ID: kc.ID, Algorithm: keyAlgorithm, SignKey: signKey, VerifyKey: verifyKey}, nil
+}
+
+func loadSigningSecret(kc util.SigningKeyConfig) (managers.SigningKey, error) {
+	secret := kc.Secret
+	if secret == "" && kc.KeyFile != "" {
+		content, err := os.ReadFile(kc.KeyFile)
+		if err != nil {
+			return managers.SigningKey{}, err
+		}
+		secret = strings.TrimSpace(string(content))
+	}
+	if len(secret) < minSigningSecretLength {
+		return managers.SigningKey{}, errors.New("configErrorWeakSigningKey")
+	}
+	return managers.SigningKey{ID: kc.ID, Algorithm: "HS256", SignKey: []byte(secret), VerifyKey: []byte(secret)}, nil
+}
+
+// A private key signs and verifies, a public key only verifies tokens signed before it was rotated out
+func parsePemKey(content []byte) (any, any, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, nil, errors.New("configErrorInvalidSigningKey")
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.New("configErrorInvalidSigningKey")
+		}
+		return key, &key.PublicKey, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.New("configErrorInvalidSigningKey")
+		}
+		return key, &key.PublicKey, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.New("configErrorInvalidSigningKey")
+		}
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			return key, &key.PublicKey, nil
+		case *ecdsa.PrivateKey:
+			return key, &key.PublicKey, nil
+		}
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.New("configErrorInvalidSigningKey")
+		}
+		return nil, key, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.New("configErrorInvalidSigningKey")
+		}
+		return nil, key, nil
+	}
+	return nil, nil, errors.New("configErrorInvalidSigningKey")
+}
+
+func newEphemeralSigningKey() (managers.SigningKey, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return managers.SigningKey{}, err
+	}
+	suffix := make([]byte, 6)
+	_, err = rand.Read(suffix)
+	if err != nil {
+		return managers.SigningKey{}, err
+	}
+	id := "ephemeral-" + base64.RawURLEncoding.EncodeToString(suffix)
+	return managers.SigningKey{ID: id, Algorithm: "HS256", SignKey: secret, VerifyKey: secret}, nil
+}
@@ -0,0 +1,66 @@
+package implementations
+
+import (
+	"context"
+	"echo-api/managers"
+	"strings"
+)
+
+const echoModel = "echo"
+
+/* This implementation is used while the assistant is disabled in configuration.
+ * Every message is answered with itself, so the endpoints stay usable without any provider.
+ */
+type EchoCommunicationManager struct {
+}
+
+func NewEchoCommunicationManager() *EchoCommunicationManager {
+	return &EchoCommunicationManager{}
+}
+
+func (cm *EchoCommunicationManager) SendPrompt(contextID string, msg string) (managers.Completion, error) {
+	return cm.SendMessage(contextID, msg)
+}
+
+func (cm *EchoCommunicationManager) SendMessage(contextID string, msg string) (managers.Completion, error) {
+	return managers.Completion{Content: msg, Model: echoModel}, nil
+}
+
+func (cm *EchoCommunicationManager) Complete(msg string) (managers.Completion, error) {
+	return cm.SendMessage("", msg)
+}
+
+// Streams the message back word by word
+func (cm *EchoCommunicationManager) StreamMessage(ctx context.Context, contextID string, msg string, onDelta func(string) error) (managers.Completion, error) {
+	for _, word := range strings.SplitAfter(msg, " ") {
+		if ctx.Err() != nil {
+			return managers.Completion{}, ctx.Err()
+		}
+		err := onDelta(word)
+		if err != nil {
+			return managers.Completion{}, err
+		}
+	}
+	return managers.Completion{Content: msg, Model: echoModel}, nil
+}
+
+func (cm *EchoCommunicationManager) ResetContext(contextID string, isSoftReset bool) error {
+	return nil
+}
+
+func (cm *EchoCommunicationManager) CompactContext(contextID string, summary string, keep int) error {
+	return nil
+}
+
+func (cm *EchoCommunicationManager) DeleteContext(contextID string, ignoreMissing bool) error {
+	return nil
+}
+
+func (cm *EchoCommunicationManager) CreateContext(contextID string, initialPrompt string) error {
+	return nil
+}
+
+// Echoing needs no conversation, so every context counts as started
+func (cm *EchoCommunicationManager) HasContext(contextID string) bool {
+	return true
+}
@@ -0,0 +1,215 @@
+package implementations
+
+import (
+	"bytes"
+	"echo-api/managers"
+	"encoding/binary"
+	"strings"
+	"time"
+)
+
+const (
+	exifTagMake             = 0x010F
+	exifTagModel            = 0x0110
+	exifTagOrientation      = 0x0112
+	exifTagDateTime         = 0x0132
+	exifTagExifIfd          = 0x8769
+	exifTagGpsIfd           = 0x8825
+	exifTagDateTimeOriginal = 0x9003
+	gpsTagLatitudeRef       = 0x0001
+	gpsTagLatitude          = 0x0002
+	gpsTagLongitudeRef      = 0x0003
+	gpsTagLongitude         = 0x0004
+)
+
+const exifDateLayout = "2006:01:02 15:04:05"
+
+// Bytes per component of the TIFF field types, by type number
+var tiffTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+type tiffEntry struct {
+	fieldType uint16
+	count     int
+	value     []byte
+}
+
+// Reads the TIFF structure EXIF is stored in, every read is bounds checked as the data comes from uploads
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// Metadata is best effort, a missing or broken EXIF block leaves it empty
+func readExif(content []byte) managers.ImageMetadata {
+	var tiff []byte
+	if bytes.HasPrefix(content, []byte{0xFF, 0xD8}) {
+		tiff = findJpegExif(content)
+	} else if bytes.HasPrefix(content, []byte("\x89PNG\r\n\x1a\n")) {
+		tiff = findPngExif(content)
+	}
+	if tiff == nil {
+		return managers.ImageMetadata{}
+	}
+	return parseExif(tiff)
+}
+
+// EXIF sits in an APP1 segment before the image data starts
+func findJpegExif(content []byte) []byte {
+	i := 2
+	for i+4 <= len(content) {
+		if content[i] != 0xFF {
+			return nil
+		}
+		marker := content[i+1]
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// Start of scan and end of image, no metadata follows
+		if marker == 0xDA || marker == 0xD9 {
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(content[i+2:]))
+		if length < 2 || i+2+length > len(content) {
+			return nil
+		}
+		segment := content[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i += 2 + length
+	}
+	return nil
+}
+
+func findPngExif(content []byte) []byte {
+	i := 8
+	for i+12 <= len(content) {
+		length := int(binary.BigEndian.Uint32(content[i:]))
+		chunkType := string(content[i+4 : i+8])
+		if length < 0 || i+12+length > len(content) {
+			return nil
+		}
+		if chunkType == "eXIf" {
+			return content[i+8 : i+8+length]
+		}
+		if chunkType == "IEND" {
+			return nil
+		}
+		i += 12 + length
+	}
+	return nil
+}
+
+func parseExif(tiff []byte) managers.ImageMetadata {
+	var res managers.ImageMetadata
+	if len(tiff) < 8 {
+		return res
+	}
+	r := tiffReader{data: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return res
+	}
+	if r.order.Uint16(tiff[2:]) != 42 {
+		return res
+	}
+
+	ifd0 := r.readIfd(int(r.order.Uint32(tiff[4:])))
+	res.CameraMake = r.stringOf(ifd0[exifTagMake])
+	res.CameraModel = r.stringOf(ifd0[exifTagModel])
+	if orientation, ok := r.uintOf(ifd0[exifTagOrientation]); ok {
+		res.Orientation = int(orientation)
+	}
+	taken := r.stringOf(ifd0[exifTagDateTime])
+	if offset, ok := r.uintOf(ifd0[exifTagExifIfd]); ok {
+		exifIfd := r.readIfd(int(offset))
+		if original := r.stringOf(exifIfd[exifTagDateTimeOriginal]); original != "" {
+			taken = original
+		}
+	}
+	if t, err := time.Parse(exifDateLayout, taken); err == nil {
+		res.TakenAt = &t
+	}
+	if offset, ok := r.uintOf(ifd0[exifTagGpsIfd]); ok {
+		gps := r.readIfd(int(offset))
+		res.Latitude = r.coordinateOf(gps[gpsTagLatitude], r.stringOf(gps[gpsTagLatitudeRef]), "S")
+		res.Longitude = r.coordinateOf(gps[gpsTagLongitude], r.stringOf(gps[gpsTagLongitudeRef]), "W")
+	}
+	return res
+}
+
+// Returns the entries of the directory at offset, a broken directory gives as many entries as could be read
+func (r tiffReader) readIfd(offset int) map[uint16]tiffEntry {
+	res := make(map[uint16]tiffEntry)
+	if offset < 8 || offset+2 > len(r.data) {
+		return res
+	}
+	count := int(r.order.Uint16(r.data[offset:]))
+	for i := 0; i < count; i++ {
+		start := offset + 2 + i*12
+		if start+12 > len(r.data) {
+			break
+		}
+		tag := r.order.Uint16(r.data[start:])
+		fieldType := r.order.Uint16(r.data[start+2:])
+		components := int(r.order.Uint32(r.data[start+4:]))
+		typeSize, ok := tiffTypeSizes[fieldType]
+		if !ok || components <= 0 || components > len(r.data) {
+			continue
+		}
+		size := typeSize * components
+		// Values of up to four bytes are stored in place of the offset
+		valueStart := start + 8
+		if size > 4 {
+			valueStart = int(r.order.Uint32(r.data[start+8:]))
+		}
+		if valueStart < 0 || valueStart+size > len(r.data) {
+			continue
+		}
+		res[tag] = tiffEntry{fieldType: fieldType, count: components, value: r.data[valueStart : valueStart+size]}
+	}
+	return res
+}
+
+func (r tiffReader) stringOf(entry tiffEntry) string {
+	if entry.fieldType != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(entry.value), "\x00"))
+}
+
+func (r tiffReader) uintOf(entry tiffEntry) (uint32, bool) {
+	switch entry.fieldType {
+	case 3:
+		return uint32(r.order.Uint16(entry.value)), true
+	case 4:
+		return r.order.Uint32(entry.value), true
+	}
+	return 0, false
+}
+
+// Coordinates are stored as degrees, minutes and seconds rationals with the hemisphere in a separate reference
+func (r tiffReader) coordinateOf(entry tiffEntry, ref string, negativeRef string) *float64 {
+	if entry.fieldType != 5 || entry.count < 3 {
+		return nil
+	}
+	var parts [3]float64
+	for i := range parts {
+		numerator := r.order.Uint32(entry.value[i*8:])
+		denominator := r.order.Uint32(entry.value[i*8+4:])
+		if denominator == 0 {
+			return nil
+		}
+		parts[i] = float64(numerator) / float64(denominator)
+	}
+	res := parts[0] + parts[1]/60 + parts[2]/3600
+	if strings.EqualFold(ref, negativeRef) {
+		res = -res
+	}
+	return &res
+}
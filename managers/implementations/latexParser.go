@@ -0,0 +1,559 @@
+package implementations
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+type latexNodeKind int
+
+const (
+	latexRow latexNodeKind = iota
+	latexIdentifier
+	latexNumber
+	latexOperator
+	latexFunction
+	latexText
+	latexSpace
+	latexScripts
+	latexFraction
+	latexSqrt
+	latexRoot
+	latexAccent
+	latexStyle
+	latexFenced
+	latexTable
+)
+
+/* A node of the parsed equation, children depend on the kind:
+ * scripts have the base, subscript and superscript where missing scripts are nil,
+ * fractions the numerator and denominator, roots the radicand and index,
+ * accents and styles the styled row, fenced rows the opening delimiter, the row and the closing delimiter,
+ * and tables their rows whose children are the cells.
+ */
+type latexNode struct {
+	kind latexNodeKind
+	// Content of leaves, command of accents, mathvariant of styles and environment of tables
+	text     string
+	children []*latexNode
+}
+
+type latexEnvironment struct {
+	open      string
+	close     string
+	textOpen  string
+	textClose string
+	cellSep   string
+}
+
+var latexEnvironments = map[string]latexEnvironment{
+	"matrix":   {textOpen: "[", textClose: "]", cellSep: ", "},
+	"pmatrix":  {open: "(", close: ")", textOpen: "(", textClose: ")", cellSep: ", "},
+	"bmatrix":  {open: "[", close: "]", textOpen: "[", textClose: "]", cellSep: ", "},
+	"Bmatrix":  {open: "{", close: "}", textOpen: "{", textClose: "}", cellSep: ", "},
+	"vmatrix":  {open: "|", close: "|", textOpen: "|", textClose: "|", cellSep: ", "},
+	"Vmatrix":  {open: "‖", close: "‖", textOpen: "‖", textClose: "‖", cellSep: ", "},
+	"cases":    {open: "{", textOpen: "{", textClose: "}", cellSep: ", "},
+	"aligned":  {},
+	"align":    {},
+	"align*":   {},
+	"gathered": {},
+}
+
+var latexIdentifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε", "zeta": "ζ", "eta": "η",
+	"theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π",
+	"rho": "ρ", "sigma": "σ", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ",
+	"Phi": "Φ", "Psi": "Ψ", "Omega": "Ω", "infty": "∞", "partial": "∂", "nabla": "∇", "ell": "ℓ", "hbar": "ℏ",
+	"emptyset": "∅", "aleph": "ℵ",
+}
+
+var latexOperators = map[string]string{
+	"cdot": "⋅", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "leq": "≤", "le": "≤", "geq": "≥", "ge": "≥",
+	"neq": "≠", "ne": "≠", "approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "propto": "∝", "ll": "≪", "gg": "≫",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "leftrightarrow": "↔", "Rightarrow": "⇒", "Leftarrow": "⇐",
+	"Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺", "mapsto": "↦", "in": "∈", "notin": "∉", "ni": "∋",
+	"subset": "⊂", "subseteq": "⊆", "supset": "⊃", "supseteq": "⊇", "cup": "∪", "cap": "∩", "setminus": "∖",
+	"forall": "∀", "exists": "∃", "neg": "¬", "lnot": "¬", "land": "∧", "lor": "∨", "wedge": "∧", "vee": "∨",
+	"oplus": "⊕", "otimes": "⊗", "circ": "∘", "ast": "∗", "star": "⋆", "cdots": "⋯", "ldots": "…", "dots": "…",
+	"vdots": "⋮", "ddots": "⋱", "sum": "∑", "prod": "∏", "coprod": "∐", "int": "∫", "iint": "∬", "iiint": "∭",
+	"oint": "∮", "bigcup": "⋃", "bigcap": "⋂", "langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋",
+	"lceil": "⌈", "rceil": "⌉", "mid": "∣", "perp": "⊥", "parallel": "∥", "angle": "∠", "prime": "′",
+	"{": "{", "}": "}", "|": "‖", "%": "%", "_": "_", "&": "&", "#": "#", "$": "$",
+}
+
+// Operators whose limits go below and above them instead of beside
+var latexLargeOperators = map[string]bool{"∑": true, "∏": true, "∐": true, "⋃": true, "⋂": true, "lim": true, "max": true, "min": true, "sup": true, "inf": true}
+
+var latexFunctions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "cot": true, "sec": true, "csc": true, "arcsin": true, "arccos": true,
+	"arctan": true, "sinh": true, "cosh": true, "tanh": true, "log": true, "ln": true, "lg": true, "exp": true,
+	"lim": true, "max": true, "min": true, "sup": true, "inf": true, "det": true, "dim": true, "ker": true,
+	"gcd": true, "arg": true, "deg": true, "Pr": true,
+}
+
+var latexAccents = map[string]string{
+	"hat": "^", "widehat": "^", "bar": "¯", "overline": "¯", "vec": "→", "dot": "˙", "ddot": "¨", "tilde": "~", "widetilde": "~",
+}
+
+var latexStyles = map[string]string{
+	"mathbf": "bold", "boldsymbol": "bold", "mathit": "italic", "mathrm": "normal", "operatorname": "normal",
+	"mathbb": "double-struck", "mathcal": "script", "mathfrak": "fraktur", "mathsf": "sans-serif", "mathtt": "monospace",
+}
+
+// Widths in em of the spacing commands, negative space is dropped
+var latexSpaces = map[string]string{",": "0.167em", ":": "0.222em", ";": "0.278em", " ": "0.333em", "quad": "1em", "qquad": "2em", "!": ""}
+
+const latexOperatorChars = "+-=<>,;:!?()[]|/*.'"
+
+// Every recursion of the parser goes through an atom or an argument, deeper nesting is refused before it can overflow the stack
+const maxLatexDepth = 100
+
+type latexParser struct {
+	src   []rune
+	pos   int
+	depth int
+}
+
+// Strips the math delimiters an equation is usually written in
+func trimLatexDelimiters(s string) string {
+	s = strings.TrimSpace(s)
+	for _, d := range [][2]string{{"$$", "$$"}, {"\\[", "\\]"}, {"\\(", "\\)"}, {"$", "$"}} {
+		if len(s) >= len(d[0])+len(d[1]) && strings.HasPrefix(s, d[0]) && strings.HasSuffix(s, d[1]) {
+			return strings.TrimSpace(s[len(d[0]) : len(s)-len(d[1])])
+		}
+	}
+	return s
+}
+
+func parseLatex(s string) (*latexNode, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, errors.New("equationErrorEmpty")
+	}
+	p := &latexParser{src: []rune(s)}
+	row, err := p.parseRow()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.unexpectedTerminator()
+	}
+	return row, nil
+}
+
+// Parses atoms until the end of the source or of the enclosing group, cell, row or delimiters
+func (p *latexParser) parseRow() (*latexNode, error) {
+	row := &latexNode{kind: latexRow}
+	for {
+		p.skipSpaces()
+		if p.atTerminator() {
+			return row, nil
+		}
+		atom, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		if atom != nil {
+			row.children = append(row.children, atom)
+		}
+	}
+}
+
+func (p *latexParser) parseAtom() (*latexNode, error) {
+	err := p.descend()
+	if err != nil {
+		return nil, err
+	}
+	defer p.ascend()
+	var base *latexNode
+	if r := p.src[p.pos]; r != '^' && r != '_' {
+		base, err = p.parseBase()
+		if err != nil || base == nil {
+			return base, err
+		}
+	}
+	var sub, sup *latexNode
+	for {
+		p.skipSpaces()
+		if p.pos >= len(p.src) || (p.src[p.pos] != '^' && p.src[p.pos] != '_') {
+			break
+		}
+		isSub := p.src[p.pos] == '_'
+		if (isSub && sub != nil) || (!isSub && sup != nil) {
+			return nil, errors.New("equationErrorDoubleScript")
+		}
+		p.pos++
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		if isSub {
+			sub = arg
+		} else {
+			sup = arg
+		}
+	}
+	if sub == nil && sup == nil {
+		return base, nil
+	}
+	if base == nil {
+		base = &latexNode{kind: latexRow}
+	}
+	return &latexNode{kind: latexScripts, children: []*latexNode{base, sub, sup}}, nil
+}
+
+func (p *latexParser) parseBase() (*latexNode, error) {
+	r := p.src[p.pos]
+	switch {
+	case r == '{':
+		return p.parseGroup()
+	case r == '\\':
+		return p.parseCommand()
+	case unicode.IsDigit(r):
+		start := p.pos
+		for p.pos < len(p.src) && (unicode.IsDigit(p.src[p.pos]) || (p.src[p.pos] == '.' && p.pos+1 < len(p.src) && unicode.IsDigit(p.src[p.pos+1]))) {
+			p.pos++
+		}
+		return &latexNode{kind: latexNumber, text: string(p.src[start:p.pos])}, nil
+	case unicode.IsLetter(r):
+		p.pos++
+		return &latexNode{kind: latexIdentifier, text: string(r)}, nil
+	case r == '\'':
+		p.pos++
+		return &latexNode{kind: latexOperator, text: "′"}, nil
+	case r == '~':
+		p.pos++
+		return &latexNode{kind: latexSpace, text: latexSpaces[" "]}, nil
+	case strings.ContainsRune(latexOperatorChars, r):
+		p.pos++
+		return &latexNode{kind: latexOperator, text: string(r)}, nil
+	default:
+		return nil, errors.New("equationErrorUnexpectedSymbol")
+	}
+}
+
+// Arguments are either a group or a single token, as in \frac12
+func (p *latexParser) parseArgument() (*latexNode, error) {
+	err := p.descend()
+	if err != nil {
+		return nil, err
+	}
+	defer p.ascend()
+	p.skipSpaces()
+	if p.pos >= len(p.src) || p.atTerminator() || p.src[p.pos] == '^' || p.src[p.pos] == '_' {
+		return nil, errors.New("equationErrorMissingArgument")
+	}
+	r := p.src[p.pos]
+	if r == '{' || r == '\\' {
+		arg, err := p.parseBase()
+		if err == nil && arg == nil {
+			return nil, errors.New("equationErrorMissingArgument")
+		}
+		return arg, err
+	}
+	if unicode.IsDigit(r) {
+		p.pos++
+		return &latexNode{kind: latexNumber, text: string(r)}, nil
+	}
+	return p.parseBase()
+}
+
+func (p *latexParser) parseGroup() (*latexNode, error) {
+	p.pos++
+	row, err := p.parseRow()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != '}' {
+		return nil, errors.New("equationErrorUnbalancedBraces")
+	}
+	p.pos++
+	return row, nil
+}
+
+func (p *latexParser) parseCommand() (*latexNode, error) {
+	name := p.readCommandName()
+	if op, ok := latexOperators[name]; ok {
+		return &latexNode{kind: latexOperator, text: op}, nil
+	}
+	if id, ok := latexIdentifiers[name]; ok {
+		return &latexNode{kind: latexIdentifier, text: id}, nil
+	}
+	if latexFunctions[name] {
+		return &latexNode{kind: latexFunction, text: name}, nil
+	}
+	if width, ok := latexSpaces[name]; ok {
+		if width == "" {
+			return nil, nil
+		}
+		return &latexNode{kind: latexSpace, text: width}, nil
+	}
+	if _, ok := latexAccents[name]; ok {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return &latexNode{kind: latexAccent, text: name, children: []*latexNode{arg}}, nil
+	}
+	if variant, ok := latexStyles[name]; ok {
+		arg, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return &latexNode{kind: latexStyle, text: variant, children: []*latexNode{arg}}, nil
+	}
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		num, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		den, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		return &latexNode{kind: latexFraction, children: []*latexNode{num, den}}, nil
+	case "sqrt":
+		return p.parseSqrt()
+	case "text", "textrm", "mbox":
+		text, err := p.readRawGroup()
+		if err != nil {
+			return nil, err
+		}
+		return &latexNode{kind: latexText, text: text}, nil
+	case "left":
+		return p.parseFenced()
+	case "begin":
+		return p.parseEnvironment()
+	case "right":
+		return nil, errors.New("equationErrorUnbalancedDelimiters")
+	case "end":
+		return nil, errors.New("equationErrorUnbalancedEnvironment")
+	default:
+		return nil, errors.New("equationErrorUnknownCommand")
+	}
+}
+
+func (p *latexParser) parseSqrt() (*latexNode, error) {
+	p.skipSpaces()
+	var index *latexNode
+	if p.pos < len(p.src) && p.src[p.pos] == '[' {
+		p.pos++
+		index = &latexNode{kind: latexRow}
+		for {
+			p.skipSpaces()
+			if p.pos >= len(p.src) || p.atTerminator() {
+				return nil, errors.New("equationErrorUnbalancedBraces")
+			}
+			if p.src[p.pos] == ']' {
+				p.pos++
+				break
+			}
+			atom, err := p.parseAtom()
+			if err != nil {
+				return nil, err
+			}
+			if atom != nil {
+				index.children = append(index.children, atom)
+			}
+		}
+	}
+	radicand, err := p.parseArgument()
+	if err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return &latexNode{kind: latexSqrt, children: []*latexNode{radicand}}, nil
+	}
+	return &latexNode{kind: latexRoot, children: []*latexNode{radicand, index}}, nil
+}
+
+func (p *latexParser) parseFenced() (*latexNode, error) {
+	open, err := p.parseDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	inner, err := p.parseRow()
+	if err != nil {
+		return nil, err
+	}
+	if !p.atCommand("right") {
+		return nil, errors.New("equationErrorUnbalancedDelimiters")
+	}
+	p.readCommandName()
+	close, err := p.parseDelimiter()
+	if err != nil {
+		return nil, err
+	}
+	return &latexNode{kind: latexFenced, children: []*latexNode{open, inner, close}}, nil
+}
+
+// "." stands for no delimiter
+func (p *latexParser) parseDelimiter() (*latexNode, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.src) {
+		return nil, errors.New("equationErrorMissingArgument")
+	}
+	r := p.src[p.pos]
+	switch {
+	case r == '.':
+		p.pos++
+		return &latexNode{kind: latexOperator}, nil
+	case r == '\\':
+		name := p.readCommandName()
+		op, ok := latexOperators[name]
+		if !ok {
+			return nil, errors.New("equationErrorUnknownCommand")
+		}
+		return &latexNode{kind: latexOperator, text: op}, nil
+	case strings.ContainsRune("()[]|/", r):
+		p.pos++
+		return &latexNode{kind: latexOperator, text: string(r)}, nil
+	default:
+		return nil, errors.New("equationErrorMissingArgument")
+	}
+}
+
+func (p *latexParser) parseEnvironment() (*latexNode, error) {
+	name, err := p.readRawGroup()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := latexEnvironments[name]; !ok {
+		return nil, errors.New("equationErrorUnknownEnvironment")
+	}
+	table := &latexNode{kind: latexTable, text: name}
+	row := &latexNode{kind: latexRow}
+	for {
+		cell, err := p.parseRow()
+		if err != nil {
+			return nil, err
+		}
+		row.children = append(row.children, cell)
+		switch {
+		case p.pos < len(p.src) && p.src[p.pos] == '&':
+			p.pos++
+		case p.atCommand("\\"):
+			p.readCommandName()
+			table.children = append(table.children, row)
+			row = &latexNode{kind: latexRow}
+		case p.atCommand("end"):
+			p.readCommandName()
+			end, err := p.readRawGroup()
+			if err != nil {
+				return nil, err
+			}
+			if end != name {
+				return nil, errors.New("equationErrorUnbalancedEnvironment")
+			}
+			// A trailing \\ does not start another row
+			if len(row.children) > 1 || len(row.children[0].children) > 0 {
+				table.children = append(table.children, row)
+			}
+			return table, nil
+		default:
+			return nil, errors.New("equationErrorUnbalancedEnvironment")
+		}
+	}
+}
+
+// Reads the braced argument of \text or \begin as is
+func (p *latexParser) readRawGroup() (string, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.src) || p.src[p.pos] != '{' {
+		return "", errors.New("equationErrorMissingArgument")
+	}
+	start := p.pos + 1
+	depth := 0
+	for ; p.pos < len(p.src); p.pos++ {
+		switch p.src[p.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return string(p.src[start : p.pos-1]), nil
+			}
+		}
+	}
+	return "", errors.New("equationErrorUnbalancedBraces")
+}
+
+// Command names are either letters or a single other character, as in \, or \\
+func (p *latexParser) readCommandName() string {
+	p.pos++
+	if p.pos >= len(p.src) {
+		return ""
+	}
+	start := p.pos
+	if !isLatexLetter(p.src[p.pos]) {
+		p.pos++
+		return string(p.src[start:p.pos])
+	}
+	for p.pos < len(p.src) && isLatexLetter(p.src[p.pos]) {
+		p.pos++
+	}
+	return string(p.src[start:p.pos])
+}
+
+func (p *latexParser) atCommand(name string) bool {
+	if p.pos >= len(p.src) || p.src[p.pos] != '\\' {
+		return false
+	}
+	rest := p.src[p.pos+1:]
+	if len(rest) < len([]rune(name)) || string(rest[:len([]rune(name))]) != name {
+		return false
+	}
+	following := p.pos + 1 + len([]rune(name))
+	return !isLatexLetter([]rune(name)[0]) || following >= len(p.src) || !isLatexLetter(p.src[following])
+}
+
+func (p *latexParser) atTerminator() bool {
+	if p.pos >= len(p.src) {
+		return true
+	}
+	switch p.src[p.pos] {
+	case '}', '&':
+		return true
+	}
+	return p.atCommand("\\") || p.atCommand("end") || p.atCommand("right")
+}
+
+// Tells why parsing stopped before the end of the source
+func (p *latexParser) unexpectedTerminator() error {
+	switch {
+	case p.src[p.pos] == '}':
+		return errors.New("equationErrorUnbalancedBraces")
+	case p.src[p.pos] == '&' || p.atCommand("\\"):
+		return errors.New("equationErrorMisplacedAlignment")
+	case p.atCommand("right"):
+		return errors.New("equationErrorUnbalancedDelimiters")
+	default:
+		return errors.New("equationErrorUnbalancedEnvironment")
+	}
+}
+
+func (p *latexParser) descend() error {
+	p.depth++
+	if p.depth > maxLatexDepth {
+		return errors.New("equationErrorTooDeep")
+	}
+	return nil
+}
+
+func (p *latexParser) ascend() {
+	p.depth--
+}
+
+func (p *latexParser) skipSpaces() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func isLatexLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
@@ -0,0 +1,69 @@
+package implementations
+
+import (
+	"echo-api/managers"
+	"unicode"
+)
+
+const defaultChunkSize = 1000
+
+/* This implementation splits texts on whitespace into overlapping windows of at most chunkSize bytes.
+ * Overlap keeps a sentence that falls on a window border readable in at least one of the chunks.
+ */
+type LocalChunkingManager struct {
+	chunkSize int
+	overlap   int
+}
+
+func NewLocalChunkingManager(chunkSize int) *LocalChunkingManager {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	return &LocalChunkingManager{chunkSize: chunkSize, overlap: chunkSize / 5}
+}
+
+func (m *LocalChunkingManager) Split(text string) []managers.TextChunk {
+	words := m.findWords(text)
+	res := make([]managers.TextChunk, 0)
+	for i := 0; i < len(words); {
+		start := words[i][0]
+		j := i
+		for j+1 < len(words) && words[j+1][1]-start <= m.chunkSize {
+			j++
+		}
+		end := words[j][1]
+		res = append(res, managers.TextChunk{Content: text[start:end], StartOffset: start, EndOffset: end})
+		if j+1 >= len(words) {
+			break
+		}
+
+		// Next window starts within the overlap of this one but always moves forward
+		next := j + 1
+		for next-1 > i && words[next-1][0] >= end-m.overlap {
+			next--
+		}
+		i = next
+	}
+
+	return res
+}
+
+// Returns [start, end) byte offsets of every whitespace separated word
+func (m *LocalChunkingManager) findWords(text string) [][2]int {
+	res := make([][2]int, 0)
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				res = append(res, [2]int{start, i})
+				start = -1
+			}
+		} else if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		res = append(res, [2]int{start, len(text)})
+	}
+	return res
+}
@@ -0,0 +1,94 @@
+package implementations
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const defaultEmbeddingDimensions = 256
+
+var stopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "by": {}, "for": {}, "from": {},
+	"in": {}, "is": {}, "it": {}, "of": {}, "on": {}, "or": {}, "that": {}, "the": {}, "this": {}, "to": {},
+	"was": {}, "what": {}, "with": {}, "how": {}, "which": {}, "who": {}, "why": {},
+}
+
+/* This implementation works fully in process so retrieval is available without any network access.
+ * Texts are embedded with feature hashing of words, word pairs and character trigrams.
+ * Same text always results in the same vector, which keeps stored embeddings valid across restarts.
+ */
+type LocalEmbeddingManager struct {
+	dimensions int
+}
+
+func NewLocalEmbeddingManager() *LocalEmbeddingManager {
+	return &LocalEmbeddingManager{dimensions: defaultEmbeddingDimensions}
+}
+
+func (m *LocalEmbeddingManager) Model() string {
+	return fmt.Sprintf("local-hashing-%d", m.dimensions)
+}
+
+func (m *LocalEmbeddingManager) Embed(texts []string) ([][]float32, error) {
+	res := make([][]float32, len(texts))
+	for i, text := range texts {
+		res[i] = m.embedOne(text)
+	}
+	return res, nil
+}
+
+func (m *LocalEmbeddingManager) embedOne(text string) []float32 {
+	vector := make([]float64, m.dimensions)
+	tokens := tokenize(text)
+	for i, token := range tokens {
+		m.addFeature(vector, "w:"+token, 1)
+		if i > 0 {
+			m.addFeature(vector, "b:"+tokens[i-1]+" "+token, 0.5)
+		}
+		padded := []rune("^" + token + "$")
+		for k := 0; k+3 <= len(padded); k++ {
+			m.addFeature(vector, "t:"+string(padded[k:k+3]), 0.25)
+		}
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	res := make([]float32, m.dimensions)
+	if norm == 0 {
+		return res
+	}
+	for i, v := range vector {
+		res[i] = float32(v / norm)
+	}
+	return res
+}
+
+func (m *LocalEmbeddingManager) addFeature(vector []float64, feature string, weight float64) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	index := sum % uint64(m.dimensions)
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[index] += weight
+}
+
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	res := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := stopWords[f]; !ok {
+			res = append(res, f)
+		}
+	}
+	return res
+}
@@ -0,0 +1,240 @@
+package implementations
+
+import (
+	"echo-api/managers"
+	"html"
+	"strings"
+)
+
+const mathMLNamespace = "http://www.w3.org/1998/Math/MathML"
+
+// Operators the plain text form puts spaces around
+var textSpacedOperators = map[string]bool{
+	"=": true, "+": true, "-": true, "<": true, ">": true, "≤": true, "≥": true, "≠": true, "≈": true, "≡": true,
+	"∼": true, "≃": true, "∝": true, "≪": true, "≫": true, "→": true, "←": true, "↔": true, "⇒": true, "⇐": true,
+	"⇔": true, "⟹": true, "⟺": true, "↦": true, "∈": true, "∉": true, "∋": true, "⊂": true, "⊆": true, "⊃": true,
+	"⊇": true, "∪": true, "∩": true, "∖": true, "×": true, "⋅": true, "÷": true, "±": true, "∓": true, "∧": true,
+	"∨": true, "⊕": true, "⊗": true, "∣": true,
+}
+
+var textDoubleStruck = map[string]string{"R": "ℝ", "N": "ℕ", "Z": "ℤ", "Q": "ℚ", "C": "ℂ", "P": "ℙ"}
+
+/* This implementation parses a commonly used subset of LaTeX math locally.
+ * Supported are fractions, roots, scripts, accents, font styles, \left \right delimiters, \text and the matrix, cases and aligned environments.
+ */
+type LocalEquationManager struct {
+}
+
+func NewLocalEquationManager() *LocalEquationManager {
+	return &LocalEquationManager{}
+}
+
+func (m *LocalEquationManager) Render(latex string) (managers.RenderedEquation, error) {
+	latex = trimLatexDelimiters(latex)
+	root, err := parseLatex(latex)
+	if err != nil {
+		return managers.RenderedEquation{}, err
+	}
+	var sb strings.Builder
+	sb.WriteString(`<math xmlns="` + mathMLNamespace + `" display="block">`)
+	writeMathML(&sb, root)
+	sb.WriteString("</math>")
+	return managers.RenderedEquation{Latex: latex, MathML: sb.String(), Text: strings.TrimSpace(renderText(root))}, nil
+}
+
+func writeMathML(sb *strings.Builder, n *latexNode) {
+	switch n.kind {
+	case latexRow:
+		if len(n.children) == 1 {
+			writeMathML(sb, n.children[0])
+			return
+		}
+		sb.WriteString("<mrow>")
+		for _, child := range n.children {
+			writeMathML(sb, child)
+		}
+		sb.WriteString("</mrow>")
+	case latexIdentifier:
+		writeMathMLLeaf(sb, "mi", n.text)
+	case latexFunction:
+		if len(n.text) == 1 {
+			sb.WriteString(`<mi mathvariant="normal">` + html.EscapeString(n.text) + "</mi>")
+		} else {
+			writeMathMLLeaf(sb, "mi", n.text)
+		}
+	case latexNumber:
+		writeMathMLLeaf(sb, "mn", n.text)
+	case latexOperator:
+		if n.text != "" {
+			writeMathMLLeaf(sb, "mo", n.text)
+		}
+	case latexText:
+		writeMathMLLeaf(sb, "mtext", n.text)
+	case latexSpace:
+		sb.WriteString(`<mspace width="` + n.text + `"/>`)
+	case latexScripts:
+		writeMathMLScripts(sb, n.children[0], n.children[1], n.children[2])
+	case latexFraction:
+		writeMathMLElement(sb, "mfrac", n.children...)
+	case latexSqrt:
+		writeMathMLElement(sb, "msqrt", n.children[0])
+	case latexRoot:
+		writeMathMLElement(sb, "mroot", n.children...)
+	case latexAccent:
+		sb.WriteString(`<mover accent="true">`)
+		writeMathML(sb, n.children[0])
+		writeMathMLLeaf(sb, "mo", latexAccents[n.text])
+		sb.WriteString("</mover>")
+	case latexStyle:
+		sb.WriteString(`<mstyle mathvariant="` + n.text + `">`)
+		writeMathML(sb, n.children[0])
+		sb.WriteString("</mstyle>")
+	case latexFenced:
+		sb.WriteString("<mrow>")
+		for _, child := range n.children {
+			writeMathML(sb, child)
+		}
+		sb.WriteString("</mrow>")
+	case latexTable:
+		env := latexEnvironments[n.text]
+		sb.WriteString("<mrow>")
+		if env.open != "" {
+			writeMathMLLeaf(sb, "mo", env.open)
+		}
+		sb.WriteString("<mtable>")
+		for _, row := range n.children {
+			sb.WriteString("<mtr>")
+			for _, cell := range row.children {
+				writeMathMLElement(sb, "mtd", cell)
+			}
+			sb.WriteString("</mtr>")
+		}
+		sb.WriteString("</mtable>")
+		if env.close != "" {
+			writeMathMLLeaf(sb, "mo", env.close)
+		}
+		sb.WriteString("</mrow>")
+	}
+}
+
+// Limits of large operators go below and above them
+func writeMathMLScripts(sb *strings.Builder, base *latexNode, sub *latexNode, sup *latexNode) {
+	under, over, both := "msub", "msup", "msubsup"
+	if latexLargeOperators[base.text] && (base.kind == latexOperator || base.kind == latexFunction) {
+		under, over, both = "munder", "mover", "munderover"
+	}
+	switch {
+	case sub != nil && sup != nil:
+		writeMathMLElement(sb, both, base, sub, sup)
+	case sub != nil:
+		writeMathMLElement(sb, under, base, sub)
+	default:
+		writeMathMLElement(sb, over, base, sup)
+	}
+}
+
+// Every child of a MathML element with a fixed number of arguments has to be a single element
+func writeMathMLElement(sb *strings.Builder, tag string, children ...*latexNode) {
+	sb.WriteString("<" + tag + ">")
+	for _, child := range children {
+		if child.kind == latexRow && len(child.children) != 1 {
+			sb.WriteString("<mrow>")
+			for _, c := range child.children {
+				writeMathML(sb, c)
+			}
+			sb.WriteString("</mrow>")
+		} else {
+			writeMathML(sb, child)
+		}
+	}
+	sb.WriteString("</" + tag + ">")
+}
+
+func writeMathMLLeaf(sb *strings.Builder, tag string, text string) {
+	sb.WriteString("<" + tag + ">" + html.EscapeString(text) + "</" + tag + ">")
+}
+
+func renderText(n *latexNode) string {
+	switch n.kind {
+	case latexRow:
+		var sb strings.Builder
+		for i, child := range n.children {
+			text := renderText(child)
+			switch {
+			case child.kind == latexOperator && textSpacedOperators[child.text] && i > 0:
+				sb.WriteString(" " + text + " ")
+			case child.kind == latexOperator && child.text == ",":
+				sb.WriteString(", ")
+			case child.kind == latexFunction && i+1 < len(n.children) && n.children[i+1].kind != latexFenced && n.children[i+1].kind != latexOperator:
+				sb.WriteString(text + " ")
+			default:
+				sb.WriteString(text)
+			}
+		}
+		return strings.Join(strings.Fields(sb.String()), " ")
+	case latexSpace:
+		return " "
+	case latexScripts:
+		base := n.children[0]
+		res := wrapText(base)
+		if n.children[1] != nil {
+			res += "_" + wrapText(n.children[1])
+		}
+		if n.children[2] != nil {
+			res += "^" + wrapText(n.children[2])
+		}
+		// Keeps the limits of sums, integrals and the like apart from what they apply to
+		if base.kind == latexOperator || base.kind == latexFunction {
+			res += " "
+		}
+		return res
+	case latexFraction:
+		return wrapText(n.children[0]) + "/" + wrapText(n.children[1])
+	case latexSqrt:
+		return "sqrt(" + renderText(n.children[0]) + ")"
+	case latexRoot:
+		return "root(" + renderText(n.children[1]) + ", " + renderText(n.children[0]) + ")"
+	case latexAccent:
+		return n.text + "(" + renderText(n.children[0]) + ")"
+	case latexStyle:
+		text := renderText(n.children[0])
+		if n.text == "double-struck" {
+			if letter, ok := textDoubleStruck[text]; ok {
+				return letter
+			}
+		}
+		return text
+	case latexFenced:
+		return n.children[0].text + renderText(n.children[1]) + n.children[2].text
+	case latexTable:
+		env := latexEnvironments[n.text]
+		rows := make([]string, len(n.children))
+		for i, row := range n.children {
+			cells := make([]string, len(row.children))
+			for j, cell := range row.children {
+				cells[j] = renderText(cell)
+			}
+			rows[i] = strings.Join(cells, env.cellSep)
+		}
+		return env.textOpen + strings.Join(rows, "; ") + env.textClose
+	default:
+		return n.text
+	}
+}
+
+// Parenthesizes everything but single symbols so scripts and fractions stay unambiguous
+func wrapText(n *latexNode) string {
+	text := renderText(n)
+	for n.kind == latexRow && len(n.children) == 1 {
+		n = n.children[0]
+	}
+	switch n.kind {
+	case latexIdentifier, latexNumber, latexOperator, latexFunction, latexFenced, latexSqrt, latexRoot, latexAccent:
+		return text
+	case latexStyle:
+		if len([]rune(text)) == 1 {
+			return text
+		}
+	}
+	return "(" + text + ")"
+}
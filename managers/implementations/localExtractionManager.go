@@ -0,0 +1,231 @@
+package implementations
+
+import (
+	"bytes"
+	"echo-api/managers"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type extractor func([]byte, *textBuilder) error
+
+/* This implementation extracts text in process with a dedicated extractor per file extension.
+ * Every extractor writes paragraphs into a textBuilder, which also keeps track of the page or section boundaries.
+ */
+type LocalExtractionManager struct {
+	extractors map[string]extractor
+}
+
+func NewLocalExtractionManager() *LocalExtractionManager {
+	return &LocalExtractionManager{extractors: map[string]extractor{
+		"txt":      extractPlainText,
+		"text":     extractPlainText,
+		"md":       extractMarkdown,
+		"markdown": extractMarkdown,
+		"html":     extractHtml,
+		"htm":      extractHtml,
+		"xhtml":    extractHtml,
+		"docx":     extractDocx,
+		"odt":      extractOdt,
+		"epub":     extractEpub,
+		"pdf":      extractPdf,
+	}}
+}
+
+func (m *LocalExtractionManager) Supports(extension string) bool {
+	_, ok := m.extractors[strings.ToLower(extension)]
+	return ok
+}
+
+func (m *LocalExtractionManager) Extract(content []byte, extension string) (managers.ExtractedText, error) {
+	extract, ok := m.extractors[strings.ToLower(extension)]
+	if !ok {
+		return managers.ExtractedText{}, errors.New("extractionErrorUnsupportedExtension")
+	}
+	builder := newTextBuilder()
+	err := extract(content, builder)
+	if err != nil {
+		return managers.ExtractedText{}, err
+	}
+	return builder.build(), nil
+}
+
+type textBuilder struct {
+	sb       strings.Builder
+	sections []managers.TextSection
+}
+
+func newTextBuilder() *textBuilder {
+	return &textBuilder{sections: make([]managers.TextSection, 0)}
+}
+
+// Closes the running section, following paragraphs belong to the new one
+func (b *textBuilder) startSection(title string) {
+	b.closeSection()
+	b.sections = append(b.sections, managers.TextSection{Title: title, StartOffset: b.sb.Len(), EndOffset: -1})
+}
+
+func (b *textBuilder) closeSection() {
+	if len(b.sections) == 0 {
+		return
+	}
+	last := &b.sections[len(b.sections)-1]
+	if last.EndOffset < 0 {
+		last.EndOffset = b.sb.Len()
+	}
+}
+
+// Collapses inner whitespace of the paragraph, blank paragraphs are skipped
+func (b *textBuilder) writeParagraph(s string) {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return
+	}
+	if b.sb.Len() > 0 {
+		b.sb.WriteString("\n\n")
+	}
+	b.sb.WriteString(s)
+}
+
+func (b *textBuilder) build() managers.ExtractedText {
+	b.closeSection()
+	return managers.ExtractedText{Text: b.sb.String(), Sections: b.sections}
+}
+
+func extractPlainText(content []byte, b *textBuilder) error {
+	if !utf8.Valid(content) {
+		return errors.New("extractionErrorInvalidEncoding")
+	}
+	for _, p := range splitParagraphs(string(content)) {
+		b.writeParagraph(p)
+	}
+	return nil
+}
+
+var markdownHeading = regexp.MustCompile(`^\s{0,3}(#{1,6})\s+(.*?)\s*#*\s*$`)
+var markdownImage = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+var markdownLink = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+var markdownEmphasis = regexp.MustCompile("(\\*\\*|__|\\*|`|~~)")
+var markdownBlockPrefix = regexp.MustCompile(`^\s*(>\s?)+`)
+
+func extractMarkdown(content []byte, b *textBuilder) error {
+	if !utf8.Valid(content) {
+		return errors.New("extractionErrorInvalidEncoding")
+	}
+	var paragraph strings.Builder
+	flush := func() {
+		b.writeParagraph(paragraph.String())
+		paragraph.Reset()
+	}
+	inCode := false
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			// Code keeps one paragraph per line so its layout is not collapsed
+			b.writeParagraph(line)
+			continue
+		}
+		if match := markdownHeading.FindStringSubmatch(line); match != nil {
+			flush()
+			title := cleanMarkdownInline(match[2])
+			b.startSection(title)
+			b.writeParagraph(title)
+			continue
+		}
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		paragraph.WriteString(cleanMarkdownInline(markdownBlockPrefix.ReplaceAllString(line, "")))
+		paragraph.WriteString(" ")
+	}
+	flush()
+	return nil
+}
+
+func cleanMarkdownInline(s string) string {
+	s = markdownImage.ReplaceAllString(s, "$1")
+	s = markdownLink.ReplaceAllString(s, "$1")
+	return markdownEmphasis.ReplaceAllString(s, "")
+}
+
+func extractHtml(content []byte, b *textBuilder) error {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	var paragraph strings.Builder
+	walkHtml(doc, b, &paragraph)
+	b.writeParagraph(paragraph.String())
+	return nil
+}
+
+func walkHtml(n *html.Node, b *textBuilder, paragraph *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		paragraph.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Script, atom.Style, atom.Noscript, atom.Head, atom.Template:
+			return
+		case atom.H1, atom.H2, atom.H3:
+			b.writeParagraph(paragraph.String())
+			paragraph.Reset()
+			var heading strings.Builder
+			collectHtmlText(n, &heading)
+			title := strings.Join(strings.Fields(heading.String()), " ")
+			b.startSection(title)
+			b.writeParagraph(title)
+			return
+		case atom.Br:
+			paragraph.WriteString(" ")
+			return
+		}
+	}
+
+	isBlock := n.Type == html.ElementNode && htmlBlockElements[n.DataAtom]
+	if isBlock {
+		b.writeParagraph(paragraph.String())
+		paragraph.Reset()
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkHtml(c, b, paragraph)
+	}
+	if isBlock {
+		b.writeParagraph(paragraph.String())
+		paragraph.Reset()
+	}
+}
+
+func collectHtmlText(n *html.Node, sb *strings.Builder) {
+	if n.Type == html.TextNode {
+		sb.WriteString(n.Data)
+		sb.WriteString(" ")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collectHtmlText(c, sb)
+	}
+}
+
+var htmlBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Li: true, atom.Tr: true,
+	atom.Blockquote: true, atom.Pre: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Table: true,
+	atom.Ul: true, atom.Ol: true, atom.Dd: true, atom.Dt: true, atom.Figcaption: true, atom.Header: true, atom.Footer: true,
+}
+
+var blankLines = regexp.MustCompile(`\n\s*\n`)
+
+func splitParagraphs(s string) []string {
+	return blankLines.Split(strings.ReplaceAll(s, "\r\n", "\n"), -1)
+}
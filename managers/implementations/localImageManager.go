@@ -0,0 +1,152 @@
+package implementations
+
+import (
+	"bytes"
+	"echo-api/managers"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+const defaultThumbnailSize = 256
+
+// Larger images are rejected before decoding so a small file can not claim gigabytes of memory
+const maxImagePixels = 50_000_000
+
+// Samples per axis averaged into one thumbnail pixel
+const maxThumbnailSamples = 4
+
+/* This implementation decodes images with the standard library, so only JPEG, PNG and GIF are supported.
+ * EXIF is read from the APP1 segment of JPEG files and the eXIf chunk of PNG files.
+ */
+type LocalImageManager struct {
+	thumbnailSize int
+	extensions    map[string]bool
+}
+
+func NewLocalImageManager(thumbnailSize int) *LocalImageManager {
+	if thumbnailSize <= 0 {
+		thumbnailSize = defaultThumbnailSize
+	}
+	return &LocalImageManager{thumbnailSize: thumbnailSize, extensions: map[string]bool{"jpg": true, "jpeg": true, "png": true, "gif": true}}
+}
+
+func (m *LocalImageManager) Supports(extension string) bool {
+	return m.extensions[strings.ToLower(extension)]
+}
+
+func (m *LocalImageManager) Process(content []byte, extension string) (managers.ProcessedImage, error) {
+	if !m.Supports(extension) {
+		return managers.ProcessedImage{}, errors.New("imageErrorUnsupportedExtension")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return managers.ProcessedImage{}, errors.New("imageErrorInvalidImage")
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return managers.ProcessedImage{}, errors.New("imageErrorInvalidImage")
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return managers.ProcessedImage{}, errors.New("imageErrorTooLarge")
+	}
+	img, format, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return managers.ProcessedImage{}, errors.New("imageErrorInvalidImage")
+	}
+
+	metadata := readExif(content)
+	if metadata.Orientation < 1 || metadata.Orientation > 8 {
+		metadata.Orientation = 1
+	}
+	thumbnail := newThumbnail(img, metadata.Orientation, m.thumbnailSize)
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 80})
+	if err != nil {
+		return managers.ProcessedImage{}, err
+	}
+
+	width, height := orientedSize(img.Bounds().Dx(), img.Bounds().Dy(), metadata.Orientation)
+	return managers.ProcessedImage{
+		MimeType:  "image/" + format,
+		Width:     width,
+		Height:    height,
+		Thumbnail: buf.Bytes(),
+		Metadata:  metadata,
+	}, nil
+}
+
+// Orientations from 5 to 8 swap width and height
+func orientedSize(width int, height int, orientation int) (int, int) {
+	if orientation >= 5 {
+		return height, width
+	}
+	return width, height
+}
+
+// Maps a pixel of the upright image back to the stored one
+func orientedSource(x int, y int, width int, height int, orientation int) (int, int) {
+	switch orientation {
+	case 2:
+		return width - 1 - x, y
+	case 3:
+		return width - 1 - x, height - 1 - y
+	case 4:
+		return x, height - 1 - y
+	case 5:
+		return y, x
+	case 6:
+		return y, height - 1 - x
+	case 7:
+		return width - 1 - y, height - 1 - x
+	case 8:
+		return width - 1 - y, x
+	default:
+		return x, y
+	}
+}
+
+// Averages a grid of samples per pixel and puts transparent areas on white, as JPEG has no alpha
+func newThumbnail(img image.Image, orientation int, size int) *image.RGBA {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	width, height := orientedSize(srcWidth, srcHeight, orientation)
+	thumbWidth, thumbHeight := width, height
+	if width > size || height > size {
+		if width >= height {
+			thumbWidth, thumbHeight = size, max(1, height*size/width)
+		} else {
+			thumbWidth, thumbHeight = max(1, width*size/height), size
+		}
+	}
+
+	scaleX := float64(width) / float64(thumbWidth)
+	scaleY := float64(height) / float64(thumbHeight)
+	samplesX := min(maxThumbnailSamples, max(1, int(scaleX)))
+	samplesY := min(maxThumbnailSamples, max(1, int(scaleY)))
+	res := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for ty := 0; ty < thumbHeight; ty++ {
+		for tx := 0; tx < thumbWidth; tx++ {
+			var r, g, b, a uint32
+			for sy := 0; sy < samplesY; sy++ {
+				for sx := 0; sx < samplesX; sx++ {
+					x := min(width-1, int((float64(tx)+(float64(sx)+0.5)/float64(samplesX))*scaleX))
+					y := min(height-1, int((float64(ty)+(float64(sy)+0.5)/float64(samplesY))*scaleY))
+					srcX, srcY := orientedSource(x, y, srcWidth, srcHeight, orientation)
+					cr, cg, cb, ca := img.At(bounds.Min.X+srcX, bounds.Min.Y+srcY).RGBA()
+					r, g, b, a = r+cr, g+cg, b+cb, a+ca
+				}
+			}
+			count := uint32(samplesX * samplesY)
+			r, g, b, a = r/count, g/count, b/count, a/count
+			// Colors are premultiplied, so adding the missing coverage is enough to blend over white
+			white := 0xffff - a
+			res.SetRGBA64(tx, ty, color.RGBA64{R: uint16(r + white), G: uint16(g + white), B: uint16(b + white), A: 0xffff})
+		}
+	}
+	return res
+}
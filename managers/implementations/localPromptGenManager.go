@@ -4,83 +4,193 @@ import (
 	"echo-api/managers"
 	"echo-api/models/entities"
 	"errors"
-	"fmt"
-	"io"
 	"strings"
+	"sync"
+	"text/template"
 )
 
 /* This implementation is for locally generating prompts
  * I am planning to create another go module to do this seperately then dev another implementation of promptManager that interacts with that API
+ * Templates come from the given source, without one the default templates are used
  */
+// Longer recognised text only reaches the assistant as excerpts
+const maxRecognizedTextInPrompt = 2000
+
 type LocalPromptGenManager struct {
-	fileManager managers.FileManager
+	source managers.PromptTemplateSource
+	mutex  sync.Mutex
+	parsed map[string]*template.Template
+}
+
+func NewLocalPromptGenManager(source managers.PromptTemplateSource) *LocalPromptGenManager {
+	return &LocalPromptGenManager{source: source, parsed: make(map[string]*template.Template)}
 }
 
-func NewLocalPromptGenManager(fm managers.FileManager) LocalPromptGenManager {
-	return LocalPromptGenManager{fileManager: fm}
+func (m *LocalPromptGenManager) GenerateInitial(languageID string) (string, error) {
+	return m.render(managers.Initial, languageID, managers.TemplateData{})
 }
 
-func (m LocalPromptGenManager) GeneratePrompt(val any) (string, error) {
+// Returns what a prompt about the value asks the assistant to remember or forget
+func (m *LocalPromptGenManager) GenerateSubject(val any, languageID string) (string, error) {
 	switch val := val.(type) {
 	case entities.Note:
-		return m.generatePromptForNote(val)
+		return m.generateSubjectForNote(val, languageID)
 	case entities.Document:
-		return m.generatePromptForDocument(val)
+		return m.generateSubjectForDocument(val, languageID)
+	case entities.Equation:
+		return m.generateSubjectForEquation(val, languageID)
 	case string:
-		return m.promptizeString(managers.Remember, val), nil
+		return val, nil
 	default:
 		return "", errors.ErrUnsupported
 	}
 }
-func (m LocalPromptGenManager) GeneratePromptWith(val any, action managers.PromptAction) (string, error) {
+
+func (m *LocalPromptGenManager) GeneratePrompt(val any, languageID string) (string, error) {
+	return m.GeneratePromptWith(val, managers.Remember, languageID)
+}
+
+func (m *LocalPromptGenManager) GeneratePromptWith(val any, action managers.PromptAction, languageID string) (string, error) {
+	subject, err := m.GenerateSubject(val, languageID)
+	if err != nil {
+		return "", err
+	}
+	return m.promptizeString(action, subject, languageID)
+}
+
+func (m *LocalPromptGenManager) GenerateMessage(val string, languageID string) (string, error) {
+	return m.render(managers.Message, languageID, managers.TemplateData{Value: val})
+}
+
+func (m *LocalPromptGenManager) GenerateMessageWith(val string, chunks []entities.Chunk, languageID string) (string, error) {
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		excerpt, err := m.render(managers.Excerpt, languageID, managers.TemplateData{Value: chunk.Content, Number: i + 1})
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(excerpt)
+		sb.WriteString("\n")
+	}
+	msg, err := m.GenerateMessage(val, languageID)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(msg)
+	return sb.String(), nil
+}
+
+// Turns are written one per line with their role, the previous summary comes first
+func (m *LocalPromptGenManager) GenerateSummarize(previousSummary string, messages []entities.Message, languageID string) (string, error) {
+	var sb strings.Builder
+	if previousSummary != "" {
+		sb.WriteString("summary: ")
+		sb.WriteString(previousSummary)
+		sb.WriteString("\n")
+	}
+	for _, msg := range messages {
+		sb.WriteString(msg.Role.String())
+		sb.WriteString(": ")
+		sb.WriteString(msg.Content)
+		sb.WriteString("\n")
+	}
+	return m.render(managers.Summarize, languageID, managers.TemplateData{Value: strings.TrimSuffix(sb.String(), "\n")})
+}
+
+// The whole note or document is given since the cards should cover all of it
+func (m *LocalPromptGenManager) GenerateFlashcards(val any, count int, languageID string) (string, error) {
 	switch val := val.(type) {
 	case entities.Note:
-		return m.generatePromptForNote(val)
+		return m.render(managers.Flashcards, languageID, managers.TemplateData{Kind: "Note", Number: count, Value: val.Header + "\n" + val.Payload})
 	case entities.Document:
-		return m.generatePromptForDocument(val)
-	case string:
-		return m.promptizeString(managers.Remember, val), nil
+		return m.render(managers.Flashcards, languageID, managers.TemplateData{Kind: "Document", Number: count, Value: val.Name + "\n" + val.Text})
 	default:
 		return "", errors.ErrUnsupported
 	}
 }
 
-func (m LocalPromptGenManager) GenerateMessage(val string) (string, error) {
-	return m.messageizeString(val), nil
+func (m *LocalPromptGenManager) GenerateQuiz(material string, count int, languageID string) (string, error) {
+	return m.render(managers.Quiz, languageID, managers.TemplateData{Number: count, Value: material})
+}
+
+func (m *LocalPromptGenManager) GenerateGrading(question string, expected string, given string, languageID string) (string, error) {
+	value := "Question: " + question + "\nExpected answer: " + expected + "\nGiven answer: " + given
+	return m.render(managers.Grading, languageID, managers.TemplateData{Value: value})
+}
+
+// Notes and documents are only announced, their content reaches the assistant as excerpts picked per message
+func (m *LocalPromptGenManager) generateSubjectForNote(val entities.Note, languageID string) (string, error) {
+	return m.render(managers.Source, languageID, managers.TemplateData{Kind: "Note", Value: val.Header})
+}
+
+// Text recognised in an image is short and has no structure to pick excerpts from, so it is given along
+func (m *LocalPromptGenManager) generateSubjectForDocument(val entities.Document, languageID string) (string, error) {
+	if val.Image == nil {
+		return m.render(managers.Source, languageID, managers.TemplateData{Kind: "Document", Value: val.Name})
+	}
+	subject, err := m.render(managers.Source, languageID, managers.TemplateData{Kind: "Image", Value: val.Name})
+	if err != nil || val.Text == "" {
+		return subject, err
+	}
+	text := val.Text
+	if len(text) > maxRecognizedTextInPrompt {
+		text = strings.ToValidUTF8(text[:maxRecognizedTextInPrompt], "") + "..."
+	}
+	return subject + "\nText in the image: " + text, nil
 }
 
-func (m LocalPromptGenManager) generatePromptForNote(val entities.Note) (string, error) {
-	return m.GeneratePrompt(val.Payload)
+// Equations are short enough to be given whole, the plain text form reads better to a model than LaTeX alone
+func (m *LocalPromptGenManager) generateSubjectForEquation(val entities.Equation, languageID string) (string, error) {
+	value := val.Text + " | LaTeX: " + val.Latex
+	if val.Label != "" {
+		value = val.Label + ": " + value
+	}
+	return m.render(managers.Equation, languageID, managers.TemplateData{Value: value})
 }
 
-func (m LocalPromptGenManager) generatePromptForDocument(val entities.Document) (string, error) {
-	f, err := m.fileManager.GetFile(val.Location, val.Name, managers.DefaultFileOpeningOptions())
+func (m *LocalPromptGenManager) promptizeString(act managers.PromptAction, s string, languageID string) (string, error) {
+	action, err := m.render(act, languageID, managers.TemplateData{Value: s})
 	if err != nil {
 		return "", err
 	}
-	defer f.Close()
-	const buffSize = 1024
-	buffer := make([]byte, buffSize)
-	var sb strings.Builder
-	for {
-		readTotal, err := f.Read(buffer)
+	return m.render(managers.Prompt, languageID, managers.TemplateData{Value: action})
+}
+
+func (m *LocalPromptGenManager) render(action managers.PromptAction, languageID string, data managers.TemplateData) (string, error) {
+	body, ok := managers.DefaultTemplates[action]
+	if !ok {
+		return "", errors.New("templateErrorUnknownName")
+	}
+	if m.source != nil {
+		var err error
+		body, err = m.source.GetTemplate(action, languageID)
 		if err != nil {
-			if err == io.EOF {
-				sb.Write(buffer[:readTotal])
-				break
-			}
 			return "", err
 		}
-		sb.Write(buffer[:readTotal])
 	}
-
-	return m.GeneratePrompt(sb.String())
-}
-
-func (m LocalPromptGenManager) messageizeString(s string) string {
-	return fmt.Sprintf(managers.Message.String(), s)
+	t, err := m.parse(body)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	err = t.Execute(&sb, data)
+	if err != nil {
+		return "", errors.New("templateErrorInvalidBody")
+	}
+	return sb.String(), nil
 }
 
-func (m LocalPromptGenManager) promptizeString(act managers.PromptAction, s string) string {
-	return fmt.Sprintf(managers.Prompt.String(), fmt.Sprintf(act.String(), s))
+// Parsed templates are kept by body so an edited template is parsed again while unchanged ones are reused
+func (m *LocalPromptGenManager) parse(body string) (*template.Template, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if t, ok := m.parsed[body]; ok {
+		return t, nil
+	}
+	t, err := managers.ParseTemplate(body)
+	if err != nil {
+		return nil, err
+	}
+	m.parsed[body] = t
+	return t, nil
 }
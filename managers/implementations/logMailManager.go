@@ -0,0 +1,23 @@
+package implementations
+
+import (
+	"echo-api/managers"
+	"echo-api/util"
+	"fmt"
+)
+
+/* This implementation writes mails to the log instead of sending them, it is meant for development.
+ * Mails carry tokens that grant access to accounts, so it must not be used where others can read the log.
+ */
+type LogMailManager struct {
+	logger *util.Logger
+}
+
+func NewLogMailManager(logger *util.Logger) *LogMailManager {
+	return &LogMailManager{logger: logger}
+}
+
+func (m *LogMailManager) Send(mail managers.Mail) error {
+	m.logger.Warn().Msg(fmt.Sprintf("LogMailManager_Send to: %s subject: %s\n%s", mail.To, mail.Subject, mail.Body))
+	return nil
+}
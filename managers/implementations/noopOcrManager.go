@@ -0,0 +1,15 @@
+package implementations
+
+/* This implementation never finds any text, it is used when no OCR engine is configured or installed.
+ * Images are still accepted, the assistant only learns their name and metadata.
+ */
+type NoopOcrManager struct {
+}
+
+func NewNoopOcrManager() *NoopOcrManager {
+	return &NoopOcrManager{}
+}
+
+func (m *NoopOcrManager) Recognize(content []byte, extension string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,144 @@
+package implementations
+
+import (
+	"context"
+	"echo-api/managers"
+	"echo-api/util"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Talks to an Ollama style "/api/chat" endpoint, which streams one JSON object per line
+type ollamaChatAdapter struct {
+	baseUrl string
+	logger  *util.Logger
+	client  *http.Client
+}
+
+type ollamaChatRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+}
+
+// Images travel base64 encoded next to the content
+type ollamaMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Model           string      `json:"model"`
+	Message         chatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+	Error           string      `json:"error"`
+}
+
+func NewOllamaCommunicationManager(provider util.AiProvider, model string, logger *util.Logger) *ChatCommunicationManager {
+	baseUrl := provider.BaseUrl
+	if baseUrl == "" {
+		baseUrl = defaultOllamaBaseUrl
+	}
+	adapter := &ollamaChatAdapter{
+		baseUrl: baseUrl,
+		logger:  logger,
+		client:  &http.Client{Timeout: getProviderTimeout(provider)},
+	}
+	return newChatCommunicationManager(adapter, model, provider.SupportsImages)
+}
+
+func (a *ollamaChatAdapter) complete(ctx context.Context, model string, messages []chatMessage) (managers.Completion, error) {
+	resp, err := a.postChat(ctx, ollamaChatRequest{Model: model, Messages: toOllamaMessages(messages), Stream: false})
+	if err != nil {
+		return managers.Completion{}, err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("OllamaCommunicationManager_complete could not read the provider response")
+		return managers.Completion{}, errors.New("aiErrorProviderInvalidResponse")
+	}
+	var chat ollamaChatResponse
+	err = json.Unmarshal(payload, &chat)
+	if err != nil || chat.Error != "" {
+		a.logger.Error().Err(err).Msg(fmt.Sprintf("OllamaCommunicationManager_complete got an unexpected body: %s", string(payload)))
+		return managers.Completion{}, errors.New("aiErrorProviderInvalidResponse")
+	}
+
+	return managers.Completion{
+		Content:          chat.Message.Content,
+		Model:            chat.Model,
+		PromptTokens:     chat.PromptEvalCount,
+		CompletionTokens: chat.EvalCount,
+	}, nil
+}
+
+// Reads newline delimited JSON objects until the one marked done, which carries the usage
+func (a *ollamaChatAdapter) stream(ctx context.Context, model string, messages []chatMessage, onDelta func(string) error) (managers.Completion, error) {
+	var res managers.Completion
+	resp, err := a.postChat(ctx, ollamaChatRequest{Model: model, Messages: toOllamaMessages(messages), Stream: true})
+	if err != nil {
+		return res, err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	err = scanProviderStream(ctx, resp.Body, a.logger, "OllamaCommunicationManager_stream", func(line string) (bool, error) {
+		if strings.TrimSpace(line) == "" {
+			return false, nil
+		}
+		var chunk ollamaChatResponse
+		err := json.Unmarshal([]byte(line), &chunk)
+		if err != nil || chunk.Error != "" {
+			a.logger.Error().Err(err).Msg(fmt.Sprintf("OllamaCommunicationManager_stream got an unexpected chunk: %s", line))
+			return true, errors.New("aiErrorProviderInvalidResponse")
+		}
+		if chunk.Model != "" {
+			res.Model = chunk.Model
+		}
+		if chunk.Message.Content != "" {
+			sb.WriteString(chunk.Message.Content)
+			err = onDelta(chunk.Message.Content)
+			if err != nil {
+				return true, err
+			}
+		}
+		if chunk.Done {
+			res.PromptTokens = chunk.PromptEvalCount
+			res.CompletionTokens = chunk.EvalCount
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Content = sb.String()
+
+	return res, nil
+}
+
+func toOllamaMessages(messages []chatMessage) []ollamaMessage {
+	res := make([]ollamaMessage, len(messages))
+	for i, m := range messages {
+		res[i] = ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, image := range m.images {
+			res[i].Images = append(res[i].Images, base64.StdEncoding.EncodeToString(image.Data))
+		}
+	}
+	return res
+}
+
+func (a *ollamaChatAdapter) postChat(ctx context.Context, request ollamaChatRequest) (*http.Response, error) {
+	url := strings.TrimRight(a.baseUrl, "/") + "/api/chat"
+	return postToProvider(ctx, a.client, a.logger, "OllamaCommunicationManager_postChat", url, "", request, nil)
+}
@@ -0,0 +1,69 @@
+package implementations
+
+import (
+	"context"
+	"echo-api/util"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const defaultOllamaBaseUrl = "http://localhost:11434"
+
+/* This implementation talks to a locally running Ollama through its "/api/embed" endpoint.
+ * Ollama needs no api key, so documents never leave the machine.
+ */
+type OllamaEmbeddingManager struct {
+	baseUrl   string
+	model     string
+	batchSize int
+	logger    *util.Logger
+	client    *http.Client
+}
+
+type ollamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func NewOllamaEmbeddingManager(c *util.Configuration, logger *util.Logger) *OllamaEmbeddingManager {
+	baseUrl := c.EmbeddingBaseUrl
+	if baseUrl == "" {
+		baseUrl = defaultOllamaBaseUrl
+	}
+	return &OllamaEmbeddingManager{
+		baseUrl:   baseUrl,
+		model:     c.EmbeddingModel,
+		batchSize: getEmbeddingBatchSize(c),
+		logger:    logger,
+		client:    &http.Client{Timeout: getAiTimeout(c)},
+	}
+}
+
+func (m *OllamaEmbeddingManager) Model() string {
+	return "ollama:" + m.model
+}
+
+func (m *OllamaEmbeddingManager) Embed(texts []string) ([][]float32, error) {
+	if m.model == "" {
+		return nil, errors.New("aiErrorNotConfigured")
+	}
+	return embedInBatches(texts, m.batchSize, m.embedBatch)
+}
+
+func (m *OllamaEmbeddingManager) embedBatch(texts []string) ([][]float32, error) {
+	url := strings.TrimRight(m.baseUrl, "/") + "/api/embed"
+	resp, err := postToProvider(context.Background(), m.client, m.logger, "OllamaEmbeddingManager_embedBatch", url, "", embeddingRequest{Model: m.model, Input: texts}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embeddings ollamaEmbedResponse
+	err = json.NewDecoder(resp.Body).Decode(&embeddings)
+	if err != nil {
+		m.logger.Error().Msg("OllamaEmbeddingManager_embedBatch got an unexpected body")
+		return nil, errors.New("aiErrorProviderInvalidResponse")
+	}
+	return embeddings.Embeddings, nil
+}
@@ -1,41 +1,192 @@
 package implementations
 
 import (
+	"context"
+	"echo-api/managers"
 	"echo-api/util"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 )
 
-// TODO Implement
-type OpenAiCommunicationManager struct {
-	configuration *util.Configuration
+const defaultAiTimeoutSeconds = 60
+
+// Talks to any OpenAI compatible "/v1/chat/completions" endpoint
+type openAiChatAdapter struct {
+	baseUrl string
+	apiKey  string
+	logger  *util.Logger
+	client  *http.Client
+}
+
+type chatCompletionRequest struct {
+	Model         string             `json:"model"`
+	Messages      []any              `json:"messages"`
+	Stream        bool               `json:"stream,omitempty"`
+	StreamOptions *chatStreamOptions `json:"stream_options,omitempty"`
+}
+
+// Messages with images send their content as parts
+type openAiPartsMessage struct {
+	Role    string              `json:"role"`
+	Content []openAiContentPart `json:"content"`
+}
+
+type openAiContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageUrl *openAiImageUrl `json:"image_url,omitempty"`
 }
 
-func NewOpenAiCommunicationManager(c *util.Configuration) OpenAiCommunicationManager {
-	return OpenAiCommunicationManager{configuration: c}
+type openAiImageUrl struct {
+	Url string `json:"url"`
 }
 
-func (cm OpenAiCommunicationManager) SendPrompt(contextID string, msg string) (string, error) {
-	if cm.configuration.IsAiAssistantEnabled {
-		return "", errors.ErrUnsupported
+type chatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage chatUsage `json:"usage"`
+}
+
+type chatCompletionChunk struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Delta        chatMessage `json:"delta"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+func NewOpenAiCommunicationManager(provider util.AiProvider, model string, logger *util.Logger) *ChatCommunicationManager {
+	adapter := &openAiChatAdapter{
+		baseUrl: provider.BaseUrl,
+		apiKey:  provider.ApiKey,
+		logger:  logger,
+		client:  &http.Client{Timeout: getProviderTimeout(provider)},
 	}
-	return msg, nil
+	return newChatCommunicationManager(adapter, model, provider.SupportsImages)
 }
-func (cm OpenAiCommunicationManager) ResetContext(contextID string, isSoftReset bool) error {
-	if cm.configuration.IsAiAssistantEnabled {
-		return errors.ErrUnsupported
+
+func (a *openAiChatAdapter) complete(ctx context.Context, model string, messages []chatMessage) (managers.Completion, error) {
+	resp, err := a.postChat(ctx, chatCompletionRequest{Model: model, Messages: toOpenAiMessages(messages)})
+	if err != nil {
+		return managers.Completion{}, err
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("OpenAiCommunicationManager_complete could not read the provider response")
+		return managers.Completion{}, errors.New("aiErrorProviderInvalidResponse")
 	}
-	return nil
+	var completion chatCompletionResponse
+	err = json.Unmarshal(payload, &completion)
+	if err != nil || len(completion.Choices) == 0 {
+		a.logger.Error().Err(err).Msg(fmt.Sprintf("OpenAiCommunicationManager_complete got an unexpected body: %s", string(payload)))
+		return managers.Completion{}, errors.New("aiErrorProviderInvalidResponse")
+	}
+
+	return managers.Completion{
+		Content:          completion.Choices[0].Message.Content,
+		Model:            completion.Model,
+		PromptTokens:     completion.Usage.PromptTokens,
+		CompletionTokens: completion.Usage.CompletionTokens,
+	}, nil
 }
 
-func (cm OpenAiCommunicationManager) DeleteContext(string, bool) error {
-	if cm.configuration.IsAiAssistantEnabled {
-		return errors.ErrUnsupported
+// Reads the "data: {...}" server-sent events of a streamed completion until "data: [DONE]"
+func (a *openAiChatAdapter) stream(ctx context.Context, model string, messages []chatMessage, onDelta func(string) error) (managers.Completion, error) {
+	var res managers.Completion
+	request := chatCompletionRequest{Model: model, Messages: toOpenAiMessages(messages), Stream: true, StreamOptions: &chatStreamOptions{IncludeUsage: true}}
+	resp, err := a.postChat(ctx, request)
+	if err != nil {
+		return res, err
 	}
-	return nil
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	err = scanProviderStream(ctx, resp.Body, a.logger, "OpenAiCommunicationManager_stream", func(line string) (bool, error) {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			return false, nil
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return true, nil
+		}
+		var chunk chatCompletionChunk
+		err := json.Unmarshal([]byte(data), &chunk)
+		if err != nil {
+			a.logger.Error().Err(err).Msg(fmt.Sprintf("OpenAiCommunicationManager_stream got an unexpected chunk: %s", data))
+			return true, errors.New("aiErrorProviderInvalidResponse")
+		}
+		if chunk.Model != "" {
+			res.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			res.PromptTokens = chunk.Usage.PromptTokens
+			res.CompletionTokens = chunk.Usage.CompletionTokens
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			sb.WriteString(choice.Delta.Content)
+			err = onDelta(choice.Delta.Content)
+			if err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		return res, err
+	}
+	res.Content = sb.String()
+
+	return res, nil
+}
+
+// Images are sent inline as data URLs
+func toOpenAiMessages(messages []chatMessage) []any {
+	res := make([]any, len(messages))
+	for i, m := range messages {
+		if len(m.images) == 0 {
+			res[i] = m
+			continue
+		}
+		parts := []openAiContentPart{{Type: "text", Text: m.Content}}
+		for _, image := range m.images {
+			url := "data:" + image.MimeType + ";base64," + base64.StdEncoding.EncodeToString(image.Data)
+			parts = append(parts, openAiContentPart{Type: "image_url", ImageUrl: &openAiImageUrl{Url: url}})
+		}
+		res[i] = openAiPartsMessage{Role: m.Role, Content: parts}
+	}
+	return res
 }
-func (cm OpenAiCommunicationManager) CreateContext(string, bool) error {
-	if cm.configuration.IsAiAssistantEnabled {
-		return errors.ErrUnsupported
+
+// Returned response always has a 200 status, other statuses are mapped to errors
+func (a *openAiChatAdapter) postChat(ctx context.Context, request chatCompletionRequest) (*http.Response, error) {
+	if a.baseUrl == "" {
+		return nil, errors.New("aiErrorNotConfigured")
 	}
-	return nil
+	url := strings.TrimRight(a.baseUrl, "/") + "/v1/chat/completions"
+	return postToProvider(ctx, a.client, a.logger, "OpenAiCommunicationManager_postChat", url, a.apiKey, request, nil)
 }
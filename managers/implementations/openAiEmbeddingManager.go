@@ -0,0 +1,106 @@
+package implementations
+
+import (
+	"context"
+	"echo-api/util"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultEmbeddingBatchSize = 64
+
+/* This implementation talks to any OpenAI compatible "/v1/embeddings" endpoint.
+ * When no embedding specific url or api key is configured the ones of the assistant are used.
+ */
+type OpenAiEmbeddingManager struct {
+	baseUrl   string
+	apiKey    string
+	model     string
+	batchSize int
+	logger    *util.Logger
+	client    *http.Client
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func NewOpenAiEmbeddingManager(c *util.Configuration, logger *util.Logger) *OpenAiEmbeddingManager {
+	m := &OpenAiEmbeddingManager{
+		baseUrl:   c.EmbeddingBaseUrl,
+		apiKey:    c.EmbeddingApiKey,
+		model:     c.EmbeddingModel,
+		batchSize: getEmbeddingBatchSize(c),
+		logger:    logger,
+		client:    &http.Client{Timeout: getAiTimeout(c)},
+	}
+	if m.baseUrl == "" {
+		m.baseUrl = c.AiBaseUrl
+	}
+	if m.apiKey == "" {
+		m.apiKey = c.AiApiKey
+	}
+	return m
+}
+
+func (m *OpenAiEmbeddingManager) Model() string {
+	return "openai:" + m.model
+}
+
+func (m *OpenAiEmbeddingManager) Embed(texts []string) ([][]float32, error) {
+	if m.baseUrl == "" || m.model == "" {
+		return nil, errors.New("aiErrorNotConfigured")
+	}
+	return embedInBatches(texts, m.batchSize, m.embedBatch)
+}
+
+func (m *OpenAiEmbeddingManager) embedBatch(texts []string) ([][]float32, error) {
+	url := strings.TrimRight(m.baseUrl, "/") + "/v1/embeddings"
+	resp, err := postToProvider(context.Background(), m.client, m.logger, "OpenAiEmbeddingManager_embedBatch", url, m.apiKey, embeddingRequest{Model: m.model, Input: texts}, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embeddings embeddingResponse
+	err = json.NewDecoder(resp.Body).Decode(&embeddings)
+	if err != nil || len(embeddings.Data) != len(texts) {
+		m.logger.Error().Msg("OpenAiEmbeddingManager_embedBatch got an unexpected body")
+		return nil, errors.New("aiErrorProviderInvalidResponse")
+	}
+	// Provider may answer in any order, index points at the input
+	res := make([][]float32, len(texts))
+	for _, d := range embeddings.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, errors.New("aiErrorProviderInvalidResponse")
+		}
+		res[d.Index] = d.Embedding
+	}
+	return res, nil
+}
+
+func getEmbeddingBatchSize(c *util.Configuration) int {
+	if c.EmbeddingBatchSize <= 0 {
+		return defaultEmbeddingBatchSize
+	}
+	return c.EmbeddingBatchSize
+}
+
+func getAiTimeout(c *util.Configuration) time.Duration {
+	timeout := c.AiTimeoutSeconds
+	if timeout <= 0 {
+		timeout = defaultAiTimeoutSeconds
+	}
+	return time.Duration(timeout) * time.Second
+}
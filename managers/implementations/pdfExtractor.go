@@ -0,0 +1,38 @@
+package implementations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Every page becomes its own section so answers can point at the page they came from.
+// The pdf reader panics on malformed objects, which would take the whole server down from a bulk upload goroutine
+func extractPdf(content []byte, b *textBuilder) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.New("extractionErrorInvalidDocument")
+		}
+	}()
+	reader, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return errors.New("extractionErrorInvalidDocument")
+	}
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return errors.New("extractionErrorInvalidDocument")
+		}
+		b.startSection(fmt.Sprintf("Page %d", i))
+		for _, p := range splitParagraphs(text) {
+			b.writeParagraph(p)
+		}
+	}
+	return nil
+}
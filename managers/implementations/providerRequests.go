@@ -0,0 +1,123 @@
+package implementations
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"echo-api/util"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type providerErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// Posts the body as JSON, returned response always has a 200 status and other statuses are mapped to errors
+// Api key is sent as a bearer token, providers authenticating otherwise pass their headers instead
+func postToProvider(ctx context.Context, client *http.Client, logger *util.Logger, caller string, url string, apiKey string, body any, headers map[string]string) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		logger.Error().Err(err).Msg(fmt.Sprintf("%s could not reach the provider", caller))
+		return nil, errors.New("aiErrorProviderUnreachable")
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		payload, _ := io.ReadAll(resp.Body)
+		return nil, mapProviderError(logger, caller, resp.StatusCode, payload)
+	}
+
+	return resp, nil
+}
+
+func mapProviderError(logger *util.Logger, caller string, status int, payload []byte) error {
+	var providerErr providerErrorResponse
+	message := string(payload)
+	if json.Unmarshal(payload, &providerErr) == nil && providerErr.Error.Message != "" {
+		message = providerErr.Error.Message
+	}
+	logger.Error().Msg(fmt.Sprintf("%s provider answered with status: %d message: %s", caller, status, message))
+
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.New("aiErrorProviderUnauthorized")
+	case http.StatusTooManyRequests:
+		return errors.New("aiErrorProviderRateLimited")
+	default:
+		return errors.New("aiErrorProviderRejected")
+	}
+}
+
+// Calls onLine for every line of a streamed response until it asks to stop or the stream ends
+func scanProviderStream(ctx context.Context, body io.Reader, logger *util.Logger, caller string, onLine func(string) (bool, error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		stop, err := onLine(scanner.Text())
+		if err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger.Error().Err(err).Msg(fmt.Sprintf("%s could not read the provider stream", caller))
+		return errors.New("aiErrorProviderInvalidResponse")
+	}
+	return nil
+}
+
+func getProviderTimeout(provider util.AiProvider) time.Duration {
+	timeout := provider.TimeoutSeconds
+	if timeout <= 0 {
+		timeout = defaultAiTimeoutSeconds
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// Calls embed with at most batchSize texts at a time and keeps the order of the given texts
+func embedInBatches(texts []string, batchSize int, embed func([]string) ([][]float32, error)) ([][]float32, error) {
+	res := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		vectors, err := embed(texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != end-start {
+			return nil, errors.New("aiErrorProviderInvalidResponse")
+		}
+		res = append(res, vectors...)
+	}
+	return res, nil
+}
@@ -0,0 +1,79 @@
+package implementations
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const totpDigits = 6
+const totpModulus = 1_000_000
+const totpPeriodSeconds = 30
+const totpSecretSize = 20
+
+// Codes of the steps right before and after the current one are accepted, so clocks may be off by up to 30 seconds
+const totpAllowedSkew = 1
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+/* This implementation follows RFC 6238 with the defaults every authenticator app supports, SHA-1, six digits and 30 second steps.
+ * Secrets are 160 random bits in unpadded base32, the format the otpauth URI carries.
+ */
+type Rfc6238TotpManager struct {
+	issuer string
+}
+
+func NewRfc6238TotpManager(issuer string) *Rfc6238TotpManager {
+	return &Rfc6238TotpManager{issuer: issuer}
+}
+
+func (m *Rfc6238TotpManager) GenerateSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+func (m *Rfc6238TotpManager) ProvisioningUri(secret string, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", m.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriodSeconds))
+	label := url.PathEscape(m.issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func (m *Rfc6238TotpManager) Validate(secret string, code string, at time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	current := at.Unix() / totpPeriodSeconds
+	for step := current - totpAllowedSkew; step <= current+totpAllowedSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// HOTP of RFC 4226 with the time step as counter
+func totpCode(key []byte, step int64) string {
+	mac := hmac.New(sha1.New, key)
+	binary.Write(mac, binary.BigEndian, step)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%totpModulus)
+}
@@ -0,0 +1,56 @@
+package implementations
+
+import (
+	"echo-api/managers"
+	"errors"
+	"math"
+	"time"
+)
+
+const sm2InitialEaseFactor = 2.5
+const sm2MinimumEaseFactor = 1.3
+const sm2PassingGrade = 3
+const sm2MaximumGrade = 5
+
+/* This implementation follows the SuperMemo 2 algorithm.
+ * A failed card starts over with a one day interval, a passed one is shown after 1, 6 and then ease factor times the last interval days.
+ */
+type Sm2SchedulingManager struct {
+}
+
+func NewSm2SchedulingManager() *Sm2SchedulingManager {
+	return &Sm2SchedulingManager{}
+}
+
+func (m *Sm2SchedulingManager) Initial() managers.ReviewState {
+	return managers.ReviewState{EaseFactor: sm2InitialEaseFactor}
+}
+
+func (m *Sm2SchedulingManager) Schedule(state managers.ReviewState, grade int, now time.Time) (managers.ReviewState, error) {
+	if grade < 0 || grade > sm2MaximumGrade {
+		return managers.ReviewState{}, errors.New("argumentErrorInvalidGrade")
+	}
+	if state.EaseFactor == 0 {
+		state.EaseFactor = sm2InitialEaseFactor
+	}
+
+	if grade < sm2PassingGrade {
+		state.Repetitions = 0
+		state.IntervalDays = 1
+	} else {
+		state.Repetitions++
+		switch state.Repetitions {
+		case 1:
+			state.IntervalDays = 1
+		case 2:
+			state.IntervalDays = 6
+		default:
+			state.IntervalDays = int(math.Round(float64(state.IntervalDays) * state.EaseFactor))
+		}
+	}
+
+	miss := float64(sm2MaximumGrade - grade)
+	state.EaseFactor = max(sm2MinimumEaseFactor, state.EaseFactor+0.1-miss*(0.08+miss*0.02))
+	state.DueAt = now.AddDate(0, 0, state.IntervalDays)
+	return state, nil
+}
@@ -0,0 +1,158 @@
+package implementations
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"echo-api/managers"
+	"echo-api/util"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultSmtpPort = 587
+const smtpTimeout = 15 * time.Second
+
+// Port of SMTP submission over TLS, every other port starts in plain text and upgrades with STARTTLS when offered
+const smtpsPort = 465
+
+/* This implementation hands mails to an SMTP server, authenticating with PLAIN when a username is configured.
+ * Go refuses PLAIN over an unencrypted connection unless the server is on localhost, so remote servers have to offer TLS.
+ */
+type SmtpMailManager struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	logger   *util.Logger
+}
+
+func NewSmtpMailManager(c *util.Configuration, logger *util.Logger) *SmtpMailManager {
+	port := c.SmtpPort
+	if port <= 0 {
+		port = defaultSmtpPort
+	}
+	return &SmtpMailManager{host: c.SmtpHost, port: port, username: c.SmtpUsername, password: c.SmtpPassword, from: c.MailFrom, logger: logger}
+}
+
+func (m *SmtpMailManager) Send(msg managers.Mail) error {
+	from, err := mail.ParseAddress(m.from)
+	if err != nil {
+		return errors.New("mailErrorInvalidAddress")
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return errors.New("mailErrorInvalidAddress")
+	}
+	content, err := m.buildMessage(from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	err = m.deliver(from.Address, to.Address, content)
+	if err != nil {
+		m.logger.Error().Err(err).Msg(fmt.Sprintf("SmtpMailManager_Send could not deliver the mail through %s:%d", m.host, m.port))
+		return errors.New("mailErrorDeliveryFailed")
+	}
+	return nil
+}
+
+func (m *SmtpMailManager) deliver(from string, to string, content []byte) error {
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	var err error
+	if m.port == smtpsPort {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: m.host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	// A server that stops answering would otherwise hold the request forever
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && m.port != smtpsPort {
+		err = client.StartTLS(&tls.Config{ServerName: m.host})
+		if err != nil {
+			return err
+		}
+	}
+	if m.username != "" {
+		err = client.Auth(smtp.PlainAuth("", m.username, m.password, m.host))
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(from)
+	if err != nil {
+		return err
+	}
+	err = client.Rcpt(to)
+	if err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	if err != nil {
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// The body is quoted-printable so long lines and non ASCII text survive servers that only take 7 bit
+func (m *SmtpMailManager) buildMessage(from *mail.Address, to *mail.Address, msg managers.Mail) ([]byte, error) {
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, errors.New("mailErrorInvalidHeader")
+	}
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	w := quotedprintable.NewWriter(&buf)
+	_, err = w.Write([]byte(msg.Body))
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
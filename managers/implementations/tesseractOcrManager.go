@@ -0,0 +1,57 @@
+package implementations
+
+import (
+	"bytes"
+	"context"
+	"echo-api/util"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const defaultOcrTimeoutSeconds = 60
+
+/* This implementation runs the Tesseract command line tool, which has to be installed on the server.
+ * The image is piped through stdin, so nothing is written to disk.
+ */
+type TesseractOcrManager struct {
+	binary    string
+	languages string
+	timeout   time.Duration
+	logger    *util.Logger
+}
+
+func NewTesseractOcrManager(binary string, languages string, logger *util.Logger) *TesseractOcrManager {
+	if binary == "" {
+		binary = "tesseract"
+	}
+	if languages == "" {
+		languages = "eng"
+	}
+	return &TesseractOcrManager{binary: binary, languages: languages, timeout: defaultOcrTimeoutSeconds * time.Second, logger: logger}
+}
+
+func (m *TesseractOcrManager) Recognize(content []byte, extension string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, m.binary, "stdin", "stdout", "-l", m.languages)
+	cmd.Stdin = bytes.NewReader(content)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		m.logger.Error().Err(err).Msg(fmt.Sprintf("TesseractOcrManager_Recognize failed for a %s image: %s", extension, strings.TrimSpace(stderr.String())))
+		return "", errors.New("ocrErrorFailed")
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Tells whether the binary can be found, so the dependency injection can fall back when it is missing
+func (m *TesseractOcrManager) IsAvailable() bool {
+	_, err := exec.LookPath(m.binary)
+	return err == nil
+}
@@ -0,0 +1,12 @@
+package managers
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Delivers plain text mails to a single recipient
+type MailManager interface {
+	Send(Mail) error
+}
@@ -0,0 +1,6 @@
+package managers
+
+// Recognizes the text in an image, an empty text means none was found
+type OcrManager interface {
+	Recognize([]byte, string) (string, error)
+}
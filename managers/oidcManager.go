@@ -0,0 +1,17 @@
+package managers
+
+// Who the provider says signed in, the subject is the stable id of the user at the provider
+type OidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Signs users in with OpenID Connect providers through the authorization code flow with PKCE
+type OidcManager interface {
+	Providers() []string
+	AuthorizationUrl(provider string, state string, nonce string, codeChallenge string) (string, error)
+	// Trades the code of the callback for the identity in the verified ID token
+	Exchange(provider string, code string, codeVerifier string, nonce string) (OidcIdentity, error)
+}
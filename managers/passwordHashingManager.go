@@ -0,0 +1,7 @@
+package managers
+
+// Hashes passwords, hashes made by an older algorithm or with weaker settings still verify but should be replaced
+type PasswordHashingManager interface {
+	HashingManager
+	NeedsRehash(hashed string) bool
+}
@@ -0,0 +1,6 @@
+package managers
+
+// Rejects passwords that are too easy to guess, personal is what the user is known by, like their email and name
+type PasswordPolicyManager interface {
+	Check(password string, personal ...string) error
+}
@@ -1,22 +1,96 @@
 package managers
 
+import (
+	"echo-api/models/entities"
+	"errors"
+	"strings"
+	"text/template"
+)
+
+// Every generation renders the templates of the given language, an empty language id uses the language independent ones
 type PromptGenManager interface {
-	GeneratePrompt(any) (string, error)
-	GeneratePromptWith(any, PromptAction) (string, error)
-	GenerateMessage(string) (string, error)
+	GenerateInitial(string) (string, error)
+	GenerateSubject(any, string) (string, error)
+	GeneratePrompt(any, string) (string, error)
+	GeneratePromptWith(any, PromptAction, string) (string, error)
+	GenerateMessage(string, string) (string, error)
+	GenerateMessageWith(string, []entities.Chunk, string) (string, error)
+	// Asks for a summary of the previous summary and the given turns
+	GenerateSummarize(string, []entities.Message, string) (string, error)
+	// Asks for at most the given number of flashcards about a note or document
+	GenerateFlashcards(any, int, string) (string, error)
+	// Asks for a quiz of the given number of questions about the given material
+	GenerateQuiz(string, int, string) (string, error)
+	// Asks whether the given answer of a question matches the expected one
+	GenerateGrading(string, string, string, string) (string, error)
 }
 
+// Returns the template body that is currently in effect for the action and language
+type PromptTemplateSource interface {
+	GetTemplate(PromptAction, string) (string, error)
+}
+
+// Name of a prompt template, the body is looked up through a PromptTemplateSource
 type PromptAction string
 
 const (
-	Initial   PromptAction = "Hi, you are going to assist customers with their questions or any request within the context given to you. Rules are these:\n 1. There will be prompts where you will need to do according to the action in them. Syntax is \"Prompt(<action>)\"\n 2. You will answer messages within the context as an assistant when a message sent. Syntax is \"Message(<string>)\"\n 3. Actions might be remember, forget or forgetAll. You will do the action and if it is done successfully respond \"done\", if there is any error on your side please respond with \"failed. <error>\". Syntax is \"<action>(<string optional>)\"\n 4. Remember action is for you to keep a given message in mind for future interactions\n 5. Forget action is for you to forget and dont bring up a given info anymore\n 6. ForgetAll action is for you to forget all the previous Prompts given and start fresh.\nPlease, try to keep answers short and focused and thank you for assisting me and the customers. "
-	Prompt    PromptAction = "Prompt(%s)"
-	Message   PromptAction = "Message(%s)"
-	Remember  PromptAction = "Remember(%s)"
-	Forget    PromptAction = "Forget(%s)"
-	ForgetAll PromptAction = "ForgetAll()"
+	Initial    PromptAction = "initial"
+	Prompt     PromptAction = "prompt"
+	Message    PromptAction = "message"
+	Remember   PromptAction = "remember"
+	Forget     PromptAction = "forget"
+	ForgetAll  PromptAction = "forgetAll"
+	Excerpt    PromptAction = "excerpt"
+	Source     PromptAction = "source"
+	Summarize  PromptAction = "summarize"
+	Summary    PromptAction = "summary"
+	Flashcards PromptAction = "flashcards"
+	Quiz       PromptAction = "quiz"
+	Grading    PromptAction = "grading"
+	Equation   PromptAction = "equation"
 )
 
 func (pa PromptAction) String() string {
 	return string(pa)
 }
+
+// Fields a template can refer to, which of them are set depends on the action
+type TemplateData struct {
+	// Text the action wraps, for "prompt" it is the rendered inner action and for "summarize" the conversation
+	Value string
+	// Number of an excerpt, for "flashcards" and "quiz" the number of cards or questions asked for
+	Number int
+	// "Note", "Document" or "Image" for a source, "Note" or "Document" for flashcards
+	Kind string
+}
+
+// Seeded into the database and used whenever no stored version exists
+var DefaultTemplates = map[PromptAction]string{
+	Initial:    "Hi, you are going to assist customers with their questions or any request within the context given to you. Rules are these:\n 1. There will be prompts where you will need to do according to the action in them. Syntax is \"Prompt(<action>)\"\n 2. You will answer messages within the context as an assistant when a message sent. Syntax is \"Message(<string>)\"\n 3. Actions might be remember, forget or forgetAll. You will do the action and if it is done successfully respond \"done\", if there is any error on your side please respond with \"failed. <error>\". Syntax is \"<action>(<string optional>)\"\n 4. Remember action is for you to keep a given message in mind for future interactions\n 5. Forget action is for you to forget and dont bring up a given info anymore\n 6. ForgetAll action is for you to forget all the previous Prompts given and start fresh.\n 7. Messages might start with excerpts of the notes and documents in the context, use them to answer and refer to them by their number. Syntax is \"Excerpt([<number>] <string>)\"\n 8. Summary action gives you a summary of the earlier conversation that is no longer shown to you, treat it as if it was said\n 9. Equation action gives you an equation of the context as plain text followed by its LaTeX source, refer to it by its label when it has one. Syntax is \"Equation(<label>: <text> | LaTeX: <latex>)\"\nPlease, try to keep answers short and focused and thank you for assisting me and the customers. ",
+	Prompt:     "Prompt({{.Value}})",
+	Message:    "Message({{.Value}})",
+	Remember:   "Remember({{.Value}})",
+	Forget:     "Forget({{.Value}})",
+	ForgetAll:  "ForgetAll()",
+	Excerpt:    "Excerpt([{{.Number}}] {{.Value}})",
+	Source:     "{{.Kind}} \"{{.Value}}\" is in the context, its relevant excerpts will be given with messages",
+	Summarize:  "Summarize the conversation below in a few sentences. Keep every fact, decision and open question the customer may refer to later.\n{{.Value}}",
+	Summary:    "Summary({{.Value}})",
+	Equation:   "Equation({{.Value}})",
+	Quiz:       "Write a quiz of {{.Number}} questions about the material below, mix multiple choice and short answer questions. Answer only with a JSON array of objects that have a \"kind\" of \"multipleChoice\" or \"shortAnswer\" and the \"question\". Multiple choice questions also have the \"options\" and the zero based index of the correct one as \"correctOption\", short answer questions have the expected \"answer\".\n{{.Value}}",
+	Grading:    "Grade the given answer of a quiz question against the expected answer, an answer that means the same is correct. Answer only with a JSON object that has \"correct\" as true or false and a short \"feedback\" for the student.\n{{.Value}}",
+	Flashcards: "Write at most {{.Number}} flashcards to study the {{.Kind}} below. Answer only with a JSON array of objects that have a \"front\" with a question and a \"back\" with its answer.\n{{.Value}}",
+}
+
+// Parses a body the same way it will be rendered, unknown fields fail here instead of on a customer's message
+func ParseTemplate(body string) (*template.Template, error) {
+	t, err := template.New("prompt").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, errors.New("templateErrorInvalidBody")
+	}
+	err = t.Execute(&strings.Builder{}, TemplateData{Value: "value", Number: 1, Kind: "Note"})
+	if err != nil {
+		return nil, errors.New("templateErrorInvalidBody")
+	}
+	return t, nil
+}
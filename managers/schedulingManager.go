@@ -0,0 +1,16 @@
+package managers
+
+import "time"
+
+// Decides when a card is shown again from the grade it got, grades go from 0 (forgotten) to 5 (perfect recall)
+type SchedulingManager interface {
+	Initial() ReviewState
+	Schedule(ReviewState, int, time.Time) (ReviewState, error)
+}
+
+type ReviewState struct {
+	Repetitions  int
+	EaseFactor   float64
+	IntervalDays int
+	DueAt        time.Time
+}
@@ -0,0 +1,34 @@
+package managers
+
+// Keeps the keys tokens are signed with, keys other than the active one only verify tokens issued before a rotation
+type SigningKeyManager interface {
+	ActiveKey() SigningKey
+	GetKey(kid string) (SigningKey, error)
+	// Public keys as a JSON Web Key Set, symmetric keys are never published
+	PublicKeys() JsonWebKeySet
+}
+
+// SignKey is nil for keys that can only verify
+type SigningKey struct {
+	ID        string
+	Algorithm string
+	SignKey   any
+	VerifyKey any
+}
+
+type JsonWebKeySet struct {
+	Keys []JsonWebKey `json:"keys"`
+}
+
+// Fields are base64url encoded as RFC 7517 and 7518 describe, only the ones of the key type are set
+type JsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
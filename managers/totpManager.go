@@ -0,0 +1,11 @@
+package managers
+
+import "time"
+
+// Time based one-time passwords as authenticator apps generate them
+type TotpManager interface {
+	GenerateSecret() (string, error)
+	ProvisioningUri(secret string, account string) string
+	// Returns the time step the code belongs to, so a code can be refused once its step was used
+	Validate(secret string, code string, at time.Time) (int64, bool)
+}
@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"context"
+	"echo-api/managers"
+)
+
+type RecordedReset struct {
+	ContextID   string
+	IsSoftReset bool
+}
+
+// Wraps another communication manager and records every prompt, message, reset and deletion it was given
+type MockAiCommunicationManager struct {
+	inner    managers.AiCommunicationManager
+	Prompts  []string
+	Messages []string
+	Resets   []RecordedReset
+	Deletes  []string
+	// Summaries the conversation was compacted to
+	Summaries []string
+}
+
+func NewMockAiCommunicationManager(inner managers.AiCommunicationManager) *MockAiCommunicationManager {
+	return &MockAiCommunicationManager{inner: inner, Prompts: make([]string, 0), Messages: make([]string, 0), Resets: make([]RecordedReset, 0), Deletes: make([]string, 0), Summaries: make([]string, 0)}
+}
+
+func (m *MockAiCommunicationManager) SendPrompt(contextID string, msg string) (managers.Completion, error) {
+	m.Prompts = append(m.Prompts, msg)
+	return m.inner.SendPrompt(contextID, msg)
+}
+
+func (m *MockAiCommunicationManager) SendMessage(contextID string, msg string) (managers.Completion, error) {
+	m.Messages = append(m.Messages, msg)
+	return m.inner.SendMessage(contextID, msg)
+}
+
+func (m *MockAiCommunicationManager) StreamMessage(ctx context.Context, contextID string, msg string, onDelta func(string) error) (managers.Completion, error) {
+	m.Messages = append(m.Messages, msg)
+	return m.inner.StreamMessage(ctx, contextID, msg, onDelta)
+}
+
+func (m *MockAiCommunicationManager) ResetContext(contextID string, isSoftReset bool) error {
+	m.Resets = append(m.Resets, RecordedReset{ContextID: contextID, IsSoftReset: isSoftReset})
+	return m.inner.ResetContext(contextID, isSoftReset)
+}
+
+func (m *MockAiCommunicationManager) Complete(msg string) (managers.Completion, error) {
+	return m.inner.Complete(msg)
+}
+
+func (m *MockAiCommunicationManager) CompactContext(contextID string, summary string, keep int) error {
+	m.Summaries = append(m.Summaries, summary)
+	return m.inner.CompactContext(contextID, summary, keep)
+}
+
+func (m *MockAiCommunicationManager) DeleteContext(contextID string, ignoreMissing bool) error {
+	m.Deletes = append(m.Deletes, contextID)
+	return m.inner.DeleteContext(contextID, ignoreMissing)
+}
+
+func (m *MockAiCommunicationManager) CreateContext(contextID string, initialPrompt string) error {
+	return m.inner.CreateContext(contextID, initialPrompt)
+}
+
+func (m *MockAiCommunicationManager) HasContext(contextID string) bool {
+	return m.inner.HasContext(contextID)
+}
+
+// Hands out the same manager for every provider and model
+type MockAiProviderRegistry struct {
+	Manager managers.AiCommunicationManager
+}
+
+func (r *MockAiProviderRegistry) Get(provider string, model string) (managers.AiCommunicationManager, error) {
+	return r.Manager, nil
+}
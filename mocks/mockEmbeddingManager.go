@@ -0,0 +1,22 @@
+package mocks
+
+import "echo-api/managers"
+
+// Wraps another embedding manager and records every text it had to embed
+type MockEmbeddingManager struct {
+	inner    managers.EmbeddingManager
+	Embedded []string
+}
+
+func NewMockEmbeddingManager(inner managers.EmbeddingManager) *MockEmbeddingManager {
+	return &MockEmbeddingManager{inner: inner, Embedded: make([]string, 0)}
+}
+
+func (m *MockEmbeddingManager) Embed(texts []string) ([][]float32, error) {
+	m.Embedded = append(m.Embedded, texts...)
+	return m.inner.Embed(texts)
+}
+
+func (m *MockEmbeddingManager) Model() string {
+	return m.inner.Model()
+}
@@ -0,0 +1,24 @@
+package mocks
+
+import (
+	"echo-api/managers"
+	"errors"
+)
+
+// Keeps sent mails for inspection instead of delivering them, Fail makes every send fail
+type MockMailManager struct {
+	Sent []managers.Mail
+	Fail bool
+}
+
+func NewMockMailManager() *MockMailManager {
+	return &MockMailManager{Sent: make([]managers.Mail, 0)}
+}
+
+func (m *MockMailManager) Send(mail managers.Mail) error {
+	if m.Fail {
+		return errors.New("mailErrorDeliveryFailed")
+	}
+	m.Sent = append(m.Sent, mail)
+	return nil
+}
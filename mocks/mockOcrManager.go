@@ -0,0 +1,21 @@
+package mocks
+
+import "errors"
+
+// Recognizes the same text in every image, or fails when no text is given
+type MockOcrManager struct {
+	text       string
+	Recognized int
+}
+
+func NewMockOcrManager(text string) *MockOcrManager {
+	return &MockOcrManager{text: text}
+}
+
+func (m *MockOcrManager) Recognize(content []byte, extension string) (string, error) {
+	m.Recognized++
+	if m.text == "" {
+		return "", errors.New("ocrErrorFailed")
+	}
+	return m.text, nil
+}
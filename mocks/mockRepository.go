@@ -8,11 +8,13 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"gorm.io/gorm/clause"
 )
 
 type statement struct {
+	Column     string
 	Value      any
 	Comparison string
 }
@@ -44,14 +46,9 @@ func (r *MockRepository[T]) First(id string, shouldPreload bool) (T, error) {
 func (r *MockRepository[T]) Find(shouldPreload bool) ([]T, error) {
 	res := make([]T, 0)
 	for _, v := range r.data {
-		valueOf := reflect.ValueOf(v)
-		for sK, sV := range r.statements {
-			f := valueOf.FieldByName(sK).Elem().Interface()
-			if f != sV {
-				break
-			}
+		if r.matchesStatements(v) {
+			res = append(res, v)
 		}
-		res = append(res, v)
 	}
 	slices.SortFunc(res, r.orderByReflection)
 	return res, nil
@@ -76,11 +73,29 @@ func (r *MockRepository[T]) Create(val *T) (T, error) {
 		return temp, errors.New("idCantSetError")
 	}
 	f.SetString(currIdStr)
+	// Like gorm, timestamps are only filled when the caller left them empty
+	now := time.Now()
+	for _, name := range []string{"CreatedAt", "UpdatedAt"} {
+		t := reflect.ValueOf(val).Elem().FieldByName(name)
+		if t.CanSet() && t.Interface().(time.Time).IsZero() {
+			t.Set(reflect.ValueOf(now))
+		}
+	}
 	r.data[currIdStr] = *val
 	r.idCounter = currId
 	return *val, nil
 }
 
+func (r *MockRepository[T]) CreateMany(vals []T) ([]T, error) {
+	for i := range vals {
+		_, err := r.Create(&vals[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
 func (r *MockRepository[T]) Update(val *T) (T, error) {
 	id := reflect.ValueOf(val).Elem().FieldByName("ID").String()
 	r.data[id] = *val
@@ -98,13 +113,59 @@ func (r *MockRepository[T]) Delete(id string) error {
 	return nil
 }
 
+func (r *MockRepository[T]) DeleteWhere(query string, args ...any) error {
+	q := &MockRepository[T]{statements: make(map[string]statement), data: r.data}
+	q.Where(query, args...)
+	if len(q.statements) == 0 {
+		return errors.New("missingWhereClause")
+	}
+	for id, v := range r.data {
+		if q.matchesStatements(v) {
+			delete(r.data, id)
+		}
+	}
+	return nil
+}
+
+func (r *MockRepository[T]) UpdateColumn(column string, value any) (int64, error) {
+	if len(r.statements) == 0 {
+		return 0, errors.New("missingWhereClause")
+	}
+	var count int64
+	for id, v := range r.data {
+		if !r.matchesStatements(v) {
+			continue
+		}
+		updated := reflect.New(reflect.TypeOf(v)).Elem()
+		updated.Set(reflect.ValueOf(v))
+		f := fieldByColumn(updated, column)
+		if !f.CanSet() {
+			return count, errors.New("unknownColumnError")
+		}
+		val := reflect.ValueOf(value)
+		if f.Kind() == reflect.Pointer && val.Kind() != reflect.Pointer {
+			ptr := reflect.New(val.Type())
+			ptr.Elem().Set(val)
+			val = ptr
+		}
+		f.Set(val)
+		r.data[id] = updated.Interface().(T)
+		count++
+	}
+	return count, nil
+}
+
 func (r *MockRepository[T]) Where(query string, args ...any) util.Repository[T] {
 	queryParts := strings.Split(query, " ")
 	if len(queryParts) < 2 {
 		return r
 	}
-	st := statement{Value: args, Comparison: queryParts[1]}
-	r.statements[queryParts[0]] = st
+	// Keyed with the comparison too so a range on the same column keeps both bounds
+	st := statement{Column: queryParts[0], Value: args, Comparison: queryParts[1]}
+	if strings.ToUpper(queryParts[1]) == "IS" {
+		st.Comparison = strings.ToUpper(strings.Join(queryParts[1:], " "))
+	}
+	r.statements[queryParts[0]+" "+queryParts[1]] = st
 	return r
 }
 
@@ -118,8 +179,8 @@ func (r *MockRepository[T]) Limit(limit int) util.Repository[T] {
 }
 
 func (r *MockRepository[T]) Order(args ...any) util.Repository[T] {
-	if len(args) > 1 {
-		r.order = args[0].(string)
+	if len(args) > 0 {
+		r.order, _ = args[0].(string)
 	}
 	return r
 }
@@ -128,11 +189,105 @@ func (r *MockRepository[T]) Clauses(conds ...clause.Expression) util.Repository[
 }
 
 func (r *MockRepository[T]) orderByReflection(a T, b T) int {
-	aV := reflect.ValueOf(a).FieldByName(r.order)
-	bV := reflect.ValueOf(b).FieldByName(r.order)
-	if !aV.IsValid() || !bV.IsValid() {
-		return 1
+	aV := fieldByColumn(reflect.ValueOf(a), r.order)
+	bV := fieldByColumn(reflect.ValueOf(b), r.order)
+	if aV.IsValid() && bV.IsValid() {
+		if aT, ok := aV.Interface().(time.Time); ok {
+			if res := aT.Compare(bV.Interface().(time.Time)); res != 0 {
+				return res
+			}
+		}
+		res := strings.Compare(fmt.Sprint(reflect.Indirect(aV).Interface()), fmt.Sprint(reflect.Indirect(bV).Interface()))
+		if res != 0 {
+			return res
+		}
 	}
 
-	return strings.Compare(aV.Elem().String(), bV.Elem().String())
+	// Mock ids are increasing numbers so ties keep the insertion order
+	aID, _ := strconv.ParseUint(fieldByColumn(reflect.ValueOf(a), "ID").String(), 10, 64)
+	bID, _ := strconv.ParseUint(fieldByColumn(reflect.ValueOf(b), "ID").String(), 10, 64)
+	return int(aID) - int(bID)
+}
+
+func (r *MockRepository[T]) matchesStatements(v T) bool {
+	valueOf := reflect.ValueOf(v)
+	for _, st := range r.statements {
+		f := fieldByColumn(valueOf, st.Column)
+		if st.Comparison == "IS NULL" || st.Comparison == "IS NOT NULL" {
+			if f.IsValid() && f.Kind() == reflect.Pointer && f.IsNil() != (st.Comparison == "IS NULL") {
+				return false
+			}
+			continue
+		}
+		args, ok := st.Value.([]any)
+		if !f.IsValid() || !ok || len(args) == 0 {
+			continue
+		}
+		if f.Kind() == reflect.Pointer {
+			if f.IsNil() {
+				return false
+			}
+			f = f.Elem()
+		}
+		if t, ok := f.Interface().(time.Time); ok {
+			if !matchesTime(t, st.Comparison, args[0]) {
+				return false
+			}
+			continue
+		}
+		actual := fmt.Sprint(f.Interface())
+		switch strings.ToUpper(st.Comparison) {
+		case "=":
+			if actual != fmt.Sprint(args[0]) {
+				return false
+			}
+		case "IN":
+			expected := reflect.ValueOf(args[0])
+			if expected.Kind() != reflect.Slice {
+				continue
+			}
+			found := false
+			for i := 0; i < expected.Len(); i++ {
+				if actual == fmt.Sprint(expected.Index(i).Interface()) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesTime(actual time.Time, comparison string, arg any) bool {
+	expected, ok := arg.(time.Time)
+	if !ok {
+		return true
+	}
+	switch comparison {
+	case "=":
+		return actual.Equal(expected)
+	case ">":
+		return actual.After(expected)
+	case ">=":
+		return !actual.Before(expected)
+	case "<":
+		return actual.Before(expected)
+	case "<=":
+		return !actual.After(expected)
+	}
+	return true
+}
+
+// Matches both "context_id" and "contextID" style column names to the ContextID field
+func fieldByColumn(v reflect.Value, column string) reflect.Value {
+	normalized := strings.ToLower(strings.ReplaceAll(column, "_", ""))
+	if normalized == "" {
+		return reflect.Value{}
+	}
+	return v.FieldByNameFunc(func(name string) bool {
+		return strings.ToLower(name) == normalized
+	})
 }
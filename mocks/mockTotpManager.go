@@ -0,0 +1,24 @@
+package mocks
+
+import "time"
+
+// Accepts a single fixed code, its time step is the current one like a real manager would report
+type MockTotpManager struct {
+	Code string
+}
+
+func NewMockTotpManager(code string) *MockTotpManager {
+	return &MockTotpManager{Code: code}
+}
+
+func (m *MockTotpManager) GenerateSecret() (string, error) {
+	return "SECRET", nil
+}
+
+func (m *MockTotpManager) ProvisioningUri(secret string, account string) string {
+	return "otpauth://totp/" + account + "?secret=" + secret
+}
+
+func (m *MockTotpManager) Validate(secret string, code string, at time.Time) (int64, bool) {
+	return at.Unix() / 30, code == m.Code
+}
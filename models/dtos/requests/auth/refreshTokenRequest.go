@@ -0,0 +1,5 @@
+package auth
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
@@ -0,0 +1,5 @@
+package auth
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required"`
+}
@@ -0,0 +1,5 @@
+package auth
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
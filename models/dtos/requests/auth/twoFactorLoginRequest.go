@@ -0,0 +1,7 @@
+package auth
+
+// Code is either from the authenticator app or one of the recovery codes
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package card
+
+type CreateCardRequest struct {
+	DeckID string `json:"deckId"`
+	Front  string `json:"front"`
+	Back   string `json:"back"`
+}
@@ -0,0 +1,7 @@
+package card
+
+// Grade goes from 0 (forgotten) to 5 (perfect recall), 3 and above count as recalled
+type ReviewCardRequest struct {
+	ID    string `json:"-"`
+	Grade *int   `json:"grade"`
+}
@@ -0,0 +1,7 @@
+package card
+
+type UpdateCardRequest struct {
+	ID    string  `json:"id"`
+	Front *string `json:"front"`
+	Back  *string `json:"back"`
+}
@@ -1,6 +1,9 @@
 package context
 
+// Empty Provider and Model use the configured defaults
 type CreateContextRequest struct {
 	UserID     string
 	LanguageID string
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
 }
@@ -0,0 +1,20 @@
+package context
+
+import "errors"
+
+// Mode is "soft" or "hard", an empty mode is soft
+type ResetContextRequest struct {
+	ID   string `json:"-"`
+	Mode string `json:"mode" form:"mode"`
+}
+
+func (r *ResetContextRequest) IsSoftReset() (bool, error) {
+	switch r.Mode {
+	case "", "soft":
+		return true, nil
+	case "hard":
+		return false, nil
+	default:
+		return false, errors.New("argumentErrorUnknownResetMode")
+	}
+}
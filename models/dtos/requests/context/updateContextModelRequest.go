@@ -0,0 +1,7 @@
+package context
+
+type UpdateContextModelRequest struct {
+	ID       string `json:"-"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
@@ -0,0 +1,7 @@
+package deck
+
+type CreateDeckRequest struct {
+	Name      string  `json:"name"`
+	ContextID string  `json:"contextId"`
+	UserID    *string `json:"userId"`
+}
@@ -0,0 +1,11 @@
+package deck
+
+import "echo-api/models/dtos/requests/base"
+
+type FilterDecksRequest struct {
+	IDs        *[]string `json:"ids" form:"ids"`
+	Name       *string   `json:"name" form:"name"`
+	UserIDs    *[]string `json:"users" form:"users"`
+	ContextIDs *[]string `json:"contexts" form:"contexts"`
+	base.PaginationRequestBase
+}
@@ -0,0 +1,8 @@
+package deck
+
+import "echo-api/models/dtos/requests/base"
+
+type FilterDueCardsRequest struct {
+	DeckID string `json:"-" form:"-"`
+	base.PaginationRequestBase
+}
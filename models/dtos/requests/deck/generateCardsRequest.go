@@ -0,0 +1,9 @@
+package deck
+
+// SourceType is "note" or "document", a zero Count asks for the default number of cards
+type GenerateCardsRequest struct {
+	DeckID     string `json:"-"`
+	SourceType string `json:"sourceType"`
+	SourceID   string `json:"sourceId"`
+	Count      int    `json:"count"`
+}
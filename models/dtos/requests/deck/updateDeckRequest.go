@@ -0,0 +1,6 @@
+package deck
+
+type UpdateDeckRequest struct {
+	ID   string  `json:"id"`
+	Name *string `json:"name"`
+}
@@ -0,0 +1,10 @@
+package equation
+
+// The context of the note is used when no ContextID is given
+type CreateEquationRequest struct {
+	Latex     string  `json:"latex"`
+	Label     string  `json:"label"`
+	NoteID    *string `json:"noteId"`
+	ContextID string  `json:"contextId"`
+	UserID    *string `json:"userId"`
+}
@@ -0,0 +1,12 @@
+package equation
+
+import "echo-api/models/dtos/requests/base"
+
+type FilterEquationsRequest struct {
+	IDs        *[]string `json:"ids" form:"ids"`
+	Label      *string   `json:"label" form:"label"`
+	UserIDs    *[]string `json:"users" form:"users"`
+	NoteIDs    *[]string `json:"notes" form:"notes"`
+	ContextIDs *[]string `json:"contexts" form:"contexts"`
+	base.PaginationRequestBase
+}
@@ -0,0 +1,5 @@
+package equation
+
+type RenderEquationRequest struct {
+	Latex string `json:"latex"`
+}
@@ -0,0 +1,7 @@
+package equation
+
+type UpdateEquationRequest struct {
+	ID    string  `json:"id"`
+	Latex *string `json:"latex"`
+	Label *string `json:"label"`
+}
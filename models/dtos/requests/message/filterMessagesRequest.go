@@ -0,0 +1,11 @@
+package message
+
+import (
+	"echo-api/models/dtos/requests/base"
+)
+
+type FilterMessagesRequest struct {
+	base.PaginationRequestBase
+	ContextID string    `json:"contextId" form:"contextId"`
+	Roles     *[]string `json:"roles" form:"roles"`
+}
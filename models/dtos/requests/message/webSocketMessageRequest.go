@@ -0,0 +1,6 @@
+package message
+
+type WebSocketMessageRequest struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
@@ -1,7 +1,11 @@
 package prompt
 
+import "echo-api/managers"
+
 type CreatePromptRequest struct {
 	Value     any    `json:"value" form:"value"`
 	ContextID string `json:"contextId" form:"contextId"`
 	EntityID  string `json:"entityId" form:"entityId"`
+	// Only given to models that can look at images
+	Images []managers.ImageAttachment `json:"-" form:"-"`
 }
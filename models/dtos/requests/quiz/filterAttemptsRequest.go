@@ -0,0 +1,9 @@
+package quiz
+
+import "echo-api/models/dtos/requests/base"
+
+type FilterAttemptsRequest struct {
+	UserID  string    `json:"-" form:"-"`
+	QuizIDs *[]string `json:"quizzes" form:"quizzes"`
+	base.PaginationRequestBase
+}
@@ -0,0 +1,11 @@
+package quiz
+
+import "echo-api/models/dtos/requests/base"
+
+type FilterQuizzesRequest struct {
+	IDs        *[]string `json:"ids" form:"ids"`
+	Title      *string   `json:"title" form:"title"`
+	UserIDs    *[]string `json:"users" form:"users"`
+	ContextIDs *[]string `json:"contexts" form:"contexts"`
+	base.PaginationRequestBase
+}
@@ -0,0 +1,9 @@
+package quiz
+
+// A zero QuestionCount asks for the default number of questions
+type GenerateQuizRequest struct {
+	ContextID     string  `json:"contextId"`
+	Title         string  `json:"title"`
+	QuestionCount int     `json:"questionCount"`
+	UserID        *string `json:"userId"`
+}
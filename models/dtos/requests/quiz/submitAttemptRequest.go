@@ -0,0 +1,15 @@
+package quiz
+
+// Questions without an answer are graded as wrong
+type SubmitAttemptRequest struct {
+	QuizID  string          `json:"-"`
+	UserID  string          `json:"-"`
+	Answers []AnswerRequest `json:"answers"`
+}
+
+// SelectedOption answers a multiple choice question, Text a short answer question
+type AnswerRequest struct {
+	QuestionID     string `json:"questionId"`
+	SelectedOption *int   `json:"selectedOption"`
+	Text           string `json:"text"`
+}
@@ -0,0 +1,8 @@
+package template
+
+// Creates the next version of the named template for the language
+type CreatePromptTemplateRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	LanguageID *string `json:"languageId"`
+	Body       string  `json:"body" binding:"required"`
+}
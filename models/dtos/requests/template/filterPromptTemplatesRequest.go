@@ -0,0 +1,9 @@
+package template
+
+import "echo-api/models/dtos/requests/base"
+
+type FilterPromptTemplatesRequest struct {
+	base.PaginationRequestBase
+	Names       *[]string `json:"names" form:"names"`
+	LanguageIDs *[]string `json:"languageIds" form:"languageIds"`
+}
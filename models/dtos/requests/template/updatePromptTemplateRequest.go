@@ -0,0 +1,7 @@
+package template
+
+// Stores the body as a new version of the template with the given id, the old version is kept
+type UpdatePromptTemplateRequest struct {
+	ID   string `json:"id" binding:"required"`
+	Body string `json:"body" binding:"required"`
+}
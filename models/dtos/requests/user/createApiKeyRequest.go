@@ -0,0 +1,8 @@
+package user
+
+// Scopes are a resource and an access, like "notes:write" or "documents:read"
+type CreateApiKeyRequest struct {
+	UserID string   `json:"-"`
+	Name   string   `json:"name" binding:"required"`
+	Scopes []string `json:"scopes" binding:"required"`
+}
@@ -0,0 +1,10 @@
+package user
+
+import "time"
+
+// From defaults to the start of the current month and To to now, To is exclusive
+type FilterUsageRequest struct {
+	UserID string     `json:"-" form:"-"`
+	From   *time.Time `json:"from" form:"from" time_format:"2006-01-02"`
+	To     *time.Time `json:"to" form:"to" time_format:"2006-01-02"`
+}
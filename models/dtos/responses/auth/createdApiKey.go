@@ -0,0 +1,9 @@
+package auth
+
+import "echo-api/models/entities"
+
+// Key is only returned on creation, afterwards the key can not be read again
+type CreatedApiKey struct {
+	entities.ApiKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,7 @@
+package auth
+
+// Carries either the tokens or the challenge for the second step, the fields of the one that is set are inlined
+type LoginResponse struct {
+	*TokenPair
+	*TwoFactorChallenge
+}
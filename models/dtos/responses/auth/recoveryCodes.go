@@ -0,0 +1,6 @@
+package auth
+
+// Shown once, only their hashes are kept
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
@@ -0,0 +1,9 @@
+package auth
+
+// Token keeps its name from before refresh tokens so existing clients keep working
+type TokenPair struct {
+	AccessToken  string `json:"Token"`
+	RefreshToken string `json:"RefreshToken"`
+	// Seconds until the access token expires
+	ExpiresIn int `json:"ExpiresIn"`
+}
@@ -0,0 +1,9 @@
+package auth
+
+// Returned by the login instead of tokens when the user has two-factor authentication enabled
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"TwoFactorRequired"`
+	ChallengeToken    string `json:"ChallengeToken"`
+	// Seconds left to send the code with the challenge, named apart from ExpiresIn of the tokens as both share the login response
+	ChallengeExpiresIn int `json:"ChallengeExpiresIn"`
+}
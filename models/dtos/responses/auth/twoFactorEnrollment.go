@@ -0,0 +1,7 @@
+package auth
+
+// The secret is for typing into apps that can not scan the otpauth URI as a QR code
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauthUri"`
+}
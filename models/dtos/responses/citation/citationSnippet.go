@@ -0,0 +1,8 @@
+package citation
+
+import "echo-api/models/entities"
+
+type CitationSnippet struct {
+	entities.Citation
+	Snippet string `json:"snippet"`
+}
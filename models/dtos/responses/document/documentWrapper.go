@@ -5,4 +5,6 @@ import "echo-api/models/entities"
 type DocumentWrapped struct {
 	entities.Document
 	Path string
+	// Only set for images
+	ThumbnailPath string `json:",omitempty"`
 }
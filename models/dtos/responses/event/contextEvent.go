@@ -0,0 +1,27 @@
+package event
+
+// ContextEvent
+// @Description Event pushed to every open websocket of a context
+type ContextEvent struct {
+	Type      EventType `json:"type"`
+	ContextID string    `json:"contextId"`
+	Payload   any       `json:"payload,omitempty"`
+}
+
+type EventType string
+
+const (
+	Delta         EventType = "delta"
+	Message       EventType = "message"
+	Typing        EventType = "typing"
+	Status        EventType = "status"
+	PromptAdded   EventType = "promptAdded"
+	PromptUpdated EventType = "promptUpdated"
+	PromptRemoved EventType = "promptRemoved"
+	ContextReset  EventType = "contextReset"
+	Error         EventType = "error"
+)
+
+func (e EventType) String() string {
+	return string(e)
+}
@@ -0,0 +1,22 @@
+package usage
+
+import "time"
+
+type ModelUsage struct {
+	Model            string `json:"model"`
+	Calls            int    `json:"calls"`
+	PromptTokens     int    `json:"promptTokens"`
+	CompletionTokens int    `json:"completionTokens"`
+	TotalTokens      int    `json:"totalTokens"`
+}
+
+type UsageSummary struct {
+	UserID           string       `json:"userId"`
+	From             time.Time    `json:"from"`
+	To               time.Time    `json:"to"`
+	Calls            int          `json:"calls"`
+	PromptTokens     int          `json:"promptTokens"`
+	CompletionTokens int          `json:"completionTokens"`
+	TotalTokens      int          `json:"totalTokens"`
+	Models           []ModelUsage `json:"models"`
+}
@@ -0,0 +1,10 @@
+package usage
+
+// Limits of zero mean the role of the user has no budget
+type UserUsage struct {
+	UsageSummary
+	DailyLimit    int `json:"dailyLimit"`
+	MonthlyLimit  int `json:"monthlyLimit"`
+	UsedToday     int `json:"usedToday"`
+	UsedThisMonth int `json:"usedThisMonth"`
+}
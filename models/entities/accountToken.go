@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// Single use token mailed to the user, only its hash is stored
+type AccountToken struct {
+	Base
+	UserID    string              `gorm:"type:uuid;index" json:"userId"`
+	Purpose   AccountTokenPurpose `json:"purpose"`
+	TokenHash string              `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt time.Time           `gorm:"index" json:"expiresAt"`
+	UsedAt    *time.Time          `json:"usedAt"`
+}
+
+type AccountTokenPurpose uint
+
+const (
+	EmailVerification AccountTokenPurpose = iota + 1
+	PasswordReset
+)
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// Personal key for scripts, the prefix is shown to tell keys apart and only the hash of the secret is stored
+type ApiKey struct {
+	Base
+	UserID     string     `gorm:"type:uuid;index" json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `gorm:"uniqueIndex" json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scopes     StringList `gorm:"type:text" json:"scopes"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
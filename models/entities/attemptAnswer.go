@@ -0,0 +1,14 @@
+package entities
+
+type AttemptAnswer struct {
+	Base
+	AttemptID      string `gorm:"type:uuid;index" json:"attemptId"`
+	QuestionID     string `gorm:"type:uuid" json:"questionId"`
+	SelectedOption *int   `json:"selectedOption,omitempty"`
+	Text           string `json:"text,omitempty"`
+	IsCorrect      bool   `json:"isCorrect"`
+	// Correct option or answer of the question, shown once the answer is graded
+	Expected string `json:"expected"`
+	// Why a short answer was graded the way it was
+	Feedback string `json:"feedback,omitempty"`
+}
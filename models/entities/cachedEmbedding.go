@@ -0,0 +1,9 @@
+package entities
+
+// Vectors are cached per model and content hash so the same text is embedded only once
+type CachedEmbedding struct {
+	Base
+	Model       string `gorm:"index:idx_cached_embedding,unique" json:"model"`
+	ContentHash string `gorm:"index:idx_cached_embedding,unique" json:"contentHash"`
+	Vector      Vector `gorm:"type:real[]" json:"-"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+type Card struct {
+	Base
+	DeckID string `gorm:"type:uuid;index" json:"deckId"`
+	Front  string `json:"front"`
+	Back   string `json:"back"`
+	// Note or document the card was generated from, empty for cards written by hand
+	SourceType SourceType `json:"sourceType,omitempty"`
+	SourceID   *string    `gorm:"type:uuid" json:"sourceId,omitempty"`
+	// Scheduling state, a new card is due right away
+	Repetitions    int          `json:"repetitions"`
+	EaseFactor     float64      `json:"easeFactor"`
+	IntervalDays   int          `json:"intervalDays"`
+	DueAt          time.Time    `gorm:"index" json:"dueAt"`
+	LastReviewedAt *time.Time   `json:"lastReviewedAt"`
+	Reviews        []CardReview `gorm:"constraint:OnDelete:CASCADE;" json:"reviews,omitempty"`
+}
@@ -0,0 +1,10 @@
+package entities
+
+// Grade given to a card and the schedule it resulted in
+type CardReview struct {
+	Base
+	CardID       string  `gorm:"type:uuid;index" json:"cardId"`
+	Grade        int     `json:"grade"`
+	IntervalDays int     `json:"intervalDays"`
+	EaseFactor   float64 `json:"easeFactor"`
+}
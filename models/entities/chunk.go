@@ -0,0 +1,25 @@
+package entities
+
+type Chunk struct {
+	Base
+	ContextID   string     `gorm:"type:uuid;index" json:"contextId"`
+	SourceType  SourceType `json:"sourceType"`
+	SourceID    string     `gorm:"type:uuid;index" json:"sourceId"`
+	Index       int        `json:"index"`
+	Section     string     `json:"section,omitempty"`
+	Content     string     `json:"content"`
+	StartOffset int        `json:"startOffset"`
+	EndOffset   int        `json:"endOffset"`
+	Embedding   Vector     `gorm:"type:real[]" json:"-"`
+}
+
+type SourceType string
+
+const (
+	NoteSource     SourceType = "note"
+	DocumentSource SourceType = "document"
+)
+
+func (t SourceType) String() string {
+	return string(t)
+}
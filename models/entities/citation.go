@@ -0,0 +1,15 @@
+package entities
+
+// Points at the excerpt of a note or document that was given to the assistant for a reply
+type Citation struct {
+	Base
+	MessageID string `gorm:"type:uuid;index" json:"messageId"`
+	ContextID string `gorm:"type:uuid;index" json:"contextId"`
+	// Number of the excerpt as the assistant saw it, "[1]" is 1
+	Number      int        `json:"number"`
+	SourceType  SourceType `json:"sourceType"`
+	SourceID    string     `gorm:"type:uuid" json:"sourceId"`
+	Section     string     `json:"section,omitempty"`
+	StartOffset int        `json:"startOffset"`
+	EndOffset   int        `json:"endOffset"`
+}
@@ -5,7 +5,14 @@ type Context struct {
 	Notes      []Note     `json:"notes"`
 	Documents  []Document `json:"documents"`
 	Prompts    []Prompt   `json:"prompts"`
+	Messages   []Message  `gorm:"constraint:OnDelete:CASCADE;" json:"messages,omitempty"`
+	Chunks     []Chunk    `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+	Decks      []Deck     `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+	Quizzes    []Quiz     `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+	Equations  []Equation `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
 	UserID     string     `gorm:"type:uuid" json:"userId"`
 	LanguageID string     `gorm:"type:uuid" json:"languageId"`
 	ExternalID string     `json:"externalId"`
+	Provider   string     `json:"provider"`
+	Model      string     `json:"model"`
 }
@@ -0,0 +1,9 @@
+package entities
+
+type Deck struct {
+	Base
+	Name      string `json:"name"`
+	ContextID string `gorm:"type:uuid;index" json:"contextId"`
+	UserID    string `gorm:"type:uuid" json:"userId"`
+	Cards     []Card `gorm:"constraint:OnDelete:CASCADE;" json:"cards,omitempty"`
+}
@@ -9,4 +9,8 @@ type Document struct {
 	UserID          string  `gorm:"type:uuid" json:"userId"`
 	ContextID       string  `gorm:"type:uuid" json:"contextId"`
 	IsReadableByAll bool    `json:"isReadableByAll"`
+	// Text extracted from the file at upload, it is what gets indexed for the assistant
+	Text     string            `gorm:"type:text" json:"-"`
+	Sections []DocumentSection `gorm:"constraint:OnDelete:CASCADE;" json:"sections,omitempty"`
+	Image    *DocumentImage    `gorm:"constraint:OnDelete:CASCADE;" json:"image,omitempty"`
 }
@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// Kept for documents that are images, the thumbnail is stored next to the original file
+type DocumentImage struct {
+	Base
+	DocumentID    string     `gorm:"type:uuid;uniqueIndex" json:"documentId"`
+	MimeType      string     `json:"mimeType"`
+	Width         int        `json:"width"`
+	Height        int        `json:"height"`
+	ThumbnailName string     `json:"-"`
+	TakenAt       *time.Time `json:"takenAt"`
+	CameraMake    string     `json:"cameraMake"`
+	CameraModel   string     `json:"cameraModel"`
+	Orientation   int        `json:"orientation"`
+	Latitude      *float64   `json:"latitude"`
+	Longitude     *float64   `json:"longitude"`
+}
@@ -0,0 +1,11 @@
+package entities
+
+// Offsets are byte offsets within the extracted text of the document
+type DocumentSection struct {
+	Base
+	DocumentID  string `gorm:"type:uuid;index" json:"documentId"`
+	Index       int    `json:"index"`
+	Title       string `json:"title"`
+	StartOffset int    `json:"startOffset"`
+	EndOffset   int    `json:"endOffset"`
+}
@@ -0,0 +1,14 @@
+package entities
+
+// LaTeX math of a context, optionally placed in one of its notes
+type Equation struct {
+	Base
+	Latex string `gorm:"type:text" json:"latex"`
+	Label string `json:"label,omitempty"`
+	// Rendered from Latex whenever it is saved
+	MathML    string  `gorm:"type:text" json:"mathml"`
+	Text      string  `gorm:"type:text" json:"text"`
+	NoteID    *string `gorm:"type:uuid;index" json:"noteId,omitempty"`
+	ContextID string  `gorm:"type:uuid;index" json:"contextId"`
+	UserID    string  `gorm:"type:uuid" json:"userId"`
+}
@@ -0,0 +1,11 @@
+package entities
+
+// Account of a user at an OpenID Connect provider, the subject is what the provider keeps stable for it
+type ExternalIdentity struct {
+	Base
+	UserID   string `gorm:"type:uuid;index" json:"userId"`
+	Provider string `gorm:"uniqueIndex:idx_external_identity_subject" json:"provider"`
+	Subject  string `gorm:"uniqueIndex:idx_external_identity_subject" json:"subject"`
+	// Email the provider sent at the last login, only informative
+	Email string `json:"email"`
+}
@@ -0,0 +1,25 @@
+package entities
+
+type Message struct {
+	Base
+	ContextID        string      `gorm:"type:uuid;index" json:"contextId"`
+	Role             MessageRole `json:"role"`
+	Content          string      `json:"content"`
+	Model            string      `json:"model"`
+	PromptTokens     int         `json:"promptTokens"`
+	CompletionTokens int         `json:"completionTokens"`
+	Citations        []Citation  `gorm:"constraint:OnDelete:CASCADE;" json:"citations"`
+	// Compacted turns stay in the history but are no longer part of the conversation the assistant sees
+	IsCompacted bool `json:"isCompacted"`
+}
+
+type MessageRole string
+
+const (
+	UserMessage      MessageRole = "user"
+	AssistantMessage MessageRole = "assistant"
+)
+
+func (r MessageRole) String() string {
+	return string(r)
+}
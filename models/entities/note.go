@@ -7,5 +7,6 @@ type Note struct {
 	UserID     string     `gorm:"type:uuid" json:"userId"`
 	LanguageID string     `gorm:"type:uuid" json:"languageId"`
 	Documents  []Document `json:"documents"`
+	Equations  []Equation `gorm:"constraint:OnDelete:SET NULL;" json:"equations,omitempty"`
 	ContextID  string     `gorm:"type:uuid" json:"contextId"`
 }
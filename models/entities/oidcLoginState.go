@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+// Login started at an OpenID Connect provider, found again by the hash of the state it comes back with
+type OidcLoginState struct {
+	Base
+	Provider     string     `json:"provider"`
+	StateHash    string     `gorm:"uniqueIndex" json:"-"`
+	CodeVerifier string     `json:"-"`
+	Nonce        string     `json:"-"`
+	ExpiresAt    time.Time  `gorm:"index" json:"expiresAt"`
+	UsedAt       *time.Time `json:"usedAt"`
+}
@@ -5,4 +5,8 @@ type Prompt struct {
 	Value     string
 	ContextID string  `gorm:"type:uuid" json:"contextId"`
 	EntityID  *string `gorm:"type:uuid" json:"entityId"`
+	// What the assistant was told to remember, so it can be told to forget the same thing
+	Subject string `json:"subject"`
+	// Summary of the compacted turns of the context, there is at most one per context
+	IsSummary bool `json:"isSummary"`
 }
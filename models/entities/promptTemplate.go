@@ -0,0 +1,11 @@
+package entities
+
+// Every edit is stored as a new version, the highest version of a name and language is the one in effect
+type PromptTemplate struct {
+	Base
+	Name string `gorm:"uniqueIndex:idx_prompt_template_version" json:"name"`
+	// Empty for the template used by every language without a variant of its own
+	LanguageID *string `gorm:"type:uuid;uniqueIndex:idx_prompt_template_version" json:"languageId"`
+	Version    int     `gorm:"uniqueIndex:idx_prompt_template_version" json:"version"`
+	Body       string  `gorm:"type:text" json:"body"`
+}
@@ -0,0 +1,25 @@
+package entities
+
+type Question struct {
+	Base
+	QuizID string       `gorm:"type:uuid;index" json:"quizId"`
+	Number int          `json:"number"`
+	Kind   QuestionKind `json:"kind"`
+	Text   string       `json:"text"`
+	// Only multiple choice questions have options
+	Options StringList `gorm:"type:text" json:"options,omitempty"`
+	// Answers are only shown with the graded attempt
+	CorrectOption int    `json:"-"`
+	Answer        string `json:"-"`
+}
+
+type QuestionKind string
+
+const (
+	MultipleChoiceQuestion QuestionKind = "multipleChoice"
+	ShortAnswerQuestion    QuestionKind = "shortAnswer"
+)
+
+func (k QuestionKind) String() string {
+	return string(k)
+}
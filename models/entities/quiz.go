@@ -0,0 +1,10 @@
+package entities
+
+type Quiz struct {
+	Base
+	Title     string        `json:"title"`
+	ContextID string        `gorm:"type:uuid;index" json:"contextId"`
+	UserID    string        `gorm:"type:uuid" json:"userId"`
+	Questions []Question    `gorm:"constraint:OnDelete:CASCADE;" json:"questions,omitempty"`
+	Attempts  []QuizAttempt `gorm:"constraint:OnDelete:CASCADE;" json:"-"`
+}
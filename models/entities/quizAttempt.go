@@ -0,0 +1,12 @@
+package entities
+
+type QuizAttempt struct {
+	Base
+	QuizID        string `gorm:"type:uuid;index" json:"quizId"`
+	UserID        string `gorm:"type:uuid;index" json:"userId"`
+	CorrectCount  int    `json:"correctCount"`
+	QuestionCount int    `json:"questionCount"`
+	// Percentage of the questions answered correctly
+	Score   float64         `json:"score"`
+	Answers []AttemptAnswer `gorm:"constraint:OnDelete:CASCADE;" json:"answers,omitempty"`
+}
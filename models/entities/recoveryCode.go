@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// Lets a user who lost their authenticator log in once, only the hash is stored
+type RecoveryCode struct {
+	Base
+	UserID   string     `gorm:"type:uuid;index" json:"userId"`
+	CodeHash string     `json:"-"`
+	UsedAt   *time.Time `json:"usedAt"`
+}
@@ -0,0 +1,18 @@
+package entities
+
+import "time"
+
+// Only the hash of a refresh token is stored, tokens rotated out of the same login share a family
+type RefreshToken struct {
+	Base
+	UserID    string     `gorm:"type:uuid;index" json:"userId"`
+	FamilyID  string     `gorm:"index" json:"familyId"`
+	TokenHash string     `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	// Access token issued together with this refresh token, denied when the session is revoked
+	AccessJti       string    `json:"-"`
+	AccessExpiresAt time.Time `json:"-"`
+	// Whether the login passed two-factor authentication, tokens of the family keep the claim when rotated
+	SecondFactor bool `json:"-"`
+}
@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+// Access tokens rejected before they expire, rows are purged once the token would have expired anyway
+type RevokedToken struct {
+	Base
+	Jti       string    `gorm:"uniqueIndex" json:"jti"`
+	UserID    string    `gorm:"type:uuid;index" json:"userId"`
+	ExpiresAt time.Time `gorm:"index" json:"expiresAt"`
+}
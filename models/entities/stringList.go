@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// StringList is stored as a JSON array in a text column
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return nil, nil
+	}
+	res, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+	return string(res), nil
+}
+
+func (l *StringList) Scan(src any) error {
+	var b []byte
+	switch src := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		b = []byte(src)
+	case []byte:
+		b = src
+	default:
+		return errors.New("stringListErrorUnsupportedType")
+	}
+
+	var res []string
+	err := json.Unmarshal(b, &res)
+	if err != nil {
+		return err
+	}
+	*l = res
+	return nil
+}
@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// TOTP enrolment of a user, it only guards the login once EnabledAt is set
+type TwoFactor struct {
+	Base
+	UserID    string     `gorm:"type:uuid;uniqueIndex" json:"userId"`
+	Secret    string     `json:"-"`
+	EnabledAt *time.Time `json:"enabledAt"`
+	// Time step of the last accepted code, codes of that step or earlier are refused so they can not be replayed
+	LastUsedStep   int64      `json:"-"`
+	FailedAttempts int        `json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+}
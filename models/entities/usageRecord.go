@@ -0,0 +1,30 @@
+package entities
+
+type UsageRecord struct {
+	Base
+	UserID           string    `gorm:"type:uuid;index" json:"userId"`
+	ContextID        string    `gorm:"type:uuid" json:"contextId"`
+	Kind             UsageKind `json:"kind"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"promptTokens"`
+	CompletionTokens int       `json:"completionTokens"`
+}
+
+type UsageKind string
+
+const (
+	PromptUsage    UsageKind = "prompt"
+	MessageUsage   UsageKind = "message"
+	SummaryUsage   UsageKind = "summary"
+	FlashcardUsage UsageKind = "flashcards"
+	QuizUsage      UsageKind = "quiz"
+	GradingUsage   UsageKind = "grading"
+)
+
+func (k UsageKind) String() string {
+	return string(k)
+}
+
+func (r UsageRecord) TotalTokens() int {
+	return r.PromptTokens + r.CompletionTokens
+}
@@ -1,5 +1,7 @@
 package entities
 
+import "time"
+
 type User struct {
 	Base
 	Name      string      `json:"name"`
@@ -10,6 +12,8 @@ type User struct {
 	Contexts  []Context   `json:"contexts"`
 	Password  Password    `json:"password"`
 	Role      Role        `json:"role"`
+	// Nil until the user opens the link of the verification mail
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
 }
 
 type Role uint
@@ -0,0 +1,54 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Vector is stored as a postgres real[] column
+type Vector []float32
+
+func (v Vector) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	parts := make([]string, len(v))
+	for i, f := range v {
+		parts[i] = strconv.FormatFloat(float64(f), 'g', -1, 32)
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
+func (v *Vector) Scan(src any) error {
+	var s string
+	switch src := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return errors.New("vectorErrorUnsupportedType")
+	}
+
+	s = strings.Trim(s, "{}")
+	if s == "" {
+		*v = Vector{}
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	res := make(Vector, len(parts))
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 32)
+		if err != nil {
+			return err
+		}
+		res[i] = float32(f)
+	}
+	*v = res
+	return nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"echo-api/managers"
+	requests "echo-api/models/dtos/requests/auth"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const emailVerificationTTL = 48 * time.Hour
+const passwordResetTTL = time.Hour
+
+type AccountService struct {
+	tokenRepo    util.Repository[entities.AccountToken]
+	userRepo     util.Repository[entities.User]
+	passwordRepo util.Repository[entities.Password]
+	logger       *util.Logger
+	mails        managers.MailManager
+	policy       managers.PasswordPolicyManager
+	hasher       managers.HashingManager
+	tokens       *TokenService
+	publicUrl    string
+}
+
+func NewAccountService(tokenRepo util.Repository[entities.AccountToken], userRepo util.Repository[entities.User], passwordRepo util.Repository[entities.Password], logger *util.Logger, mails managers.MailManager, policy managers.PasswordPolicyManager, hasher managers.HashingManager, tokens *TokenService, publicUrl string) *AccountService {
+	return &AccountService{
+		tokenRepo:    tokenRepo,
+		userRepo:     userRepo,
+		passwordRepo: passwordRepo,
+		logger:       logger,
+		mails:        mails,
+		policy:       policy,
+		hasher:       hasher,
+		tokens:       tokens,
+		publicUrl:    strings.TrimRight(publicUrl, "/"),
+	}
+}
+
+func (s *AccountService) SendEmailVerification(user entities.User) error {
+	s.logger.Debug().Msg(fmt.Sprintf("AccountService_SendEmailVerification for user: %s", user.ID))
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	token, err := s.issueToken(user.ID, entities.EmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return s.mails.Send(managers.Mail{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email address, the link is valid for %d hours.\n\n%s\n\nIf you did not create an account you can ignore this mail.\n",
+			user.Name, int(emailVerificationTTL.Hours()), s.linkOf("verify-email", token)),
+	})
+}
+
+// Unknown and already verified addresses are answered the same way, so the endpoint does not tell which emails have accounts
+func (s *AccountService) ResendEmailVerification(request requests.ResendVerificationRequest) error {
+	s.logger.Debug().Msg("AccountService_ResendEmailVerification has started")
+	user, found, err := s.findUserByEmail(request.Email)
+	if err != nil || !found {
+		return err
+	}
+	return s.SendEmailVerification(user)
+}
+
+func (s *AccountService) VerifyEmail(request requests.VerifyEmailRequest) error {
+	s.logger.Debug().Msg("AccountService_VerifyEmail has started")
+	token, err := s.findToken(request.Token, entities.EmailVerification)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.First(token.UserID, false)
+	if err != nil {
+		s.logger.Error().Msg("AccountService_VerifyEmail had an error when getting the user from repo")
+		return err
+	}
+	err = s.useToken(token)
+	if err != nil {
+		return err
+	}
+	return s.markVerified(user)
+}
+
+// Same as resending the verification, an unknown email gets no mail and no error
+func (s *AccountService) ForgotPassword(request requests.ForgotPasswordRequest) error {
+	s.logger.Debug().Msg("AccountService_ForgotPassword has started")
+	user, found, err := s.findUserByEmail(request.Email)
+	if err != nil || !found {
+		return err
+	}
+	token, err := s.issueToken(user.ID, entities.PasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.mails.Send(managers.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nchoose a new password with the link below, it is valid for %d minutes and can be used once.\n\n%s\n\nIf you did not ask for this you can ignore this mail, your password stays the same.\n",
+			user.Name, int(passwordResetTTL.Minutes()), s.linkOf("reset-password", token)),
+	})
+}
+
+// The token is only used up once the new password is accepted, every session is ended as whoever had the old password may be logged in
+func (s *AccountService) ResetPassword(request requests.ResetPasswordRequest) error {
+	s.logger.Debug().Msg("AccountService_ResetPassword has started")
+	token, err := s.findToken(request.Token, entities.PasswordReset)
+	if err != nil {
+		return err
+	}
+	user, err := s.userRepo.First(token.UserID, false)
+	if err != nil {
+		s.logger.Error().Msg("AccountService_ResetPassword had an error when getting the user from repo")
+		return err
+	}
+	err = s.policy.Check(request.Password, user.Email, user.Name)
+	if err != nil {
+		return err
+	}
+	hash, err := s.hasher.GetHash(request.Password)
+	if err != nil {
+		s.logger.Error().Msg("AccountService_ResetPassword had an error when trying to hash password")
+		return err
+	}
+
+	err = s.useToken(token)
+	if err != nil {
+		return err
+	}
+	err = s.savePassword(user.ID, hash)
+	if err != nil {
+		return err
+	}
+	// The reset link reached the mailbox, that verifies it as well
+	err = s.markVerified(user)
+	if err != nil {
+		return err
+	}
+	return s.tokens.RevokeAll(user.ID)
+}
+
+// A new token replaces the unused ones of the same purpose, so only the latest mail works
+func (s *AccountService) issueToken(userID string, purpose entities.AccountTokenPurpose, ttl time.Duration) (string, error) {
+	err := s.tokenRepo.DeleteWhere("expires_at < ?", time.Now())
+	if err != nil {
+		s.logger.Error().Msg("AccountService_issueToken had an error when purging expired tokens from repo")
+		return "", err
+	}
+	_, err = s.tokenRepo.Query().Where("user_id = ?", userID).Where("purpose = ?", purpose).Where("used_at IS NULL").UpdateColumn("used_at", time.Now())
+	if err != nil {
+		s.logger.Error().Msg("AccountService_issueToken had an error when using up previous tokens in repo")
+		return "", err
+	}
+
+	raw, err := newRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.tokenRepo.Create(&entities.AccountToken{UserID: userID, Purpose: purpose, TokenHash: hashToken(raw), ExpiresAt: time.Now().Add(ttl)})
+	if err != nil {
+		s.logger.Error().Msg("AccountService_issueToken had an error when saving to repo")
+		return "", err
+	}
+	return raw, nil
+}
+
+func (s *AccountService) findToken(raw string, purpose entities.AccountTokenPurpose) (entities.AccountToken, error) {
+	found, err := s.tokenRepo.Query().Where("token_hash = ?", hashToken(raw)).Where("purpose = ?", purpose).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("AccountService_findToken had an error when getting from repo")
+		return entities.AccountToken{}, err
+	}
+	if len(found) == 0 || found[0].UsedAt != nil {
+		return entities.AccountToken{}, errors.New("accountErrorTokenInvalid")
+	}
+	if time.Now().After(found[0].ExpiresAt) {
+		return entities.AccountToken{}, errors.New("accountErrorTokenExpired")
+	}
+	return found[0], nil
+}
+
+// Used up in one conditional update, so of two requests racing with the same link only one goes through
+func (s *AccountService) useToken(token entities.AccountToken) error {
+	used, err := s.tokenRepo.Query().Where("id = ?", token.ID).Where("used_at IS NULL").UpdateColumn("used_at", time.Now())
+	if err != nil {
+		s.logger.Error().Msg("AccountService_useToken had an error when updating in repo")
+		return err
+	}
+	if used != 1 {
+		return errors.New("accountErrorTokenInvalid")
+	}
+	return nil
+}
+
+func (s *AccountService) savePassword(userID string, hash string) error {
+	found, err := s.passwordRepo.Query().Where("user_id = ?", userID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("AccountService_savePassword had an error when getting from repo")
+		return err
+	}
+	if len(found) == 0 {
+		_, err = s.passwordRepo.Create(&entities.Password{UserID: userID, Value: hash})
+	} else {
+		found[0].Value = hash
+		_, err = s.passwordRepo.Update(&found[0])
+	}
+	if err != nil {
+		s.logger.Error().Msg("AccountService_savePassword had an error when saving to repo")
+		return err
+	}
+	return nil
+}
+
+func (s *AccountService) markVerified(user entities.User) error {
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	_, err := s.userRepo.Update(&user)
+	if err != nil {
+		s.logger.Error().Msg("AccountService_markVerified had an error when updating in repo")
+		return err
+	}
+	return nil
+}
+
+func (s *AccountService) findUserByEmail(email string) (entities.User, bool, error) {
+	found, err := s.userRepo.Query().Where("email = ?", strings.TrimSpace(email)).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("AccountService_findUserByEmail had an error when getting from repo")
+		return entities.User{}, false, err
+	}
+	if len(found) == 0 {
+		s.logger.Debug().Msg("AccountService_findUserByEmail found no user with the given email")
+		return entities.User{}, false, nil
+	}
+	return found[0], true, nil
+}
+
+// Without a public url the mail carries the bare token for the client to submit
+func (s *AccountService) linkOf(path string, token string) string {
+	if s.publicUrl == "" {
+		return "Your code: " + token
+	}
+	return fmt.Sprintf("%s/%s?token=%s", s.publicUrl, path, url.QueryEscape(token))
+}
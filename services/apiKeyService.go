@@ -0,0 +1,152 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	requests "echo-api/models/dtos/requests/user"
+	responses "echo-api/models/dtos/responses/auth"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const maxApiKeysPerUser = 20
+
+// Writing last use on every request would turn reads into writes, a minute is close enough to tell stale keys apart
+const apiKeyLastUsedPrecision = time.Minute
+
+// Resources an API key can be scoped to, a scope is one of them followed by ":read" or ":write"
+var apiKeyResources = []string{"notes", "documents", "equations", "contexts", "prompts", "citations", "decks", "cards", "quizzes", "attempts", "languages"}
+
+type ApiKeyService struct {
+	repo     util.Repository[entities.ApiKey]
+	userRepo util.Repository[entities.User]
+	logger   *util.Logger
+}
+
+func NewApiKeyService(repo util.Repository[entities.ApiKey], userRepo util.Repository[entities.User], logger *util.Logger) *ApiKeyService {
+	return &ApiKeyService{repo: repo, userRepo: userRepo, logger: logger}
+}
+
+// The key is made of the prefix and the secret split by a dot, it is only returned here
+func (s *ApiKeyService) Create(request requests.CreateApiKeyRequest) (responses.CreatedApiKey, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("ApiKeyService_Create for user: %s", request.UserID))
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return responses.CreatedApiKey{}, errors.New("apiKeyErrorNameRequired")
+	}
+	scopes, err := normalizeScopes(request.Scopes)
+	if err != nil {
+		return responses.CreatedApiKey{}, err
+	}
+	count, err := s.repo.Query().Where("user_id = ?", request.UserID).Count()
+	if err != nil {
+		s.logger.Error().Msg("ApiKeyService_Create had an error when counting in repo")
+		return responses.CreatedApiKey{}, err
+	}
+	if count >= maxApiKeysPerUser {
+		return responses.CreatedApiKey{}, errors.New("apiKeyErrorLimitReached")
+	}
+
+	buf := make([]byte, 6)
+	_, err = rand.Read(buf)
+	if err != nil {
+		return responses.CreatedApiKey{}, err
+	}
+	prefix := hex.EncodeToString(buf)
+	secret, err := newRandomToken(32)
+	if err != nil {
+		return responses.CreatedApiKey{}, err
+	}
+	key, err := s.repo.Create(&entities.ApiKey{UserID: request.UserID, Name: name, Prefix: prefix, SecretHash: hashToken(secret), Scopes: scopes})
+	if err != nil {
+		s.logger.Error().Msg("ApiKeyService_Create had an error when saving to repo")
+		return responses.CreatedApiKey{}, err
+	}
+	return responses.CreatedApiKey{ApiKey: key, Key: prefix + "." + secret}, nil
+}
+
+func (s *ApiKeyService) GetAll(userID string) ([]entities.ApiKey, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("ApiKeyService_GetAll for user: %s", userID))
+	keys, err := s.repo.Query().Where("user_id = ?", userID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("ApiKeyService_GetAll had an error when getting from repo")
+		return nil, err
+	}
+	return keys, nil
+}
+
+// The key stops working right away
+func (s *ApiKeyService) Revoke(userID string, keyID string) error {
+	s.logger.Debug().Msg(fmt.Sprintf("ApiKeyService_Revoke key %s of user: %s", keyID, userID))
+	found, err := s.repo.Query().Where("id = ?", keyID).Where("user_id = ?", userID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("ApiKeyService_Revoke had an error when getting from repo")
+		return err
+	}
+	if len(found) == 0 {
+		return errors.New("apiKeyErrorNotFound")
+	}
+	err = s.repo.Delete(found[0].ID)
+	if err != nil {
+		s.logger.Error().Msg("ApiKeyService_Revoke had an error when deleting from repo")
+		return err
+	}
+	return nil
+}
+
+// Finds the key and its user, unknown keys and wrong secrets are refused the same way
+func (s *ApiKeyService) Authenticate(raw string) (entities.ApiKey, entities.User, error) {
+	prefix, secret, ok := strings.Cut(strings.TrimSpace(raw), ".")
+	if !ok || prefix == "" || secret == "" {
+		return entities.ApiKey{}, entities.User{}, errors.New("apiKeyErrorInvalid")
+	}
+	found, err := s.repo.Query().Where("prefix = ?", prefix).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("ApiKeyService_Authenticate had an error when getting from repo")
+		return entities.ApiKey{}, entities.User{}, err
+	}
+	if len(found) == 0 || subtle.ConstantTimeCompare([]byte(found[0].SecretHash), []byte(hashToken(secret))) != 1 {
+		return entities.ApiKey{}, entities.User{}, errors.New("apiKeyErrorInvalid")
+	}
+	key := found[0]
+	user, err := s.userRepo.First(key.UserID, false)
+	if err != nil {
+		s.logger.Error().Msg("ApiKeyService_Authenticate had an error when getting the user from repo")
+		return entities.ApiKey{}, entities.User{}, err
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedPrecision {
+		key.LastUsedAt = &now
+		_, err = s.repo.Update(&key)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("ApiKeyService_Authenticate could not record the last use")
+		}
+	}
+	return key, user, nil
+}
+
+// Lower cased and without duplicates, write access does not include read access
+func normalizeScopes(scopes []string) (entities.StringList, error) {
+	res := entities.StringList{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		resource, access, _ := strings.Cut(scope, ":")
+		if !slices.Contains(apiKeyResources, resource) || (access != "read" && access != "write") {
+			return nil, errors.New("apiKeyErrorInvalidScope")
+		}
+		if !slices.Contains(res, scope) {
+			res = append(res, scope)
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.New("apiKeyErrorInvalidScope")
+	}
+	return res, nil
+}
@@ -3,11 +3,14 @@ package services
 import (
 	"echo-api/managers"
 	requests "echo-api/models/dtos/requests/auth"
+	responses "echo-api/models/dtos/responses/auth"
 	"echo-api/models/entities"
 	"echo-api/util"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -17,86 +20,220 @@ import (
 
 type AuthService struct {
 	db        *gorm.DB
-	hasher    managers.HashingManager
+	hasher    managers.PasswordHashingManager
 	logger    *util.Logger
-	secretKey string
+	tokens    *TokenService
+	twoFactor *TwoFactorService
+	oidc      *OidcService
+	apiKeys   *ApiKeyService
 }
 
-func NewAuthService(db *gorm.DB, hasher managers.HashingManager, logger *util.Logger, secretKey string) *AuthService {
-	return &AuthService{db: db, hasher: hasher, logger: logger, secretKey: secretKey}
+func NewAuthService(db *gorm.DB, hasher managers.PasswordHashingManager, logger *util.Logger, ts *TokenService, tfs *TwoFactorService, os *OidcService, aks *ApiKeyService) *AuthService {
+	return &AuthService{db: db, hasher: hasher, logger: logger, tokens: ts, twoFactor: tfs, oidc: os, apiKeys: aks}
 }
 
-func (s *AuthService) Login(request requests.LoginRequest) (string, error) {
+// Users with two-factor authentication get a challenge to send with their code instead of tokens
+func (s *AuthService) Login(request requests.LoginRequest) (responses.LoginResponse, error) {
 	var user entities.User
 	s.logger.Debug().Msg("AuthService_Login has started")
 	res := s.db.Preload("Password").Where("email = ?", request.Username).First(&user)
 	if res.Error != nil {
 		s.logger.Error().Msg(fmt.Sprintf("AuthService_Login had errors when looking for given username: %v", request.Username))
-		return "", res.Error
+		return responses.LoginResponse{}, res.Error
 	}
 
 	check, err := s.hasher.Verify(user.Password.Value, request.Password)
 	if !check {
 		if err != nil {
 			s.logger.Error().Err(err).Msg("AuthService_Login had failed while trying to match passwords via hashinManager")
-			return "", err
+			return responses.LoginResponse{}, err
 		}
-		return "", errors.New("passwordIncorrect")
+		return responses.LoginResponse{}, errors.New("passwordIncorrect")
 	}
+	s.rehashIfNeeded(user.Password, request.Password)
+	return s.completeLogin(user)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": user.ID,
-		"role":   user.Role,
-		"exp":    time.Now().Add(time.Hour * 8).Unix(),
-	})
+// The provider only replaces the password, users with two-factor authentication still get a challenge
+func (s *AuthService) LoginWithOidc(provider string, code string, state string) (responses.LoginResponse, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("AuthService_LoginWithOidc has started for provider: %s", provider))
+	user, err := s.oidc.Complete(provider, code, state)
+	if err != nil {
+		return responses.LoginResponse{}, err
+	}
+	return s.completeLogin(user)
+}
 
-	tokenString, err := token.SignedString([]byte(s.secretKey))
+func (s *AuthService) completeLogin(user entities.User) (responses.LoginResponse, error) {
+	enabled, err := s.twoFactor.IsEnabled(user.ID)
 	if err != nil {
-		s.logger.Error().Msg("AuthService_Login had errors when trying to get signed token string")
-		return "", err
+		return responses.LoginResponse{}, err
+	}
+	if enabled {
+		challenge, err := s.tokens.IssueChallenge(user)
+		if err != nil {
+			return responses.LoginResponse{}, err
+		}
+		return responses.LoginResponse{TwoFactorChallenge: &challenge}, nil
 	}
+	tokens, err := s.tokens.Issue(user)
+	if err != nil {
+		return responses.LoginResponse{}, err
+	}
+	return responses.LoginResponse{TokenPair: &tokens}, nil
+}
 
-	return tokenString, nil
+// Second step of the login, a wrong code leaves the challenge usable until the second factor locks
+func (s *AuthService) LoginWithSecondFactor(request requests.TwoFactorLoginRequest) (responses.TokenPair, error) {
+	s.logger.Debug().Msg("AuthService_LoginWithSecondFactor has started")
+	claims, err := s.tokens.ParseChallenge(request.ChallengeToken)
+	if err != nil {
+		return responses.TokenPair{}, err
+	}
+	// Used up before the code is checked, so a replayed challenge can not race the real one
+	err = s.tokens.UseChallenge(claims)
+	if err != nil {
+		return responses.TokenPair{}, err
+	}
+	userID, _ := claims["userID"].(string)
+	err = s.twoFactor.VerifyCode(userID, request.Code)
+	if err != nil {
+		return responses.TokenPair{}, err
+	}
+
+	var user entities.User
+	res := s.db.Where("id = ?", userID).First(&user)
+	if res.Error != nil {
+		s.logger.Error().Msg("AuthService_LoginWithSecondFactor had errors when looking for the user")
+		return responses.TokenPair{}, res.Error
+	}
+	return s.tokens.IssueAfterSecondFactor(user)
 }
 
-func (s *AuthService) keyFunc() jwt.Keyfunc {
-	return func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			s.logger.Error().Err(http.ErrAbortHandler).Msg("AuthService_AuthMiddleware had errors when get key while trying to Parse token")
-			return nil, http.ErrAbortHandler
-		}
-		return []byte(s.secretKey), nil
+// Only a login knows the plain password, so that is when an outdated hash gets replaced. A failed upgrade is retried on the next login
+func (s *AuthService) rehashIfNeeded(password entities.Password, plain string) {
+	if !s.hasher.NeedsRehash(password.Value) {
+		return
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("AuthService_rehashIfNeeded upgrading the password hash of user: %s", password.UserID))
+	hash, err := s.hasher.GetHash(plain)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("AuthService_rehashIfNeeded had an error when hashing the password")
+		return
+	}
+	res := s.db.Model(&password).Update("value", hash)
+	if res.Error != nil {
+		s.logger.Error().Err(res.Error).Msg("AuthService_rehashIfNeeded had an error when saving the new hash")
 	}
 }
 
+func (s *AuthService) Refresh(request requests.RefreshTokenRequest) (responses.TokenPair, error) {
+	return s.tokens.Refresh(request.RefreshToken)
+}
+
+// Ends the session the access token belongs to, the token itself is denied right away
+func (s *AuthService) Logout(claims jwt.MapClaims) error {
+	userID, _ := claims["userID"].(string)
+	sessionID, _ := claims["sid"].(string)
+	s.logger.Debug().Msg(fmt.Sprintf("AuthService_Logout for user: %s", userID))
+	err := s.tokens.RevokeSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	return s.tokens.RevokeAccessToken(userID, jti, time.Unix(int64(exp), 0))
+}
+
+func (s *AuthService) PublicKeys() managers.JsonWebKeySet {
+	return s.tokens.PublicKeys()
+}
+
+func (s *AuthService) LogoutAll(userID string) error {
+	s.logger.Debug().Msg(fmt.Sprintf("AuthService_LogoutAll for user: %s", userID))
+	return s.tokens.RevokeAll(userID)
+}
+
+// Requests are authenticated with a bearer JWT or with "ApiKey <key>", the claims of a key name its user and scopes
 func (s *AuthService) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString := c.GetHeader("Authorization")
-
-		token, err := jwt.Parse(tokenString, s.keyFunc())
-		if err != nil {
-			s.logger.Error().Err(err).Msg("AuthService_AuthMiddleware had an error when parsing jwt token")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized - Token could not be parsed"})
-			c.Abort()
+		if key, ok := strings.CutPrefix(c.GetHeader("Authorization"), "ApiKey "); ok {
+			s.authenticateApiKey(c, key)
 			return
 		}
-		if !token.Valid {
-			s.logger.Debug().Msg(fmt.Sprintf("AuthService_AuthMiddleware token is not valid: %v", tokenString))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized - Token is not valid"})
+		tokenString := s.getTokenFromRequest(c)
+
+		claims, err := s.tokens.ParseAccessToken(tokenString)
+		if err != nil {
+			s.logger.Debug().Msg(fmt.Sprintf("AuthService_AuthMiddleware rejected the token: %s", err.Error()))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": unauthorizedMessage(err)})
 			c.Abort()
 			return
 		}
+		c.Set("claims", claims)
+
+		c.Next()
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("claims", claims)
+// Keys only reach the resources of their scopes, everything else like the account, keys and admin routes needs a login
+func (s *AuthService) authenticateApiKey(c *gin.Context, raw string) {
+	key, user, err := s.apiKeys.Authenticate(raw)
+	if err != nil {
+		s.logger.Debug().Msg(fmt.Sprintf("AuthService_authenticateApiKey rejected the key: %s", err.Error()))
+		if err.Error() != "apiKeyErrorInvalid" {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 		} else {
-			s.logger.Debug().Msg(fmt.Sprintf("AuthService_AuthMiddleware claims are not valid: %v", tokenString))
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized - Claims are not valid"})
-			c.Abort()
-			return
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized - API key is not valid"})
 		}
+		c.Abort()
+		return
+	}
+	scope := scopeOfRequest(c)
+	if !slices.Contains(key.Scopes, scope) {
+		s.logger.Debug().Msg(fmt.Sprintf("AuthService_authenticateApiKey key %s lacks scope: %s", key.ID, scope))
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden - API key does not have the scope " + scope})
+		c.Abort()
+		return
+	}
+	// Numbers are float64 in parsed tokens, the key claims look the same to handlers
+	c.Set("claims", jwt.MapClaims{
+		"userID":   user.ID,
+		"role":     float64(user.Role),
+		"apiKeyID": key.ID,
+		"scopes":   []string(key.Scopes),
+	})
 
-		c.Next()
+	c.Next()
+}
+
+// Routes that are opened with GET but send messages and spend the budget of the user
+var writingGetRoutes = []string{"/contexts/:id/messages/stream", "/contexts/:id/ws"}
+
+// The resource is the first part of the route after the api and version prefix, reading is GET and HEAD and every other method writes
+func scopeOfRequest(c *gin.Context) string {
+	access := "write"
+	if (c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead) &&
+		!slices.ContainsFunc(writingGetRoutes, func(route string) bool { return strings.HasSuffix(c.FullPath(), route) }) {
+		access = "read"
+	}
+	for _, part := range strings.Split(c.FullPath(), "/") {
+		if part == "" || part == "api" || (len(part) > 1 && part[0] == 'v' && strings.Trim(part[1:], "0123456789") == "") {
+			continue
+		}
+		return part + ":" + access
+	}
+	return ":" + access
+}
+
+func unauthorizedMessage(err error) string {
+	switch err.Error() {
+	case "tokenErrorRevoked":
+		return "Unauthorized - Token has been revoked"
+	case "tokenErrorClaimsNotValid":
+		return "Unauthorized - Claims are not valid"
+	default:
+		return "Unauthorized - Token could not be parsed"
 	}
 }
 
@@ -111,6 +248,13 @@ func (s *AuthService) AdminMiddleware() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		// Tokens from a login without the second factor still reach the enrolment endpoints, just not the admin ones
+		if secondFactor, _ := claims["mfa"].(bool); !secondFactor && s.twoFactor.IsRequiredFor(entities.Admin) {
+			s.logger.Debug().Msg("AuthService_AdminMiddleware admin logged in without two-factor authentication")
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden - Two-factor authentication is required for admins"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
@@ -132,6 +276,15 @@ func (s *AuthService) CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+// Browsers cannot set headers on websocket upgrades so those can carry the token as a query parameter
+func (s *AuthService) getTokenFromRequest(c *gin.Context) string {
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" && c.IsWebsocket() {
+		tokenString = c.Query("token")
+	}
+	return tokenString
+}
+
 func (s *AuthService) GetUserIDFromToken(tokenString string) (string, error) {
 	claims, err := s.ExtractClaims(tokenString)
 	if err != nil {
@@ -146,16 +299,11 @@ func (s *AuthService) GetUserIDFromToken(tokenString string) (string, error) {
 
 func (s *AuthService) ExtractClaims(tokenString string) (map[string]any, error) {
 	res := make(map[string]any)
-	token, err := jwt.Parse(tokenString, s.keyFunc())
+	claims, err := s.tokens.ParseAccessToken(tokenString)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("AuthService_AuthMiddleware had an error when parsing jwt token")
+		s.logger.Error().Err(err).Msg("AuthService_ExtractClaims had an error when parsing jwt token")
 		return res, err
 	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return res, errors.New("tokenErrorClaimsNotValid")
-	}
 	return s.mapClaimsToDict(claims, res), nil
 }
 
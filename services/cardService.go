@@ -0,0 +1,250 @@
+package services
+
+import (
+	"echo-api/managers"
+	requests "echo-api/models/dtos/requests/card"
+	deckRequests "echo-api/models/dtos/requests/deck"
+	responses "echo-api/models/dtos/responses/pagination"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+const defaultGeneratedCardCount = 10
+const maxGeneratedCardCount = 50
+
+type CardService struct {
+	repo       util.Repository[entities.Card]
+	reviewRepo util.Repository[entities.CardReview]
+	logger     *util.Logger
+	scheduler  managers.SchedulingManager
+	prompts    *PromptService
+}
+
+func NewCardService(repo util.Repository[entities.Card], reviewRepo util.Repository[entities.CardReview], logger *util.Logger, scheduler managers.SchedulingManager, ps *PromptService) *CardService {
+	return &CardService{repo: repo, reviewRepo: reviewRepo, logger: logger, scheduler: scheduler, prompts: ps}
+}
+
+func (s *CardService) GetOne(id string) (entities.Card, error) {
+	if id == "" {
+		return entities.Card{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("CardService_GetOne with id: %s", id))
+	res, err := s.repo.First(id, true)
+	if err != nil {
+		s.logger.Error().Msg("CardService_GetOne had an error when getting from repo")
+		return entities.Card{}, err
+	}
+
+	return res, nil
+}
+
+// Cards whose review is due, the most overdue first
+func (s *CardService) FilterDue(request deckRequests.FilterDueCardsRequest) (responses.PaginationResponse[entities.Card], error) {
+	if request.DeckID == "" {
+		return responses.PaginationResponse[entities.Card]{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("CardService_FilterDue for deck: %s on page: %d with size: %d", request.DeckID, request.Page, request.Size))
+	offset := request.CalculateOffset()
+
+	q := s.repo.Query().Where("deck_id = ?", request.DeckID).Where("due_at <= ?", time.Now()).Order("due_at")
+	q.Offset(int(offset)).Limit(int(request.Size))
+	res, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("CardService_FilterDue had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Card]{}, err
+	}
+	count, err := q.Count()
+	if err != nil {
+		s.logger.Error().Msg("CardService_FilterDue had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Card]{}, err
+	}
+	return responses.PaginationResponse[entities.Card]{Content: res, Page: request.Page, Size: len(res), TotalCount: int(count)}, nil
+}
+
+func (s *CardService) CreateOne(request requests.CreateCardRequest) (entities.Card, error) {
+	if request.DeckID == "" {
+		return entities.Card{}, errors.New("argumentErrorIDMissing")
+	}
+	if strings.TrimSpace(request.Front) == "" || strings.TrimSpace(request.Back) == "" {
+		return entities.Card{}, errors.New("argumentErrorMissing")
+	}
+	s.logger.Debug().Msg("CardService_CreateOne has started")
+	card := s.newCard(request.DeckID, request.Front, request.Back)
+	card, err := s.repo.Create(&card)
+	if err != nil {
+		s.logger.Error().Msg("CardService_CreateOne had an error when saving to repo")
+		return entities.Card{}, err
+	}
+
+	return card, nil
+}
+
+// Asks the assistant of the deck's context for cards about the note or document and stores every card it answered with
+func (s *CardService) GenerateFromSource(deck entities.Deck, source any, count int) ([]entities.Card, error) {
+	if count <= 0 {
+		count = defaultGeneratedCardCount
+	}
+	count = min(count, maxGeneratedCardCount)
+	var sourceType entities.SourceType
+	var sourceID string
+	switch source := source.(type) {
+	case entities.Note:
+		sourceType, sourceID = entities.NoteSource, source.ID
+	case entities.Document:
+		sourceType, sourceID = entities.DocumentSource, source.ID
+	default:
+		return nil, errors.ErrUnsupported
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("CardService_GenerateFromSource for deck: %s from %s: %s", deck.ID, sourceType, sourceID))
+
+	reply, err := s.prompts.GenerateFlashcards(deck.ContextID, source, count)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := parseGeneratedCards(reply)
+	if err != nil {
+		s.logger.Error().Msg("CardService_GenerateFromSource could not read the cards in the reply")
+		return nil, err
+	}
+	if len(generated) > count {
+		generated = generated[:count]
+	}
+
+	cards := make([]entities.Card, len(generated))
+	for i, v := range generated {
+		cards[i] = s.newCard(deck.ID, v.Front, v.Back)
+		cards[i].SourceType = sourceType
+		cards[i].SourceID = &sourceID
+	}
+	cards, err = s.repo.CreateMany(cards)
+	if err != nil {
+		s.logger.Error().Msg("CardService_GenerateFromSource had an error when saving to repo")
+		return nil, err
+	}
+
+	return cards, nil
+}
+
+func (s *CardService) UpdateOne(request requests.UpdateCardRequest) (entities.Card, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("CardService_UpdateOne has started with given id: %s", request.ID))
+	card, err := s.repo.Query().Clauses(clause.Locking{Strength: "UPDATE"}).First(request.ID, false)
+	if err != nil {
+		s.logger.Error().Msg(fmt.Sprintf("CardService_UpdateOne could not find a record with given id: %s", request.ID))
+		return entities.Card{}, err
+	}
+
+	if request.Front != nil && *request.Front != "" {
+		s.logger.Debug().Msg(fmt.Sprintf("CardService_UpdateOne updated Front. From: %v => To: %v", card.Front, *request.Front))
+		card.Front = *request.Front
+	}
+
+	if request.Back != nil && *request.Back != "" {
+		s.logger.Debug().Msg(fmt.Sprintf("CardService_UpdateOne updated Back. From: %v => To: %v", card.Back, *request.Back))
+		card.Back = *request.Back
+	}
+
+	card, err = s.repo.Update(&card)
+	if err != nil {
+		s.logger.Error().Msg("CardService_UpdateOne had an error while trying to save to repo")
+		return entities.Card{}, err
+	}
+	return card, nil
+}
+
+func (s *CardService) DeleteOne(id string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("CardService_DeleteOne has started with given id: %s", id))
+	err := s.repo.Delete(id)
+	if err != nil {
+		s.logger.Error().Msg("CardService_DeleteOne had an error when deleting from repo")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Records the grade and moves the card to its next due date
+func (s *CardService) Review(request requests.ReviewCardRequest) (entities.Card, error) {
+	if request.ID == "" {
+		return entities.Card{}, errors.New("argumentErrorIDMissing")
+	}
+	if request.Grade == nil {
+		return entities.Card{}, errors.New("argumentErrorInvalidGrade")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("CardService_Review for card: %s with grade: %d", request.ID, *request.Grade))
+	card, err := s.repo.Query().Clauses(clause.Locking{Strength: "UPDATE"}).First(request.ID, false)
+	if err != nil {
+		s.logger.Error().Msg(fmt.Sprintf("CardService_Review could not find a record with given id: %s", request.ID))
+		return entities.Card{}, err
+	}
+
+	now := time.Now()
+	state := managers.ReviewState{Repetitions: card.Repetitions, EaseFactor: card.EaseFactor, IntervalDays: card.IntervalDays, DueAt: card.DueAt}
+	state, err = s.scheduler.Schedule(state, *request.Grade, now)
+	if err != nil {
+		return entities.Card{}, err
+	}
+	card.Repetitions = state.Repetitions
+	card.EaseFactor = state.EaseFactor
+	card.IntervalDays = state.IntervalDays
+	card.DueAt = state.DueAt
+	card.LastReviewedAt = &now
+
+	review := entities.CardReview{
+		CardID:       card.ID,
+		Grade:        *request.Grade,
+		IntervalDays: state.IntervalDays,
+		EaseFactor:   state.EaseFactor,
+	}
+	_, err = s.reviewRepo.Create(&review)
+	if err != nil {
+		s.logger.Error().Msg("CardService_Review had an error when saving the review to repo")
+		return entities.Card{}, err
+	}
+	card, err = s.repo.Update(&card)
+	if err != nil {
+		s.logger.Error().Msg("CardService_Review had an error while trying to save to repo")
+		return entities.Card{}, err
+	}
+	return card, nil
+}
+
+func (s *CardService) newCard(deckID string, front string, back string) entities.Card {
+	state := s.scheduler.Initial()
+	return entities.Card{
+		DeckID:       deckID,
+		Front:        strings.TrimSpace(front),
+		Back:         strings.TrimSpace(back),
+		Repetitions:  state.Repetitions,
+		EaseFactor:   state.EaseFactor,
+		IntervalDays: state.IntervalDays,
+		DueAt:        time.Now(),
+	}
+}
+
+type generatedCard struct {
+	Front string `json:"front"`
+	Back  string `json:"back"`
+}
+
+func parseGeneratedCards(reply string) ([]generatedCard, error) {
+	var cards []generatedCard
+	if !unmarshalReply(reply, "[", "]", &cards) {
+		return nil, errors.New("flashcardErrorInvalidReply")
+	}
+	res := make([]generatedCard, 0, len(cards))
+	for _, card := range cards {
+		if strings.TrimSpace(card.Front) != "" && strings.TrimSpace(card.Back) != "" {
+			res = append(res, card)
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.New("flashcardErrorInvalidReply")
+	}
+	return res, nil
+}
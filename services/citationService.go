@@ -0,0 +1,72 @@
+package services
+
+import (
+	citationResponse "echo-api/models/dtos/responses/citation"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+)
+
+type CitationService struct {
+	repo         util.Repository[entities.Citation]
+	noteRepo     util.Repository[entities.Note]
+	documentRepo util.Repository[entities.Document]
+	logger       *util.Logger
+}
+
+func NewCitationService(repo util.Repository[entities.Citation], noteRepo util.Repository[entities.Note], documentRepo util.Repository[entities.Document], logger *util.Logger) *CitationService {
+	return &CitationService{repo: repo, noteRepo: noteRepo, documentRepo: documentRepo, logger: logger}
+}
+
+func (s *CitationService) GetOne(id string) (entities.Citation, error) {
+	if id == "" {
+		return entities.Citation{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("CitationService_GetOne with id: %s", id))
+	res, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("CitationService_GetOne had an error when getting from repo")
+		return entities.Citation{}, err
+	}
+
+	return res, nil
+}
+
+// Snippet is cut from the current text of the source, so it fails once the source is changed to be shorter than the citation
+func (s *CitationService) GetSnippet(id string) (citationResponse.CitationSnippet, error) {
+	citation, err := s.GetOne(id)
+	if err != nil {
+		return citationResponse.CitationSnippet{}, err
+	}
+	text, err := s.getSourceText(citation)
+	if err != nil {
+		return citationResponse.CitationSnippet{}, err
+	}
+	if citation.StartOffset < 0 || citation.StartOffset > citation.EndOffset || citation.EndOffset > len(text) {
+		return citationResponse.CitationSnippet{}, errors.New("citationErrorSourceChanged")
+	}
+
+	return citationResponse.CitationSnippet{Citation: citation, Snippet: text[citation.StartOffset:citation.EndOffset]}, nil
+}
+
+func (s *CitationService) getSourceText(citation entities.Citation) (string, error) {
+	switch citation.SourceType {
+	case entities.NoteSource:
+		note, err := s.noteRepo.First(citation.SourceID, false)
+		if err != nil {
+			s.logger.Error().Msg("CitationService_getSourceText had an error when getting the note from repo")
+			return "", err
+		}
+		return note.Payload, nil
+	case entities.DocumentSource:
+		document, err := s.documentRepo.First(citation.SourceID, false)
+		if err != nil {
+			s.logger.Error().Msg("CitationService_getSourceText had an error when getting the document from repo")
+			return "", err
+		}
+		return document.Text, nil
+	default:
+		return "", errors.New("citationErrorUnknownSource")
+	}
+}
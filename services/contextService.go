@@ -1,6 +1,7 @@
 package services
 
 import (
+	"echo-api/managers"
 	requests "echo-api/models/dtos/requests/context"
 	responses "echo-api/models/dtos/responses/pagination"
 	"echo-api/models/entities"
@@ -10,12 +11,13 @@ import (
 )
 
 type ContextService struct {
-	repo   util.Repository[entities.Context]
-	logger *util.Logger
+	repo      util.Repository[entities.Context]
+	logger    *util.Logger
+	providers managers.AiProviderRegistry
 }
 
-func NewContextService(repo util.Repository[entities.Context], logger *util.Logger) *ContextService {
-	return &ContextService{repo: repo, logger: logger}
+func NewContextService(repo util.Repository[entities.Context], logger *util.Logger, providers managers.AiProviderRegistry) *ContextService {
+	return &ContextService{repo: repo, logger: logger, providers: providers}
 }
 
 func (s *ContextService) CheckIfBelongsToUser(id string, userID string) (bool, error) {
@@ -86,12 +88,18 @@ func (s *ContextService) CreateOne(request requests.CreateContextRequest) (entit
 	if request.LanguageID == "" || request.UserID == "" {
 		return entities.Context{}, errors.New("argumentErrorIDMissing")
 	}
+	_, err := s.providers.Get(request.Provider, request.Model)
+	if err != nil {
+		return entities.Context{}, err
+	}
 	context := entities.Context{
 		UserID:     request.UserID,
 		LanguageID: request.LanguageID,
+		Provider:   request.Provider,
+		Model:      request.Model,
 	}
 	s.logger.Debug().Msg("ContextService_CreateOne has started")
-	context, err := s.repo.Create(&context)
+	context, err = s.repo.Create(&context)
 	if err != nil {
 		s.logger.Error().Msg("ContextService_CreateOne had an error when saving to repo")
 		return entities.Context{}, err
@@ -100,9 +108,56 @@ func (s *ContextService) CreateOne(request requests.CreateContextRequest) (entit
 	return context, nil
 }
 
+// Later messages of the context go to the new model, the conversation so far is dropped and its prompts have to be replayed
+func (s *ContextService) UpdateModel(request requests.UpdateContextModelRequest) (entities.Context, error) {
+	if request.ID == "" {
+		return entities.Context{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("ContextService_UpdateModel with id: %s to provider: %s and model: %s", request.ID, request.Provider, request.Model))
+	_, err := s.providers.Get(request.Provider, request.Model)
+	if err != nil {
+		return entities.Context{}, err
+	}
+	context, err := s.repo.First(request.ID, false)
+	if err != nil {
+		s.logger.Error().Msg("ContextService_UpdateModel had an error when requesting from repo")
+		return entities.Context{}, err
+	}
+	// Provider or model may have been removed from configuration since, then there is no conversation to drop either
+	previous, err := s.providers.Get(context.Provider, context.Model)
+	if err == nil {
+		err = previous.DeleteContext(context.ID, true)
+		if err != nil {
+			return entities.Context{}, err
+		}
+	}
+	context.Provider = request.Provider
+	context.Model = request.Model
+	context, err = s.repo.Update(&context)
+	if err != nil {
+		s.logger.Error().Msg("ContextService_UpdateModel had an error when saving to repo")
+		return entities.Context{}, err
+	}
+
+	return context, nil
+}
+
+// The conversation kept by the provider manager is dropped with the context, or it would stay in memory for good
 func (s *ContextService) DeleteOne(id string) (bool, error) {
 	s.logger.Debug().Msg(fmt.Sprintf("ContextService_DeleteOne has started with given id: %s", id))
-	err := s.repo.Delete(id)
+	context, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("ContextService_DeleteOne had an error when requesting from repo")
+		return false, err
+	}
+	manager, err := s.providers.Get(context.Provider, context.Model)
+	if err == nil {
+		err = manager.DeleteContext(context.ID, true)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = s.repo.Delete(id)
 	if err != nil {
 		s.logger.Error().Msg("ContextService_DeleteOne had an error when deleting from repo")
 		return false, err
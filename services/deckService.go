@@ -0,0 +1,146 @@
+package services
+
+import (
+	requests "echo-api/models/dtos/requests/deck"
+	responses "echo-api/models/dtos/responses/pagination"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm/clause"
+)
+
+type DeckService struct {
+	repo   util.Repository[entities.Deck]
+	logger *util.Logger
+}
+
+func NewDeckService(repo util.Repository[entities.Deck], logger *util.Logger) *DeckService {
+	return &DeckService{repo: repo, logger: logger}
+}
+
+func (s *DeckService) CheckIfBelongsToUser(id string, userID string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("DeckService_CheckIfBelongsToUser with id: %s for user: %s", id, userID))
+	res, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("DeckService_CheckIfBelongsToUser had an error when getting from repo")
+		return false, err
+	}
+
+	return userID == res.UserID, nil
+}
+
+func (s *DeckService) GetOne(id string) (entities.Deck, error) {
+	if id == "" {
+		return entities.Deck{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("DeckService_GetOne with id: %s", id))
+	res, err := s.repo.First(id, true)
+	if err != nil {
+		s.logger.Error().Msg("DeckService_GetOne had an error when getting from repo")
+		return entities.Deck{}, err
+	}
+
+	return res, nil
+}
+
+func (s *DeckService) FilterAll(request requests.FilterDecksRequest) (responses.PaginationResponse[entities.Deck], error) {
+	s.logger.Debug().Msg(fmt.Sprintf("DeckService_FilterAll on page: %d with size: %d", request.Page, request.Size))
+	offset := request.CalculateOffset()
+
+	q := s.buildFilterQuery(request)
+	q.Offset(int(offset)).Limit(int(request.Size))
+	res, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("DeckService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Deck]{}, err
+	}
+	count, err := q.Count()
+	if err != nil {
+		s.logger.Error().Msg("DeckService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Deck]{}, err
+	}
+	return responses.PaginationResponse[entities.Deck]{Content: res, Page: request.Page, Size: len(res), TotalCount: int(count)}, nil
+}
+
+func (s *DeckService) buildFilterQuery(request requests.FilterDecksRequest) util.Repository[entities.Deck] {
+	q := s.repo.Query()
+	s.logger.Debug().Msg("*DeckService started to build Filter query")
+
+	if request.IDs != nil && len(*request.IDs) > 0 {
+		s.logger.Debug().Msg("*DeckService filtering IDs")
+		q = q.Where("id IN ?", *request.IDs)
+	}
+
+	if request.Name != nil && *request.Name != "" {
+		s.logger.Debug().Msg("*DeckService filtering Name")
+		q = q.Where("name LIKE ?", "%"+*request.Name+"%")
+	}
+
+	if request.UserIDs != nil && len(*request.UserIDs) > 0 {
+		s.logger.Debug().Msg("*DeckService filtering UserIDs")
+		q = q.Where("user_id IN ?", *request.UserIDs)
+	}
+
+	if request.ContextIDs != nil && len(*request.ContextIDs) > 0 {
+		s.logger.Debug().Msg("*DeckService filtering ContextIDs")
+		q = q.Where("context_id IN ?", *request.ContextIDs)
+	}
+
+	return q.Order("created_at")
+}
+
+func (s *DeckService) CreateOne(request requests.CreateDeckRequest) (entities.Deck, error) {
+	if request.UserID == nil || *request.UserID == "" || request.ContextID == "" {
+		return entities.Deck{}, errors.New("argumentErrorIDMissing")
+	}
+	if request.Name == "" {
+		return entities.Deck{}, errors.New("argumentErrorMissing")
+	}
+	deck := entities.Deck{
+		Name:      request.Name,
+		ContextID: request.ContextID,
+		UserID:    *request.UserID,
+	}
+	s.logger.Debug().Msg("DeckService_CreateOne has started")
+	deck, err := s.repo.Create(&deck)
+	if err != nil {
+		s.logger.Error().Msg("DeckService_CreateOne had an error when saving to repo")
+		return entities.Deck{}, err
+	}
+
+	return deck, nil
+}
+
+func (s *DeckService) UpdateOne(request requests.UpdateDeckRequest) (entities.Deck, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("DeckService_UpdateOne has started with given id: %s", request.ID))
+	deck, err := s.repo.Query().Clauses(clause.Locking{Strength: "UPDATE"}).First(request.ID, false)
+	if err != nil {
+		s.logger.Error().Msg(fmt.Sprintf("DeckService_UpdateOne could not find a record with given id: %s", request.ID))
+		return entities.Deck{}, err
+	}
+
+	if request.Name != nil && *request.Name != "" {
+		s.logger.Debug().Msg(fmt.Sprintf("DeckService_UpdateOne updated Name. From: %v => To: %v", deck.Name, *request.Name))
+		deck.Name = *request.Name
+	}
+
+	deck, err = s.repo.Update(&deck)
+	if err != nil {
+		s.logger.Error().Msg("DeckService_UpdateOne had an error while trying to save to repo")
+		return entities.Deck{}, err
+	}
+	return deck, nil
+}
+
+func (s *DeckService) DeleteOne(id string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("DeckService_DeleteOne has started with given id: %s", id))
+	err := s.repo.Delete(id)
+	if err != nil {
+		s.logger.Error().Msg("DeckService_DeleteOne had an error when deleting from repo")
+		return false, err
+	}
+
+	return true, nil
+}
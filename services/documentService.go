@@ -9,18 +9,33 @@ import (
 	"echo-api/util"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"sync"
 )
 
+// Suffix of the thumbnail stored next to an image document
+const thumbnailSuffix = ".thumb.jpg"
+
+// Larger images are not given to multimodal models, their recognised text still is
+const maxImageAttachmentBytes = 5 << 20
+
+// Uploads are read into memory for extraction, so larger ones are refused
+const maxUploadedDocumentBytes = 64 << 20
+
 type DocumentService struct {
-	repo        util.Repository[entities.Document]
-	logger      *util.Logger
-	fileManager managers.FileManager
+	repo               util.Repository[entities.Document]
+	logger             *util.Logger
+	fileManager        managers.FileManager
+	extractionManager  managers.ExtractionManager
+	imageManager       managers.ImageManager
+	ocrManager         managers.OcrManager
+	acceptedExtensions []string
 }
 
-func NewDocumentService(repo util.Repository[entities.Document], logger *util.Logger, manager managers.FileManager) *DocumentService {
-	return &DocumentService{repo, logger, manager}
+func NewDocumentService(repo util.Repository[entities.Document], logger *util.Logger, manager managers.FileManager, em managers.ExtractionManager, im managers.ImageManager, om managers.OcrManager, acceptedExtensions []string) *DocumentService {
+	return &DocumentService{repo, logger, manager, em, im, om, acceptedExtensions}
 }
 
 func (s *DocumentService) CheckIfBelongsToUser(id string, userID string) (bool, error) {
@@ -36,7 +51,7 @@ func (s *DocumentService) CheckIfBelongsToUser(id string, userID string) (bool,
 
 func (s *DocumentService) GetOne(id string) (documentResponse.DocumentWrapped, error) {
 	s.logger.Debug().Msg(fmt.Sprintf("DocumentService_GetOne with id: %s", id))
-	document, err := s.repo.First(id, false)
+	document, err := s.repo.First(id, true)
 	if err != nil {
 		s.logger.Error().Msg("DocumentService_GetOne had an error when getting from repo")
 		return documentResponse.DocumentWrapped{}, err
@@ -51,7 +66,7 @@ func (s *DocumentService) FilterAll(request documentRequest.FilterDocumentsReque
 
 	q := s.buildFilterQuery(request)
 	q.Offset(int(offset)).Limit(int(request.Size))
-	docs, err := q.Find(false)
+	docs, err := q.Find(true)
 	if err != nil {
 		s.logger.Error().Msg("DocumentService_FilterAll had an error when requesting the data from repo")
 		return responses.PaginationResponse[documentResponse.DocumentWrapped]{}, err
@@ -111,8 +126,9 @@ func (s *DocumentService) CreateBulkFromMultipart(request documentRequest.Create
 	count := len(request.Files)
 	res := make([]entities.Document, count)
 	var wg sync.WaitGroup
-	resch := make(chan entities.Document)
-	errch := make(chan error)
+	// Buffered so workers never block on sending before every one of them is done
+	resch := make(chan entities.Document, count)
+	errch := make(chan error, count)
 	for i := 0; i < count; i++ {
 		wg.Add(1)
 		go s.concurrentCreateOneFromMultipart(&wg, resch, errch, documentRequest.CreateDocumentMultipartRequest{File: request.Files[i], CreateDocumentRequestBase: request.CreateDocumentRequestBase})
@@ -138,18 +154,50 @@ func (s *DocumentService) CreateOneFromMultipart(request documentRequest.CreateD
 		return entities.Document{}, errors.New("argumentErrorMissing")
 	}
 	s.logger.Debug().Msg("DocumentService_CreateOneFromMultipart has started")
-	err := s.saveMultipartFile(request)
+	name := request.File.Filename
+	extension := s.getFileExtension(name)
+	if !s.isAcceptedExtension(extension) {
+		s.logger.Debug().Msg(fmt.Sprintf("DocumentService_CreateOneFromMultipart rejected the extension: %s", extension))
+		return entities.Document{}, errors.New("argumentErrorUnsupportedExtension")
+	}
+	content, err := s.readMultipartFile(request)
+	if err != nil {
+		return entities.Document{}, err
+	}
+	var extracted managers.ExtractedText
+	var processed managers.ProcessedImage
+	isImage := s.imageManager.Supports(extension)
+	if isImage {
+		processed, extracted, err = s.processImage(content, extension)
+	} else {
+		extracted, err = s.extractionManager.Extract(content, extension)
+	}
+	if err != nil {
+		s.logger.Error().Msg("DocumentService_CreateOneFromMultipart had an error when extracting the text")
+		return entities.Document{}, err
+	}
+	err = s.saveMultipartFile(request)
 	if err != nil {
 		return entities.Document{}, err
 	}
-	name := request.File.Filename
-	extension := s.getFileExtension(name)
 	document := entities.Document{
 		Name:            name,
 		Location:        request.Location,
 		Extension:       extension,
 		UserID:          request.UserID,
+		ContextID:       request.ContextID,
 		IsReadableByAll: request.IsReadableByAll,
+		Text:            extracted.Text,
+		Sections:        make([]entities.DocumentSection, len(extracted.Sections)),
+	}
+	for i, section := range extracted.Sections {
+		document.Sections[i] = entities.DocumentSection{Index: i, Title: section.Title, StartOffset: section.StartOffset, EndOffset: section.EndOffset}
+	}
+	if isImage {
+		document.Image, err = s.saveThumbnail(request.Location, name, processed)
+		if err != nil {
+			return entities.Document{}, err
+		}
 	}
 	if request.EntityType != nil && request.EntityID != nil && *request.EntityType != "" && *request.EntityID != "" {
 		document, err = s.addDocumentEntityRelation(document, *request.EntityType, *request.EntityID)
@@ -167,7 +215,7 @@ func (s *DocumentService) CreateOneFromMultipart(request documentRequest.CreateD
 
 func (s *DocumentService) DeleteOne(id string) (bool, error) {
 	s.logger.Debug().Msg(fmt.Sprintf("DocumentService_DeleteOne has started with given id: %s", id))
-	document, err := s.repo.First(id, false)
+	document, err := s.repo.First(id, true)
 	if err != nil {
 		s.logger.Error().Msg("DocumentService_DeleteOne had an error when getting from repo")
 		return false, err
@@ -177,6 +225,12 @@ func (s *DocumentService) DeleteOne(id string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if document.Image != nil && document.Image.ThumbnailName != "" {
+		err = s.fileManager.DeleteFile(document.Location, document.Image.ThumbnailName)
+		if err != nil {
+			return false, err
+		}
+	}
 	err = s.repo.Delete(id)
 	if err != nil {
 		s.logger.Error().Msg("DocumentService_DeleteOne had an error when deleting from repo")
@@ -199,10 +253,10 @@ func (s *DocumentService) saveMultipartFile(request documentRequest.CreateDocume
 	buffer := make([]byte, buffSize)
 	for offset < size {
 		countRead, err := f.ReadAt(buffer, offset)
-		if err != nil {
+		if err != nil && err != io.EOF {
 			return err
 		}
-		countWritten, err := s.fileManager.SaveFile(request.Location, filename, buffer, managers.FileOpeningOptions{StartPoint: managers.CUSTOM, Offset: uint64(offset)})
+		countWritten, err := s.fileManager.SaveFile(request.Location, filename, buffer[:countRead], managers.FileOpeningOptions{StartPoint: managers.CUSTOM, Offset: uint64(offset)})
 		if err != nil {
 			return err
 		} else if countRead != countWritten {
@@ -214,9 +268,118 @@ func (s *DocumentService) saveMultipartFile(request documentRequest.CreateDocume
 	return nil
 }
 
+func (s *DocumentService) readMultipartFile(request documentRequest.CreateDocumentMultipartRequest) ([]byte, error) {
+	f, err := request.File.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, maxUploadedDocumentBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxUploadedDocumentBytes {
+		return nil, errors.New("documentErrorTooLarge")
+	}
+	return content, nil
+}
+
+// Recognised text becomes the text of the document, an image without text or with failed recognition is still accepted
+func (s *DocumentService) processImage(content []byte, extension string) (managers.ProcessedImage, managers.ExtractedText, error) {
+	processed, err := s.imageManager.Process(content, extension)
+	if err != nil {
+		return managers.ProcessedImage{}, managers.ExtractedText{}, err
+	}
+	text, err := s.ocrManager.Recognize(content, extension)
+	if err != nil {
+		s.logger.Error().Msg("DocumentService_processImage had an error when recognizing the text, the image is kept without it")
+		text = ""
+	}
+	extracted := managers.ExtractedText{Text: text}
+	if text != "" {
+		extracted.Sections = []managers.TextSection{{StartOffset: 0, EndOffset: len(text)}}
+	}
+	return processed, extracted, nil
+}
+
+func (s *DocumentService) saveThumbnail(location string, name string, processed managers.ProcessedImage) (*entities.DocumentImage, error) {
+	thumbnailName := name + thumbnailSuffix
+	_, err := s.fileManager.SaveFile(location, thumbnailName, processed.Thumbnail, managers.DefaultFileOpeningOptions())
+	if err != nil {
+		s.logger.Error().Msg("DocumentService_saveThumbnail had an error when saving the thumbnail")
+		return nil, err
+	}
+	metadata := processed.Metadata
+	return &entities.DocumentImage{
+		MimeType:      processed.MimeType,
+		Width:         processed.Width,
+		Height:        processed.Height,
+		ThumbnailName: thumbnailName,
+		TakenAt:       metadata.TakenAt,
+		CameraMake:    metadata.CameraMake,
+		CameraModel:   metadata.CameraModel,
+		Orientation:   metadata.Orientation,
+		Latitude:      metadata.Latitude,
+		Longitude:     metadata.Longitude,
+	}, nil
+}
+
+// Returns the original image for models that can look at it, other documents and oversized images give none
+func (s *DocumentService) GetImageAttachments(document entities.Document) ([]managers.ImageAttachment, error) {
+	if document.Image == nil {
+		return nil, nil
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("DocumentService_GetImageAttachments with id: %s", document.ID))
+	f, err := s.fileManager.GetFile(document.Location, document.Name, managers.DefaultFileOpeningOptions())
+	if err != nil {
+		s.logger.Error().Msg("DocumentService_GetImageAttachments had an error when opening the file")
+		return nil, err
+	}
+	defer f.Close()
+	content, err := io.ReadAll(io.LimitReader(f, maxImageAttachmentBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxImageAttachmentBytes {
+		s.logger.Debug().Msg(fmt.Sprintf("DocumentService_GetImageAttachments skipped the oversized image: %s", document.ID))
+		return nil, nil
+	}
+	return []managers.ImageAttachment{{MimeType: document.Image.MimeType, Data: content}}, nil
+}
+
+// Returns the opened thumbnail of an image document
+func (s *DocumentService) GetThumbnail(id string) (*os.File, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("DocumentService_GetThumbnail with id: %s", id))
+	document, err := s.repo.First(id, true)
+	if err != nil {
+		s.logger.Error().Msg("DocumentService_GetThumbnail had an error when getting from repo")
+		return nil, err
+	}
+	if document.Image == nil || document.Image.ThumbnailName == "" {
+		return nil, errors.New("imageErrorNoThumbnail")
+	}
+	return s.fileManager.GetFile(document.Location, document.Image.ThumbnailName, managers.DefaultFileOpeningOptions())
+}
+
 func (s *DocumentService) getFileExtension(filename string) string {
 	parts := strings.Split(filename, ".")
-	return parts[len(parts)-1]
+	return strings.ToLower(parts[len(parts)-1])
+}
+
+// Extension has to be readable by the extraction or image manager, and configured as accepted when a list is configured
+func (s *DocumentService) isAcceptedExtension(extension string) bool {
+	if !s.extractionManager.Supports(extension) && !s.imageManager.Supports(extension) {
+		return false
+	}
+	if len(s.acceptedExtensions) == 0 {
+		return true
+	}
+	for _, v := range s.acceptedExtensions {
+		if strings.EqualFold(strings.TrimPrefix(v, "."), extension) {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *DocumentService) concurrentCreateOneFromMultipart(wg *sync.WaitGroup, resch chan entities.Document, errch chan error, request documentRequest.CreateDocumentMultipartRequest) {
@@ -237,7 +400,11 @@ func (s *DocumentService) addDocumentEntityRelation(document entities.Document,
 }
 
 func (s *DocumentService) mapOneToDocumentWrapped(doc entities.Document) documentResponse.DocumentWrapped {
-	return documentResponse.DocumentWrapped{Document: doc, Path: s.fileManager.GetFullPath(doc.Location, doc.Name)}
+	res := documentResponse.DocumentWrapped{Document: doc, Path: s.fileManager.GetFullPath(doc.Location, doc.Name)}
+	if doc.Image != nil && doc.Image.ThumbnailName != "" {
+		res.ThumbnailPath = s.fileManager.GetFullPath(doc.Location, doc.Image.ThumbnailName)
+	}
+	return res
 }
 
 func (s *DocumentService) mapToDocumentWrapped(docs []entities.Document) []documentResponse.DocumentWrapped {
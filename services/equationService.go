@@ -0,0 +1,205 @@
+package services
+
+import (
+	"echo-api/managers"
+	requests "echo-api/models/dtos/requests/equation"
+	responses "echo-api/models/dtos/responses/pagination"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+)
+
+// Equations are written by hand, longer LaTeX is refused before it is parsed
+const maxEquationLatexLength = 10_000
+
+type EquationService struct {
+	repo            util.Repository[entities.Equation]
+	noteRepo        util.Repository[entities.Note]
+	logger          *util.Logger
+	equationManager managers.EquationManager
+}
+
+func NewEquationService(repo util.Repository[entities.Equation], noteRepo util.Repository[entities.Note], logger *util.Logger, em managers.EquationManager) *EquationService {
+	return &EquationService{repo: repo, noteRepo: noteRepo, logger: logger, equationManager: em}
+}
+
+func (s *EquationService) CheckIfBelongsToUser(id string, userID string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("EquationService_CheckIfBelongsToUser with id: %s for user: %s", id, userID))
+	res, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("EquationService_CheckIfBelongsToUser had an error when getting from repo")
+		return false, err
+	}
+
+	return userID == res.UserID, nil
+}
+
+func (s *EquationService) GetOne(id string) (entities.Equation, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("EquationService_GetOne with id: %s", id))
+	res, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("EquationService_GetOne had an error when getting from repo")
+		return entities.Equation{}, err
+	}
+
+	return res, nil
+}
+
+func (s *EquationService) FilterAll(request requests.FilterEquationsRequest) (responses.PaginationResponse[entities.Equation], error) {
+	s.logger.Debug().Msg(fmt.Sprintf("EquationService_FilterAll on page: %d with size: %d", request.Page, request.Size))
+	offset := request.CalculateOffset()
+
+	q := s.buildFilterQuery(request)
+	q.Offset(int(offset)).Limit(int(request.Size))
+	res, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("EquationService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Equation]{}, err
+	}
+	count, err := q.Count()
+	if err != nil {
+		s.logger.Error().Msg("EquationService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Equation]{}, err
+	}
+	return responses.PaginationResponse[entities.Equation]{Content: res, Page: request.Page, Size: len(res), TotalCount: int(count)}, nil
+}
+
+func (s *EquationService) buildFilterQuery(request requests.FilterEquationsRequest) util.Repository[entities.Equation] {
+	q := s.repo.Query()
+	s.logger.Debug().Msg("*EquationService started to build Filter query")
+
+	if request.IDs != nil && len(*request.IDs) > 0 {
+		s.logger.Debug().Msg("*EquationService filtering IDs")
+		q = q.Where("id IN ?", *request.IDs)
+	}
+
+	if request.Label != nil && *request.Label != "" {
+		s.logger.Debug().Msg("*EquationService filtering Label")
+		q = q.Where("label LIKE ?", "%"+*request.Label+"%")
+	}
+
+	if request.UserIDs != nil && len(*request.UserIDs) > 0 {
+		s.logger.Debug().Msg("*EquationService filtering UserIDs")
+		q = q.Where("user_id IN ?", *request.UserIDs)
+	}
+
+	if request.NoteIDs != nil && len(*request.NoteIDs) > 0 {
+		s.logger.Debug().Msg("*EquationService filtering NoteIDs")
+		q = q.Where("note_id IN ?", *request.NoteIDs)
+	}
+
+	if request.ContextIDs != nil && len(*request.ContextIDs) > 0 {
+		s.logger.Debug().Msg("*EquationService filtering ContextIDs")
+		q = q.Where("context_id IN ?", *request.ContextIDs)
+	}
+
+	return q.Order("created_at")
+}
+
+// Validates the LaTeX and keeps its rendered forms next to it
+func (s *EquationService) CreateOne(request requests.CreateEquationRequest) (entities.Equation, error) {
+	if request.UserID == nil || *request.UserID == "" {
+		return entities.Equation{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg("EquationService_CreateOne has started")
+	equation := entities.Equation{
+		Label:     strings.TrimSpace(request.Label),
+		ContextID: request.ContextID,
+		UserID:    *request.UserID,
+	}
+	if request.NoteID != nil && *request.NoteID != "" {
+		note, err := s.noteRepo.First(*request.NoteID, false)
+		if err != nil {
+			s.logger.Error().Msg("EquationService_CreateOne had an error when getting the note from repo")
+			return entities.Equation{}, err
+		}
+		if equation.ContextID == "" {
+			equation.ContextID = note.ContextID
+		} else if equation.ContextID != note.ContextID {
+			return entities.Equation{}, errors.New("argumentErrorSourceOutsideContext")
+		}
+		equation.NoteID = &note.ID
+	}
+	if equation.ContextID == "" {
+		return entities.Equation{}, errors.New("argumentErrorIDMissing")
+	}
+	err := s.render(&equation, request.Latex)
+	if err != nil {
+		return entities.Equation{}, err
+	}
+	equation, err = s.repo.Create(&equation)
+	if err != nil {
+		s.logger.Error().Msg("EquationService_CreateOne had an error when saving to repo")
+		return entities.Equation{}, err
+	}
+
+	return equation, nil
+}
+
+func (s *EquationService) UpdateOne(request requests.UpdateEquationRequest) (entities.Equation, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("EquationService_UpdateOne has started with given id: %s", request.ID))
+	equation, err := s.repo.Query().Clauses(clause.Locking{Strength: "UPDATE"}).First(request.ID, false)
+	if err != nil {
+		s.logger.Error().Msg(fmt.Sprintf("EquationService_UpdateOne could not find a record with given id: %s", request.ID))
+		return entities.Equation{}, err
+	}
+
+	if request.Latex != nil && *request.Latex != equation.Latex {
+		s.logger.Debug().Msg(fmt.Sprintf("EquationService_UpdateOne updated Latex. From: %v => To: %v", equation.Latex, *request.Latex))
+		err = s.render(&equation, *request.Latex)
+		if err != nil {
+			return entities.Equation{}, err
+		}
+	}
+
+	if request.Label != nil {
+		s.logger.Debug().Msg(fmt.Sprintf("EquationService_UpdateOne updated Label. From: %v => To: %v", equation.Label, *request.Label))
+		equation.Label = strings.TrimSpace(*request.Label)
+	}
+
+	equation, err = s.repo.Update(&equation)
+	if err != nil {
+		s.logger.Error().Msg("EquationService_UpdateOne had an error while trying to save to repo")
+		return entities.Equation{}, err
+	}
+	return equation, nil
+}
+
+func (s *EquationService) DeleteOne(id string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("EquationService_DeleteOne has started with given id: %s", id))
+	err := s.repo.Delete(id)
+	if err != nil {
+		s.logger.Error().Msg("EquationService_DeleteOne had an error when deleting from repo")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Renders without saving, for previews while an equation is written
+func (s *EquationService) Render(request requests.RenderEquationRequest) (managers.RenderedEquation, error) {
+	s.logger.Debug().Msg("EquationService_Render has started")
+	if len(request.Latex) > maxEquationLatexLength {
+		return managers.RenderedEquation{}, errors.New("equationErrorTooLong")
+	}
+	return s.equationManager.Render(request.Latex)
+}
+
+func (s *EquationService) render(equation *entities.Equation, latex string) error {
+	if len(latex) > maxEquationLatexLength {
+		return errors.New("equationErrorTooLong")
+	}
+	rendered, err := s.equationManager.Render(latex)
+	if err != nil {
+		s.logger.Debug().Msg(fmt.Sprintf("EquationService_render rejected the LaTeX: %s", latex))
+		return err
+	}
+	equation.Latex = rendered.Latex
+	equation.MathML = rendered.MathML
+	equation.Text = rendered.Text
+	return nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"echo-api/models/dtos/responses/event"
+	"echo-api/util"
+	"fmt"
+	"sync"
+)
+
+const subscriptionBufferSize = 64
+
+/* HubService fans out events of a context to every websocket connected to it.
+ * Only the owner of a context can subscribe to it so every subscription is one open tab of the owning user.
+ */
+type HubService struct {
+	logger        *util.Logger
+	mutex         sync.RWMutex
+	subscriptions map[string]map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	ContextID string
+	UserID    string
+	Events    chan event.ContextEvent
+}
+
+func NewHubService(logger *util.Logger) *HubService {
+	return &HubService{logger: logger, subscriptions: make(map[string]map[*Subscription]struct{})}
+}
+
+func (s *HubService) Subscribe(contextID string, userID string) *Subscription {
+	s.logger.Debug().Msg(fmt.Sprintf("HubService_Subscribe for context: %s by user: %s", contextID, userID))
+	sub := &Subscription{ContextID: contextID, UserID: userID, Events: make(chan event.ContextEvent, subscriptionBufferSize)}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.subscriptions[contextID]; !ok {
+		s.subscriptions[contextID] = make(map[*Subscription]struct{})
+	}
+	s.subscriptions[contextID][sub] = struct{}{}
+
+	return sub
+}
+
+func (s *HubService) Unsubscribe(sub *Subscription) {
+	s.logger.Debug().Msg(fmt.Sprintf("HubService_Unsubscribe for context: %s by user: %s", sub.ContextID, sub.UserID))
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	subs, ok := s.subscriptions[sub.ContextID]
+	if !ok {
+		return
+	}
+	if _, ok = subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.Events)
+	if len(subs) == 0 {
+		delete(s.subscriptions, sub.ContextID)
+	}
+}
+
+// Publish never blocks, a subscriber that is too slow to drain its buffer misses the event
+func (s *HubService) Publish(contextID string, eventType event.EventType, payload any) {
+	e := event.ContextEvent{Type: eventType, ContextID: contextID, Payload: payload}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for sub := range s.subscriptions[contextID] {
+		select {
+		case sub.Events <- e:
+		default:
+			s.logger.Error().Msg(fmt.Sprintf("HubService_Publish dropped a %s event for a slow subscriber of context: %s", eventType, contextID))
+		}
+	}
+}
+
+func (s *HubService) CountSubscriptions(contextID string) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.subscriptions[contextID])
+}
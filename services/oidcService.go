@@ -0,0 +1,182 @@
+package services
+
+import (
+	"crypto/sha256"
+	"echo-api/managers"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Time the user has at the provider before the login has to be started again
+const oidcLoginTTL = 10 * time.Minute
+
+type OidcService struct {
+	stateRepo    util.Repository[entities.OidcLoginState]
+	identityRepo util.Repository[entities.ExternalIdentity]
+	userRepo     util.Repository[entities.User]
+	logger       *util.Logger
+	oidc         managers.OidcManager
+}
+
+func NewOidcService(stateRepo util.Repository[entities.OidcLoginState], identityRepo util.Repository[entities.ExternalIdentity], userRepo util.Repository[entities.User], logger *util.Logger, oidc managers.OidcManager) *OidcService {
+	return &OidcService{
+		stateRepo:    stateRepo,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		logger:       logger,
+		oidc:         oidc,
+	}
+}
+
+func (s *OidcService) Providers() []string {
+	return s.oidc.Providers()
+}
+
+// Returns the url of the provider to send the user to, the state it comes back with is stored to find this login again
+func (s *OidcService) Begin(provider string) (string, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("OidcService_Begin for provider: %s", provider))
+	err := s.stateRepo.DeleteWhere("expires_at < ?", time.Now())
+	if err != nil {
+		s.logger.Error().Msg("OidcService_Begin had an error when purging expired logins from repo")
+		return "", err
+	}
+	state, err := newRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := newRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := newRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	url, err := s.oidc.AuthorizationUrl(provider, state, nonce, base64.RawURLEncoding.EncodeToString(challenge[:]))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.stateRepo.Create(&entities.OidcLoginState{Provider: provider, StateHash: hashToken(state), CodeVerifier: verifier, Nonce: nonce, ExpiresAt: time.Now().Add(oidcLoginTTL)})
+	if err != nil {
+		s.logger.Error().Msg("OidcService_Begin had an error when saving to repo")
+		return "", err
+	}
+	return url, nil
+}
+
+// The state is used up before the code is redeemed, so a callback can not be replayed even when the provider refuses it
+func (s *OidcService) Complete(provider string, code string, state string) (entities.User, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("OidcService_Complete for provider: %s", provider))
+	login, err := s.useState(provider, state)
+	if err != nil {
+		return entities.User{}, err
+	}
+	identity, err := s.oidc.Exchange(provider, code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		return entities.User{}, err
+	}
+	return s.resolveUser(provider, identity)
+}
+
+// Marked as used in a single conditional update, so of two callbacks racing with the same state only one gets the row
+func (s *OidcService) useState(provider string, state string) (entities.OidcLoginState, error) {
+	stateHash := hashToken(state)
+	now := time.Now()
+	used, err := s.stateRepo.Query().Where("state_hash = ?", stateHash).Where("provider = ?", provider).Where("used_at IS NULL").Where("expires_at > ?", now).UpdateColumn("used_at", now)
+	if err != nil {
+		s.logger.Error().Msg("OidcService_useState had an error when updating in repo")
+		return entities.OidcLoginState{}, err
+	}
+	if used != 1 {
+		return entities.OidcLoginState{}, errors.New("oidcErrorStateInvalid")
+	}
+	found, err := s.stateRepo.Query().Where("state_hash = ?", stateHash).Where("provider = ?", provider).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("OidcService_useState had an error when getting from repo")
+		return entities.OidcLoginState{}, err
+	}
+	if len(found) == 0 {
+		return entities.OidcLoginState{}, errors.New("oidcErrorStateInvalid")
+	}
+	return found[0], nil
+}
+
+// An identity seen before logs in its user. Otherwise it is linked by email, which both the provider and this
+// service must have verified, as linking to an account someone registered with another person's email would hand it over
+func (s *OidcService) resolveUser(provider string, identity managers.OidcIdentity) (entities.User, error) {
+	linked, err := s.identityRepo.Query().Where("provider = ?", provider).Where("subject = ?", identity.Subject).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("OidcService_resolveUser had an error when getting identities from repo")
+		return entities.User{}, err
+	}
+	if len(linked) > 0 {
+		user, err := s.userRepo.First(linked[0].UserID, false)
+		if err != nil {
+			s.logger.Error().Msg("OidcService_resolveUser had an error when getting the linked user from repo")
+			return entities.User{}, err
+		}
+		if linked[0].Email != identity.Email {
+			linked[0].Email = identity.Email
+			_, err = s.identityRepo.Update(&linked[0])
+			if err != nil {
+				s.logger.Error().Msg("OidcService_resolveUser had an error when updating the identity in repo")
+				return entities.User{}, err
+			}
+		}
+		return user, nil
+	}
+
+	if identity.Email == "" {
+		return entities.User{}, errors.New("oidcErrorEmailMissing")
+	}
+	if !identity.EmailVerified {
+		return entities.User{}, errors.New("oidcErrorEmailNotVerified")
+	}
+	email, err := normalizeEmail(identity.Email)
+	if err != nil {
+		return entities.User{}, err
+	}
+	found, err := s.userRepo.Query().Where("email = ?", email).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("OidcService_resolveUser had an error when getting users from repo")
+		return entities.User{}, err
+	}
+
+	var user entities.User
+	if len(found) > 0 {
+		user = found[0]
+		if user.EmailVerifiedAt == nil {
+			return entities.User{}, errors.New("oidcErrorAccountNotVerified")
+		}
+		s.logger.Debug().Msg(fmt.Sprintf("OidcService_resolveUser linking %s to user: %s", provider, user.ID))
+	} else {
+		now := time.Now()
+		user, err = s.userRepo.Create(&entities.User{Name: nameOf(identity), Email: email, Role: entities.Customer, EmailVerifiedAt: &now})
+		if err != nil {
+			s.logger.Error().Msg("OidcService_resolveUser had an error when saving the user to repo")
+			return entities.User{}, err
+		}
+		s.logger.Debug().Msg(fmt.Sprintf("OidcService_resolveUser created user %s for %s", user.ID, provider))
+	}
+	_, err = s.identityRepo.Create(&entities.ExternalIdentity{UserID: user.ID, Provider: provider, Subject: identity.Subject, Email: identity.Email})
+	if err != nil {
+		s.logger.Error().Msg("OidcService_resolveUser had an error when saving the identity to repo")
+		return entities.User{}, err
+	}
+	return user, nil
+}
+
+func nameOf(identity managers.OidcIdentity) string {
+	if identity.Name != "" {
+		return identity.Name
+	}
+	name, _, _ := strings.Cut(identity.Email, "@")
+	return name
+}
@@ -1,24 +1,41 @@
 package services
 
 import (
+	"context"
 	"echo-api/managers"
+	contextRequests "echo-api/models/dtos/requests/context"
+	messageRequests "echo-api/models/dtos/requests/message"
 	requests "echo-api/models/dtos/requests/prompt"
 	responses "echo-api/models/dtos/responses/pagination"
 	"echo-api/models/entities"
 	"echo-api/util"
 	"errors"
 	"fmt"
+	"strings"
 )
 
+const defaultContextWindowTokens = 8000
+
+// Most recent messages stay in the conversation as they are when older ones are summarised
+const keptMessagesOnCompaction = 4
+
 type PromptService struct {
 	repo          util.Repository[entities.Prompt]
+	messageRepo   util.Repository[entities.Message]
+	contextRepo   util.Repository[entities.Context]
 	logger        *util.Logger
 	promptManager managers.PromptGenManager
-	commsManager  managers.AiCommunicationManager
+	providers     managers.AiProviderRegistry
+	retrieval     *RetrievalService
+	usage         *UsageService
+	windowTokens  int
 }
 
-func NewPromptService(repo util.Repository[entities.Prompt], logger *util.Logger, pm managers.PromptGenManager, cm managers.AiCommunicationManager) *PromptService {
-	return &PromptService{repo: repo, logger: logger, promptManager: pm, commsManager: cm}
+func NewPromptService(repo util.Repository[entities.Prompt], messageRepo util.Repository[entities.Message], contextRepo util.Repository[entities.Context], logger *util.Logger, pm managers.PromptGenManager, providers managers.AiProviderRegistry, rs *RetrievalService, us *UsageService, windowTokens int) *PromptService {
+	if windowTokens <= 0 {
+		windowTokens = defaultContextWindowTokens
+	}
+	return &PromptService{repo: repo, messageRepo: messageRepo, contextRepo: contextRepo, logger: logger, promptManager: pm, providers: providers, retrieval: rs, usage: us, windowTokens: windowTokens}
 }
 
 func (s *PromptService) GetOne(id string) (entities.Prompt, error) {
@@ -65,7 +82,7 @@ func (s *PromptService) buildFilterQuery(request requests.FilterPromptsRequest)
 
 	if request.ContextIDs != nil && len(*request.ContextIDs) > 0 {
 		s.logger.Debug().Msg("*PromptService filtering ContextID")
-		q = q.Where("contextID IN ?", *request.ContextIDs)
+		q = q.Where("context_id IN ?", *request.ContextIDs)
 	}
 
 	return q.Order("created_at")
@@ -77,9 +94,9 @@ func (s *PromptService) FindByEntityAndContext(request requests.FindPromptByEnti
 	}
 	q := s.repo.Query()
 	if request.ContextID != "" {
-		q = q.Where("contextID = ?", request.ContextID)
+		q = q.Where("context_id = ?", request.ContextID)
 	}
-	res, err := q.Where("entityID = ?", request.EntityID).Find(false)
+	res, err := q.Where("entity_id = ?", request.EntityID).Find(false)
 	if err != nil {
 		s.logger.Error().Msg("PromptService_GetOne had an error when fetching from repo")
 		return entities.Prompt{}, err
@@ -94,11 +111,27 @@ func (s *PromptService) GenerateAndSendPrompt(request requests.CreatePromptReque
 	if request.ContextID == "" {
 		return entities.Prompt{}, errors.New("argumentErrorIDMissing")
 	}
-	promptValue, err := s.promptManager.GeneratePrompt(request.Value)
+	conversation, commsManager, err := s.getBudgetedConversation(request.ContextID)
+	if err != nil {
+		return entities.Prompt{}, err
+	}
+	subject, err := s.promptManager.GenerateSubject(request.Value, conversation.LanguageID)
+	if err != nil {
+		return entities.Prompt{}, err
+	}
+	promptValue, err := s.promptManager.GeneratePromptWith(subject, managers.Remember, conversation.LanguageID)
+	if err != nil {
+		return entities.Prompt{}, err
+	}
+	err = s.indexSource(request.ContextID, request.Value)
 	if err != nil {
 		return entities.Prompt{}, err
 	}
-	_, err = s.commsManager.SendPrompt(request.ContextID, promptValue)
+	resp, err := s.sendPromptWithImages(commsManager, request.ContextID, promptValue, request.Images)
+	if err != nil {
+		return entities.Prompt{}, err
+	}
+	err = s.usage.Record(conversation.UserID, conversation.ID, entities.PromptUsage, resp)
 	if err != nil {
 		return entities.Prompt{}, err
 	}
@@ -107,6 +140,7 @@ func (s *PromptService) GenerateAndSendPrompt(request requests.CreatePromptReque
 		ContextID: request.ContextID,
 		Value:     promptValue,
 		EntityID:  &request.EntityID,
+		Subject:   subject,
 	}
 	if request.EntityID == "" {
 		prompt.EntityID = nil
@@ -121,46 +155,523 @@ func (s *PromptService) GenerateAndSendPrompt(request requests.CreatePromptReque
 	return prompt, nil
 }
 
-func (s *PromptService) GenerateAndSendMessage(request requests.CreateMessageRequest) (string, error) {
+func (s *PromptService) GenerateAndSendMessage(request requests.CreateMessageRequest) (entities.Message, error) {
+	if request.ContextID == "" {
+		return entities.Message{}, errors.New("argumentErrorIDMissing")
+	}
+	conversation, commsManager, err := s.getBudgetedConversation(request.ContextID)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	err = s.compactIfNeeded(conversation, commsManager)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	promptValue, excerpts, err := s.generateMessageWithExcerpts(request, conversation.LanguageID)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	resp, err := commsManager.SendMessage(request.ContextID, promptValue)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	err = s.usage.Record(conversation.UserID, conversation.ID, entities.MessageUsage, resp)
+	if err != nil {
+		return entities.Message{}, err
+	}
+
+	return s.saveConversationTurn(request, resp, excerpts)
+}
+
+// Relays every delta of the reply to onDelta, the full reply is only stored once the stream completes
+func (s *PromptService) GenerateAndStreamMessage(ctx context.Context, request requests.CreateMessageRequest, onDelta func(string) error) (entities.Message, error) {
 	if request.ContextID == "" {
+		return entities.Message{}, errors.New("argumentErrorIDMissing")
+	}
+	conversation, commsManager, err := s.getBudgetedConversation(request.ContextID)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	err = s.compactIfNeeded(conversation, commsManager)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	promptValue, excerpts, err := s.generateMessageWithExcerpts(request, conversation.LanguageID)
+	if err != nil {
+		return entities.Message{}, err
+	}
+	var streamed strings.Builder
+	resp, err := commsManager.StreamMessage(ctx, request.ContextID, promptValue, func(delta string) error {
+		streamed.WriteString(delta)
+		return onDelta(delta)
+	})
+	if err != nil {
+		// The provider was paid for what it produced already, leaving before the end must not skip the budget
+		if streamed.Len() > 0 || ctx.Err() != nil {
+			s.recordAbortedStream(conversation, promptValue, streamed.String())
+		}
+		return entities.Message{}, err
+	}
+	err = s.usage.Record(conversation.UserID, conversation.ID, entities.MessageUsage, resp)
+	if err != nil {
+		return entities.Message{}, err
+	}
+
+	return s.saveConversationTurn(request, resp, excerpts)
+}
+
+// The provider reports no usage for a stream that ended early, so it is estimated from the stored conversation and what arrived
+func (s *PromptService) recordAbortedStream(conversation entities.Context, promptValue string, streamed string) {
+	texts := []string{promptValue}
+	prompts, err := s.repo.Query().Where("context_id = ?", conversation.ID).Find(false)
+	if err == nil {
+		for _, prompt := range prompts {
+			texts = append(texts, prompt.Value)
+		}
+	}
+	messages, err := s.messageRepo.Query().Where("context_id = ?", conversation.ID).Where("is_compacted = ?", false).Find(false)
+	if err == nil {
+		for _, msg := range messages {
+			texts = append(texts, msg.Content)
+		}
+	}
+	estimate := managers.Completion{Model: conversation.Model, PromptTokens: util.EstimateMessageTokens(texts...), CompletionTokens: util.EstimateTokens(streamed)}
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_recordAbortedStream estimated %d tokens for context: %s", estimate.PromptTokens+estimate.CompletionTokens, conversation.ID))
+	err = s.usage.Record(conversation.UserID, conversation.ID, entities.MessageUsage, estimate)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("PromptService_recordAbortedStream had an error when recording usage")
+	}
+}
+
+// Starts a fresh conversation on the current model of the context and sends its stored prompts again in their original order
+func (s *PromptService) ReplayPrompts(contextID string) error {
+	if contextID == "" {
+		return errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_ReplayPrompts for context: %s", contextID))
+	conversation, commsManager, err := s.getBudgetedConversation(contextID)
+	if err != nil {
+		return err
+	}
+	prompts, err := s.repo.Query().Where("context_id = ?", contextID).Order("created_at").Find(false)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_ReplayPrompts had an error when requesting from repo")
+		return err
+	}
+	initialPrompt, err := s.promptManager.GenerateInitial(conversation.LanguageID)
+	if err != nil {
+		return err
+	}
+	err = commsManager.CreateContext(contextID, initialPrompt)
+	if err != nil {
+		return err
+	}
+	for _, prompt := range prompts {
+		// The summary needs no answer and has to stay replaceable by the next compaction
+		if prompt.IsSummary {
+			err = commsManager.CompactContext(contextID, prompt.Value, 0)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		resp, err := commsManager.SendPrompt(contextID, prompt.Value)
+		if err != nil {
+			return err
+		}
+		err = s.usage.Record(conversation.UserID, conversation.ID, entities.PromptUsage, resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Every context talks to the provider and model stored on it, as long as its owner has budget left
+func (s *PromptService) getBudgetedConversation(contextID string) (entities.Context, managers.AiCommunicationManager, error) {
+	conversation, commsManager, err := s.getConversation(contextID)
+	if err != nil {
+		return entities.Context{}, nil, err
+	}
+	err = s.usage.CheckBudget(conversation.UserID)
+	if err != nil {
+		return entities.Context{}, nil, err
+	}
+	return conversation, commsManager, nil
+}
+
+// A conversation that is not started yet starts with the initial prompt in the language of the context
+func (s *PromptService) getConversation(contextID string) (entities.Context, managers.AiCommunicationManager, error) {
+	conversation, err := s.contextRepo.First(contextID, false)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_getConversation had an error when requesting the context from repo")
+		return entities.Context{}, nil, err
+	}
+	commsManager, err := s.providers.Get(conversation.Provider, conversation.Model)
+	if err != nil {
+		return entities.Context{}, nil, err
+	}
+	if !commsManager.HasContext(contextID) {
+		initialPrompt, err := s.promptManager.GenerateInitial(conversation.LanguageID)
+		if err != nil {
+			return entities.Context{}, nil, err
+		}
+		err = commsManager.CreateContext(contextID, initialPrompt)
+		if err != nil {
+			return entities.Context{}, nil, err
+		}
+	}
+	return conversation, commsManager, nil
+}
+
+// Asks the model of the context for flashcards outside of its conversation and returns the raw reply
+func (s *PromptService) GenerateFlashcards(contextID string, source any, count int) (string, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_GenerateFlashcards for context: %s", contextID))
+	return s.completeOutsideConversation(contextID, entities.FlashcardUsage, func(languageID string) (string, error) {
+		return s.promptManager.GenerateFlashcards(source, count, languageID)
+	})
+}
+
+// Asks the model of the context for a quiz about the material and returns the raw reply
+func (s *PromptService) GenerateQuiz(contextID string, material string, count int) (string, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_GenerateQuiz for context: %s", contextID))
+	return s.completeOutsideConversation(contextID, entities.QuizUsage, func(languageID string) (string, error) {
+		return s.promptManager.GenerateQuiz(material, count, languageID)
+	})
+}
+
+// Asks the model of the context to grade a short answer and returns the raw reply
+func (s *PromptService) GradeAnswer(contextID string, question string, expected string, given string) (string, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_GradeAnswer for context: %s", contextID))
+	return s.completeOutsideConversation(contextID, entities.GradingUsage, func(languageID string) (string, error) {
+		return s.promptManager.GenerateGrading(question, expected, given, languageID)
+	})
+}
+
+// Study tools use the model and budget of the context without adding to its conversation
+func (s *PromptService) completeOutsideConversation(contextID string, kind entities.UsageKind, generate func(string) (string, error)) (string, error) {
+	if contextID == "" {
 		return "", errors.New("argumentErrorIDMissing")
 	}
-	promptValue, err := s.promptManager.GenerateMessage(request.Value)
+	conversation, commsManager, err := s.getBudgetedConversation(contextID)
 	if err != nil {
 		return "", err
 	}
-	resp, err := s.commsManager.SendPrompt(request.ContextID, promptValue)
+	promptValue, err := generate(conversation.LanguageID)
 	if err != nil {
 		return "", err
 	}
+	resp, err := commsManager.Complete(promptValue)
+	if err != nil {
+		return "", err
+	}
+	err = s.usage.Record(conversation.UserID, conversation.ID, kind, resp)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Content, nil
+}
+
+// Once the stored messages and prompts of the context are estimated above the window, every message but the most recent ones is summarised into the summary prompt of the context
+// Summarised messages are only marked, the full history stays in the database
+func (s *PromptService) compactIfNeeded(conversation entities.Context, commsManager managers.AiCommunicationManager) error {
+	prompts, err := s.repo.Query().Where("context_id = ?", conversation.ID).Order("created_at").Find(false)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_compactIfNeeded had an error when requesting prompts from repo")
+		return err
+	}
+	messages, err := s.messageRepo.Query().Where("context_id = ?", conversation.ID).Where("is_compacted = ?", false).Order("created_at").Find(false)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_compactIfNeeded had an error when requesting messages from repo")
+		return err
+	}
+	if len(messages) <= keptMessagesOnCompaction {
+		return nil
+	}
+	texts := make([]string, 0, len(prompts)+len(messages))
+	for _, prompt := range prompts {
+		texts = append(texts, prompt.Value)
+	}
+	for _, msg := range messages {
+		texts = append(texts, msg.Content)
+	}
+	estimate := util.EstimateMessageTokens(texts...)
+	if estimate <= s.windowTokens {
+		return nil
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_compactIfNeeded compacting context: %s estimated at %d tokens", conversation.ID, estimate))
+
+	previousSummary := ""
+	for _, prompt := range prompts {
+		if prompt.IsSummary {
+			previousSummary = prompt.Subject
+		}
+	}
+	compacted := messages[:len(messages)-keptMessagesOnCompaction]
+	summarizeValue, err := s.promptManager.GenerateSummarize(previousSummary, compacted, conversation.LanguageID)
+	if err != nil {
+		return err
+	}
+	resp, err := commsManager.Complete(summarizeValue)
+	if err != nil {
+		return err
+	}
+	err = s.usage.Record(conversation.UserID, conversation.ID, entities.SummaryUsage, resp)
+	if err != nil {
+		return err
+	}
+	summaryValue, err := s.promptManager.GeneratePromptWith(resp.Content, managers.Summary, conversation.LanguageID)
+	if err != nil {
+		return err
+	}
+	err = commsManager.CompactContext(conversation.ID, summaryValue, keptMessagesOnCompaction)
+	if err != nil {
+		return err
+	}
+
+	for _, prompt := range prompts {
+		if !prompt.IsSummary {
+			continue
+		}
+		err = s.repo.Delete(prompt.ID)
+		if err != nil {
+			s.logger.Error().Msg("PromptService_compactIfNeeded had an error when deleting the previous summary from repo")
+			return err
+		}
+	}
+	summary := entities.Prompt{
+		ContextID: conversation.ID,
+		Value:     summaryValue,
+		Subject:   resp.Content,
+		IsSummary: true,
+	}
+	_, err = s.repo.Create(&summary)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_compactIfNeeded had an error when saving the summary to repo")
+		return err
+	}
+	return s.markCompacted(compacted)
+}
+
+func (s *PromptService) markCompacted(messages []entities.Message) error {
+	for _, msg := range messages {
+		msg.IsCompacted = true
+		_, err := s.messageRepo.Update(&msg)
+		if err != nil {
+			s.logger.Error().Msg("PromptService_markCompacted had an error when updating the message in repo")
+			return err
+		}
+	}
+	return nil
+}
+
+// Returned chunks are the excerpts in the order they were numbered within the message
+func (s *PromptService) generateMessageWithExcerpts(request requests.CreateMessageRequest, languageID string) (string, []entities.Chunk, error) {
+	chunks, err := s.retrieval.Retrieve(request.ContextID, request.Value)
+	if err != nil {
+		return "", nil, err
+	}
+	promptValue, err := s.promptManager.GenerateMessageWith(request.Value, chunks, languageID)
+	if err != nil {
+		return "", nil, err
+	}
+	return promptValue, chunks, nil
+}
+
+// Images are dropped for models that can not look at them, the prompt still carries their recognised text
+func (s *PromptService) sendPromptWithImages(commsManager managers.AiCommunicationManager, contextID string, promptValue string, images []managers.ImageAttachment) (managers.Completion, error) {
+	if len(images) > 0 {
+		if m, ok := commsManager.(managers.ImageAiCommunicationManager); ok && m.SupportsImages() {
+			s.logger.Debug().Msg(fmt.Sprintf("PromptService_sendPromptWithImages sending %d images for context: %s", len(images), contextID))
+			return m.SendPromptWithImages(contextID, promptValue, images)
+		}
+	}
+	return commsManager.SendPrompt(contextID, promptValue)
+}
+
+func (s *PromptService) indexSource(contextID string, val any) error {
+	var err error
+	switch val := val.(type) {
+	case entities.Note:
+		_, err = s.retrieval.IndexNote(contextID, val)
+	case entities.Document:
+		_, err = s.retrieval.IndexDocument(contextID, val)
+	}
+	return err
+}
+
+func (s *PromptService) saveConversationTurn(request requests.CreateMessageRequest, resp managers.Completion, excerpts []entities.Chunk) (entities.Message, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_saveConversationTurn saving the conversation turn for context: %s", request.ContextID))
+	userMessage := entities.Message{
+		ContextID: request.ContextID,
+		Role:      entities.UserMessage,
+		Content:   request.Value,
+		Model:     resp.Model,
+	}
+	_, err := s.messageRepo.Create(&userMessage)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_saveConversationTurn had an error when saving the user message to repo")
+		return entities.Message{}, err
+	}
+	reply := entities.Message{
+		ContextID:        request.ContextID,
+		Role:             entities.AssistantMessage,
+		Content:          resp.Content,
+		Model:            resp.Model,
+		PromptTokens:     resp.PromptTokens,
+		CompletionTokens: resp.CompletionTokens,
+		Citations:        make([]entities.Citation, len(excerpts)),
+	}
+	for i, chunk := range excerpts {
+		reply.Citations[i] = entities.Citation{
+			ContextID:   request.ContextID,
+			Number:      i + 1,
+			SourceType:  chunk.SourceType,
+			SourceID:    chunk.SourceID,
+			Section:     chunk.Section,
+			StartOffset: chunk.StartOffset,
+			EndOffset:   chunk.EndOffset,
+		}
+	}
+	reply, err = s.messageRepo.Create(&reply)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_saveConversationTurn had an error when saving the reply to repo")
+		return entities.Message{}, err
+	}
 
-	return resp, nil
+	return reply, nil
 }
 
+func (s *PromptService) FilterMessages(request messageRequests.FilterMessagesRequest) (responses.PaginationResponse[entities.Message], error) {
+	if request.ContextID == "" {
+		return responses.PaginationResponse[entities.Message]{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_FilterMessages for context: %s on page: %d with size: %d", request.ContextID, request.Page, request.Size))
+	offset := request.CalculateOffset()
+
+	q := s.messageRepo.Query().Where("context_id = ?", request.ContextID)
+	if request.Roles != nil && len(*request.Roles) > 0 {
+		s.logger.Debug().Msg("*PromptService filtering message Roles")
+		q = q.Where("role IN ?", *request.Roles)
+	}
+	q = q.Order("created_at")
+	q.Offset(int(offset)).Limit(int(request.Size))
+	res, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_FilterMessages had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Message]{}, err
+	}
+	count, err := q.Count()
+	if err != nil {
+		s.logger.Error().Msg("PromptService_FilterMessages had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Message]{}, err
+	}
+	return responses.PaginationResponse[entities.Message]{Content: res, Page: request.Page, Size: len(res), TotalCount: int(count)}, nil
+}
+
+// Removes the prompt and its excerpts, then tells the assistant to forget what the prompt asked it to remember
 func (s *PromptService) DeleteAndSend(id string) (bool, error) {
-	s.logger.Debug().Msg(fmt.Sprintf("PromptService_DeleteOne has started with given id: %s", id))
-	err := s.repo.Delete(id)
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_DeleteAndSend has started with given id: %s", id))
+	found, err := s.GetOne(id)
+	if err != nil {
+		return false, err
+	}
+	// Resolved before anything is deleted, and without the budget check, as running out of budget must not keep material in the context
+	conversation, commsManager, err := s.getConversation(found.ContextID)
+	if err != nil {
+		return false, err
+	}
+	if found.EntityID != nil {
+		err = s.retrieval.RemoveSource(*found.EntityID)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = s.repo.Delete(id)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_DeleteAndSend had an error when deleting from repo")
+		return false, err
+	}
+
+	subject := found.Subject
+	if subject == "" {
+		// Prompts stored before subjects were kept are forgotten by their full text
+		subject = found.Value
+	}
+	forgetValue, err := s.promptManager.GeneratePromptWith(subject, managers.Forget, conversation.LanguageID)
+	if err != nil {
+		return false, err
+	}
+	resp, err := commsManager.SendPrompt(found.ContextID, forgetValue)
+	if err != nil {
+		return false, err
+	}
+	err = s.usage.Record(conversation.UserID, conversation.ID, entities.PromptUsage, resp)
 	if err != nil {
-		s.logger.Error().Msg("PromptService_DeleteOne had an error when deleting from repo")
 		return false, err
 	}
 
 	return true, nil
 }
 
+// Soft reset drops the conversation but keeps the material of the context, hard reset also forgets every note and document given so far
+func (s *PromptService) ResetContext(request contextRequests.ResetContextRequest) error {
+	if request.ID == "" {
+		return errors.New("argumentErrorIDMissing")
+	}
+	isSoftReset, err := request.IsSoftReset()
+	if err != nil {
+		return err
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("PromptService_ResetContext for context: %s with mode: %s", request.ID, request.Mode))
+	_, commsManager, err := s.getConversation(request.ID)
+	if err != nil {
+		return err
+	}
+	if !isSoftReset {
+		err = s.repo.DeleteWhere("context_id = ?", request.ID)
+		if err != nil {
+			s.logger.Error().Msg("PromptService_ResetContext had an error when deleting from repo")
+			return err
+		}
+		err = s.retrieval.RemoveContext(request.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = commsManager.ResetContext(request.ID, isSoftReset)
+	if err != nil {
+		return err
+	}
+
+	// Dropped messages must not come back with the next summary
+	messages, err := s.messageRepo.Query().Where("context_id = ?", request.ID).Where("is_compacted = ?", false).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("PromptService_ResetContext had an error when requesting messages from repo")
+		return err
+	}
+	return s.markCompacted(messages)
+}
+
 func (s *PromptService) UpdatePrompt(request requests.UpdatePromptRequest) (entities.Prompt, error) {
 	findRequest := requests.FindPromptByEntityAndContextRequest{
 		EntityID:  request.EntityID,
 		ContextID: request.ContextID,
 	}
 	found, err := s.FindByEntityAndContext(findRequest)
-	if err != nil {
+	if err != nil && err.Error() != "notFoundError" {
 		return entities.Prompt{}, err
 	}
-	_, err = s.DeleteAndSend(found.ID)
-	if err != nil {
-		return entities.Prompt{}, err
+	// Hard reset forgets every prompt, an edit after it is remembered again from scratch
+	if err == nil {
+		_, err = s.DeleteAndSend(found.ID)
+		if err != nil {
+			return entities.Prompt{}, err
+		}
 	}
 
-	return s.GenerateAndSendPrompt(requests.CreatePromptRequest(request))
+	return s.GenerateAndSendPrompt(requests.CreatePromptRequest{Value: request.Value, ContextID: request.ContextID, EntityID: request.EntityID})
 }
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"echo-api/managers"
+	requests "echo-api/models/dtos/requests/template"
+	responses "echo-api/models/dtos/responses/pagination"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+)
+
+type PromptTemplateService struct {
+	repo   util.Repository[entities.PromptTemplate]
+	logger *util.Logger
+}
+
+func NewPromptTemplateService(repo util.Repository[entities.PromptTemplate], logger *util.Logger) *PromptTemplateService {
+	return &PromptTemplateService{repo: repo, logger: logger}
+}
+
+func (s *PromptTemplateService) GetOne(id string) (entities.PromptTemplate, error) {
+	if id == "" {
+		return entities.PromptTemplate{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("PromptTemplateService_GetOne with id: %s", id))
+	res, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("PromptTemplateService_GetOne had an error when requesting from repo")
+		return entities.PromptTemplate{}, err
+	}
+
+	return res, nil
+}
+
+func (s *PromptTemplateService) FilterAll(request requests.FilterPromptTemplatesRequest) (responses.PaginationResponse[entities.PromptTemplate], error) {
+	s.logger.Debug().Msg(fmt.Sprintf("PromptTemplateService_FilterAll on page: %d with size: %d", request.Page, request.Size))
+	offset := request.CalculateOffset()
+
+	q := s.buildFilterQuery(request)
+	q.Offset(int(offset)).Limit(int(request.Size))
+	res, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("PromptTemplateService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.PromptTemplate]{}, err
+	}
+	count, err := q.Count()
+	if err != nil {
+		s.logger.Error().Msg("PromptTemplateService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.PromptTemplate]{}, err
+	}
+	return responses.PaginationResponse[entities.PromptTemplate]{Content: res, Page: request.Page, Size: len(res), TotalCount: int(count)}, nil
+}
+
+func (s *PromptTemplateService) buildFilterQuery(request requests.FilterPromptTemplatesRequest) util.Repository[entities.PromptTemplate] {
+	q := s.repo.Query()
+	s.logger.Debug().Msg("*PromptTemplateService started to build Filter query")
+
+	if request.Names != nil && len(*request.Names) > 0 {
+		s.logger.Debug().Msg("*PromptTemplateService filtering Names")
+		q = q.Where("name IN ?", *request.Names)
+	}
+
+	if request.LanguageIDs != nil && len(*request.LanguageIDs) > 0 {
+		s.logger.Debug().Msg("*PromptTemplateService filtering LanguageIDs")
+		q = q.Where("language_id IN ?", *request.LanguageIDs)
+	}
+
+	return q.Order("name, version")
+}
+
+func (s *PromptTemplateService) CreateOne(request requests.CreatePromptTemplateRequest) (entities.PromptTemplate, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("PromptTemplateService_CreateOne for template: %s", request.Name))
+	if _, ok := managers.DefaultTemplates[managers.PromptAction(request.Name)]; !ok {
+		return entities.PromptTemplate{}, errors.New("templateErrorUnknownName")
+	}
+	_, err := managers.ParseTemplate(request.Body)
+	if err != nil {
+		return entities.PromptTemplate{}, err
+	}
+	if request.LanguageID != nil && *request.LanguageID == "" {
+		request.LanguageID = nil
+	}
+
+	versions, err := s.findVersions(managers.PromptAction(request.Name))
+	if err != nil {
+		return entities.PromptTemplate{}, err
+	}
+	template := entities.PromptTemplate{
+		Name:       request.Name,
+		LanguageID: request.LanguageID,
+		Version:    1,
+		Body:       request.Body,
+	}
+	if latest, ok := latestVersion(versions, request.LanguageID); ok {
+		template.Version = latest.Version + 1
+	}
+	template, err = s.repo.Create(&template)
+	if err != nil {
+		s.logger.Error().Msg("PromptTemplateService_CreateOne had an error when saving to repo")
+		return entities.PromptTemplate{}, err
+	}
+
+	return template, nil
+}
+
+func (s *PromptTemplateService) UpdateOne(request requests.UpdatePromptTemplateRequest) (entities.PromptTemplate, error) {
+	found, err := s.GetOne(request.ID)
+	if err != nil {
+		return entities.PromptTemplate{}, err
+	}
+	return s.CreateOne(requests.CreatePromptTemplateRequest{Name: found.Name, LanguageID: found.LanguageID, Body: request.Body})
+}
+
+// Deleting the latest version puts the previous one back in effect
+func (s *PromptTemplateService) DeleteOne(id string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("PromptTemplateService_DeleteOne has started with given id: %s", id))
+	err := s.repo.Delete(id)
+	if err != nil {
+		s.logger.Error().Msg("PromptTemplateService_DeleteOne had an error when deleting from repo")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Falls back from the language variant to the language independent template and then to the built in default
+func (s *PromptTemplateService) GetTemplate(action managers.PromptAction, languageID string) (string, error) {
+	body, ok := managers.DefaultTemplates[action]
+	if !ok {
+		return "", errors.New("templateErrorUnknownName")
+	}
+	versions, err := s.findVersions(action)
+	if err != nil {
+		return "", err
+	}
+	if languageID != "" {
+		if latest, ok := latestVersion(versions, &languageID); ok {
+			return latest.Body, nil
+		}
+	}
+	if latest, ok := latestVersion(versions, nil); ok {
+		return latest.Body, nil
+	}
+
+	return body, nil
+}
+
+// Stores the built in templates as the first version of every name that has none yet
+func (s *PromptTemplateService) SeedDefaults() error {
+	for action, body := range managers.DefaultTemplates {
+		versions, err := s.findVersions(action)
+		if err != nil {
+			return err
+		}
+		if _, ok := latestVersion(versions, nil); ok {
+			continue
+		}
+		s.logger.Debug().Msg(fmt.Sprintf("PromptTemplateService_SeedDefaults seeding template: %s", action))
+		template := entities.PromptTemplate{Name: action.String(), Version: 1, Body: body}
+		_, err = s.repo.Create(&template)
+		if err != nil {
+			s.logger.Error().Msg("PromptTemplateService_SeedDefaults had an error when saving to repo")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *PromptTemplateService) findVersions(action managers.PromptAction) ([]entities.PromptTemplate, error) {
+	res, err := s.repo.Query().Where("name = ?", action.String()).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("PromptTemplateService_findVersions had an error when requesting from repo")
+		return nil, err
+	}
+	return res, nil
+}
+
+func latestVersion(versions []entities.PromptTemplate, languageID *string) (entities.PromptTemplate, bool) {
+	var res entities.PromptTemplate
+	found := false
+	for _, v := range versions {
+		sameLanguage := (v.LanguageID == nil && languageID == nil) || (v.LanguageID != nil && languageID != nil && *v.LanguageID == *languageID)
+		if sameLanguage && (!found || v.Version > res.Version) {
+			res = v
+			found = true
+		}
+	}
+	return res, found
+}
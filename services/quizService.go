@@ -0,0 +1,415 @@
+package services
+
+import (
+	requests "echo-api/models/dtos/requests/quiz"
+	responses "echo-api/models/dtos/responses/pagination"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const defaultQuizQuestionCount = 5
+const maxQuizQuestionCount = 20
+
+// Material beyond this estimate is left out of the quiz request
+const quizMaterialTokens = 6000
+
+type QuizService struct {
+	repo         util.Repository[entities.Quiz]
+	questionRepo util.Repository[entities.Question]
+	attemptRepo  util.Repository[entities.QuizAttempt]
+	answerRepo   util.Repository[entities.AttemptAnswer]
+	noteRepo     util.Repository[entities.Note]
+	documentRepo util.Repository[entities.Document]
+	logger       *util.Logger
+	prompts      *PromptService
+}
+
+func NewQuizService(repo util.Repository[entities.Quiz], questionRepo util.Repository[entities.Question], attemptRepo util.Repository[entities.QuizAttempt], answerRepo util.Repository[entities.AttemptAnswer], noteRepo util.Repository[entities.Note], documentRepo util.Repository[entities.Document], logger *util.Logger, ps *PromptService) *QuizService {
+	return &QuizService{repo: repo, questionRepo: questionRepo, attemptRepo: attemptRepo, answerRepo: answerRepo, noteRepo: noteRepo, documentRepo: documentRepo, logger: logger, prompts: ps}
+}
+
+func (s *QuizService) CheckIfBelongsToUser(id string, userID string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_CheckIfBelongsToUser with id: %s for user: %s", id, userID))
+	res, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_CheckIfBelongsToUser had an error when getting from repo")
+		return false, err
+	}
+
+	return userID == res.UserID, nil
+}
+
+func (s *QuizService) CheckIfAttemptBelongsToUser(id string, userID string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_CheckIfAttemptBelongsToUser with id: %s for user: %s", id, userID))
+	res, err := s.attemptRepo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_CheckIfAttemptBelongsToUser had an error when getting from repo")
+		return false, err
+	}
+
+	return userID == res.UserID, nil
+}
+
+// Questions come in the order they were generated
+func (s *QuizService) GetOne(id string) (entities.Quiz, error) {
+	if id == "" {
+		return entities.Quiz{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_GetOne with id: %s", id))
+	res, err := s.repo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_GetOne had an error when getting from repo")
+		return entities.Quiz{}, err
+	}
+	res.Questions, err = s.questionRepo.Query().Where("quiz_id = ?", id).Order("number").Find(false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_GetOne had an error when requesting questions from repo")
+		return entities.Quiz{}, err
+	}
+
+	return res, nil
+}
+
+func (s *QuizService) FilterAll(request requests.FilterQuizzesRequest) (responses.PaginationResponse[entities.Quiz], error) {
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_FilterAll on page: %d with size: %d", request.Page, request.Size))
+	offset := request.CalculateOffset()
+
+	q := s.buildFilterQuery(request)
+	q.Offset(int(offset)).Limit(int(request.Size))
+	res, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Quiz]{}, err
+	}
+	count, err := q.Count()
+	if err != nil {
+		s.logger.Error().Msg("QuizService_FilterAll had an error when requesting from repo")
+		return responses.PaginationResponse[entities.Quiz]{}, err
+	}
+	return responses.PaginationResponse[entities.Quiz]{Content: res, Page: request.Page, Size: len(res), TotalCount: int(count)}, nil
+}
+
+func (s *QuizService) buildFilterQuery(request requests.FilterQuizzesRequest) util.Repository[entities.Quiz] {
+	q := s.repo.Query()
+	s.logger.Debug().Msg("*QuizService started to build Filter query")
+
+	if request.IDs != nil && len(*request.IDs) > 0 {
+		s.logger.Debug().Msg("*QuizService filtering IDs")
+		q = q.Where("id IN ?", *request.IDs)
+	}
+
+	if request.Title != nil && *request.Title != "" {
+		s.logger.Debug().Msg("*QuizService filtering Title")
+		q = q.Where("title LIKE ?", "%"+*request.Title+"%")
+	}
+
+	if request.UserIDs != nil && len(*request.UserIDs) > 0 {
+		s.logger.Debug().Msg("*QuizService filtering UserIDs")
+		q = q.Where("user_id IN ?", *request.UserIDs)
+	}
+
+	if request.ContextIDs != nil && len(*request.ContextIDs) > 0 {
+		s.logger.Debug().Msg("*QuizService filtering ContextIDs")
+		q = q.Where("context_id IN ?", *request.ContextIDs)
+	}
+
+	return q.Order("created_at")
+}
+
+// Asks the assistant of the context for a quiz about its notes and documents
+func (s *QuizService) Generate(request requests.GenerateQuizRequest) (entities.Quiz, error) {
+	if request.UserID == nil || *request.UserID == "" || request.ContextID == "" {
+		return entities.Quiz{}, errors.New("argumentErrorIDMissing")
+	}
+	count := request.QuestionCount
+	if count <= 0 {
+		count = defaultQuizQuestionCount
+	}
+	count = min(count, maxQuizQuestionCount)
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_Generate for context: %s with %d questions", request.ContextID, count))
+
+	material, err := s.collectMaterial(request.ContextID)
+	if err != nil {
+		return entities.Quiz{}, err
+	}
+	if material == "" {
+		return entities.Quiz{}, errors.New("quizErrorNoMaterial")
+	}
+	reply, err := s.prompts.GenerateQuiz(request.ContextID, material, count)
+	if err != nil {
+		return entities.Quiz{}, err
+	}
+	questions, err := parseGeneratedQuestions(reply)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_Generate could not read the questions in the reply")
+		return entities.Quiz{}, err
+	}
+	if len(questions) > count {
+		questions = questions[:count]
+	}
+
+	title := strings.TrimSpace(request.Title)
+	if title == "" {
+		title = "Quiz of " + time.Now().Format(time.DateOnly)
+	}
+	quiz := entities.Quiz{Title: title, ContextID: request.ContextID, UserID: *request.UserID}
+	quiz, err = s.repo.Create(&quiz)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_Generate had an error when saving to repo")
+		return entities.Quiz{}, err
+	}
+	for i := range questions {
+		questions[i].QuizID = quiz.ID
+		questions[i].Number = i + 1
+	}
+	quiz.Questions, err = s.questionRepo.CreateMany(questions)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_Generate had an error when saving questions to repo")
+		return entities.Quiz{}, err
+	}
+
+	return quiz, nil
+}
+
+func (s *QuizService) DeleteOne(id string) (bool, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_DeleteOne has started with given id: %s", id))
+	err := s.repo.Delete(id)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_DeleteOne had an error when deleting from repo")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Multiple choice answers are compared with the correct option, short answers are graded by the assistant unless they match the expected answer
+func (s *QuizService) SubmitAttempt(request requests.SubmitAttemptRequest) (entities.QuizAttempt, error) {
+	if request.QuizID == "" || request.UserID == "" {
+		return entities.QuizAttempt{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_SubmitAttempt for quiz: %s by user: %s", request.QuizID, request.UserID))
+	quiz, err := s.GetOne(request.QuizID)
+	if err != nil {
+		return entities.QuizAttempt{}, err
+	}
+	given := make(map[string]requests.AnswerRequest, len(request.Answers))
+	for _, answer := range request.Answers {
+		given[answer.QuestionID] = answer
+	}
+	if len(given) != len(request.Answers) {
+		return entities.QuizAttempt{}, errors.New("argumentErrorUnknownQuestion")
+	}
+
+	answers := make([]entities.AttemptAnswer, len(quiz.Questions))
+	correctCount := 0
+	for i, question := range quiz.Questions {
+		answer := given[question.ID]
+		delete(given, question.ID)
+		answers[i], err = s.grade(quiz.ContextID, question, answer)
+		if err != nil {
+			return entities.QuizAttempt{}, err
+		}
+		if answers[i].IsCorrect {
+			correctCount++
+		}
+	}
+	if len(given) > 0 {
+		return entities.QuizAttempt{}, errors.New("argumentErrorUnknownQuestion")
+	}
+
+	attempt := entities.QuizAttempt{
+		QuizID:        quiz.ID,
+		UserID:        request.UserID,
+		CorrectCount:  correctCount,
+		QuestionCount: len(quiz.Questions),
+	}
+	if attempt.QuestionCount > 0 {
+		attempt.Score = math.Round(float64(correctCount)*10000/float64(attempt.QuestionCount)) / 100
+	}
+	attempt, err = s.attemptRepo.Create(&attempt)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_SubmitAttempt had an error when saving to repo")
+		return entities.QuizAttempt{}, err
+	}
+	for i := range answers {
+		answers[i].AttemptID = attempt.ID
+	}
+	attempt.Answers, err = s.answerRepo.CreateMany(answers)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_SubmitAttempt had an error when saving answers to repo")
+		return entities.QuizAttempt{}, err
+	}
+
+	return attempt, nil
+}
+
+func (s *QuizService) GetAttempt(id string) (entities.QuizAttempt, error) {
+	if id == "" {
+		return entities.QuizAttempt{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_GetAttempt with id: %s", id))
+	res, err := s.attemptRepo.First(id, false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_GetAttempt had an error when getting from repo")
+		return entities.QuizAttempt{}, err
+	}
+	res.Answers, err = s.answerRepo.Query().Where("attempt_id = ?", id).Order("created_at").Find(false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_GetAttempt had an error when requesting answers from repo")
+		return entities.QuizAttempt{}, err
+	}
+
+	return res, nil
+}
+
+// Score history of a user, oldest attempt first
+func (s *QuizService) FilterAttempts(request requests.FilterAttemptsRequest) (responses.PaginationResponse[entities.QuizAttempt], error) {
+	if request.UserID == "" {
+		return responses.PaginationResponse[entities.QuizAttempt]{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("QuizService_FilterAttempts for user: %s on page: %d with size: %d", request.UserID, request.Page, request.Size))
+	offset := request.CalculateOffset()
+
+	q := s.attemptRepo.Query().Where("user_id = ?", request.UserID)
+	if request.QuizIDs != nil && len(*request.QuizIDs) > 0 {
+		s.logger.Debug().Msg("*QuizService filtering attempt QuizIDs")
+		q = q.Where("quiz_id IN ?", *request.QuizIDs)
+	}
+	q = q.Order("created_at")
+	q.Offset(int(offset)).Limit(int(request.Size))
+	res, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_FilterAttempts had an error when requesting from repo")
+		return responses.PaginationResponse[entities.QuizAttempt]{}, err
+	}
+	count, err := q.Count()
+	if err != nil {
+		s.logger.Error().Msg("QuizService_FilterAttempts had an error when requesting from repo")
+		return responses.PaginationResponse[entities.QuizAttempt]{}, err
+	}
+	return responses.PaginationResponse[entities.QuizAttempt]{Content: res, Page: request.Page, Size: len(res), TotalCount: int(count)}, nil
+}
+
+func (s *QuizService) grade(contextID string, question entities.Question, answer requests.AnswerRequest) (entities.AttemptAnswer, error) {
+	res := entities.AttemptAnswer{QuestionID: question.ID, SelectedOption: answer.SelectedOption, Text: strings.TrimSpace(answer.Text)}
+	switch question.Kind {
+	case entities.MultipleChoiceQuestion:
+		res.Expected = question.Options[question.CorrectOption]
+		res.IsCorrect = answer.SelectedOption != nil && *answer.SelectedOption == question.CorrectOption
+		return res, nil
+	default:
+		res.Expected = question.Answer
+	}
+	if res.Text == "" {
+		return res, nil
+	}
+	if normalizeAnswer(res.Text) == normalizeAnswer(question.Answer) {
+		res.IsCorrect = true
+		return res, nil
+	}
+
+	reply, err := s.prompts.GradeAnswer(contextID, question.Text, question.Answer, res.Text)
+	if err != nil {
+		return entities.AttemptAnswer{}, err
+	}
+	var graded struct {
+		Correct  bool   `json:"correct"`
+		Feedback string `json:"feedback"`
+	}
+	if !unmarshalReply(reply, "{", "}", &graded) {
+		s.logger.Error().Msg("QuizService_grade could not read the grade in the reply")
+		return entities.AttemptAnswer{}, errors.New("quizErrorInvalidReply")
+	}
+	res.IsCorrect = graded.Correct
+	res.Feedback = graded.Feedback
+	return res, nil
+}
+
+// Notes come before documents, both in the order they were added, until the material estimate is reached
+func (s *QuizService) collectMaterial(contextID string) (string, error) {
+	notes, err := s.noteRepo.Query().Where("context_id = ?", contextID).Order("created_at").Find(false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_collectMaterial had an error when requesting notes from repo")
+		return "", err
+	}
+	documents, err := s.documentRepo.Query().Where("context_id = ?", contextID).Order("created_at").Find(false)
+	if err != nil {
+		s.logger.Error().Msg("QuizService_collectMaterial had an error when requesting documents from repo")
+		return "", err
+	}
+	sources := make([]string, 0, len(notes)+len(documents))
+	for _, note := range notes {
+		sources = append(sources, "Note \""+note.Header+"\"\n"+note.Payload)
+	}
+	for _, document := range documents {
+		if strings.TrimSpace(document.Text) != "" {
+			sources = append(sources, "Document \""+document.Name+"\"\n"+document.Text)
+		}
+	}
+
+	var sb strings.Builder
+	tokens := 0
+	for _, source := range sources {
+		sourceTokens := util.EstimateTokens(source)
+		if tokens+sourceTokens > quizMaterialTokens {
+			break
+		}
+		tokens += sourceTokens
+		sb.WriteString(source)
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+type generatedQuestion struct {
+	Kind          entities.QuestionKind `json:"kind"`
+	Question      string                `json:"question"`
+	Options       []string              `json:"options"`
+	CorrectOption *int                  `json:"correctOption"`
+	Answer        string                `json:"answer"`
+}
+
+// Questions that can not be graded are left out
+func parseGeneratedQuestions(reply string) ([]entities.Question, error) {
+	var generated []generatedQuestion
+	if !unmarshalReply(reply, "[", "]", &generated) {
+		return nil, errors.New("quizErrorInvalidReply")
+	}
+	res := make([]entities.Question, 0, len(generated))
+	for _, q := range generated {
+		text := strings.TrimSpace(q.Question)
+		if text == "" {
+			continue
+		}
+		switch q.Kind {
+		case entities.MultipleChoiceQuestion:
+			if len(q.Options) < 2 || q.CorrectOption == nil || *q.CorrectOption < 0 || *q.CorrectOption >= len(q.Options) {
+				continue
+			}
+			res = append(res, entities.Question{Kind: q.Kind, Text: text, Options: q.Options, CorrectOption: *q.CorrectOption})
+		case entities.ShortAnswerQuestion:
+			if strings.TrimSpace(q.Answer) == "" {
+				continue
+			}
+			res = append(res, entities.Question{Kind: q.Kind, Text: text, Answer: strings.TrimSpace(q.Answer)})
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.New("quizErrorInvalidReply")
+	}
+	return res, nil
+}
+
+// Case, punctuation and spacing do not make an answer wrong
+func normalizeAnswer(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,16 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Models tend to wrap JSON in prose or a code block, only the outermost value between the given delimiters is read
+func unmarshalReply(reply string, open string, close string, v any) bool {
+	start := strings.Index(reply, open)
+	end := strings.LastIndex(reply, close)
+	if start < 0 || end < start {
+		return false
+	}
+	return json.Unmarshal([]byte(reply[start:end+len(close)]), v) == nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"echo-api/managers"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/zeebo/blake3"
+	"gorm.io/gorm/clause"
+)
+
+const defaultRetrievalTopK = 4
+
+type RetrievalService struct {
+	repo             util.Repository[entities.Chunk]
+	cacheRepo        util.Repository[entities.CachedEmbedding]
+	logger           *util.Logger
+	chunkingManager  managers.ChunkingManager
+	embeddingManager managers.EmbeddingManager
+	topK             int
+}
+
+func NewRetrievalService(repo util.Repository[entities.Chunk], cacheRepo util.Repository[entities.CachedEmbedding], logger *util.Logger, cm managers.ChunkingManager, em managers.EmbeddingManager, topK int) *RetrievalService {
+	if topK <= 0 {
+		topK = defaultRetrievalTopK
+	}
+	return &RetrievalService{repo: repo, cacheRepo: cacheRepo, logger: logger, chunkingManager: cm, embeddingManager: em, topK: topK}
+}
+
+func (s *RetrievalService) IndexNote(contextID string, note entities.Note) ([]entities.Chunk, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("RetrievalService_IndexNote with id: %s for context: %s", note.ID, contextID))
+	return s.index(contextID, entities.NoteSource, note.ID, note.Payload, nil)
+}
+
+func (s *RetrievalService) IndexDocument(contextID string, document entities.Document) ([]entities.Chunk, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("RetrievalService_IndexDocument with id: %s for context: %s", document.ID, contextID))
+	return s.index(contextID, entities.DocumentSource, document.ID, document.Text, document.Sections)
+}
+
+func (s *RetrievalService) RemoveSource(sourceID string) error {
+	if sourceID == "" {
+		return errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("RetrievalService_RemoveSource with id: %s", sourceID))
+	err := s.repo.DeleteWhere("source_id = ?", sourceID)
+	if err != nil {
+		s.logger.Error().Msg("RetrievalService_RemoveSource had an error when deleting from repo")
+		return err
+	}
+	return nil
+}
+
+func (s *RetrievalService) RemoveContext(contextID string) error {
+	if contextID == "" {
+		return errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("RetrievalService_RemoveContext with id: %s", contextID))
+	err := s.repo.DeleteWhere("context_id = ?", contextID)
+	if err != nil {
+		s.logger.Error().Msg("RetrievalService_RemoveContext had an error when deleting from repo")
+		return err
+	}
+	return nil
+}
+
+// Returns the chunks of the context most similar to the query, best match first
+func (s *RetrievalService) Retrieve(contextID string, query string) ([]entities.Chunk, error) {
+	if contextID == "" {
+		return nil, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("RetrievalService_Retrieve for context: %s", contextID))
+	chunks, err := s.repo.Query().Where("context_id = ?", contextID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("RetrievalService_Retrieve had an error when requesting from repo")
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return chunks, nil
+	}
+	vectors, err := s.embeddingManager.Embed([]string{query})
+	if err != nil {
+		s.logger.Error().Msg("RetrievalService_Retrieve had an error when embedding the query")
+		return nil, err
+	}
+
+	scores := make(map[string]float32, len(chunks))
+	res := make([]entities.Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		score := dot(vectors[0], chunk.Embedding)
+		if score <= 0 {
+			continue
+		}
+		scores[chunk.ID] = score
+		res = append(res, chunk)
+	}
+	slices.SortStableFunc(res, func(a, b entities.Chunk) int {
+		if scores[a.ID] > scores[b.ID] {
+			return -1
+		} else if scores[a.ID] < scores[b.ID] {
+			return 1
+		}
+		return 0
+	})
+	if len(res) > s.topK {
+		res = res[:s.topK]
+	}
+
+	return res, nil
+}
+
+// Replaces every chunk of the source so re-indexing an updated source never leaves stale chunks behind
+func (s *RetrievalService) index(contextID string, sourceType entities.SourceType, sourceID string, text string, sections []entities.DocumentSection) ([]entities.Chunk, error) {
+	if contextID == "" || sourceID == "" {
+		return nil, errors.New("argumentErrorIDMissing")
+	}
+	err := s.RemoveSource(sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := s.chunkingManager.Split(text)
+	if len(parts) == 0 {
+		return make([]entities.Chunk, 0), nil
+	}
+	contents := make([]string, len(parts))
+	for i, p := range parts {
+		contents[i] = p.Content
+	}
+	vectors, err := s.embedWithCache(contents)
+	if err != nil {
+		s.logger.Error().Msg("RetrievalService_index had an error when embedding the chunks")
+		return nil, err
+	}
+
+	chunks := make([]entities.Chunk, len(parts))
+	for i, p := range parts {
+		chunks[i] = entities.Chunk{
+			ContextID:   contextID,
+			SourceType:  sourceType,
+			SourceID:    sourceID,
+			Index:       i,
+			Section:     findSection(sections, p.StartOffset),
+			Content:     p.Content,
+			StartOffset: p.StartOffset,
+			EndOffset:   p.EndOffset,
+			Embedding:   vectors[i],
+		}
+	}
+	chunks, err = s.repo.CreateMany(chunks)
+	if err != nil {
+		s.logger.Error().Msg("RetrievalService_index had an error when saving to repo")
+		return nil, err
+	}
+
+	return chunks, nil
+}
+
+// Only texts that were never embedded with the current model reach the embedding manager
+func (s *RetrievalService) embedWithCache(texts []string) ([][]float32, error) {
+	model := s.embeddingManager.Model()
+	hashes := make([]string, len(texts))
+	for i, text := range texts {
+		sum := blake3.Sum256([]byte(text))
+		hashes[i] = hex.EncodeToString(sum[:])
+	}
+	cached, err := s.cacheRepo.Query().Where("model = ?", model).Where("content_hash IN ?", hashes).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("RetrievalService_embedWithCache had an error when requesting from repo")
+		return nil, err
+	}
+	vectors := make(map[string][]float32, len(texts))
+	for _, c := range cached {
+		vectors[c.ContentHash] = c.Vector
+	}
+
+	missingTexts := make([]string, 0)
+	missingHashes := make([]string, 0)
+	for i, hash := range hashes {
+		if _, ok := vectors[hash]; ok {
+			continue
+		}
+		// Placeholder keeps a text repeated within the same call from being embedded twice
+		vectors[hash] = nil
+		missingTexts = append(missingTexts, texts[i])
+		missingHashes = append(missingHashes, hash)
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("RetrievalService_embedWithCache found %d of %d vectors in cache", len(texts)-len(missingTexts), len(texts)))
+	if len(missingTexts) > 0 {
+		embedded, err := s.embeddingManager.Embed(missingTexts)
+		if err != nil {
+			s.logger.Error().Msg("RetrievalService_embedWithCache had an error when embedding")
+			return nil, err
+		}
+		toCache := make([]entities.CachedEmbedding, len(missingTexts))
+		for i, hash := range missingHashes {
+			vectors[hash] = embedded[i]
+			toCache[i] = entities.CachedEmbedding{Model: model, ContentHash: hash, Vector: embedded[i]}
+		}
+		// Another indexing of the same text may have cached it in the meantime, its vector is as good as ours
+		_, err = s.cacheRepo.Query().Clauses(clause.OnConflict{DoNothing: true}).CreateMany(toCache)
+		if err != nil {
+			s.logger.Error().Msg("RetrievalService_embedWithCache had an error when saving to repo")
+			return nil, err
+		}
+	}
+
+	res := make([][]float32, len(texts))
+	for i, hash := range hashes {
+		res[i] = vectors[hash]
+	}
+	return res, nil
+}
+
+// Returns the title of the page or section the offset falls into
+func findSection(sections []entities.DocumentSection, offset int) string {
+	for _, section := range sections {
+		if section.StartOffset <= offset && offset < section.EndOffset {
+			return section.Title
+		}
+	}
+	return ""
+}
+
+func dot(a []float32, b []float32) float32 {
+	var res float32
+	for i := 0; i < len(a) && i < len(b); i++ {
+		res += a[i] * b[i]
+	}
+	return res
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"echo-api/managers"
+	responses "echo-api/models/dtos/responses/auth"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const defaultAccessTokenMinutes = 15
+const defaultRefreshTokenDays = 30
+
+// Time to enter the code of the authenticator after the password was accepted
+const challengeTTL = 5 * time.Minute
+const challengePurpose = "2fa"
+
+type TokenService struct {
+	refreshRepo util.Repository[entities.RefreshToken]
+	revokedRepo util.Repository[entities.RevokedToken]
+	userRepo    util.Repository[entities.User]
+	logger      *util.Logger
+	keys        managers.SigningKeyManager
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+func NewTokenService(refreshRepo util.Repository[entities.RefreshToken], revokedRepo util.Repository[entities.RevokedToken], userRepo util.Repository[entities.User], logger *util.Logger, keys managers.SigningKeyManager, accessTokenMinutes int, refreshTokenDays int) *TokenService {
+	if accessTokenMinutes <= 0 {
+		accessTokenMinutes = defaultAccessTokenMinutes
+	}
+	if refreshTokenDays <= 0 {
+		refreshTokenDays = defaultRefreshTokenDays
+	}
+	return &TokenService{
+		refreshRepo: refreshRepo,
+		revokedRepo: revokedRepo,
+		userRepo:    userRepo,
+		logger:      logger,
+		keys:        keys,
+		accessTTL:   time.Duration(accessTokenMinutes) * time.Minute,
+		refreshTTL:  time.Duration(refreshTokenDays) * 24 * time.Hour,
+	}
+}
+
+// Starts a new session, every login gets its own refresh token family
+func (s *TokenService) Issue(user entities.User) (responses.TokenPair, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("TokenService_Issue for user: %s", user.ID))
+	return s.startSession(user, false)
+}
+
+// Starts a session whose tokens carry the mfa claim
+func (s *TokenService) IssueAfterSecondFactor(user entities.User) (responses.TokenPair, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("TokenService_IssueAfterSecondFactor for user: %s", user.ID))
+	return s.startSession(user, true)
+}
+
+// Proves the password was accepted, it is only good for the second step of the login and only once
+func (s *TokenService) IssueChallenge(user entities.User) (responses.TwoFactorChallenge, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("TokenService_IssueChallenge for user: %s", user.ID))
+	jti, err := newRandomToken(16)
+	if err != nil {
+		return responses.TwoFactorChallenge{}, err
+	}
+	now := time.Now()
+	challenge, err := s.sign(jwt.MapClaims{
+		"userID":  user.ID,
+		"jti":     jti,
+		"purpose": challengePurpose,
+		"iat":     now.Unix(),
+		"exp":     now.Add(challengeTTL).Unix(),
+	})
+	if err != nil {
+		s.logger.Error().Msg("TokenService_IssueChallenge had errors when trying to get signed token string")
+		return responses.TwoFactorChallenge{}, err
+	}
+	return responses.TwoFactorChallenge{TwoFactorRequired: true, ChallengeToken: challenge, ChallengeExpiresIn: int(challengeTTL.Seconds())}, nil
+}
+
+// Rotates the refresh token, presenting an already rotated one revokes the whole session as the token was likely stolen
+func (s *TokenService) Refresh(refreshToken string) (responses.TokenPair, error) {
+	s.logger.Debug().Msg("TokenService_Refresh has started")
+	found, err := s.refreshRepo.Query().Where("token_hash = ?", hashToken(refreshToken)).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("TokenService_Refresh had an error when getting from repo")
+		return responses.TokenPair{}, err
+	}
+	if len(found) == 0 {
+		return responses.TokenPair{}, errors.New("tokenErrorRefreshInvalid")
+	}
+	current := found[0]
+	if current.RevokedAt != nil {
+		return responses.TokenPair{}, s.revokeReusedSession(current)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return responses.TokenPair{}, errors.New("tokenErrorRefreshExpired")
+	}
+	user, err := s.userRepo.First(current.UserID, false)
+	if err != nil {
+		s.logger.Error().Msg("TokenService_Refresh had an error when getting the user from repo")
+		return responses.TokenPair{}, err
+	}
+
+	// Rotated in one conditional update, so of two refreshes racing with the same token only one gets a new pair
+	rotated, err := s.refreshRepo.Query().Where("id = ?", current.ID).Where("revoked_at IS NULL").UpdateColumn("revoked_at", time.Now())
+	if err != nil {
+		s.logger.Error().Msg("TokenService_Refresh had an error when updating in repo")
+		return responses.TokenPair{}, err
+	}
+	if rotated != 1 {
+		return responses.TokenPair{}, s.revokeReusedSession(current)
+	}
+	return s.issueInFamily(user, current.FamilyID, current.SecondFactor)
+}
+
+func (s *TokenService) revokeReusedSession(token entities.RefreshToken) error {
+	s.logger.Debug().Msg(fmt.Sprintf("TokenService_Refresh got a rotated token, revoking session: %s", token.FamilyID))
+	err := s.RevokeSession(token.UserID, token.FamilyID)
+	if err != nil {
+		return err
+	}
+	return errors.New("tokenErrorRefreshReused")
+}
+
+// Revokes every refresh token of the session and denies the access tokens issued with them
+func (s *TokenService) RevokeSession(userID string, familyID string) error {
+	s.logger.Debug().Msg(fmt.Sprintf("TokenService_RevokeSession for user: %s", userID))
+	if familyID == "" {
+		return errors.New("tokenErrorClaimsNotValid")
+	}
+	tokens, err := s.refreshRepo.Query().Where("family_id = ?", familyID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("TokenService_RevokeSession had an error when getting from repo")
+		return err
+	}
+	return s.revokeTokens(userID, tokens)
+}
+
+// Logs the user out everywhere
+func (s *TokenService) RevokeAll(userID string) error {
+	s.logger.Debug().Msg(fmt.Sprintf("TokenService_RevokeAll for user: %s", userID))
+	tokens, err := s.refreshRepo.Query().Where("user_id = ?", userID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("TokenService_RevokeAll had an error when getting from repo")
+		return err
+	}
+	return s.revokeTokens(userID, tokens)
+}
+
+// Denies a single access token until it expires
+func (s *TokenService) RevokeAccessToken(userID string, jti string, expiresAt time.Time) error {
+	if jti == "" || time.Now().After(expiresAt) {
+		return nil
+	}
+	revoked, err := s.IsRevoked(jti)
+	if err != nil || revoked {
+		return err
+	}
+	_, err = s.revokedRepo.Create(&entities.RevokedToken{Jti: jti, UserID: userID, ExpiresAt: expiresAt})
+	if err != nil {
+		s.logger.Error().Msg("TokenService_RevokeAccessToken had an error when saving to repo")
+		return err
+	}
+	return nil
+}
+
+// The jti of a challenge is unique among revoked tokens, so of two logins racing with the same challenge only one can insert it
+func (s *TokenService) UseChallenge(claims jwt.MapClaims) error {
+	userID, _ := claims["userID"].(string)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	_, err := s.revokedRepo.Create(&entities.RevokedToken{Jti: jti, UserID: userID, ExpiresAt: time.Unix(int64(exp), 0)})
+	if err != nil {
+		if revoked, _ := s.IsRevoked(jti); revoked {
+			return errors.New("tokenErrorChallengeInvalid")
+		}
+		s.logger.Error().Msg("TokenService_UseChallenge had an error when saving to repo")
+		return err
+	}
+	return nil
+}
+
+func (s *TokenService) IsRevoked(jti string) (bool, error) {
+	count, err := s.revokedRepo.Query().Where("jti = ?", jti).Count()
+	if err != nil {
+		s.logger.Error().Msg("TokenService_IsRevoked had an error when counting in repo")
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Returns the claims of a valid access token that was not revoked
+func (s *TokenService) ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	// A challenge is signed with the same keys but must not open the API
+	if _, ok := claims["purpose"]; ok {
+		return nil, errors.New("tokenErrorClaimsNotValid")
+	}
+	return claims, nil
+}
+
+// Returns the claims of a challenge that was not used yet, the caller uses it up before the second factor is checked
+func (s *TokenService) ParseChallenge(tokenString string) (jwt.MapClaims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		s.logger.Debug().Msg(fmt.Sprintf("TokenService_ParseChallenge rejected the challenge: %s", err.Error()))
+		return nil, errors.New("tokenErrorChallengeInvalid")
+	}
+	if claims["purpose"] != challengePurpose {
+		return nil, errors.New("tokenErrorChallengeInvalid")
+	}
+	return claims, nil
+}
+
+func (s *TokenService) parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, s.keyFunc())
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("tokenErrorClaimsNotValid")
+	}
+	// Tokens from before revocation existed carry no jti and can not be revoked, so they are not accepted
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, errors.New("tokenErrorClaimsNotValid")
+	}
+	revoked, err := s.IsRevoked(jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("tokenErrorRevoked")
+	}
+	return claims, nil
+}
+
+func (s *TokenService) startSession(user entities.User, secondFactor bool) (responses.TokenPair, error) {
+	familyID, err := newRandomToken(16)
+	if err != nil {
+		return responses.TokenPair{}, err
+	}
+	err = s.refreshRepo.DeleteWhere("expires_at < ?", time.Now())
+	if err != nil {
+		s.logger.Error().Msg("TokenService_startSession had an error when purging expired refresh tokens from repo")
+		return responses.TokenPair{}, err
+	}
+	return s.issueInFamily(user, familyID, secondFactor)
+}
+
+func (s *TokenService) issueInFamily(user entities.User, familyID string, secondFactor bool) (responses.TokenPair, error) {
+	jti, err := newRandomToken(16)
+	if err != nil {
+		return responses.TokenPair{}, err
+	}
+	refreshToken, err := newRandomToken(32)
+	if err != nil {
+		return responses.TokenPair{}, err
+	}
+	now := time.Now()
+	accessExpiresAt := now.Add(s.accessTTL)
+	accessToken, err := s.sign(jwt.MapClaims{
+		"userID": user.ID,
+		"role":   user.Role,
+		"jti":    jti,
+		"sid":    familyID,
+		"mfa":    secondFactor,
+		"iat":    now.Unix(),
+		"exp":    accessExpiresAt.Unix(),
+	})
+	if err != nil {
+		s.logger.Error().Msg("TokenService_issueInFamily had errors when trying to get signed token string")
+		return responses.TokenPair{}, err
+	}
+
+	_, err = s.refreshRepo.Create(&entities.RefreshToken{
+		UserID:          user.ID,
+		FamilyID:        familyID,
+		TokenHash:       hashToken(refreshToken),
+		ExpiresAt:       now.Add(s.refreshTTL),
+		AccessJti:       jti,
+		AccessExpiresAt: accessExpiresAt,
+		SecondFactor:    secondFactor,
+	})
+	if err != nil {
+		s.logger.Error().Msg("TokenService_issueInFamily had an error when saving to repo")
+		return responses.TokenPair{}, err
+	}
+
+	return responses.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken, ExpiresIn: int(s.accessTTL.Seconds())}, nil
+}
+
+func (s *TokenService) sign(claims jwt.MapClaims) (string, error) {
+	key := s.keys.ActiveKey()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.SignKey)
+}
+
+func (s *TokenService) revokeTokens(userID string, tokens []entities.RefreshToken) error {
+	err := s.revokedRepo.DeleteWhere("expires_at < ?", time.Now())
+	if err != nil {
+		s.logger.Error().Msg("TokenService_revokeTokens had an error when purging expired tokens from repo")
+		return err
+	}
+	now := time.Now()
+	for _, token := range tokens {
+		if token.UserID != userID {
+			return errors.New("authorizationErrorUnauthorizedForContent")
+		}
+		err = s.RevokeAccessToken(userID, token.AccessJti, token.AccessExpiresAt)
+		if err != nil {
+			return err
+		}
+		if token.RevokedAt != nil {
+			continue
+		}
+		token.RevokedAt = &now
+		_, err = s.refreshRepo.Update(&token)
+		if err != nil {
+			s.logger.Error().Msg("TokenService_revokeTokens had an error when updating in repo")
+			return err
+		}
+	}
+	return nil
+}
+
+// The key is picked by the kid header, a token has to use the algorithm of its key so a public key is never taken for an HMAC secret
+func (s *TokenService) keyFunc() jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := s.keys.GetKey(kid)
+		if err != nil {
+			s.logger.Debug().Msg(fmt.Sprintf("TokenService_keyFunc got a token signed with an unknown key: %s", kid))
+			return nil, err
+		}
+		if token.Method.Alg() != key.Algorithm {
+			s.logger.Error().Msg(fmt.Sprintf("TokenService_keyFunc got a token signed with %s for a %s key", token.Method.Alg(), key.Algorithm))
+			return nil, errors.New("tokenErrorUnexpectedAlgorithm")
+		}
+		return key.VerifyKey, nil
+	}
+}
+
+// Public keys to verify tokens with, for services that trust this API
+func (s *TokenService) PublicKeys() managers.JsonWebKeySet {
+	return s.keys.PublicKeys()
+}
+
+// Refresh tokens are random, so a fast unsalted hash is enough to keep them useless when the table leaks
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRandomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,314 @@
+package services
+
+import (
+	"crypto/rand"
+	"echo-api/managers"
+	responses "echo-api/models/dtos/responses/auth"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const recoveryCodeCount = 10
+const recoveryCodeLength = 10
+
+// Wrong codes in a row before the second factor is locked, six digit codes can not be guessed in that many tries
+const maxSecondFactorAttempts = 5
+const secondFactorLockout = 15 * time.Minute
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type TwoFactorService struct {
+	twoFactorRepo    util.Repository[entities.TwoFactor]
+	recoveryRepo     util.Repository[entities.RecoveryCode]
+	userRepo         util.Repository[entities.User]
+	logger           *util.Logger
+	totp             managers.TotpManager
+	hasher           managers.HashingManager
+	requireForAdmins bool
+}
+
+func NewTwoFactorService(twoFactorRepo util.Repository[entities.TwoFactor], recoveryRepo util.Repository[entities.RecoveryCode], userRepo util.Repository[entities.User], logger *util.Logger, totp managers.TotpManager, hasher managers.HashingManager, requireForAdmins bool) *TwoFactorService {
+	return &TwoFactorService{
+		twoFactorRepo:    twoFactorRepo,
+		recoveryRepo:     recoveryRepo,
+		userRepo:         userRepo,
+		logger:           logger,
+		totp:             totp,
+		hasher:           hasher,
+		requireForAdmins: requireForAdmins,
+	}
+}
+
+// Admins can be made to use two-factor authentication by configuration, everyone else opts in
+func (s *TwoFactorService) IsRequiredFor(role entities.Role) bool {
+	return s.requireForAdmins && role == entities.Admin
+}
+
+func (s *TwoFactorService) IsEnabled(userID string) (bool, error) {
+	twoFactor, found, err := s.find(userID)
+	if err != nil {
+		return false, err
+	}
+	return found && twoFactor.EnabledAt != nil, nil
+}
+
+// Starting over before activation replaces the secret, so a QR code that was not scanned does not linger
+func (s *TwoFactorService) Enroll(userID string) (responses.TwoFactorEnrollment, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("TwoFactorService_Enroll for user: %s", userID))
+	user, err := s.userRepo.First(userID, false)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_Enroll had an error when getting the user from repo")
+		return responses.TwoFactorEnrollment{}, err
+	}
+	twoFactor, found, err := s.find(userID)
+	if err != nil {
+		return responses.TwoFactorEnrollment{}, err
+	}
+	if found && twoFactor.EnabledAt != nil {
+		return responses.TwoFactorEnrollment{}, errors.New("twoFactorErrorAlreadyEnabled")
+	}
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_Enroll had an error when generating the secret")
+		return responses.TwoFactorEnrollment{}, err
+	}
+
+	if found {
+		twoFactor = entities.TwoFactor{Base: twoFactor.Base, UserID: userID, Secret: secret}
+		_, err = s.twoFactorRepo.Update(&twoFactor)
+	} else {
+		_, err = s.twoFactorRepo.Create(&entities.TwoFactor{UserID: userID, Secret: secret})
+	}
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_Enroll had an error when saving to repo")
+		return responses.TwoFactorEnrollment{}, err
+	}
+	return responses.TwoFactorEnrollment{Secret: secret, OtpauthUri: s.totp.ProvisioningUri(secret, user.Email)}, nil
+}
+
+// A code from the app proves it was set up right, only then the login asks for codes
+func (s *TwoFactorService) Activate(userID string, code string) (responses.RecoveryCodes, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("TwoFactorService_Activate for user: %s", userID))
+	twoFactor, found, err := s.find(userID)
+	if err != nil {
+		return responses.RecoveryCodes{}, err
+	}
+	if !found {
+		return responses.RecoveryCodes{}, errors.New("twoFactorErrorNotEnrolled")
+	}
+	if twoFactor.EnabledAt != nil {
+		return responses.RecoveryCodes{}, errors.New("twoFactorErrorAlreadyEnabled")
+	}
+	err = s.verify(&twoFactor, code, false)
+	if err != nil {
+		return responses.RecoveryCodes{}, err
+	}
+
+	now := time.Now()
+	twoFactor.EnabledAt = &now
+	_, err = s.twoFactorRepo.Update(&twoFactor)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_Activate had an error when updating in repo")
+		return responses.RecoveryCodes{}, err
+	}
+	return s.replaceRecoveryCodes(userID)
+}
+
+// Checks a code of the authenticator or an unused recovery code, which is used up by it
+func (s *TwoFactorService) VerifyCode(userID string, code string) error {
+	s.logger.Debug().Msg(fmt.Sprintf("TwoFactorService_VerifyCode for user: %s", userID))
+	twoFactor, err := s.findEnabled(userID)
+	if err != nil {
+		return err
+	}
+	return s.verify(&twoFactor, code, true)
+}
+
+// Earlier recovery codes stop working
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID string, code string) (responses.RecoveryCodes, error) {
+	s.logger.Debug().Msg(fmt.Sprintf("TwoFactorService_RegenerateRecoveryCodes for user: %s", userID))
+	err := s.VerifyCode(userID, code)
+	if err != nil {
+		return responses.RecoveryCodes{}, err
+	}
+	return s.replaceRecoveryCodes(userID)
+}
+
+func (s *TwoFactorService) Disable(userID string, code string) error {
+	s.logger.Debug().Msg(fmt.Sprintf("TwoFactorService_Disable for user: %s", userID))
+	user, err := s.userRepo.First(userID, false)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_Disable had an error when getting the user from repo")
+		return err
+	}
+	if s.IsRequiredFor(user.Role) {
+		return errors.New("twoFactorErrorRequired")
+	}
+	twoFactor, err := s.findEnabled(userID)
+	if err != nil {
+		return err
+	}
+	err = s.verify(&twoFactor, code, true)
+	if err != nil {
+		return err
+	}
+
+	err = s.twoFactorRepo.Delete(twoFactor.ID)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_Disable had an error when deleting from repo")
+		return err
+	}
+	err = s.recoveryRepo.DeleteWhere("user_id = ?", userID)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_Disable had an error when deleting recovery codes from repo")
+		return err
+	}
+	return nil
+}
+
+// Counts wrong codes and locks the second factor for a while once there are too many, a replayed code counts as wrong
+func (s *TwoFactorService) verify(twoFactor *entities.TwoFactor, code string, allowRecovery bool) error {
+	now := time.Now()
+	if twoFactor.LockedUntil != nil && now.Before(*twoFactor.LockedUntil) {
+		return errors.New("twoFactorErrorLocked")
+	}
+	code = strings.TrimSpace(code)
+	accepted := false
+	if step, ok := s.totp.Validate(twoFactor.Secret, code, now); ok && step > twoFactor.LastUsedStep {
+		twoFactor.LastUsedStep = step
+		accepted = true
+	}
+	if !accepted && allowRecovery {
+		used, err := s.useRecoveryCode(twoFactor.UserID, code)
+		if err != nil {
+			return err
+		}
+		accepted = used
+	}
+
+	if accepted {
+		twoFactor.FailedAttempts = 0
+		twoFactor.LockedUntil = nil
+	} else {
+		twoFactor.FailedAttempts++
+		if twoFactor.FailedAttempts >= maxSecondFactorAttempts {
+			s.logger.Debug().Msg(fmt.Sprintf("TwoFactorService_verify locked the second factor of user: %s", twoFactor.UserID))
+			until := now.Add(secondFactorLockout)
+			twoFactor.LockedUntil = &until
+			twoFactor.FailedAttempts = 0
+		}
+	}
+	_, err := s.twoFactorRepo.Update(twoFactor)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_verify had an error when updating in repo")
+		return err
+	}
+	if !accepted {
+		return errors.New("twoFactorErrorInvalidCode")
+	}
+	return nil
+}
+
+func (s *TwoFactorService) useRecoveryCode(userID string, code string) (bool, error) {
+	normalized := normalizeRecoveryCode(code)
+	if len(normalized) != recoveryCodeLength {
+		return false, nil
+	}
+	codes, err := s.recoveryRepo.Query().Where("user_id = ?", userID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_useRecoveryCode had an error when getting from repo")
+		return false, err
+	}
+	for _, recovery := range codes {
+		if recovery.UsedAt != nil {
+			continue
+		}
+		ok, err := s.hasher.Verify(recovery.CodeHash, normalized)
+		if err != nil {
+			s.logger.Error().Msg("TwoFactorService_useRecoveryCode had an error when verifying a code")
+			return false, err
+		}
+		if !ok {
+			continue
+		}
+		// Used up in one conditional update, so a code can not be redeemed twice by racing logins
+		used, err := s.recoveryRepo.Query().Where("id = ?", recovery.ID).Where("used_at IS NULL").UpdateColumn("used_at", time.Now())
+		if err != nil {
+			s.logger.Error().Msg("TwoFactorService_useRecoveryCode had an error when updating in repo")
+			return false, err
+		}
+		return used == 1, nil
+	}
+	return false, nil
+}
+
+func (s *TwoFactorService) replaceRecoveryCodes(userID string) (responses.RecoveryCodes, error) {
+	err := s.recoveryRepo.DeleteWhere("user_id = ?", userID)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_replaceRecoveryCodes had an error when deleting from repo")
+		return responses.RecoveryCodes{}, err
+	}
+	res := responses.RecoveryCodes{Codes: make([]string, 0, recoveryCodeCount)}
+	stored := make([]entities.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return responses.RecoveryCodes{}, err
+		}
+		hash, err := s.hasher.GetHash(code)
+		if err != nil {
+			s.logger.Error().Msg("TwoFactorService_replaceRecoveryCodes had an error when hashing a code")
+			return responses.RecoveryCodes{}, err
+		}
+		stored = append(stored, entities.RecoveryCode{UserID: userID, CodeHash: hash})
+		res.Codes = append(res.Codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+	}
+	_, err = s.recoveryRepo.CreateMany(stored)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_replaceRecoveryCodes had an error when saving to repo")
+		return responses.RecoveryCodes{}, err
+	}
+	return res, nil
+}
+
+func (s *TwoFactorService) find(userID string) (entities.TwoFactor, bool, error) {
+	found, err := s.twoFactorRepo.Query().Where("user_id = ?", userID).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("TwoFactorService_find had an error when getting from repo")
+		return entities.TwoFactor{}, false, err
+	}
+	if len(found) == 0 {
+		return entities.TwoFactor{}, false, nil
+	}
+	return found[0], true, nil
+}
+
+func (s *TwoFactorService) findEnabled(userID string) (entities.TwoFactor, error) {
+	twoFactor, found, err := s.find(userID)
+	if err != nil {
+		return entities.TwoFactor{}, err
+	}
+	if !found || twoFactor.EnabledAt == nil {
+		return entities.TwoFactor{}, errors.New("twoFactorErrorNotEnrolled")
+	}
+	return twoFactor, nil
+}
+
+// Codes are shown in two groups of five, users may type them with or without the dash and in any case
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeLength*5/8)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(recoveryCodeEncoding.EncodeToString(buf)), nil
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"echo-api/managers"
+	requests "echo-api/models/dtos/requests/user"
+	responses "echo-api/models/dtos/responses/usage"
+	"echo-api/models/entities"
+	"echo-api/util"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type UsageService struct {
+	repo     util.Repository[entities.UsageRecord]
+	userRepo util.Repository[entities.User]
+	logger   *util.Logger
+	budgets  map[string]util.TokenBudget
+}
+
+func NewUsageService(repo util.Repository[entities.UsageRecord], userRepo util.Repository[entities.User], logger *util.Logger, budgets map[string]util.TokenBudget) *UsageService {
+	if budgets == nil {
+		budgets = make(map[string]util.TokenBudget)
+	}
+	return &UsageService{repo: repo, userRepo: userRepo, logger: logger, budgets: budgets}
+}
+
+func (s *UsageService) Record(userID string, contextID string, kind entities.UsageKind, completion managers.Completion) error {
+	if userID == "" {
+		return errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("UsageService_Record %d tokens of %s for user: %s", completion.PromptTokens+completion.CompletionTokens, completion.Model, userID))
+	record := entities.UsageRecord{
+		UserID:           userID,
+		ContextID:        contextID,
+		Kind:             kind,
+		Model:            completion.Model,
+		PromptTokens:     completion.PromptTokens,
+		CompletionTokens: completion.CompletionTokens,
+	}
+	_, err := s.repo.Create(&record)
+	if err != nil {
+		s.logger.Error().Msg("UsageService_Record had an error when saving to repo")
+		return err
+	}
+
+	return nil
+}
+
+// Fails once the user has spent the daily or monthly budget of their role, a call is never cut off half way so the last one may overshoot
+func (s *UsageService) CheckBudget(userID string) error {
+	budget, usedToday, usedThisMonth, err := s.getBudgetState(userID)
+	if err != nil {
+		return err
+	}
+	if budget.Daily > 0 && usedToday >= budget.Daily {
+		return errors.New("budgetErrorDailyExceeded")
+	}
+	if budget.Monthly > 0 && usedThisMonth >= budget.Monthly {
+		return errors.New("budgetErrorMonthlyExceeded")
+	}
+
+	return nil
+}
+
+func (s *UsageService) GetUserUsage(request requests.FilterUsageRequest) (responses.UserUsage, error) {
+	if request.UserID == "" {
+		return responses.UserUsage{}, errors.New("argumentErrorIDMissing")
+	}
+	s.logger.Debug().Msg(fmt.Sprintf("UsageService_GetUserUsage for user: %s", request.UserID))
+	from, to := getUsagePeriod(request)
+	records, err := s.repo.Query().Where("user_id = ?", request.UserID).Where("created_at >= ?", from).Where("created_at < ?", to).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("UsageService_GetUserUsage had an error when requesting from repo")
+		return responses.UserUsage{}, err
+	}
+	budget, usedToday, usedThisMonth, err := s.getBudgetState(request.UserID)
+	if err != nil {
+		return responses.UserUsage{}, err
+	}
+
+	return responses.UserUsage{
+		UsageSummary:  summarizeUsage(request.UserID, from, to, records),
+		DailyLimit:    budget.Daily,
+		MonthlyLimit:  budget.Monthly,
+		UsedToday:     usedToday,
+		UsedThisMonth: usedThisMonth,
+	}, nil
+}
+
+// Summarizes every user with usage in the period, biggest spender first
+func (s *UsageService) Report(request requests.FilterUsageRequest) ([]responses.UsageSummary, error) {
+	from, to := getUsagePeriod(request)
+	s.logger.Debug().Msg(fmt.Sprintf("UsageService_Report from: %s to: %s", from.Format(time.DateOnly), to.Format(time.DateOnly)))
+	q := s.repo.Query().Where("created_at >= ?", from).Where("created_at < ?", to)
+	if request.UserID != "" {
+		q = q.Where("user_id = ?", request.UserID)
+	}
+	records, err := q.Find(false)
+	if err != nil {
+		s.logger.Error().Msg("UsageService_Report had an error when requesting from repo")
+		return nil, err
+	}
+
+	byUser := make(map[string][]entities.UsageRecord)
+	for _, r := range records {
+		byUser[r.UserID] = append(byUser[r.UserID], r)
+	}
+	res := make([]responses.UsageSummary, 0, len(byUser))
+	for userID, userRecords := range byUser {
+		res = append(res, summarizeUsage(userID, from, to, userRecords))
+	}
+	slices.SortFunc(res, func(a, b responses.UsageSummary) int {
+		if a.TotalTokens != b.TotalTokens {
+			return b.TotalTokens - a.TotalTokens
+		}
+		return strings.Compare(a.UserID, b.UserID)
+	})
+
+	return res, nil
+}
+
+func (s *UsageService) getBudgetState(userID string) (util.TokenBudget, int, int, error) {
+	if userID == "" {
+		return util.TokenBudget{}, 0, 0, errors.New("argumentErrorIDMissing")
+	}
+	user, err := s.userRepo.First(userID, false)
+	if err != nil {
+		s.logger.Error().Msg("UsageService_getBudgetState had an error when requesting the user from repo")
+		return util.TokenBudget{}, 0, 0, err
+	}
+	budget := s.budgets[user.Role.ToString()]
+
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	records, err := s.repo.Query().Where("user_id = ?", userID).Where("created_at >= ?", monthStart).Find(false)
+	if err != nil {
+		s.logger.Error().Msg("UsageService_getBudgetState had an error when requesting from repo")
+		return util.TokenBudget{}, 0, 0, err
+	}
+	usedToday, usedThisMonth := 0, 0
+	for _, r := range records {
+		usedThisMonth += r.TotalTokens()
+		if !r.CreatedAt.Before(dayStart) {
+			usedToday += r.TotalTokens()
+		}
+	}
+
+	return budget, usedToday, usedThisMonth, nil
+}
+
+func getUsagePeriod(request requests.FilterUsageRequest) (time.Time, time.Time) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+	if request.From != nil {
+		from = *request.From
+	}
+	if request.To != nil {
+		to = *request.To
+	}
+	return from, to
+}
+
+func summarizeUsage(userID string, from time.Time, to time.Time, records []entities.UsageRecord) responses.UsageSummary {
+	res := responses.UsageSummary{UserID: userID, From: from, To: to, Models: make([]responses.ModelUsage, 0)}
+	byModel := make(map[string]int)
+	for _, r := range records {
+		res.Calls++
+		res.PromptTokens += r.PromptTokens
+		res.CompletionTokens += r.CompletionTokens
+		res.TotalTokens += r.TotalTokens()
+
+		i, ok := byModel[r.Model]
+		if !ok {
+			i = len(res.Models)
+			byModel[r.Model] = i
+			res.Models = append(res.Models, responses.ModelUsage{Model: r.Model})
+		}
+		res.Models[i].Calls++
+		res.Models[i].PromptTokens += r.PromptTokens
+		res.Models[i].CompletionTokens += r.CompletionTokens
+		res.Models[i].TotalTokens += r.TotalTokens()
+	}
+	slices.SortFunc(res.Models, func(a, b responses.ModelUsage) int {
+		return strings.Compare(a.Model, b.Model)
+	})
+	return res
+}
@@ -8,18 +8,22 @@ import (
 	"echo-api/util"
 	"errors"
 	"fmt"
+	"net/mail"
+	"strings"
 
 	"gorm.io/gorm/clause"
 )
 
 type UserService struct {
-	repo   util.Repository[entities.User]
-	hasher managers.HashingManager
-	logger *util.Logger
+	repo     util.Repository[entities.User]
+	hasher   managers.HashingManager
+	policy   managers.PasswordPolicyManager
+	accounts *AccountService
+	logger   *util.Logger
 }
 
-func NewUserService(repo util.Repository[entities.User], logger *util.Logger, hasher managers.HashingManager) *UserService {
-	return &UserService{repo: repo, logger: logger, hasher: hasher}
+func NewUserService(repo util.Repository[entities.User], logger *util.Logger, hasher managers.HashingManager, policy managers.PasswordPolicyManager, accounts *AccountService) *UserService {
+	return &UserService{repo: repo, logger: logger, hasher: hasher, policy: policy, accounts: accounts}
 }
 
 func (s *UserService) GetOne(id string) (entities.User, error) {
@@ -79,9 +83,26 @@ func (s *UserService) buildFilterQuery(request requests.FilterUsersRequest) util
 	return q.Order("created_at")
 }
 
-// TODO do email and password check if not good enough reject creation
+// The verification mail is best effort, the user can ask for it again when it does not arrive
 func (s *UserService) CreateOne(request requests.CreateUserRequest) (entities.User, error) {
 	s.logger.Debug().Msg("UserService_CreateOne has started")
+	email, err := normalizeEmail(request.Email)
+	if err != nil {
+		return entities.User{}, err
+	}
+	count, err := s.repo.Query().Where("email = ?", email).Count()
+	if err != nil {
+		s.logger.Error().Msg("UserService_CreateOne had an error when counting in repo")
+		return entities.User{}, err
+	}
+	if count > 0 {
+		return entities.User{}, errors.New("userErrorEmailTaken")
+	}
+	err = s.policy.Check(request.Password, email, request.Name)
+	if err != nil {
+		return entities.User{}, err
+	}
+
 	s.logger.Debug().Msg("UserService_CreateOne trying to get password hash")
 	hash, err := s.hasher.GetHash(request.Password)
 	if err != nil {
@@ -91,7 +112,7 @@ func (s *UserService) CreateOne(request requests.CreateUserRequest) (entities.Us
 
 	user := entities.User{
 		Name:     request.Name,
-		Email:    request.Email,
+		Email:    email,
 		Password: entities.Password{Value: hash},
 		Role:     entities.Customer,
 	}
@@ -100,9 +121,23 @@ func (s *UserService) CreateOne(request requests.CreateUserRequest) (entities.Us
 		s.logger.Error().Msg("UserService_CreateOne had an error when saving to repo")
 		return entities.User{}, err
 	}
+	err = s.accounts.SendEmailVerification(user)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("UserService_CreateOne could not send the verification mail")
+	}
 	return user, nil
 }
 
+// Only a bare address is accepted, "Jane <jane@mail.com>" would not match the email given at login
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", errors.New("userErrorInvalidEmail")
+	}
+	return email, nil
+}
+
 func (s *UserService) DeleteOne(id string) (bool, error) {
 	s.logger.Debug().Msg(fmt.Sprintf("UserService_DeleteOne has started with given id: %s", id))
 	err := s.repo.Delete(id)
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/auth"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testNewPassword = "Another_pass_9876"
+
+func TestResetPasswordReplacesPasswordAndEndsSessions(t *testing.T) {
+	env := getMockedAccountService()
+	session, _ := env.tokens.Issue(env.user)
+	err := env.service.ForgotPassword(auth.ForgotPasswordRequest{Email: env.user.Email})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if len(env.mails.Sent) != 1 || env.mails.Sent[0].To != env.user.Email {
+		t.Errorf("Expected a reset mail to the user but got %v", env.mails.Sent)
+		return
+	}
+
+	err = env.service.ResetPassword(auth.ResetPasswordRequest{Token: tokenOfMail(env.mails.Sent[0].Body), Password: testNewPassword})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	password, _ := env.passwords.First("1", false)
+	if password.Value != testNewPassword {
+		t.Errorf("Expected the new password to be stored but got %s", password.Value)
+		return
+	}
+	if _, err = env.tokens.Refresh(session.RefreshToken); err == nil {
+		t.Errorf("Expected the sessions from before the reset to be ended")
+		return
+	}
+	verified, _ := env.users.First(env.user.ID, false)
+	if verified.EmailVerifiedAt == nil {
+		t.Errorf("Expected the reset to verify the email")
+		return
+	}
+}
+
+func TestResetPasswordTokenIsSingleUse(t *testing.T) {
+	env := getMockedAccountService()
+	env.service.ForgotPassword(auth.ForgotPasswordRequest{Email: env.user.Email})
+	token := tokenOfMail(env.mails.Sent[0].Body)
+	err := env.service.ResetPassword(auth.ResetPasswordRequest{Token: token, Password: testNewPassword})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	err = env.service.ResetPassword(auth.ResetPasswordRequest{Token: token, Password: "Third_pass_5555"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+	if err.Error() != "accountErrorTokenInvalid" {
+		t.Errorf("Expected \"accountErrorTokenInvalid\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestResetPasswordKeepsTokenForWeakPassword(t *testing.T) {
+	env := getMockedAccountService()
+	env.service.ForgotPassword(auth.ForgotPasswordRequest{Email: env.user.Email})
+	token := tokenOfMail(env.mails.Sent[0].Body)
+	err := env.service.ResetPassword(auth.ResetPasswordRequest{Token: token, Password: "weakpassword"})
+	if err == nil || err.Error() != "passwordErrorTooWeak" {
+		t.Errorf("Expected \"passwordErrorTooWeak\" but got %v", err)
+		return
+	}
+
+	err = env.service.ResetPassword(auth.ResetPasswordRequest{Token: token, Password: testNewPassword})
+	if err != nil {
+		t.Errorf("Expected the token to still work but got %s", err.Error())
+		return
+	}
+}
+
+func TestResetPasswordRejectsExpiredToken(t *testing.T) {
+	env := getMockedAccountService()
+	env.service.ForgotPassword(auth.ForgotPasswordRequest{Email: env.user.Email})
+	stored, _ := env.accountTokens.First("1", false)
+	stored.ExpiresAt = time.Now().Add(-time.Minute)
+	env.accountTokens.Update(&stored)
+
+	err := env.service.ResetPassword(auth.ResetPasswordRequest{Token: tokenOfMail(env.mails.Sent[0].Body), Password: testNewPassword})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+	if err.Error() != "accountErrorTokenExpired" {
+		t.Errorf("Expected \"accountErrorTokenExpired\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestForgotPasswordReplacesEarlierLink(t *testing.T) {
+	env := getMockedAccountService()
+	env.service.ForgotPassword(auth.ForgotPasswordRequest{Email: env.user.Email})
+	env.service.ForgotPassword(auth.ForgotPasswordRequest{Email: env.user.Email})
+
+	err := env.service.ResetPassword(auth.ResetPasswordRequest{Token: tokenOfMail(env.mails.Sent[0].Body), Password: testNewPassword})
+	if err == nil || err.Error() != "accountErrorTokenInvalid" {
+		t.Errorf("Expected the first link to stop working but got %v", err)
+		return
+	}
+	err = env.service.ResetPassword(auth.ResetPasswordRequest{Token: tokenOfMail(env.mails.Sent[1].Body), Password: testNewPassword})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+}
+
+func TestForgotPasswordOfUnknownEmailSendsNothing(t *testing.T) {
+	env := getMockedAccountService()
+	err := env.service.ForgotPassword(auth.ForgotPasswordRequest{Email: "nobody@example.com"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(env.mails.Sent) != 0 {
+		t.Errorf("Expected no mails but got %v", env.mails.Sent)
+		return
+	}
+}
+
+func TestVerifyEmailMarksUserVerified(t *testing.T) {
+	env := getMockedAccountService()
+	err := env.service.SendEmailVerification(env.user)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	token := tokenOfMail(env.mails.Sent[0].Body)
+
+	err = env.service.ResetPassword(auth.ResetPasswordRequest{Token: token, Password: testNewPassword})
+	if err == nil || err.Error() != "accountErrorTokenInvalid" {
+		t.Errorf("Expected a verification token to not reset passwords but got %v", err)
+		return
+	}
+	err = env.service.VerifyEmail(auth.VerifyEmailRequest{Token: token})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	verified, _ := env.users.First(env.user.ID, false)
+	if verified.EmailVerifiedAt == nil {
+		t.Errorf("Expected the email to be verified")
+		return
+	}
+	err = env.service.ResendEmailVerification(auth.ResendVerificationRequest{Email: env.user.Email})
+	if err != nil || len(env.mails.Sent) != 1 {
+		t.Errorf("Expected no mail for a verified email but got %v %v", err, env.mails.Sent)
+		return
+	}
+}
+
+type accountTestEnv struct {
+	service       *services.AccountService
+	tokens        *services.TokenService
+	mails         *mocks.MockMailManager
+	users         *mocks.MockRepository[entities.User]
+	passwords     *mocks.MockRepository[entities.Password]
+	accountTokens *mocks.MockRepository[entities.AccountToken]
+	user          entities.User
+}
+
+func getMockedAccountService() accountTestEnv {
+	env := accountTestEnv{
+		mails:         mocks.NewMockMailManager(),
+		users:         mocks.NewMockRepo[entities.User](),
+		passwords:     mocks.NewMockRepo[entities.Password](),
+		accountTokens: mocks.NewMockRepo[entities.AccountToken](),
+	}
+	env.user, _ = env.users.Create(&entities.User{Name: "Ada", Email: "ada@example.com", Role: entities.Customer})
+	env.passwords.Create(&entities.Password{UserID: env.user.ID, Value: "!testPass_4251"})
+	keys, _ := implementations.NewConfiguredSigningKeyManager(&util.Configuration{JwtSecret: testSigningSecret}, getTestLogger())
+	env.tokens = services.NewTokenService(mocks.NewMockRepo[entities.RefreshToken](), mocks.NewMockRepo[entities.RevokedToken](), env.users, getTestLogger(), keys, 0, 0)
+	policy := implementations.NewConfiguredPasswordPolicyManager(&util.Configuration{})
+	env.service = services.NewAccountService(env.accountTokens, env.users, env.passwords, getTestLogger(), env.mails, policy, mocks.NewMockHashingManager(), env.tokens, "https://app.test/")
+	return env
+}
+
+func tokenOfMail(body string) string {
+	start := strings.Index(body, "token=") + len("token=")
+	return body[start : start+strings.IndexAny(body[start:], "\n ")]
+}
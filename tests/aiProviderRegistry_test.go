@@ -0,0 +1,321 @@
+package tests
+
+import (
+	"context"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	requests "echo-api/models/dtos/requests/context"
+	"echo-api/models/dtos/requests/prompt"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+type recordedAnthropicRequest struct {
+	Model     string `json:"model"`
+	MaxTokens int    `json:"max_tokens"`
+	System    string `json:"system"`
+	Messages  []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+	Stream bool `json:"stream"`
+}
+
+func TestAnthropicSendMessageMovesSystemPromptOutOfMessages(t *testing.T) {
+	var received recordedAnthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("Expected /v1/messages but got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("Expected api key and version headers but got %v", r.Header)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"model":"claude-test","content":[{"type":"text","text":"hello "},{"type":"text","text":"there"}],"usage":{"input_tokens":12,"output_tokens":2}}`))
+	}))
+	defer server.Close()
+
+	m := implementations.NewAnthropicCommunicationManager(util.AiProvider{BaseUrl: server.URL, ApiKey: "test-key"}, "claude-test", getTestLogger())
+	res, err := m.SendMessage("ctx", "Message(hi)")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Content != "hello there" || res.PromptTokens != 12 || res.CompletionTokens != 2 {
+		t.Errorf("Expected the reply with its usage but got %v", res)
+		return
+	}
+	if received.System == "" || len(received.Messages) != 1 || received.Messages[0].Role != "user" {
+		t.Errorf("Expected the system prompt apart from a single user message but got %v", received)
+		return
+	}
+	if received.MaxTokens <= 0 {
+		t.Errorf("Expected a default max_tokens but got %d", received.MaxTokens)
+		return
+	}
+}
+
+func TestAnthropicStreamMessageRelaysDeltas(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-test\",\"content\":[],\"usage\":{\"input_tokens\":7,\"output_tokens\":1}}}\n\n"))
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n"))
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n"))
+		w.Write([]byte("event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":2}}\n\n"))
+		w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer server.Close()
+
+	m := implementations.NewAnthropicCommunicationManager(util.AiProvider{BaseUrl: server.URL}, "claude-test", getTestLogger())
+	deltas := make([]string, 0)
+	res, err := m.StreamMessage(context.Background(), "ctx", "Message(hi)", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(deltas) != 2 || res.Content != "Hello" {
+		t.Errorf("Expected deltas of %s but got %v", "Hello", deltas)
+		return
+	}
+	if res.PromptTokens != 7 || res.CompletionTokens != 2 || res.Model != "claude-test" {
+		t.Errorf("Expected usage of the stream but got %v", res)
+		return
+	}
+}
+
+func TestAnthropicMapsProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer server.Close()
+
+	m := implementations.NewAnthropicCommunicationManager(util.AiProvider{BaseUrl: server.URL}, "claude-test", getTestLogger())
+	_, err := m.SendMessage("ctx", "Message(hi)")
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "aiErrorProviderUnauthorized" {
+		t.Errorf("Expected \"aiErrorProviderUnauthorized\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestOllamaSendMessageReturnsReplyWithUsage(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("Expected /api/chat but got %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"model":"llama-test","message":{"role":"assistant","content":"hello there"},"done":true,"prompt_eval_count":12,"eval_count":2}`))
+	}))
+	defer server.Close()
+
+	m := implementations.NewOllamaCommunicationManager(util.AiProvider{BaseUrl: server.URL}, "llama-test", getTestLogger())
+	res, err := m.SendMessage("ctx", "Message(hi)")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if received["stream"] != false {
+		t.Errorf("Expected a request without streaming but got %v", received["stream"])
+		return
+	}
+	if res.Content != "hello there" || res.PromptTokens != 12 || res.CompletionTokens != 2 {
+		t.Errorf("Expected the reply with its usage but got %v", res)
+		return
+	}
+}
+
+func TestOllamaStreamMessageRelaysDeltas(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte("{\"model\":\"llama-test\",\"message\":{\"role\":\"assistant\",\"content\":\"Hel\"},\"done\":false}\n"))
+		w.Write([]byte("{\"model\":\"llama-test\",\"message\":{\"role\":\"assistant\",\"content\":\"lo\"},\"done\":false}\n"))
+		w.Write([]byte("{\"model\":\"llama-test\",\"message\":{\"role\":\"assistant\",\"content\":\"\"},\"done\":true,\"prompt_eval_count\":7,\"eval_count\":2}\n"))
+	}))
+	defer server.Close()
+
+	m := implementations.NewOllamaCommunicationManager(util.AiProvider{BaseUrl: server.URL}, "llama-test", getTestLogger())
+	deltas := make([]string, 0)
+	res, err := m.StreamMessage(context.Background(), "ctx", "Message(hi)", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(deltas) != 2 || res.Content != "Hello" {
+		t.Errorf("Expected deltas of %s but got %v", "Hello", deltas)
+		return
+	}
+	if res.PromptTokens != 7 || res.CompletionTokens != 2 {
+		t.Errorf("Expected usage of the stream but got %v", res)
+		return
+	}
+}
+
+func TestRegistryRejectsUnknownProviderAndModel(t *testing.T) {
+	r := implementations.NewConfiguredAiProviderRegistry(getMultiProviderConfiguration("http://localhost", "http://localhost"), getTestLogger())
+	_, err := r.Get("missing", "")
+	if err == nil || err.Error() != "aiErrorUnknownProvider" {
+		t.Errorf("Expected \"aiErrorUnknownProvider\" but got %v", err)
+		return
+	}
+	_, err = r.Get("cheap", "gpt-huge")
+	if err == nil || err.Error() != "aiErrorUnknownModel" {
+		t.Errorf("Expected \"aiErrorUnknownModel\" but got %v", err)
+		return
+	}
+
+	first, err := r.Get("", "")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	second, err := r.Get("cheap", "gpt-small")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if first != second {
+		t.Errorf("Expected the defaults to resolve to the same manager")
+		return
+	}
+}
+
+func TestPromptServiceRoutesContextsToTheirModel(t *testing.T) {
+	cheapModels := make([]string, 0)
+	cheap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var received recordedChatRequest
+		json.NewDecoder(r.Body).Decode(&received)
+		cheapModels = append(cheapModels, received.Model)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"cheap"}}]}`))
+	}))
+	defer cheap.Close()
+	strong := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"model":"claude-test","content":[{"type":"text","text":"strong"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer strong.Close()
+
+	logger := getTestLogger()
+	registry := implementations.NewConfiguredAiProviderRegistry(getMultiProviderConfiguration(cheap.URL, strong.URL), logger)
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	cs := services.NewContextService(contextRepo, logger, registry)
+	ps := services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(nil), registry, getMockedRetrievalService(), getMockedUsageService(nil), 0)
+
+	flashcards, err := cs.CreateOne(requests.CreateContextRequest{UserID: "1", LanguageID: "lang", Model: "gpt-tiny"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	proofs, err := cs.CreateOne(requests.CreateContextRequest{UserID: "1", LanguageID: "lang", Provider: "strong"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	for _, c := range []entities.Context{flashcards, proofs} {
+		_, err = ps.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: c.ID, Value: "hi"})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	if len(cheapModels) != 1 || cheapModels[0] != "gpt-tiny" {
+		t.Errorf("Expected one request for %s but got %v", "gpt-tiny", cheapModels)
+		return
+	}
+	reply, err := ps.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: proofs.ID, Value: "hi"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if reply.Content != "strong" {
+		t.Errorf("Expected %s but got %s", "strong", reply.Content)
+		return
+	}
+
+	_, err = cs.UpdateModel(requests.UpdateContextModelRequest{ID: proofs.ID, Provider: "cheap"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	reply, err = ps.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: proofs.ID, Value: "hi"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if reply.Content != "cheap" || cheapModels[len(cheapModels)-1] != "gpt-small" {
+		t.Errorf("Expected the switched context to use the default model of %s but got %v", "cheap", cheapModels)
+		return
+	}
+}
+
+func TestCreateContextRejectsUnknownModel(t *testing.T) {
+	logger := getTestLogger()
+	registry := implementations.NewConfiguredAiProviderRegistry(getMultiProviderConfiguration("http://localhost", "http://localhost"), logger)
+	cs := services.NewContextService(mocks.NewMockRepo[entities.Context](), logger, registry)
+	_, err := cs.CreateOne(requests.CreateContextRequest{UserID: "1", LanguageID: "lang", Provider: "cheap", Model: "gpt-huge"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "aiErrorUnknownModel" {
+		t.Errorf("Expected \"aiErrorUnknownModel\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestDeleteContextDropsConversation(t *testing.T) {
+	recorder := mocks.NewMockAiCommunicationManager(implementations.NewEchoCommunicationManager())
+	cs := services.NewContextService(mocks.NewMockRepo[entities.Context](), getTestLogger(), &mocks.MockAiProviderRegistry{Manager: recorder})
+	c, err := cs.CreateOne(requests.CreateContextRequest{UserID: "1", LanguageID: "lang"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	ok, err := cs.DeleteOne(c.ID)
+	if err != nil || !ok {
+		t.Errorf("Expected no errors but got %v", err)
+		return
+	}
+	if len(recorder.Deletes) != 1 || recorder.Deletes[0] != c.ID {
+		t.Errorf("Expected the conversation of %s to be dropped but got %v", c.ID, recorder.Deletes)
+		return
+	}
+}
+
+func getMultiProviderConfiguration(cheapUrl string, strongUrl string) *util.Configuration {
+	return &util.Configuration{
+		IsAiAssistantEnabled: true,
+		AiDefaultProvider:    "cheap",
+		AiProviders: []util.AiProvider{
+			{Name: "cheap", Type: "openai", BaseUrl: cheapUrl, DefaultModel: "gpt-small", Models: []string{"gpt-small", "gpt-tiny"}, TimeoutSeconds: 5},
+			{Name: "strong", Type: "anthropic", BaseUrl: strongUrl, DefaultModel: "claude-test", TimeoutSeconds: 5},
+		},
+	}
+}
+
+func getTestLogger() *util.Logger {
+	return util.NewLogger(map[string]string{}, os.Stdout)
+}
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"echo-api/mocks"
+	requests "echo-api/models/dtos/requests/user"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreatedApiKeyAuthenticates(t *testing.T) {
+	s, keys, user := getMockedApiKeyService()
+	created, err := s.Create(requests.CreateApiKeyRequest{UserID: user.ID, Name: " Import script ", Scopes: []string{"Notes:Write", "notes:write", "documents:read"}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if created.Name != "Import script" || !slices.Equal(created.Scopes, []string{"notes:write", "documents:read"}) || !strings.HasPrefix(created.Key, created.Prefix+".") {
+		t.Errorf("Expected a named key with normalized scopes but got %v", created)
+		return
+	}
+	stored, _ := keys.First(created.ID, false)
+	if strings.Contains(created.Key, stored.SecretHash) {
+		t.Errorf("Expected only the hash of the secret to be stored")
+		return
+	}
+
+	key, owner, err := s.Authenticate(created.Key)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if key.ID != created.ID || owner.ID != user.ID {
+		t.Errorf("Expected the key of %s but got %v", user.ID, key)
+		return
+	}
+	stored, _ = keys.First(created.ID, false)
+	if stored.LastUsedAt == nil {
+		t.Errorf("Expected the last use to be recorded")
+		return
+	}
+}
+
+func TestCreateApiKeyRefusesUnknownScopes(t *testing.T) {
+	s, _, user := getMockedApiKeyService()
+	for _, scopes := range [][]string{{"users:write"}, {"notes:admin"}, {"notes"}, {}} {
+		_, err := s.Create(requests.CreateApiKeyRequest{UserID: user.ID, Name: "Script", Scopes: scopes})
+		if err == nil {
+			t.Errorf("Expected %v to be refused but got no errors", scopes)
+			continue
+		}
+		if err.Error() != "apiKeyErrorInvalidScope" {
+			t.Errorf("Expected \"apiKeyErrorInvalidScope\" for %v but got %s", scopes, err.Error())
+		}
+	}
+}
+
+func TestAuthenticateRefusesWrongSecret(t *testing.T) {
+	s, _, user := getMockedApiKeyService()
+	created, _ := s.Create(requests.CreateApiKeyRequest{UserID: user.ID, Name: "Script", Scopes: []string{"notes:read"}})
+	for _, raw := range []string{created.Prefix + ".wrong", created.Prefix, "unknown." + strings.Split(created.Key, ".")[1], ""} {
+		_, _, err := s.Authenticate(raw)
+		if err == nil || err.Error() != "apiKeyErrorInvalid" {
+			t.Errorf("Expected \"apiKeyErrorInvalid\" for %q but got %v", raw, err)
+		}
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	s, keys, user := getMockedApiKeyService()
+	created, _ := s.Create(requests.CreateApiKeyRequest{UserID: user.ID, Name: "Script", Scopes: []string{"notes:read"}})
+	err := s.Revoke("other-user", created.ID)
+	if err == nil || err.Error() != "apiKeyErrorNotFound" {
+		t.Errorf("Expected \"apiKeyErrorNotFound\" for the key of another user but got %v", err)
+		return
+	}
+
+	err = s.Revoke(user.ID, created.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if count, _ := keys.Query().Count(); count != 0 {
+		t.Errorf("Expected no keys but got %d", count)
+		return
+	}
+	_, _, err = s.Authenticate(created.Key)
+	if err == nil || err.Error() != "apiKeyErrorInvalid" {
+		t.Errorf("Expected \"apiKeyErrorInvalid\" for a revoked key but got %v", err)
+		return
+	}
+}
+
+func TestAuthMiddlewareChecksApiKeyScopes(t *testing.T) {
+	s, _, user := getMockedApiKeyService()
+	created, _ := s.Create(requests.CreateApiKeyRequest{UserID: user.ID, Name: "Script", Scopes: []string{"notes:read"}})
+	auth := services.NewAuthService(nil, nil, getTestLogger(), nil, nil, nil, s)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.Use(auth.AuthMiddleware())
+	respond := func(c *gin.Context) { c.JSON(http.StatusOK, c.MustGet("claims")) }
+	v1.GET("/notes/:id", respond)
+	v1.POST("/notes", respond)
+	v1.GET("/users/:id/api-keys", respond)
+
+	cases := []struct {
+		method   string
+		path     string
+		key      string
+		expected int
+	}{
+		{http.MethodGet, "/api/v1/notes/1", created.Key, http.StatusOK},
+		{http.MethodPost, "/api/v1/notes", created.Key, http.StatusForbidden},
+		{http.MethodGet, "/api/v1/users/" + user.ID + "/api-keys", created.Key, http.StatusForbidden},
+		{http.MethodGet, "/api/v1/notes/1", created.Prefix + ".wrong", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(c.method, c.path, nil)
+		req.Header.Set("Authorization", "ApiKey "+c.key)
+		router.ServeHTTP(w, req)
+		if w.Code != c.expected {
+			t.Errorf("Expected %d for %s %s but got %d", c.expected, c.method, c.path, w.Code)
+			continue
+		}
+		if c.expected == http.StatusOK && !strings.Contains(w.Body.String(), `"userID":"`+user.ID+`"`) {
+			t.Errorf("Expected the claims of the key owner but got %s", w.Body.String())
+		}
+	}
+}
+
+func TestAuthMiddlewareTreatsStreamingRoutesAsWrites(t *testing.T) {
+	s, _, user := getMockedApiKeyService()
+	reader, _ := s.Create(requests.CreateApiKeyRequest{UserID: user.ID, Name: "Reader", Scopes: []string{"contexts:read"}})
+	writer, _ := s.Create(requests.CreateApiKeyRequest{UserID: user.ID, Name: "Writer", Scopes: []string{"contexts:write"}})
+	auth := services.NewAuthService(nil, nil, getTestLogger(), nil, nil, nil, s)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.Use(auth.AuthMiddleware())
+	respond := func(c *gin.Context) { c.Status(http.StatusOK) }
+	v1.GET("/contexts/:id/messages", respond)
+	v1.GET("/contexts/:id/messages/stream", respond)
+	v1.GET("/contexts/:id/ws", respond)
+
+	cases := []struct {
+		path     string
+		key      string
+		expected int
+	}{
+		{"/api/v1/contexts/1/messages", reader.Key, http.StatusOK},
+		{"/api/v1/contexts/1/messages/stream", reader.Key, http.StatusForbidden},
+		{"/api/v1/contexts/1/ws", reader.Key, http.StatusForbidden},
+		{"/api/v1/contexts/1/messages/stream", writer.Key, http.StatusOK},
+		{"/api/v1/contexts/1/ws", writer.Key, http.StatusOK},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		req.Header.Set("Authorization", "ApiKey "+c.key)
+		router.ServeHTTP(w, req)
+		if w.Code != c.expected {
+			t.Errorf("Expected %d for %s but got %d", c.expected, c.path, w.Code)
+		}
+	}
+}
+
+func getMockedApiKeyService() (*services.ApiKeyService, *mocks.MockRepository[entities.ApiKey], entities.User) {
+	users := mocks.NewMockRepo[entities.User]()
+	user, _ := users.Create(&entities.User{Name: "Ada", Email: "ada@example.com", Role: entities.Customer})
+	keys := mocks.NewMockRepo[entities.ApiKey]()
+	return services.NewApiKeyService(keys, users, getTestLogger()), keys, user
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"bytes"
+	"echo-api/handlers"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreatingMaterialInContextOfOtherUserIsForbidden(t *testing.T) {
+	router, notes := getAuthorizedRouterAs("2")
+	cases := map[string]func() *http.Request{
+		"note": func() *http.Request {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/notes", strings.NewReader(`{"header":"Eigenvalues","payload":"Injected","contextId":"1"}`))
+			req.Header.Set("Content-Type", "application/json")
+			return req
+		},
+		"document": func() *http.Request {
+			return documentUploadRequest(t, http.MethodPost, "/api/v1/documents", "file", nil)
+		},
+		"bulk documents": func() *http.Request {
+			return documentUploadRequest(t, http.MethodPost, "/api/v1/documents/bulk", "files[]", nil)
+		},
+		"note documents": func() *http.Request {
+			return documentUploadRequest(t, http.MethodPatch, "/api/v1/notes/document", "files[]", map[string]string{"entityID": "1"})
+		},
+	}
+	for name, request := range cases {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, request())
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected %d for the %s but got %d", http.StatusForbidden, name, w.Code)
+		}
+	}
+	if count, _ := notes.Query().Count(); count != 1 {
+		t.Errorf("Expected only the note of the owner but got %d notes", count)
+		return
+	}
+}
+
+// Context 1 and note 1 belong to user 1, the router acts as the given user
+func getAuthorizedRouterAs(userID string) (*gin.Engine, *mocks.MockRepository[entities.Note]) {
+	logger := getTestLogger()
+	contexts := mocks.NewMockRepo[entities.Context]()
+	contexts.Create(&entities.Context{UserID: "1"})
+	notes := mocks.NewMockRepo[entities.Note]()
+	notes.Create(&entities.Note{Header: "Linear algebra", ContextID: "1", UserID: "1"})
+	providers := implementations.NewConfiguredAiProviderRegistry(&util.Configuration{}, logger)
+	documents := services.NewDocumentService(mocks.NewMockRepo[entities.Document](), logger, nil, implementations.NewLocalExtractionManager(), implementations.NewLocalImageManager(0), implementations.NewNoopOcrManager(), nil)
+	h := handlers.InitializeAuthorizedHandlers(logger, nil, nil, services.NewNoteService(notes, logger), nil, documents, services.NewContextService(contexts, logger, providers), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.Use(func(c *gin.Context) { c.Set("claims", jwt.MapClaims{"userID": userID}) })
+	h.ConfigureRoutes(v1)
+	return router, notes
+}
+
+func documentUploadRequest(t *testing.T, method string, path string, fileField string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for name, value := range map[string]string{"userID": "2", "location": "documents", "isReadableByAll": "true", "contextID": "1"} {
+		w.WriteField(name, value)
+	}
+	for name, value := range fields {
+		w.WriteField(name, value)
+	}
+	f, err := w.CreateFormFile(fileField, "notes.md")
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	f.Write([]byte("# Injected"))
+	w.Close()
+	req := httptest.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/base"
+	"echo-api/models/dtos/requests/card"
+	"echo-api/models/dtos/requests/deck"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReviewRecordsGradeAndReschedules(t *testing.T) {
+	s := getMockedCardService("http://localhost")
+	created, err := s.CreateOne(card.CreateCardRequest{DeckID: "1", Front: "What is an eigenvector?", Back: "A vector that keeps its direction."})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	grade := 5
+	reviewed, err := s.Review(card.ReviewCardRequest{ID: created.ID, Grade: &grade})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if reviewed.Repetitions != 1 || reviewed.IntervalDays != 1 {
+		t.Errorf("Expected the first interval but got %v", reviewed)
+		return
+	}
+	if reviewed.LastReviewedAt == nil || !reviewed.DueAt.After(*reviewed.LastReviewedAt) {
+		t.Errorf("Expected the card to be due after its review but got %v", reviewed)
+		return
+	}
+	found, err := s.GetOne(created.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if !found.DueAt.Equal(reviewed.DueAt) {
+		t.Errorf("Expected the schedule to be stored but got %s", found.DueAt)
+		return
+	}
+}
+
+func TestReviewRejectsMissingGrade(t *testing.T) {
+	s := getMockedCardService("http://localhost")
+	created, _ := s.CreateOne(card.CreateCardRequest{DeckID: "1", Front: "front", Back: "back"})
+	_, err := s.Review(card.ReviewCardRequest{ID: created.ID})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "argumentErrorInvalidGrade" {
+		t.Errorf("Expected \"argumentErrorInvalidGrade\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestFilterDueOnlyReturnsDueCardsOfDeck(t *testing.T) {
+	s := getMockedCardService("http://localhost")
+	reviewed, _ := s.CreateOne(card.CreateCardRequest{DeckID: "1", Front: "reviewed", Back: "back"})
+	due, _ := s.CreateOne(card.CreateCardRequest{DeckID: "1", Front: "due", Back: "back"})
+	s.CreateOne(card.CreateCardRequest{DeckID: "2", Front: "other deck", Back: "back"})
+	grade := 4
+	_, err := s.Review(card.ReviewCardRequest{ID: reviewed.ID, Grade: &grade})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	res, err := s.FilterDue(deck.FilterDueCardsRequest{DeckID: "1", PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 10}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Size != 1 || res.Content[0].ID != due.ID {
+		t.Errorf("Expected only %s to be due but got %v", due.ID, res.Content)
+		return
+	}
+}
+
+func TestGenerateFromSourceStoresAnsweredCards(t *testing.T) {
+	var received recordedChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		reply, _ := json.Marshal("Here you go:\n```json\n[{\"front\":\"What is an eigenvector?\",\"back\":\"A vector that keeps its direction.\"},{\"front\":\"\",\"back\":\"skipped\"}]\n```")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(reply) + `}}],"usage":{"prompt_tokens":40,"completion_tokens":20}}`))
+	}))
+	defer server.Close()
+
+	s := getMockedCardService(server.URL)
+	note := entities.Note{Base: entities.Base{ID: "note"}, Header: "Linear algebra", Payload: "An eigenvector keeps its direction.", ContextID: "1"}
+	cards, err := s.GenerateFromSource(entities.Deck{Base: entities.Base{ID: "deck"}, ContextID: "1"}, note, 3)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(cards) != 1 || cards[0].Front != "What is an eigenvector?" || cards[0].DeckID != "deck" {
+		t.Errorf("Expected the answered card but got %v", cards)
+		return
+	}
+	if cards[0].SourceType != entities.NoteSource || cards[0].SourceID == nil || *cards[0].SourceID != note.ID {
+		t.Errorf("Expected the card to point at the note but got %v", cards[0])
+		return
+	}
+	if len(received.Messages) != 1 || !strings.Contains(received.Messages[0].Content, note.Payload) || !strings.Contains(received.Messages[0].Content, "at most 3 flashcards") {
+		t.Errorf("Expected a single request with the note but got %v", received.Messages)
+		return
+	}
+}
+
+func TestGenerateFromSourceRejectsReplyWithoutCards(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"I can not do that."}}]}`))
+	}))
+	defer server.Close()
+
+	s := getMockedCardService(server.URL)
+	note := entities.Note{Base: entities.Base{ID: "note"}, Header: "Linear algebra", Payload: "An eigenvector keeps its direction.", ContextID: "1"}
+	_, err := s.GenerateFromSource(entities.Deck{Base: entities.Base{ID: "deck"}, ContextID: "1"}, note, 0)
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "flashcardErrorInvalidReply" {
+		t.Errorf("Expected \"flashcardErrorInvalidReply\" but got %s", err.Error())
+		return
+	}
+}
+
+func getMockedCardService(aiUrl string) *services.CardService {
+	logger := getTestLogger()
+	registry := implementations.NewConfiguredAiProviderRegistry(getMultiProviderConfiguration(aiUrl, aiUrl), logger)
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	contextRepo.Create(&entities.Context{UserID: "1"})
+	ps := services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(nil), registry, getMockedRetrievalService(), getMockedUsageService(nil), 0)
+	return services.NewCardService(mocks.NewMockRepo[entities.Card](), mocks.NewMockRepo[entities.CardReview](), logger, implementations.NewSm2SchedulingManager(), ps)
+}
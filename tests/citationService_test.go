@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"echo-api/mocks"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"os"
+	"testing"
+)
+
+func TestGetSnippetCutsCitedPartOfDocument(t *testing.T) {
+	citationRepo := mocks.NewMockRepo[entities.Citation]()
+	documentRepo := mocks.NewMockRepo[entities.Document]()
+	s := getCitationService(citationRepo, documentRepo)
+	doc, _ := documentRepo.Create(&entities.Document{Text: "Page one text\n\nPage two text"})
+	citation, _ := citationRepo.Create(&entities.Citation{SourceType: entities.DocumentSource, SourceID: doc.ID, Section: "Page 2", StartOffset: 15, EndOffset: 28})
+
+	res, err := s.GetSnippet(citation.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Snippet != "Page two text" || res.Section != "Page 2" {
+		t.Errorf("Expected the second page but got %v", res)
+		return
+	}
+}
+
+func TestGetSnippetFailsWhenSourceShrunk(t *testing.T) {
+	citationRepo := mocks.NewMockRepo[entities.Citation]()
+	documentRepo := mocks.NewMockRepo[entities.Document]()
+	s := getCitationService(citationRepo, documentRepo)
+	doc, _ := documentRepo.Create(&entities.Document{Text: "short"})
+	citation, _ := citationRepo.Create(&entities.Citation{SourceType: entities.DocumentSource, SourceID: doc.ID, StartOffset: 2, EndOffset: 40})
+
+	_, err := s.GetSnippet(citation.ID)
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "citationErrorSourceChanged" {
+		t.Errorf("Expected \"citationErrorSourceChanged\" but got %s", err.Error())
+		return
+	}
+}
+
+func getCitationService(citationRepo *mocks.MockRepository[entities.Citation], documentRepo *mocks.MockRepository[entities.Document]) *services.CitationService {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	return services.NewCitationService(citationRepo, mocks.NewMockRepo[entities.Note](), documentRepo, logger)
+}
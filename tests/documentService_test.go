@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/document"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCreateOneFromMultipartRejectsNotAcceptedExtension(t *testing.T) {
+	s := getMockedDocumentService([]string{"pdf", "md"})
+	for _, name := range []string{"notes.exe", "notes.docx"} {
+		_, err := s.CreateOneFromMultipart(document.CreateDocumentMultipartRequest{File: fileHeaderOf(t, name, "content")})
+		if err == nil {
+			t.Errorf("Expected errors but got none")
+			return
+		}
+
+		if err.Error() != "argumentErrorUnsupportedExtension" {
+			t.Errorf("Expected \"argumentErrorUnsupportedExtension\" but got %s", err.Error())
+			return
+		}
+	}
+}
+
+func TestCreateOneFromMultipartAcceptsSupportedExtensionsWithoutList(t *testing.T) {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	s := services.NewDocumentService(mocks.NewMockRepo[entities.Document](), logger, getTempFileManager(t), implementations.NewLocalExtractionManager(), implementations.NewLocalImageManager(0), implementations.NewNoopOcrManager(), nil)
+	_, err := s.CreateOneFromMultipart(document.CreateDocumentMultipartRequest{File: fileHeaderOf(t, "notes.exe", "content")})
+	if err == nil || err.Error() != "argumentErrorUnsupportedExtension" {
+		t.Errorf("Expected \"argumentErrorUnsupportedExtension\" but got %v", err)
+		return
+	}
+
+	created, err := s.CreateOneFromMultipart(document.CreateDocumentMultipartRequest{File: fileHeaderOf(t, "notes.md", "# Eigenvalues"), CreateDocumentRequestBase: document.CreateDocumentRequestBase{Location: "documents"}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if created.Text != "Eigenvalues" {
+		t.Errorf("Expected the text of the markdown but got %q", created.Text)
+		return
+	}
+}
+
+func TestCreateOneFromMultipartKeepsRecognizedTextOfImage(t *testing.T) {
+	fm := getTempFileManager(t)
+	s := getMockedImageDocumentService(fm, mocks.NewMockOcrManager("Eigenvalues of A"))
+	content := string(encodePng(t, halvedImage(40, 20), nil))
+	created, err := s.CreateOneFromMultipart(document.CreateDocumentMultipartRequest{File: fileHeaderOf(t, "board.png", content), CreateDocumentRequestBase: document.CreateDocumentRequestBase{Location: "documents"}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if created.Text != "Eigenvalues of A" || created.Image == nil || created.Image.Width != 40 || created.Image.MimeType != "image/png" {
+		t.Errorf("Expected the recognized text and image details but got %v", created)
+		return
+	}
+	thumbnail, err := s.GetThumbnail(created.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	thumbnail.Close()
+	images, err := s.GetImageAttachments(created)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if len(images) != 1 || string(images[0].Data) != content {
+		t.Errorf("Expected the original image as attachment but got %d attachments", len(images))
+		return
+	}
+}
+
+func TestCreateOneFromMultipartKeepsImageWhenRecognitionFails(t *testing.T) {
+	s := getMockedImageDocumentService(getTempFileManager(t), mocks.NewMockOcrManager(""))
+	created, err := s.CreateOneFromMultipart(document.CreateDocumentMultipartRequest{File: fileHeaderOf(t, "photo.png", string(encodePng(t, halvedImage(4, 4), nil))), CreateDocumentRequestBase: document.CreateDocumentRequestBase{Location: "documents"}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if created.Text != "" || created.Image == nil {
+		t.Errorf("Expected an image without text but got %v", created)
+		return
+	}
+}
+
+func TestCreateOneFromMultipartRejectsBrokenImage(t *testing.T) {
+	s := getMockedImageDocumentService(getTempFileManager(t), mocks.NewMockOcrManager("text"))
+	_, err := s.CreateOneFromMultipart(document.CreateDocumentMultipartRequest{File: fileHeaderOf(t, "photo.png", "not a png"), CreateDocumentRequestBase: document.CreateDocumentRequestBase{Location: "documents"}})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "imageErrorInvalidImage" {
+		t.Errorf("Expected \"imageErrorInvalidImage\" but got %s", err.Error())
+		return
+	}
+}
+
+func getMockedDocumentService(acceptedExtensions []string) *services.DocumentService {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	return services.NewDocumentService(mocks.NewMockRepo[entities.Document](), logger, nil, implementations.NewLocalExtractionManager(), implementations.NewLocalImageManager(0), implementations.NewNoopOcrManager(), acceptedExtensions)
+}
+
+func getMockedImageDocumentService(fm managers.FileManager, om managers.OcrManager) *services.DocumentService {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	return services.NewDocumentService(mocks.NewMockRepo[entities.Document](), logger, fm, implementations.NewLocalExtractionManager(), implementations.NewLocalImageManager(0), om, []string{"png", "md"})
+}
+
+// Files go to a "documents" location within a directory removed after the test
+func getTempFileManager(t *testing.T) managers.FileManager {
+	t.Helper()
+	dir := t.TempDir()
+	// Locations are resolved below the base path once more by GetFullPath
+	err := os.MkdirAll(filepath.Join(dir, dir, "documents"), 0755)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	return implementations.NewOnServerFileManager(dir, []string{"documents"})
+}
+
+func fileHeaderOf(t *testing.T, name string, content string) *multipart.FileHeader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	f, _ := w.CreateFormFile("file", name)
+	f.Write([]byte(content))
+	w.Close()
+	form, err := multipart.NewReader(&buf, w.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	return form.File["file"][0]
+}
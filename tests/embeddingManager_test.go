@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOpenAiEmbedBatchesAndKeepsOrder(t *testing.T) {
+	batches := make([]int, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("Expected /v1/embeddings but got %s", r.URL.Path)
+		}
+		var received struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		batches = append(batches, len(received.Input))
+
+		// Answer in reverse order, vectors hold the length of their input
+		data := make([]string, 0, len(received.Input))
+		for i := len(received.Input) - 1; i >= 0; i-- {
+			data = append(data, fmt.Sprintf(`{"index":%d,"embedding":[%d]}`, i, len(received.Input[i])))
+		}
+		w.Write([]byte(`{"data":[` + strings.Join(data, ",") + `]}`))
+	}))
+	defer server.Close()
+
+	m := implementations.NewOpenAiEmbeddingManager(&util.Configuration{EmbeddingBaseUrl: server.URL, EmbeddingModel: "test-embedding", EmbeddingBatchSize: 2}, util.NewLogger(map[string]string{}, os.Stdout))
+	res, err := m.Embed([]string{"a", "bb", "ccc", "dddd", "eeeee"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(batches) != 3 || batches[0] != 2 || batches[2] != 1 {
+		t.Errorf("Expected batches of 2, 2 and 1 but got %v", batches)
+		return
+	}
+	for i, v := range res {
+		if int(v[0]) != i+1 {
+			t.Errorf("Expected vector %d to be %d but got %v", i, i+1, v)
+			return
+		}
+	}
+}
+
+func TestOllamaEmbedReturnsVectors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			t.Errorf("Expected /api/embed but got %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]]}`))
+	}))
+	defer server.Close()
+
+	m := implementations.NewOllamaEmbeddingManager(&util.Configuration{EmbeddingBaseUrl: server.URL, EmbeddingModel: "nomic-embed-text"}, util.NewLogger(map[string]string{}, os.Stdout))
+	res, err := m.Embed([]string{"first", "second"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(res) != 2 || res[1][1] != 0.4 {
+		t.Errorf("Expected the vectors of the provider but got %v", res)
+		return
+	}
+}
+
+func TestEmbedFailsWhenProviderAnswersWrongCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"embeddings":[[0.1]]}`))
+	}))
+	defer server.Close()
+
+	m := implementations.NewOllamaEmbeddingManager(&util.Configuration{EmbeddingBaseUrl: server.URL, EmbeddingModel: "nomic-embed-text"}, util.NewLogger(map[string]string{}, os.Stdout))
+	_, err := m.Embed([]string{"first", "second"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "aiErrorProviderInvalidResponse" {
+		t.Errorf("Expected \"aiErrorProviderInvalidResponse\" but got %s", err.Error())
+		return
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"strings"
+	"testing"
+)
+
+func TestRenderEquationWritesTextForAssistant(t *testing.T) {
+	cases := map[string]string{
+		`x = \frac{-b \pm \sqrt{b^2 - 4ac}}{2a}`:                                "x = (-b ± sqrt(b^2 - 4ac))/(2a)",
+		`$$E = mc^2$$`:                                                          "E = mc^2",
+		`\sum_{i=1}^{n} i = \frac{n(n+1)}{2}`:                                   "∑_(i = 1)^n i = (n(n + 1))/2",
+		`\lim_{x \to 0} \frac{\sin x}{x} = 1`:                                   "lim_(x → 0) (sin x)/x = 1",
+		`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`:                          "(a, b; c, d)",
+		`|x| = \begin{cases} x & x \geq 0 \\ -x & \text{otherwise} \end{cases}`: "|x| = {x, x ≥ 0; -x, otherwise}",
+		`\sqrt[3]{x} \in \mathbb{R}`:                                            "root(3, x) ∈ ℝ",
+	}
+	for latex, expected := range cases {
+		res := render(t, latex)
+		if res.Text != expected {
+			t.Errorf("Expected %q for %q but got %q", expected, latex, res.Text)
+		}
+	}
+}
+
+func TestRenderEquationWritesMathML(t *testing.T) {
+	res := render(t, `\[ \int_0^1 x^2 \, dx \]`)
+	if res.Latex != `\int_0^1 x^2 \, dx` {
+		t.Errorf("Expected the delimiters to be stripped but got %q", res.Latex)
+		return
+	}
+
+	expected := `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block"><mrow><msubsup><mo>∫</mo><mn>0</mn><mn>1</mn></msubsup><msup><mi>x</mi><mn>2</mn></msup><mspace width="0.167em"/><mi>d</mi><mi>x</mi></mrow></math>`
+	if res.MathML != expected {
+		t.Errorf("Expected %s but got %s", expected, res.MathML)
+		return
+	}
+}
+
+func TestRenderEquationPutsLimitsOfSumsBelowAndAbove(t *testing.T) {
+	res := render(t, `\sum_{k=0}^{n} k`)
+	if !strings.Contains(res.MathML, "<munderover><mo>∑</mo>") {
+		t.Errorf("Expected the limits below and above but got %s", res.MathML)
+		return
+	}
+}
+
+func TestRenderEquationRejectsInvalidLatex(t *testing.T) {
+	cases := map[string]string{
+		"  ":                              "equationErrorEmpty",
+		`\frac{1}{2`:                      "equationErrorUnbalancedBraces",
+		`x + 1}`:                          "equationErrorUnbalancedBraces",
+		`\left( x`:                        "equationErrorUnbalancedDelimiters",
+		`\begin{pmatrix} a \end{bmatrix}`: "equationErrorUnbalancedEnvironment",
+		`\begin{tabular} a \end{tabular}`: "equationErrorUnknownEnvironment",
+		`\unknown{x}`:                     "equationErrorUnknownCommand",
+		`x^2^3`:                           "equationErrorDoubleScript",
+		`\frac{1}`:                        "equationErrorMissingArgument",
+		`a & b`:                           "equationErrorMisplacedAlignment",
+		`50 % 2`:                          "equationErrorUnexpectedSymbol",
+	}
+	m := implementations.NewLocalEquationManager()
+	for latex, expected := range cases {
+		_, err := m.Render(latex)
+		if err == nil {
+			t.Errorf("Expected %q for %q but got no errors", expected, latex)
+			continue
+		}
+		if err.Error() != expected {
+			t.Errorf("Expected %q for %q but got %s", expected, latex, err.Error())
+		}
+	}
+}
+
+func TestRenderEquationRejectsDeepNesting(t *testing.T) {
+	cases := []string{
+		strings.Repeat("{", 100_000) + "x" + strings.Repeat("}", 100_000),
+		strings.Repeat(`\hat`, 100_000) + "x",
+		strings.Repeat(`\sqrt[`, 100_000) + "x",
+		"x" + strings.Repeat("^{x", 100_000),
+	}
+	m := implementations.NewLocalEquationManager()
+	for _, latex := range cases {
+		_, err := m.Render(latex)
+		if err == nil || err.Error() != "equationErrorTooDeep" {
+			t.Errorf("Expected \"equationErrorTooDeep\" for %q but got %v", latex[:16], err)
+		}
+	}
+}
+
+func render(t *testing.T, latex string) managers.RenderedEquation {
+	res, err := implementations.NewLocalEquationManager().Render(latex)
+	if err != nil {
+		t.Fatalf("Expected no errors for %q but got %s", latex, err.Error())
+	}
+	return res
+}
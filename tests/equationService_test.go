@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/equation"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"strings"
+	"testing"
+)
+
+func TestCreateEquationInNoteUsesContextOfNote(t *testing.T) {
+	s := getMockedEquationService()
+	userID, noteID := "1", "1"
+	created, err := s.CreateOne(equation.CreateEquationRequest{Latex: "$a^2 + b^2 = c^2$", Label: " Pythagoras ", NoteID: &noteID, UserID: &userID})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if created.ContextID != "2" || created.NoteID == nil || *created.NoteID != noteID || created.Label != "Pythagoras" {
+		t.Errorf("Expected the equation in the note and its context but got %v", created)
+		return
+	}
+	if created.Latex != "a^2 + b^2 = c^2" || created.Text != "a^2 + b^2 = c^2" || created.MathML == "" {
+		t.Errorf("Expected the rendered forms to be stored but got %v", created)
+		return
+	}
+}
+
+func TestCreateEquationRejectsNoteOfOtherContext(t *testing.T) {
+	s := getMockedEquationService()
+	userID, noteID := "1", "1"
+	_, err := s.CreateOne(equation.CreateEquationRequest{Latex: "x", NoteID: &noteID, ContextID: "3", UserID: &userID})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "argumentErrorSourceOutsideContext" {
+		t.Errorf("Expected \"argumentErrorSourceOutsideContext\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestUpdateEquationRendersNewLatexAndKeepsInvalidOut(t *testing.T) {
+	s := getMockedEquationService()
+	userID := "1"
+	created, _ := s.CreateOne(equation.CreateEquationRequest{Latex: "x", ContextID: "2", UserID: &userID})
+	invalid := `\frac{1}{`
+	_, err := s.UpdateOne(equation.UpdateEquationRequest{ID: created.ID, Latex: &invalid})
+	if err == nil || err.Error() != "equationErrorUnbalancedBraces" {
+		t.Errorf("Expected \"equationErrorUnbalancedBraces\" but got %v", err)
+		return
+	}
+	found, _ := s.GetOne(created.ID)
+	if found.Latex != "x" {
+		t.Errorf("Expected the invalid LaTeX not to be stored but got %q", found.Latex)
+		return
+	}
+
+	latex := `\frac{1}{2}`
+	updated, err := s.UpdateOne(equation.UpdateEquationRequest{ID: created.ID, Latex: &latex})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if updated.Text != "1/2" {
+		t.Errorf("Expected the new LaTeX to be rendered but got %q", updated.Text)
+		return
+	}
+}
+
+func TestCreateEquationRejectsLongLatex(t *testing.T) {
+	s := getMockedEquationService()
+	userID := "1"
+	_, err := s.CreateOne(equation.CreateEquationRequest{Latex: strings.Repeat("x+", 5001), ContextID: "2", UserID: &userID})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "equationErrorTooLong" {
+		t.Errorf("Expected \"equationErrorTooLong\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestEquationPromptGivesTextAndLatex(t *testing.T) {
+	pm := implementations.NewLocalPromptGenManager(nil)
+	res, err := pm.GeneratePrompt(entities.Equation{Latex: `\frac{a}{b}`, Text: "a/b", Label: "Ratio"}, "")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	expected := `Prompt(Remember(Equation(Ratio: a/b | LaTeX: \frac{a}{b})))`
+	if res != expected {
+		t.Errorf("Expected %s but got %s", expected, res)
+		return
+	}
+}
+
+func getMockedEquationService() *services.EquationService {
+	noteRepo := mocks.NewMockRepo[entities.Note]()
+	noteRepo.Create(&entities.Note{Header: "Triangles", ContextID: "2", UserID: "1"})
+	return services.NewEquationService(mocks.NewMockRepo[entities.Equation](), noteRepo, getTestLogger(), implementations.NewLocalEquationManager())
+}
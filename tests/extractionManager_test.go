@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExtractMarkdownStripsSyntaxAndSplitsSections(t *testing.T) {
+	content := "# Vectors\n\nA **vector** has a [direction](https://example.com).\n\n## Matrices\n\n> A matrix is a `grid`."
+	res := extract(t, []byte(content), "md")
+	if res.Text != "Vectors\n\nA vector has a direction.\n\nMatrices\n\nA matrix is a grid." {
+		t.Errorf("Expected clean text but got %q", res.Text)
+		return
+	}
+
+	expectSections(t, res, "Vectors", "Matrices")
+}
+
+func TestExtractHtmlSkipsScriptsAndSplitsSections(t *testing.T) {
+	content := "<html><head><title>x</title><style>p{}</style></head><body><h1>Cells</h1><p>Cells are <b>small</b>.</p><script>alert(1)</script><h2>Tissues</h2><ul><li>one</li><li>two</li></ul></body></html>"
+	res := extract(t, []byte(content), "html")
+	if res.Text != "Cells\n\nCells are small.\n\nTissues\n\none\n\ntwo" {
+		t.Errorf("Expected clean text but got %q", res.Text)
+		return
+	}
+
+	expectSections(t, res, "Cells", "Tissues")
+}
+
+func TestExtractDocxUsesHeadingStyles(t *testing.T) {
+	document := `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Optics</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:t>Light </w:t></w:r><w:r><w:t>bends.</w:t></w:r></w:p></w:body></w:document>`
+	res := extract(t, zipOf(t, map[string]string{"word/document.xml": document}), "docx")
+	if res.Text != "Optics\n\nLight bends." {
+		t.Errorf("Expected clean text but got %q", res.Text)
+		return
+	}
+
+	expectSections(t, res, "Optics")
+}
+
+func TestExtractOdtUsesHeadings(t *testing.T) {
+	content := `<?xml version="1.0"?><office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:text>` +
+		`<text:h>Waves</text:h><text:p>Sound<text:s/>is a <text:span>wave</text:span>.</text:p></office:text></office:body></office:document-content>`
+	res := extract(t, zipOf(t, map[string]string{"content.xml": content}), "odt")
+	if res.Text != "Waves\n\nSound is a wave." {
+		t.Errorf("Expected clean text but got %q", res.Text)
+		return
+	}
+
+	expectSections(t, res, "Waves")
+}
+
+func TestExtractEpubFollowsSpine(t *testing.T) {
+	files := map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0"?><container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?><package><manifest><item id="c1" href="one.xhtml"/><item id="c2" href="text/two.xhtml"/></manifest>` +
+			`<spine><itemref idref="c2"/><itemref idref="c1"/></spine></package>`,
+		"OEBPS/one.xhtml":      `<html><body><h1>Second</h1><p>World</p></body></html>`,
+		"OEBPS/text/two.xhtml": `<html><body><h1>First</h1><p>Hello</p></body></html>`,
+	}
+	res := extract(t, zipOf(t, files), "epub")
+	if res.Text != "First\n\nHello\n\nSecond\n\nWorld" {
+		t.Errorf("Expected chapters in spine order but got %q", res.Text)
+		return
+	}
+
+	expectSections(t, res, "First", "Second")
+}
+
+func TestExtractPdfSplitsPages(t *testing.T) {
+	res := extract(t, pdfOf("Newton first law", "Newton second law"), "pdf")
+	expectSections(t, res, "Page 1", "Page 2")
+
+	second := res.Text[res.Sections[1].StartOffset:res.Sections[1].EndOffset]
+	if !strings.Contains(second, "second law") {
+		t.Errorf("Expected the second page to hold its text but got %q", second)
+		return
+	}
+}
+
+func TestExtractRejectsUnknownExtension(t *testing.T) {
+	m := implementations.NewLocalExtractionManager()
+	if m.Supports("exe") {
+		t.Errorf("Expected exe to not be supported")
+		return
+	}
+	_, err := m.Extract([]byte("MZ"), "exe")
+	if err == nil || err.Error() != "extractionErrorUnsupportedExtension" {
+		t.Errorf("Expected \"extractionErrorUnsupportedExtension\" but got %v", err)
+		return
+	}
+}
+
+func TestExtractPdfRejectsTruncatedDocument(t *testing.T) {
+	content := pdfOf("Eigenvalues", "Eigenvectors")
+	// The body is cut off while the cross-reference table still points into it
+	xref := bytes.Index(content, []byte("xref"))
+	truncated := append(bytes.Clone(content[:xref/3]), bytes.Repeat([]byte(" "), xref-xref/3)...)
+	truncated = append(truncated, content[xref:]...)
+	_, err := implementations.NewLocalExtractionManager().Extract(truncated, "pdf")
+	if err == nil || err.Error() != "extractionErrorInvalidDocument" {
+		t.Errorf("Expected \"extractionErrorInvalidDocument\" but got %v", err)
+		return
+	}
+}
+
+func TestExtractRefusesOversizedZipEntry(t *testing.T) {
+	document := strings.Repeat("<w:p/>", (64<<20)/6+1)
+	_, err := implementations.NewLocalExtractionManager().Extract(zipOf(t, map[string]string{"word/document.xml": document}), "docx")
+	if err == nil || err.Error() != "extractionErrorInvalidDocument" {
+		t.Errorf("Expected \"extractionErrorInvalidDocument\" but got %v", err)
+		return
+	}
+}
+
+func extract(t *testing.T, content []byte, extension string) managers.ExtractedText {
+	t.Helper()
+	res, err := implementations.NewLocalExtractionManager().Extract(content, extension)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	return res
+}
+
+func expectSections(t *testing.T, res managers.ExtractedText, titles ...string) {
+	t.Helper()
+	if len(res.Sections) != len(titles) {
+		t.Errorf("Expected %d sections but got %v", len(titles), res.Sections)
+		return
+	}
+	for i, title := range titles {
+		section := res.Sections[i]
+		if section.Title != title {
+			t.Errorf("Expected %s but got %s", title, section.Title)
+			return
+		}
+		if i > 0 && section.StartOffset < res.Sections[i-1].EndOffset {
+			t.Errorf("Expected sections to not overlap but got %v", res.Sections)
+			return
+		}
+	}
+	if res.Sections[len(res.Sections)-1].EndOffset != len(res.Text) {
+		t.Errorf("Expected the last section to end with the text but got %v", res.Sections)
+		return
+	}
+}
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Expected no errors but got %s", err.Error())
+		}
+		f.Write([]byte(content))
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+// Builds an uncompressed pdf with one line of text per page
+func pdfOf(pages ...string) []byte {
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	kids := make([]string, len(pages))
+	fontID := 3 + 2*len(pages)
+	for i, text := range pages {
+		pageID := 3 + 2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+		stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> >> >>", pageID+1, fontID),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/util"
+	"strings"
+	"testing"
+)
+
+func TestArgon2idHashIsSaltedPhcString(t *testing.T) {
+	h := implementations.NewArgon2idHashingManager(getArgon2Configuration(1024), nil)
+	first, err := h.GetHash("correct horse")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	second, _ := h.GetHash("correct horse")
+
+	if !strings.HasPrefix(first, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("Expected an Argon2id PHC string but got %s", first)
+		return
+	}
+	if first == second {
+		t.Errorf("Expected different salts but got the same hash twice")
+		return
+	}
+	for _, hash := range []string{first, second} {
+		ok, err := h.Verify(hash, "correct horse")
+		if err != nil || !ok {
+			t.Errorf("Expected the password to match %s but got %v, %v", hash, ok, err)
+			return
+		}
+	}
+	ok, _ := h.Verify(first, "wrong horse")
+	if ok {
+		t.Errorf("Expected a wrong password not to match")
+		return
+	}
+}
+
+func TestArgon2idVerifiesLegacyBlake3Hashes(t *testing.T) {
+	configuration := getArgon2Configuration(1024)
+	legacy, err := implementations.NewBlake3HashingManager(configuration)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	h := implementations.NewArgon2idHashingManager(configuration, legacy)
+	hash, _ := legacy.GetHash("correct horse")
+
+	ok, err := h.Verify(hash, "correct horse")
+	if err != nil || !ok {
+		t.Errorf("Expected the legacy hash to match but got %v, %v", ok, err)
+		return
+	}
+	if !h.NeedsRehash(hash) {
+		t.Errorf("Expected the legacy hash to need a rehash")
+		return
+	}
+}
+
+func TestArgon2idNeedsRehashWhenSettingsChange(t *testing.T) {
+	weak := implementations.NewArgon2idHashingManager(getArgon2Configuration(1024), nil)
+	strong := implementations.NewArgon2idHashingManager(getArgon2Configuration(2048), nil)
+	hash, _ := weak.GetHash("correct horse")
+
+	if weak.NeedsRehash(hash) {
+		t.Errorf("Expected a hash with current settings not to need a rehash")
+		return
+	}
+	if !strong.NeedsRehash(hash) {
+		t.Errorf("Expected a hash with older settings to need a rehash")
+		return
+	}
+	ok, err := strong.Verify(hash, "correct horse")
+	if err != nil || !ok {
+		t.Errorf("Expected the older hash to still match but got %v, %v", ok, err)
+		return
+	}
+}
+
+func TestArgon2idRejectsDamagedHash(t *testing.T) {
+	h := implementations.NewArgon2idHashingManager(getArgon2Configuration(1024), nil)
+	_, err := h.Verify("$argon2id$v=19$m=1024,t=1$salt", "correct horse")
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "hashingErrorInvalidHash" {
+		t.Errorf("Expected \"hashingErrorInvalidHash\" but got %s", err.Error())
+		return
+	}
+}
+
+// Small settings keep the tests fast
+func getArgon2Configuration(memoryKiB int) *util.Configuration {
+	return &util.Configuration{Title: "test", Version: "1", Salt: "salt", Argon2MemoryKiB: memoryKiB, Argon2Iterations: 1, Argon2Parallelism: 1}
+}
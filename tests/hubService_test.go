@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"echo-api/models/dtos/responses/event"
+	"echo-api/services"
+	"echo-api/util"
+	"os"
+	"testing"
+)
+
+func TestPublishFansOutToEverySubscriptionOfContext(t *testing.T) {
+	s := getHubService()
+	first := s.Subscribe("ctx1", "user")
+	second := s.Subscribe("ctx1", "user")
+	other := s.Subscribe("ctx2", "user")
+
+	s.Publish("ctx1", event.PromptAdded, "payload")
+
+	for _, sub := range []*services.Subscription{first, second} {
+		select {
+		case e := <-sub.Events:
+			if e.Type != event.PromptAdded || e.ContextID != "ctx1" {
+				t.Errorf("Expected a %s event of ctx1 but got %v", event.PromptAdded, e)
+				return
+			}
+		default:
+			t.Errorf("Expected an event but got none")
+			return
+		}
+	}
+	select {
+	case e := <-other.Events:
+		t.Errorf("Expected no events for another context but got %v", e)
+		return
+	default:
+	}
+}
+
+func TestUnsubscribeClosesEvents(t *testing.T) {
+	s := getHubService()
+	sub := s.Subscribe("ctx", "user")
+	s.Unsubscribe(sub)
+
+	_, ok := <-sub.Events
+	if ok {
+		t.Errorf("Expected events channel to be closed")
+		return
+	}
+	if s.CountSubscriptions("ctx") != 0 {
+		t.Errorf("Expected no subscriptions but got %d", s.CountSubscriptions("ctx"))
+		return
+	}
+
+	// Publishing without subscribers and unsubscribing twice must not panic
+	s.Publish("ctx", event.Message, nil)
+	s.Unsubscribe(sub)
+}
+
+func TestPublishDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	s := getHubService()
+	sub := s.Subscribe("ctx", "user")
+	for i := 0; i < 1000; i++ {
+		s.Publish("ctx", event.Delta, i)
+	}
+
+	if len(sub.Events) != cap(sub.Events) {
+		t.Errorf("Expected a full buffer of %d but got %d", cap(sub.Events), len(sub.Events))
+		return
+	}
+}
+
+func getHubService() *services.HubService {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	return services.NewHubService(logger)
+}
@@ -0,0 +1,240 @@
+package tests
+
+import (
+	"bytes"
+	"echo-api/managers/implementations"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestProcessShrinksToThumbnailSize(t *testing.T) {
+	m := implementations.NewLocalImageManager(100)
+	res, err := m.Process(encodePng(t, halvedImage(600, 300), nil), "png")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.MimeType != "image/png" || res.Width != 600 || res.Height != 300 {
+		t.Errorf("Expected a 600x300 png but got %s %dx%d", res.MimeType, res.Width, res.Height)
+		return
+	}
+	thumbnail, err := jpeg.Decode(bytes.NewReader(res.Thumbnail))
+	if err != nil {
+		t.Errorf("Expected a JPEG thumbnail but got %s", err.Error())
+		return
+	}
+	if thumbnail.Bounds().Dx() != 100 || thumbnail.Bounds().Dy() != 50 {
+		t.Errorf("Expected a 100x50 thumbnail but got %v", thumbnail.Bounds())
+		return
+	}
+}
+
+func TestProcessTurnsThumbnailUpright(t *testing.T) {
+	m := implementations.NewLocalImageManager(0)
+	exif := newTiffBuilder().add(0x0112, 3, 1, shortValue(6)).build()
+	res, err := m.Process(encodePng(t, halvedImage(40, 20), exif), "png")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Width != 20 || res.Height != 40 || res.Metadata.Orientation != 6 {
+		t.Errorf("Expected an upright 20x40 image but got %dx%d with orientation %d", res.Width, res.Height, res.Metadata.Orientation)
+		return
+	}
+	thumbnail, _ := jpeg.Decode(bytes.NewReader(res.Thumbnail))
+	// Turned clockwise, the red left half ends up on top
+	top, bottom := thumbnail.At(10, 5), thumbnail.At(10, 35)
+	if !isReddish(top) || isReddish(bottom) {
+		t.Errorf("Expected red on top and blue at the bottom but got %v and %v", top, bottom)
+		return
+	}
+}
+
+func TestProcessReadsExifOfJpeg(t *testing.T) {
+	m := implementations.NewLocalImageManager(0)
+	gps := newTiffBuilder().
+		add(0x0001, 2, 2, []byte("S\x00")).
+		add(0x0002, 5, 3, rationals(48, 1, 30, 1, 0, 1)).
+		add(0x0003, 2, 2, []byte("E\x00")).
+		add(0x0004, 5, 3, rationals(16, 1, 15, 1, 36, 1))
+	exifIfd := newTiffBuilder().add(0x9003, 2, 20, []byte("2024:05:01 10:30:00\x00"))
+	exif := newTiffBuilder().
+		add(0x010F, 2, 6, []byte("Canon\x00")).
+		add(0x0110, 2, 8, []byte("EOS R6 \x00")).
+		addIfd(0x8769, exifIfd).
+		addIfd(0x8825, gps).
+		build()
+	res, err := m.Process(encodeJpegWithExif(t, halvedImage(8, 8), exif), "jpg")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	metadata := res.Metadata
+	if metadata.CameraMake != "Canon" || metadata.CameraModel != "EOS R6" || metadata.Orientation != 1 {
+		t.Errorf("Expected the camera and default orientation but got %v", metadata)
+		return
+	}
+	if metadata.TakenAt == nil || metadata.TakenAt.Format("2006-01-02 15:04") != "2024-05-01 10:30" {
+		t.Errorf("Expected the original date but got %v", metadata.TakenAt)
+		return
+	}
+	if metadata.Latitude == nil || math.Abs(*metadata.Latitude+48.5) > 1e-9 || metadata.Longitude == nil || math.Abs(*metadata.Longitude-16.26) > 1e-9 {
+		t.Errorf("Expected -48.5, 16.26 but got %v, %v", metadata.Latitude, metadata.Longitude)
+		return
+	}
+}
+
+func TestProcessRejectsInvalidImage(t *testing.T) {
+	m := implementations.NewLocalImageManager(0)
+	_, err := m.Process([]byte("not an image"), "png")
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "imageErrorInvalidImage" {
+		t.Errorf("Expected \"imageErrorInvalidImage\" but got %s", err.Error())
+		return
+	}
+}
+
+// Left half red, right half blue
+func halvedImage(width int, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x < width/2 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func isReddish(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > 0x8000 && b < 0x8000
+}
+
+// Puts the EXIF block in an eXIf chunk right after the header chunk
+func encodePng(t *testing.T, img image.Image, exif []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	if exif == nil {
+		return buf.Bytes()
+	}
+	content := buf.Bytes()
+	// Signature and the IHDR chunk with its 13 bytes of data
+	headerEnd := 8 + 12 + 13
+	chunk := make([]byte, 0, 12+len(exif))
+	chunk = binary.BigEndian.AppendUint32(chunk, uint32(len(exif)))
+	chunk = append(chunk, "eXIf"...)
+	chunk = append(chunk, exif...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	return append(append(append([]byte{}, content[:headerEnd]...), chunk...), content[headerEnd:]...)
+}
+
+func encodeJpegWithExif(t *testing.T, img image.Image, exif []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, nil)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	content := buf.Bytes()
+	payload := append([]byte("Exif\x00\x00"), exif...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+	return append(append(append([]byte{}, content[:2]...), segment...), content[2:]...)
+}
+
+// Builds little endian TIFF directories, nested directories are laid out after their parent
+type tiffBuilder struct {
+	entries []tiffTestEntry
+}
+
+type tiffTestEntry struct {
+	tag       uint16
+	fieldType uint16
+	count     uint32
+	value     []byte
+	ifd       *tiffBuilder
+}
+
+func newTiffBuilder() *tiffBuilder {
+	return &tiffBuilder{}
+}
+
+func (b *tiffBuilder) add(tag uint16, fieldType uint16, count uint32, value []byte) *tiffBuilder {
+	b.entries = append(b.entries, tiffTestEntry{tag: tag, fieldType: fieldType, count: count, value: value})
+	return b
+}
+
+func (b *tiffBuilder) addIfd(tag uint16, ifd *tiffBuilder) *tiffBuilder {
+	b.entries = append(b.entries, tiffTestEntry{tag: tag, fieldType: 4, count: 1, ifd: ifd})
+	return b
+}
+
+func (b *tiffBuilder) build() []byte {
+	out := []byte("II*\x00\x08\x00\x00\x00")
+	return b.write(out)
+}
+
+func (b *tiffBuilder) write(out []byte) []byte {
+	start := len(out)
+	dataStart := start + 2 + len(b.entries)*12 + 4
+	data := make([]byte, 0)
+	directory := binary.LittleEndian.AppendUint16(nil, uint16(len(b.entries)))
+	nested := make(map[int]*tiffBuilder)
+	for i, e := range b.entries {
+		directory = binary.LittleEndian.AppendUint16(directory, e.tag)
+		directory = binary.LittleEndian.AppendUint16(directory, e.fieldType)
+		directory = binary.LittleEndian.AppendUint32(directory, e.count)
+		if e.ifd != nil {
+			nested[i] = e.ifd
+			directory = binary.LittleEndian.AppendUint32(directory, 0)
+			continue
+		}
+		if len(e.value) <= 4 {
+			directory = append(directory, append(e.value, make([]byte, 4-len(e.value))...)...)
+			continue
+		}
+		directory = binary.LittleEndian.AppendUint32(directory, uint32(dataStart+len(data)))
+		data = append(data, e.value...)
+	}
+	directory = binary.LittleEndian.AppendUint32(directory, 0)
+	out = append(append(out, directory...), data...)
+	for i, ifd := range nested {
+		binary.LittleEndian.PutUint32(out[start+2+i*12+8:], uint32(len(out)))
+		out = ifd.write(out)
+	}
+	return out
+}
+
+func shortValue(v uint16) []byte {
+	return binary.LittleEndian.AppendUint16(nil, v)
+}
+
+func rationals(values ...uint32) []byte {
+	res := make([]byte, 0, len(values)*4)
+	for _, v := range values {
+		res = binary.LittleEndian.AppendUint32(res, v)
+	}
+	return res
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bufio"
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"echo-api/util"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestSmtpMailManagerDeliversMail(t *testing.T) {
+	server := startSmtpStandIn(t)
+	m := implementations.NewSmtpMailManager(server.config(), getTestLogger())
+	body := "Hi Ada,\n\nyour link: https://app.test/reset-password?token=abc\n" + strings.Repeat("long line ", 20) + "\n.\nend\n"
+	err := m.Send(managers.Mail{To: "Ada <ada@example.com>", Subject: "Reset your password", Body: body})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	received := <-server.received
+	if received.from != "<noreply@app.test>" || received.to != "<ada@example.com>" {
+		t.Errorf("Expected the envelope addresses but got %s and %s", received.from, received.to)
+		return
+	}
+	headers, encoded, _ := strings.Cut(received.data, "\r\n\r\n")
+	if !strings.Contains(headers, "Subject: Reset your password\r\n") || !strings.Contains(headers, "To: \"Ada\" <ada@example.com>\r\n") {
+		t.Errorf("Expected the subject and recipient headers but got %s", headers)
+		return
+	}
+	decoded, _ := io.ReadAll(quotedprintable.NewReader(strings.NewReader(encoded)))
+	if strings.ReplaceAll(string(decoded), "\r\n", "\n") != body {
+		t.Errorf("Expected the body to survive the transfer but got %q", decoded)
+		return
+	}
+}
+
+func TestSmtpMailManagerRejectsSecondHeaderInSubject(t *testing.T) {
+	server := startSmtpStandIn(t)
+	m := implementations.NewSmtpMailManager(server.config(), getTestLogger())
+	err := m.Send(managers.Mail{To: "ada@example.com", Subject: "Hi\r\nBcc: eve@example.com", Body: "Hi"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "mailErrorInvalidHeader" {
+		t.Errorf("Expected \"mailErrorInvalidHeader\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestSmtpMailManagerReportsRejectedRecipient(t *testing.T) {
+	server := startSmtpStandIn(t)
+	m := implementations.NewSmtpMailManager(server.config(), getTestLogger())
+	err := m.Send(managers.Mail{To: "unknown@example.com", Subject: "Hi", Body: "Hi"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "mailErrorDeliveryFailed" {
+		t.Errorf("Expected \"mailErrorDeliveryFailed\" but got %s", err.Error())
+		return
+	}
+}
+
+type receivedMail struct {
+	from string
+	to   string
+	data string
+}
+
+// Speaks just enough SMTP to take mails, recipients starting with "unknown" are refused
+type smtpStandIn struct {
+	listener net.Listener
+	received chan receivedMail
+}
+
+func startSmtpStandIn(t *testing.T) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	s := &smtpStandIn{listener: listener, received: make(chan receivedMail, 1)}
+	t.Cleanup(func() { listener.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) config() *util.Configuration {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return &util.Configuration{SmtpHost: "127.0.0.1", SmtpPort: addr.Port, MailFrom: "App <noreply@app.test>"}
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	var mail receivedMail
+	reply("220 stand-in ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 stand-in")
+		case "MAIL":
+			mail.from = strings.TrimPrefix(command, "MAIL FROM:")
+			reply("250 ok")
+		case "RCPT":
+			mail.to = strings.TrimPrefix(command, "RCPT TO:")
+			if strings.HasPrefix(mail.to, "<unknown") {
+				reply("550 no such user")
+				continue
+			}
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			mail.data = data.String()
+			s.received <- mail
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestOidcLoginCreatesUserOnce(t *testing.T) {
+	idp := startMockIdp(t)
+	s, users := getMockedOidcService(idp)
+	first, err := loginAtMockIdp(s, idp)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if first.Email != "ada@example.com" || first.Name != "Ada Lovelace" || first.EmailVerifiedAt == nil || first.Role != entities.Customer {
+		t.Errorf("Expected a verified customer made from the identity but got %v", first)
+		return
+	}
+
+	second, err := loginAtMockIdp(s, idp)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if second.ID != first.ID {
+		t.Errorf("Expected the same user on the second login but got %s and %s", first.ID, second.ID)
+		return
+	}
+	if count, _ := users.Query().Count(); count != 1 {
+		t.Errorf("Expected one user but got %d", count)
+		return
+	}
+}
+
+func TestOidcLoginLinksUserWithVerifiedEmail(t *testing.T) {
+	idp := startMockIdp(t)
+	s, users := getMockedOidcService(idp)
+	verified := time.Now()
+	user, _ := users.Create(&entities.User{Name: "Ada", Email: "ada@example.com", Role: entities.Customer, EmailVerifiedAt: &verified})
+	res, err := loginAtMockIdp(s, idp)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.ID != user.ID {
+		t.Errorf("Expected the identity to be linked to %s but got %s", user.ID, res.ID)
+		return
+	}
+}
+
+func TestOidcLoginRefusesUnverifiedEmails(t *testing.T) {
+	cases := []struct {
+		providerVerified bool
+		userVerified     bool
+		expected         string
+	}{
+		{false, true, "oidcErrorEmailNotVerified"},
+		{true, false, "oidcErrorAccountNotVerified"},
+	}
+	for _, c := range cases {
+		idp := startMockIdp(t)
+		idp.emailVerified = c.providerVerified
+		s, users := getMockedOidcService(idp)
+		user := entities.User{Name: "Ada", Email: "ada@example.com", Role: entities.Customer}
+		if c.userVerified {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+		users.Create(&user)
+		_, err := loginAtMockIdp(s, idp)
+		if err == nil {
+			t.Errorf("Expected %s but got none", c.expected)
+			continue
+		}
+		if err.Error() != c.expected {
+			t.Errorf("Expected %s but got %s", c.expected, err.Error())
+		}
+	}
+}
+
+func TestOidcLoginRefusesReusedState(t *testing.T) {
+	idp := startMockIdp(t)
+	s, _ := getMockedOidcService(idp)
+	code, state, err := authorizeAtMockIdp(s, idp)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	_, err = s.Complete("mock", code, state)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	_, err = s.Complete("mock", code, state)
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+	if err.Error() != "oidcErrorStateInvalid" {
+		t.Errorf("Expected \"oidcErrorStateInvalid\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestOidcLoginRefusesForeignIdTokens(t *testing.T) {
+	cases := map[string]func(claims jwt.MapClaims){
+		"nonce":    func(claims jwt.MapClaims) { claims["nonce"] = "other" },
+		"audience": func(claims jwt.MapClaims) { claims["aud"] = []string{"other-app"} },
+		"issuer":   func(claims jwt.MapClaims) { claims["iss"] = "https://other.test" },
+		"expired":  func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+	}
+	for name, tamper := range cases {
+		idp := startMockIdp(t)
+		idp.tamper = tamper
+		s, _ := getMockedOidcService(idp)
+		_, err := loginAtMockIdp(s, idp)
+		if err == nil {
+			t.Errorf("Expected the token with the wrong %s to be refused", name)
+			continue
+		}
+		if err.Error() != "oidcErrorInvalidIdToken" {
+			t.Errorf("Expected \"oidcErrorInvalidIdToken\" for the wrong %s but got %s", name, err.Error())
+		}
+	}
+}
+
+func TestOidcLoginRefusesUnknownProvider(t *testing.T) {
+	idp := startMockIdp(t)
+	s, _ := getMockedOidcService(idp)
+	_, err := s.Begin("unknown")
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "oidcErrorUnknownProvider" {
+		t.Errorf("Expected \"oidcErrorUnknownProvider\" but got %s", err.Error())
+		return
+	}
+}
+
+const mockIdpClientID = "app"
+const mockIdpClientSecret = "app-secret"
+const mockIdpRedirectUrl = "https://app.test/api/v1/oidc/mock/callback"
+
+type mockIdpGrant struct {
+	challenge string
+	nonce     string
+}
+
+// Identity provider with discovery, authorization, token and key endpoints. It signs in Ada right away and checks PKCE and the client secret when codes are redeemed
+type mockIdp struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	grants        map[string]mockIdpGrant
+	emailVerified bool
+	tamper        func(claims jwt.MapClaims)
+}
+
+func startMockIdp(t *testing.T) *mockIdp {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	idp := &mockIdp{key: key, grants: make(map[string]mockIdpGrant), emailVerified: true}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdp) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{
+		"issuer":                                idp.server.URL,
+		"authorization_endpoint":                idp.server.URL + "/authorize",
+		"token_endpoint":                        idp.server.URL + "/token",
+		"jwks_uri":                              idp.server.URL + "/jwks",
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic"},
+	})
+}
+
+func (idp *mockIdp) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != mockIdpClientID || query.Get("redirect_uri") != mockIdpRedirectUrl || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	code := "code-" + query.Get("state")[:8]
+	idp.grants[code] = mockIdpGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	http.Redirect(w, r, mockIdpRedirectUrl+"?"+url.Values{"code": {code}, "state": {query.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (idp *mockIdp) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != mockIdpClientID || secret != mockIdpClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	r.ParseForm()
+	grant, found := idp.grants[r.PostForm.Get("code")]
+	delete(idp.grants, r.PostForm.Get("code"))
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !found || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != mockIdpRedirectUrl ||
+		base64.RawURLEncoding.EncodeToString(verifier[:]) != grant.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            mockIdpClientID,
+		"sub":            "ada-1815",
+		"email":          "ada@example.com",
+		"email_verified": idp.emailVerified,
+		"name":           "Ada Lovelace",
+		"nonce":          grant.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+	if idp.tamper != nil {
+		idp.tamper(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-key"
+	signed, _ := token.SignedString(idp.key)
+	json.NewEncoder(w).Encode(map[string]any{"access_token": "opaque", "token_type": "Bearer", "id_token": signed})
+}
+
+func (idp *mockIdp) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "idp-key",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+	}}})
+}
+
+// Follows the authorization url like a browser would, up to the redirect back to the app
+func authorizeAtMockIdp(s *services.OidcService, idp *mockIdp) (string, string, error) {
+	authorizationUrl, err := s.Begin("mock")
+	if err != nil {
+		return "", "", err
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authorizationUrl)
+	if err != nil {
+		return "", "", err
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return "", "", err
+	}
+	return location.Query().Get("code"), location.Query().Get("state"), nil
+}
+
+func loginAtMockIdp(s *services.OidcService, idp *mockIdp) (entities.User, error) {
+	code, state, err := authorizeAtMockIdp(s, idp)
+	if err != nil {
+		return entities.User{}, err
+	}
+	return s.Complete("mock", code, state)
+}
+
+func getMockedOidcService(idp *mockIdp) (*services.OidcService, *mocks.MockRepository[entities.User]) {
+	users := mocks.NewMockRepo[entities.User]()
+	config := &util.Configuration{OidcProviders: []util.OidcProvider{{
+		Name:         "mock",
+		Issuer:       idp.server.URL,
+		ClientID:     mockIdpClientID,
+		ClientSecret: mockIdpClientSecret,
+		RedirectUrl:  mockIdpRedirectUrl,
+	}}}
+	m := implementations.NewConfiguredOidcManager(config, getTestLogger())
+	s := services.NewOidcService(mocks.NewMockRepo[entities.OidcLoginState](), mocks.NewMockRepo[entities.ExternalIdentity](), users, getTestLogger(), m)
+	return s, users
+}
@@ -0,0 +1,327 @@
+package tests
+
+import (
+	"context"
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"echo-api/util"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type recordedChatRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"messages"`
+}
+
+func TestSendPromptReturnsProviderReply(t *testing.T) {
+	var received recordedChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Expected /v1/chat/completions but got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Expected bearer api key but got %s", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"model":"test-model","choices":[{"message":{"role":"assistant","content":"hello there"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":2,"total_tokens":14}}`))
+	}))
+	defer server.Close()
+
+	m := getOpenAiCommunicationManager(server.URL)
+	res, err := m.SendPrompt("ctx", "Message(hi)")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Content != "hello there" {
+		t.Errorf("Expected %s but got %s", "hello there", res.Content)
+		return
+	}
+	if received.Model != "test-model" {
+		t.Errorf("Expected %s but got %s", "test-model", received.Model)
+		return
+	}
+	if len(received.Messages) != 2 || received.Messages[0].Role != "system" || received.Messages[0].Content != managers.DefaultTemplates[managers.Initial] {
+		t.Errorf("Expected the initial system prompt followed by the message but got %v", received.Messages)
+		return
+	}
+}
+
+func TestSendPromptKeepsConversationPerContext(t *testing.T) {
+	var received recordedChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`))
+	}))
+	defer server.Close()
+
+	m := getOpenAiCommunicationManager(server.URL)
+	err := m.CreateContext("ctx", "")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	_, err = m.SendPrompt("ctx", "Prompt(Remember(a))")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	for _, msg := range []string{"Message(b)", "Message(c)"} {
+		_, err = m.SendMessage("ctx", msg)
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	if len(received.Messages) != 5 {
+		t.Errorf("Expected 5 messages in the last request but got %d", len(received.Messages))
+		return
+	}
+
+	err = m.ResetContext("ctx", true)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	_, err = m.SendPrompt("ctx", "Message(d)")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(received.Messages) != 3 || received.Messages[0].Content != "Prompt(Remember(a))" {
+		t.Errorf("Expected soft reset to keep only the remembered prompt but got %v", received.Messages)
+		return
+	}
+}
+
+func TestSendPromptMapsProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"message":"slow down","type":"rate_limit"}}`))
+	}))
+	defer server.Close()
+
+	m := getOpenAiCommunicationManager(server.URL)
+	_, err := m.SendPrompt("ctx", "Message(hi)")
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "aiErrorProviderRateLimited" {
+		t.Errorf("Expected \"aiErrorProviderRateLimited\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestSendPromptEchoesWhenAssistantDisabled(t *testing.T) {
+	m := implementations.NewEchoCommunicationManager()
+	res, err := m.SendPrompt("ctx", "Message(hi)")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Content != "Message(hi)" {
+		t.Errorf("Expected %s but got %s", "Message(hi)", res.Content)
+		return
+	}
+}
+
+func TestDeleteNotExistingContext(t *testing.T) {
+	m := getOpenAiCommunicationManager("http://localhost")
+	err := m.DeleteContext("missing", false)
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "aiErrorContextNotFound" {
+		t.Errorf("Expected \"aiErrorContextNotFound\" but got %s", err.Error())
+		return
+	}
+
+	err = m.DeleteContext("missing", true)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+}
+
+func TestStreamMessageRelaysDeltas(t *testing.T) {
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"model\":\"test-model\",\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Hel\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":2}}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	m := getOpenAiCommunicationManager(server.URL)
+	deltas := make([]string, 0)
+	res, err := m.StreamMessage(context.Background(), "ctx", "Message(hi)", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if received["stream"] != true {
+		t.Errorf("Expected a streamed request but got %v", received["stream"])
+		return
+	}
+	if len(deltas) != 2 || res.Content != "Hello" {
+		t.Errorf("Expected deltas of %s but got %v", "Hello", deltas)
+		return
+	}
+	if res.PromptTokens != 7 || res.CompletionTokens != 2 || res.Model != "test-model" {
+		t.Errorf("Expected usage of the stream but got %v", res)
+		return
+	}
+}
+
+func TestStreamMessageEchoesWhenAssistantDisabled(t *testing.T) {
+	m := implementations.NewEchoCommunicationManager()
+	deltas := make([]string, 0)
+	res, err := m.StreamMessage(context.Background(), "ctx", "Message(hi there)", func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(deltas) != 2 || strings.Join(deltas, "") != res.Content || res.Content != "Message(hi there)" {
+		t.Errorf("Expected the message to be streamed back but got %v", deltas)
+		return
+	}
+}
+
+func TestCompactContextReplacesOlderMessagesWithSummary(t *testing.T) {
+	var received recordedChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`))
+	}))
+	defer server.Close()
+
+	m := getOpenAiCommunicationManager(server.URL)
+	m.CreateContext("ctx", "")
+	_, err := m.SendPrompt("ctx", "Prompt(Remember(a))")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	for _, msg := range []string{"Message(b)", "Message(c)", "Message(d)"} {
+		_, err = m.SendMessage("ctx", msg)
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+	for _, summary := range []string{"Prompt(Summary(first))", "Prompt(Summary(second))"} {
+		err = m.CompactContext("ctx", summary, 2)
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+	_, err = m.SendMessage("ctx", "Message(e)")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	contents := make([]string, len(received.Messages))
+	for i, msg := range received.Messages {
+		contents[i] = msg.Content
+	}
+	expected := []string{"Prompt(Remember(a))", "done", "Prompt(Summary(second))", "done", "Message(d)", "done", "Message(e)"}
+	if strings.Join(contents, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected %v but got %v", expected, contents)
+		return
+	}
+}
+
+func TestSendPromptWithImagesSendsImageParts(t *testing.T) {
+	var received struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`))
+	}))
+	defer server.Close()
+
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	provider := util.AiProvider{BaseUrl: server.URL, TimeoutSeconds: 5, SupportsImages: true}
+	m := implementations.NewOpenAiCommunicationManager(provider, "vision-model", logger)
+	_, err := m.SendPromptWithImages("ctx", "Prompt(Remember(photo.png))", []managers.ImageAttachment{{MimeType: "image/png", Data: []byte("png")}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	var last struct {
+		Content []struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			ImageUrl struct {
+				Url string `json:"url"`
+			} `json:"image_url"`
+		} `json:"content"`
+	}
+	err = json.Unmarshal(received.Messages[len(received.Messages)-1], &last)
+	if err != nil {
+		t.Errorf("Expected the content as parts but got %s", string(received.Messages[len(received.Messages)-1]))
+		return
+	}
+	if len(last.Content) != 2 || last.Content[0].Text != "Prompt(Remember(photo.png))" || last.Content[1].ImageUrl.Url != "data:image/png;base64,cG5n" {
+		t.Errorf("Expected the text followed by the image but got %v", last.Content)
+		return
+	}
+}
+
+func TestSendPromptWithImagesDropsImagesWithoutSupport(t *testing.T) {
+	var received recordedChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"done"}}]}`))
+	}))
+	defer server.Close()
+
+	m := getOpenAiCommunicationManager(server.URL)
+	_, err := m.SendPromptWithImages("ctx", "Prompt(Remember(photo.png))", []managers.ImageAttachment{{MimeType: "image/png", Data: []byte("png")}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(received.Messages) != 2 || received.Messages[1].Content != "Prompt(Remember(photo.png))" {
+		t.Errorf("Expected a plain text prompt but got %v", received.Messages)
+		return
+	}
+}
+
+func getOpenAiCommunicationManager(baseUrl string) *implementations.ChatCommunicationManager {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	provider := util.AiProvider{BaseUrl: baseUrl, ApiKey: "test-key", TimeoutSeconds: 5}
+	return implementations.NewOpenAiCommunicationManager(provider, "test-model", logger)
+}
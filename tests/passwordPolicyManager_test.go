@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/util"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyAcceptsStrongPasswords(t *testing.T) {
+	m := implementations.NewConfiguredPasswordPolicyManager(&util.Configuration{})
+	for _, password := range []string{"!testPass_4251", "Correct4Horse", "correct horse battery staple"} {
+		err := m.Check(password, "ada.lovelace@example.com", "Ada Lovelace")
+		if err != nil {
+			t.Errorf("Expected %s to be accepted but got %s", password, err.Error())
+		}
+	}
+}
+
+func TestPasswordPolicyRejectsWeakPasswords(t *testing.T) {
+	cases := []struct {
+		password string
+		expected string
+	}{
+		{"Sh0rt!", "passwordErrorTooShort"},
+		{strings.Repeat("Long_1", 50), "passwordErrorTooLong"},
+		{"alllowercase1", "passwordErrorTooWeak"},
+		{"Lovelace_1815", "passwordErrorContainsPersonalInfo"},
+		{"ada.LOVELACE_1815", "passwordErrorContainsPersonalInfo"},
+	}
+	m := implementations.NewConfiguredPasswordPolicyManager(&util.Configuration{})
+	for _, c := range cases {
+		err := m.Check(c.password, "ada.lovelace@example.com", "Ada Lovelace")
+		if err == nil {
+			t.Errorf("Expected %s for %s but got none", c.expected, c.password)
+			continue
+		}
+		if err.Error() != c.expected {
+			t.Errorf("Expected %s for %s but got %s", c.expected, c.password, err.Error())
+		}
+	}
+}
+
+func TestPasswordPolicyKeepsLowestMinimumLength(t *testing.T) {
+	m := implementations.NewConfiguredPasswordPolicyManager(&util.Configuration{PasswordMinLength: 4})
+	err := m.Check("Ab1_xyz")
+	if err == nil || err.Error() != "passwordErrorTooShort" {
+		t.Errorf("Expected \"passwordErrorTooShort\" but got %v", err)
+		return
+	}
+}
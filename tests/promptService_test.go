@@ -0,0 +1,507 @@
+package tests
+
+import (
+	"context"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/base"
+	contextRequests "echo-api/models/dtos/requests/context"
+	"echo-api/models/dtos/requests/message"
+	"echo-api/models/dtos/requests/prompt"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGenerateAndSendMessagePersistsBothTurns(t *testing.T) {
+	s := getMockedPromptService()
+	reply, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: "What is an eigenvector?"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if reply.Role != entities.AssistantMessage {
+		t.Errorf("Expected %s but got %s", entities.AssistantMessage, reply.Role)
+		return
+	}
+	if reply.Content != "Message(What is an eigenvector?)" {
+		t.Errorf("Expected the echoed message but got %s", reply.Content)
+		return
+	}
+
+	res, err := s.FilterMessages(message.FilterMessagesRequest{ContextID: "1", PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 10}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Size != 2 {
+		t.Errorf("Expected 2 messages but got %d", res.Size)
+		return
+	}
+	if res.Content[0].Role != entities.UserMessage || res.Content[0].Content != "What is an eigenvector?" {
+		t.Errorf("Expected the user turn first but got %v", res.Content[0])
+		return
+	}
+	if res.Content[1].ID != reply.ID {
+		t.Errorf("Expected %s but got %s", reply.ID, res.Content[1].ID)
+		return
+	}
+}
+
+func TestFilterMessagesOnlyReturnsGivenContext(t *testing.T) {
+	s := getMockedPromptService()
+	for _, ctx := range []string{"1", "2", "1"} {
+		_, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: ctx, Value: "hi"})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	res, err := s.FilterMessages(message.FilterMessagesRequest{ContextID: "1", PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 10}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Size != 4 {
+		t.Errorf("Expected 4 messages but got %d", res.Size)
+		return
+	}
+	for _, v := range res.Content {
+		if v.ContextID != "1" {
+			t.Errorf("Expected %s but got %s", "1", v.ContextID)
+			return
+		}
+	}
+}
+
+func TestGenerateAndSendMessageInjectsRelevantExcerpts(t *testing.T) {
+	s := getMockedPromptService()
+	note := entities.Note{Base: entities.Base{ID: "note"}, Header: "Linear algebra", Payload: "An eigenvector keeps its direction when a linear transformation is applied.", ContextID: "1"}
+	p, err := s.GenerateAndSendPrompt(prompt.CreatePromptRequest{ContextID: "1", EntityID: note.ID, Value: note})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if strings.Contains(p.Value, note.Payload) {
+		t.Errorf("Expected the prompt to not replay the note but got %s", p.Value)
+		return
+	}
+
+	reply, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: "What is an eigenvector?"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	expected := "Excerpt([1] " + note.Payload + ")\nMessage(What is an eigenvector?)"
+	if reply.Content != expected {
+		t.Errorf("Expected %s but got %s", expected, reply.Content)
+		return
+	}
+	if len(reply.Citations) != 1 || reply.Citations[0].SourceID != note.ID || reply.Citations[0].Number != 1 {
+		t.Errorf("Expected a citation of the note but got %v", reply.Citations)
+		return
+	}
+	if reply.Citations[0].EndOffset != len(note.Payload) {
+		t.Errorf("Expected the citation to cover the whole note but got %d", reply.Citations[0].EndOffset)
+		return
+	}
+
+	_, err = s.DeleteAndSend(p.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	reply, err = s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: "What is an eigenvector?"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if strings.Contains(reply.Content, "Excerpt") {
+		t.Errorf("Expected no excerpts after the prompt is removed but got %s", reply.Content)
+		return
+	}
+}
+
+func TestGenerateAndSendMessageWithoutContext(t *testing.T) {
+	s := getMockedPromptService()
+	_, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{Value: "hi"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "argumentErrorIDMissing" {
+		t.Errorf("Expected \"argumentErrorIDMissing\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestGenerateAndStreamMessagePersistsReply(t *testing.T) {
+	s := getMockedPromptService()
+	var sb strings.Builder
+	reply, err := s.GenerateAndStreamMessage(context.Background(), prompt.CreateMessageRequest{ContextID: "1", Value: "stream this"}, func(delta string) error {
+		sb.WriteString(delta)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if sb.String() != reply.Content {
+		t.Errorf("Expected %s but got %s", reply.Content, sb.String())
+		return
+	}
+
+	res, err := s.FilterMessages(message.FilterMessagesRequest{ContextID: "1", PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 10}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Size != 2 {
+		t.Errorf("Expected 2 messages but got %d", res.Size)
+		return
+	}
+}
+
+func TestGenerateAndStreamMessageStopsWhenCancelled(t *testing.T) {
+	s := getMockedPromptService()
+	ctx, cancel := context.WithCancel(context.Background())
+	count := 0
+	_, err := s.GenerateAndStreamMessage(ctx, prompt.CreateMessageRequest{ContextID: "1", Value: "a b c d"}, func(delta string) error {
+		count++
+		cancel()
+		return nil
+	})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if count != 1 {
+		t.Errorf("Expected the stream to stop after the first delta but got %d", count)
+		return
+	}
+
+	res, err := s.FilterMessages(message.FilterMessagesRequest{ContextID: "1", PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 10}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Size != 0 {
+		t.Errorf("Expected no stored messages but got %d", res.Size)
+		return
+	}
+}
+
+func TestGenerateAndStreamMessageRecordsUsageWhenCancelled(t *testing.T) {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	userRepo := mocks.NewMockRepo[entities.User]()
+	userRepo.Create(&entities.User{Name: "customer", Role: entities.Customer})
+	usageRepo := mocks.NewMockRepo[entities.UsageRecord]()
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	contextRepo.Create(&entities.Context{UserID: "1"})
+	s := services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(nil), &mocks.MockAiProviderRegistry{Manager: implementations.NewEchoCommunicationManager()}, getMockedRetrievalService(), services.NewUsageService(usageRepo, userRepo, logger, nil), 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := s.GenerateAndStreamMessage(ctx, prompt.CreateMessageRequest{ContextID: "1", Value: "a b c d"}, func(delta string) error {
+		cancel()
+		return nil
+	})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	records, _ := usageRepo.Query().Find(false)
+	if len(records) != 1 || records[0].PromptTokens == 0 || records[0].CompletionTokens == 0 {
+		t.Errorf("Expected the streamed part to be recorded but got %v", records)
+		return
+	}
+}
+
+func TestDeleteAndSendForgetsWhatWasRemembered(t *testing.T) {
+	s, recorder := getRecordedPromptService(0)
+	note := entities.Note{Base: entities.Base{ID: "note"}, Header: "Linear algebra", Payload: "An eigenvector keeps its direction.", ContextID: "1"}
+	p, err := s.GenerateAndSendPrompt(prompt.CreatePromptRequest{ContextID: "1", EntityID: note.ID, Value: note})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	_, err = s.DeleteAndSend(p.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(recorder.Prompts) != 2 {
+		t.Errorf("Expected 2 prompts but got %d", len(recorder.Prompts))
+		return
+	}
+	expected := "Prompt(Forget(" + p.Subject + "))"
+	if recorder.Prompts[1] != expected {
+		t.Errorf("Expected %s but got %s", expected, recorder.Prompts[1])
+		return
+	}
+	if !strings.Contains(p.Subject, note.Header) {
+		t.Errorf("Expected the subject to name the note but got %s", p.Subject)
+		return
+	}
+}
+
+func TestUpdatePromptForgetsOldVersionBeforeRemembering(t *testing.T) {
+	s, recorder := getRecordedPromptService(0)
+	note := entities.Note{Base: entities.Base{ID: "note"}, Header: "Linear algebra", Payload: "An eigenvector keeps its direction.", ContextID: "1"}
+	_, err := s.GenerateAndSendPrompt(prompt.CreatePromptRequest{ContextID: "1", EntityID: note.ID, Value: note})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	note.Header = "Eigenvalues"
+	_, err = s.UpdatePrompt(prompt.UpdatePromptRequest{ContextID: "1", EntityID: note.ID, Value: note})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(recorder.Prompts) != 3 {
+		t.Errorf("Expected 3 prompts but got %d", len(recorder.Prompts))
+		return
+	}
+	if !strings.HasPrefix(recorder.Prompts[1], "Prompt(Forget(Note \"Linear algebra\"") {
+		t.Errorf("Expected the old note to be forgotten but got %s", recorder.Prompts[1])
+		return
+	}
+	if !strings.HasPrefix(recorder.Prompts[2], "Prompt(Remember(Note \"Eigenvalues\"") {
+		t.Errorf("Expected the new note to be remembered but got %s", recorder.Prompts[2])
+		return
+	}
+
+	reply, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: "What is an eigenvector?"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if strings.Count(reply.Content, "Excerpt") != 1 {
+		t.Errorf("Expected only the excerpt of the new version but got %s", reply.Content)
+		return
+	}
+}
+
+func TestSoftResetKeepsPrompts(t *testing.T) {
+	s, recorder := getRecordedPromptService(0)
+	note := entities.Note{Base: entities.Base{ID: "note"}, Header: "Linear algebra", Payload: "An eigenvector keeps its direction.", ContextID: "1"}
+	_, err := s.GenerateAndSendPrompt(prompt.CreatePromptRequest{ContextID: "1", EntityID: note.ID, Value: note})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	err = s.ResetContext(contextRequests.ResetContextRequest{ID: "1", Mode: "soft"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(recorder.Resets) != 1 || !recorder.Resets[0].IsSoftReset || recorder.Resets[0].ContextID != "1" {
+		t.Errorf("Expected a soft reset of context 1 but got %v", recorder.Resets)
+		return
+	}
+	found, err := s.FindByEntityAndContext(prompt.FindPromptByEntityAndContextRequest{ContextID: "1", EntityID: note.ID})
+	if err != nil {
+		t.Errorf("Expected the prompt to be kept but got %s", err.Error())
+		return
+	}
+	if found.EntityID == nil || *found.EntityID != note.ID {
+		t.Errorf("Expected %s but got %v", note.ID, found.EntityID)
+		return
+	}
+}
+
+func TestHardResetForgetsEveryPrompt(t *testing.T) {
+	s, recorder := getRecordedPromptService(0)
+	note := entities.Note{Base: entities.Base{ID: "note"}, Header: "Linear algebra", Payload: "An eigenvector keeps its direction.", ContextID: "1"}
+	_, err := s.GenerateAndSendPrompt(prompt.CreatePromptRequest{ContextID: "1", EntityID: note.ID, Value: note})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	err = s.ResetContext(contextRequests.ResetContextRequest{ID: "1", Mode: "hard"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(recorder.Resets) != 1 || recorder.Resets[0].IsSoftReset {
+		t.Errorf("Expected a hard reset but got %v", recorder.Resets)
+		return
+	}
+	_, err = s.FindByEntityAndContext(prompt.FindPromptByEntityAndContextRequest{ContextID: "1", EntityID: note.ID})
+	if err == nil || err.Error() != "notFoundError" {
+		t.Errorf("Expected \"notFoundError\" but got %v", err)
+		return
+	}
+	reply, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: "What is an eigenvector?"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if strings.Contains(reply.Content, "Excerpt") {
+		t.Errorf("Expected no excerpts after a hard reset but got %s", reply.Content)
+		return
+	}
+
+	// Editing the note afterwards remembers it again without forgetting anything
+	_, err = s.UpdatePrompt(prompt.UpdatePromptRequest{ContextID: "1", EntityID: note.ID, Value: note})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	last := recorder.Prompts[len(recorder.Prompts)-1]
+	if len(recorder.Prompts) != 2 || !strings.HasPrefix(last, "Prompt(Remember(") {
+		t.Errorf("Expected the note to be remembered again but got %v", recorder.Prompts)
+		return
+	}
+}
+
+func TestResetContextRejectsUnknownMode(t *testing.T) {
+	s, recorder := getRecordedPromptService(0)
+	err := s.ResetContext(contextRequests.ResetContextRequest{ID: "1", Mode: "partial"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "argumentErrorUnknownResetMode" {
+		t.Errorf("Expected \"argumentErrorUnknownResetMode\" but got %s", err.Error())
+		return
+	}
+	if len(recorder.Resets) != 0 {
+		t.Errorf("Expected no resets but got %v", recorder.Resets)
+		return
+	}
+}
+
+func TestLongConversationIsCompactedIntoSummary(t *testing.T) {
+	s, recorder := getRecordedPromptService(60)
+	for _, value := range []string{"What is an eigenvector?", "How do I find eigenvalues?", "What is a determinant?", "Why is it zero?"} {
+		_, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: value})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	if len(recorder.Summaries) == 0 {
+		t.Errorf("Expected the conversation to be compacted but it was not")
+		return
+	}
+	summaries, err := s.FilterAll(prompt.FilterPromptsRequest{ContextIDs: &[]string{"1"}, PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 10}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if summaries.Size != 1 || !summaries.Content[0].IsSummary {
+		t.Errorf("Expected a single summary prompt but got %v", summaries.Content)
+		return
+	}
+	if summaries.Content[0].Value != recorder.Summaries[len(recorder.Summaries)-1] {
+		t.Errorf("Expected the stored summary to be the last one sent but got %s", summaries.Content[0].Value)
+		return
+	}
+	if !strings.Contains(summaries.Content[0].Subject, "user: What is an eigenvector?") {
+		t.Errorf("Expected the summary to cover the first message but got %s", summaries.Content[0].Subject)
+		return
+	}
+
+	res, err := s.FilterMessages(message.FilterMessagesRequest{ContextID: "1", PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 20}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if res.Size != 8 {
+		t.Errorf("Expected the full history of 8 messages but got %d", res.Size)
+		return
+	}
+	if !res.Content[0].IsCompacted || res.Content[7].IsCompacted {
+		t.Errorf("Expected only older messages to be compacted but got %v", res.Content)
+		return
+	}
+}
+
+func TestShortConversationIsNotCompacted(t *testing.T) {
+	s, recorder := getRecordedPromptService(0)
+	for range 4 {
+		_, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: "What is an eigenvector?"})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	if len(recorder.Summaries) != 0 {
+		t.Errorf("Expected no compaction but got %v", recorder.Summaries)
+		return
+	}
+}
+
+func TestResetMessagesAreNotSummarized(t *testing.T) {
+	s, recorder := getRecordedPromptService(60)
+	_, err := s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: "Forget about this question"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	err = s.ResetContext(contextRequests.ResetContextRequest{ID: "1", Mode: "soft"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	for _, value := range []string{"What is an eigenvector?", "How do I find eigenvalues?", "What is a determinant?", "Why is it zero?"} {
+		_, err = s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: "1", Value: value})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	for _, summary := range recorder.Summaries {
+		if strings.Contains(summary, "Forget about this question") {
+			t.Errorf("Expected the reset message to stay out of the summary but got %s", summary)
+			return
+		}
+	}
+}
+
+func getRecordedPromptService(windowTokens int) (*services.PromptService, *mocks.MockAiCommunicationManager) {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	recorder := mocks.NewMockAiCommunicationManager(implementations.NewEchoCommunicationManager())
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	contextRepo.Create(&entities.Context{UserID: "1"})
+	s := services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(nil), &mocks.MockAiProviderRegistry{Manager: recorder}, getMockedRetrievalService(), getMockedUsageService(nil), windowTokens)
+	return s, recorder
+}
+
+func getMockedPromptService() *services.PromptService {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	promptManager := implementations.NewLocalPromptGenManager(nil)
+	providers := implementations.NewConfiguredAiProviderRegistry(&util.Configuration{}, logger)
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	for range 2 {
+		contextRepo.Create(&entities.Context{UserID: "1"})
+	}
+	return services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, promptManager, providers, getMockedRetrievalService(), getMockedUsageService(nil), 0)
+}
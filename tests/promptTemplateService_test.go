@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/base"
+	"echo-api/models/dtos/requests/prompt"
+	"echo-api/models/dtos/requests/template"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSeedDefaultsStoresEveryTemplateOnce(t *testing.T) {
+	s := getMockedPromptTemplateService()
+	for range 2 {
+		err := s.SeedDefaults()
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	res, err := s.FilterAll(template.FilterPromptTemplatesRequest{PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 100}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.TotalCount != len(managers.DefaultTemplates) {
+		t.Errorf("Expected %d templates but got %d", len(managers.DefaultTemplates), res.TotalCount)
+		return
+	}
+}
+
+func TestCreateTemplateRejectsUnknownNameAndInvalidBody(t *testing.T) {
+	s := getMockedPromptTemplateService()
+	cases := map[string]template.CreatePromptTemplateRequest{
+		"templateErrorUnknownName": {Name: "greeting", Body: "Hi"},
+		"templateErrorInvalidBody": {Name: "message", Body: "Message({{.Question}})"},
+	}
+	for expected, request := range cases {
+		_, err := s.CreateOne(request)
+		if err == nil {
+			t.Errorf("Expected errors but got none")
+			return
+		}
+		if err.Error() != expected {
+			t.Errorf("Expected \"%s\" but got %s", expected, err.Error())
+			return
+		}
+	}
+}
+
+func TestUpdateTemplateAddsVersionPerLanguage(t *testing.T) {
+	s := getMockedPromptTemplateService()
+	german := "de"
+	first, err := s.CreateOne(template.CreatePromptTemplateRequest{Name: "message", Body: "Message: {{.Value}}"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	_, err = s.CreateOne(template.CreatePromptTemplateRequest{Name: "message", LanguageID: &german, Body: "Nachricht: {{.Value}}"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	second, err := s.UpdateOne(template.UpdatePromptTemplateRequest{ID: first.ID, Body: "Question: {{.Value}}"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if second.Version != 2 || second.LanguageID != nil {
+		t.Errorf("Expected the second language independent version but got %v", second)
+		return
+	}
+	for languageID, expected := range map[string]string{"": "Question: {{.Value}}", "de": "Nachricht: {{.Value}}", "fr": "Question: {{.Value}}"} {
+		body, err := s.GetTemplate(managers.Message, languageID)
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+		if body != expected {
+			t.Errorf("Expected %s for \"%s\" but got %s", expected, languageID, body)
+			return
+		}
+	}
+
+	_, err = s.DeleteOne(second.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	body, _ := s.GetTemplate(managers.Message, "")
+	if body != "Message: {{.Value}}" {
+		t.Errorf("Expected the previous version back but got %s", body)
+		return
+	}
+}
+
+func TestPromptGenRendersStoredTemplates(t *testing.T) {
+	s := getMockedPromptTemplateService()
+	_, err := s.CreateOne(template.CreatePromptTemplateRequest{Name: "excerpt", Body: "<{{.Number}}>{{.Value}}"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	m := implementations.NewLocalPromptGenManager(s)
+
+	res, err := m.GenerateMessageWith("Why?", []entities.Chunk{{Content: "Because"}}, "")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res != "<1>Because\nMessage(Why?)" {
+		t.Errorf("Expected the stored excerpt template with the default message one but got %s", res)
+		return
+	}
+}
+
+func TestConversationStartsWithInitialPromptOfContextLanguage(t *testing.T) {
+	var received recordedChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Hallo"}}]}`))
+	}))
+	defer server.Close()
+
+	logger := getTestLogger()
+	templates := getMockedPromptTemplateService()
+	german := "de"
+	_, err := templates.CreateOne(template.CreatePromptTemplateRequest{Name: "initial", LanguageID: &german, Body: "Antworte auf Deutsch."})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	configuration := &util.Configuration{IsAiAssistantEnabled: true, AiBaseUrl: server.URL, AiModel: "test-model"}
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	conversation, _ := contextRepo.Create(&entities.Context{UserID: "1", LanguageID: "de"})
+	s := services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(templates), implementations.NewConfiguredAiProviderRegistry(configuration, logger), getMockedRetrievalService(), getMockedUsageService(nil), 0)
+
+	_, err = s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: conversation.ID, Value: "hi"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(received.Messages) != 2 || received.Messages[0].Content != "Antworte auf Deutsch." {
+		t.Errorf("Expected the german initial prompt but got %v", received.Messages)
+		return
+	}
+}
+
+func getMockedPromptTemplateService() *services.PromptTemplateService {
+	return services.NewPromptTemplateService(mocks.NewMockRepo[entities.PromptTemplate](), getTestLogger())
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/base"
+	"echo-api/models/dtos/requests/quiz"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const generatedQuizReply = "```json\n[" +
+	`{"kind":"multipleChoice","question":"What keeps its direction?","options":["An eigenvector","A matrix"],"correctOption":0},` +
+	`{"kind":"shortAnswer","question":"What scales an eigenvector?","answer":"Its eigenvalue"},` +
+	`{"kind":"multipleChoice","question":"Skipped","options":["Only one"],"correctOption":3}` +
+	"]\n```"
+
+func TestGenerateQuizStoresGradableQuestions(t *testing.T) {
+	var received recordedChatRequest
+	reply := generatedQuizReply
+	server := getQuizServer(&received, &reply)
+	defer server.Close()
+
+	s := getMockedQuizService(server.URL)
+	userID := "1"
+	created, err := s.Generate(quiz.GenerateQuizRequest{ContextID: "1", QuestionCount: 3, UserID: &userID})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(created.Questions) != 2 || created.Questions[0].Number != 1 || created.Questions[1].Kind != entities.ShortAnswerQuestion {
+		t.Errorf("Expected the two gradable questions but got %v", created.Questions)
+		return
+	}
+	if len(received.Messages) != 1 || !strings.Contains(received.Messages[0].Content, "eigenvector keeps its direction") || strings.Contains(received.Messages[0].Content, "other context") {
+		t.Errorf("Expected a single request with the material of the context but got %v", received.Messages)
+		return
+	}
+	found, err := s.GetOne(created.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if len(found.Questions) != 2 {
+		t.Errorf("Expected the questions to be stored but got %v", found.Questions)
+		return
+	}
+}
+
+func TestGenerateQuizRejectsContextWithoutMaterial(t *testing.T) {
+	s := getMockedQuizService("http://localhost")
+	userID := "1"
+	_, err := s.Generate(quiz.GenerateQuizRequest{ContextID: "3", UserID: &userID})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "quizErrorNoMaterial" {
+		t.Errorf("Expected \"quizErrorNoMaterial\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestSubmitAttemptGradesMultipleChoiceWithoutAssistant(t *testing.T) {
+	var received recordedChatRequest
+	reply := generatedQuizReply
+	server := getQuizServer(&received, &reply)
+	defer server.Close()
+
+	s := getMockedQuizService(server.URL)
+	created := generateQuiz(t, s)
+	received = recordedChatRequest{}
+	wrong := 1
+	attempt, err := s.SubmitAttempt(quiz.SubmitAttemptRequest{QuizID: created.ID, UserID: "1", Answers: []quiz.AnswerRequest{
+		{QuestionID: created.Questions[0].ID, SelectedOption: &wrong},
+		{QuestionID: created.Questions[1].ID, Text: "its  EIGENVALUE."},
+	}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(received.Messages) != 0 {
+		t.Errorf("Expected no request to the assistant but got %v", received.Messages)
+		return
+	}
+	if attempt.CorrectCount != 1 || attempt.QuestionCount != 2 || attempt.Score != 50 {
+		t.Errorf("Expected half of the answers to be correct but got %v", attempt)
+		return
+	}
+	if attempt.Answers[0].IsCorrect || attempt.Answers[0].Expected != "An eigenvector" || !attempt.Answers[1].IsCorrect {
+		t.Errorf("Expected the graded answers but got %v", attempt.Answers)
+		return
+	}
+}
+
+func TestSubmitAttemptGradesShortAnswerWithAssistant(t *testing.T) {
+	var received recordedChatRequest
+	reply := generatedQuizReply
+	server := getQuizServer(&received, &reply)
+	defer server.Close()
+
+	s := getMockedQuizService(server.URL)
+	created := generateQuiz(t, s)
+	reply = `{"correct": true, "feedback": "Same meaning."}`
+	attempt, err := s.SubmitAttempt(quiz.SubmitAttemptRequest{QuizID: created.ID, UserID: "1", Answers: []quiz.AnswerRequest{
+		{QuestionID: created.Questions[1].ID, Text: "The matching eigenvalue does"},
+	}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if !strings.Contains(received.Messages[0].Content, "Given answer: The matching eigenvalue does") {
+		t.Errorf("Expected the answer to be sent for grading but got %v", received.Messages)
+		return
+	}
+	if attempt.CorrectCount != 1 || attempt.Answers[0].IsCorrect || !attempt.Answers[1].IsCorrect || attempt.Answers[1].Feedback != "Same meaning." {
+		t.Errorf("Expected only the short answer to be correct but got %v", attempt.Answers)
+		return
+	}
+}
+
+func TestSubmitAttemptRejectsUnknownQuestion(t *testing.T) {
+	reply := generatedQuizReply
+	server := getQuizServer(&recordedChatRequest{}, &reply)
+	defer server.Close()
+
+	s := getMockedQuizService(server.URL)
+	created := generateQuiz(t, s)
+	_, err := s.SubmitAttempt(quiz.SubmitAttemptRequest{QuizID: created.ID, UserID: "1", Answers: []quiz.AnswerRequest{{QuestionID: "unknown", Text: "answer"}}})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "argumentErrorUnknownQuestion" {
+		t.Errorf("Expected \"argumentErrorUnknownQuestion\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestFilterAttemptsOnlyReturnsAttemptsOfUser(t *testing.T) {
+	reply := generatedQuizReply
+	server := getQuizServer(&recordedChatRequest{}, &reply)
+	defer server.Close()
+
+	s := getMockedQuizService(server.URL)
+	created := generateQuiz(t, s)
+	s.SubmitAttempt(quiz.SubmitAttemptRequest{QuizID: created.ID, UserID: "1"})
+	s.SubmitAttempt(quiz.SubmitAttemptRequest{QuizID: created.ID, UserID: "2"})
+	res, err := s.FilterAttempts(quiz.FilterAttemptsRequest{UserID: "1", PaginationRequestBase: base.PaginationRequestBase{Page: 1, Size: 10}})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Size != 1 || res.Content[0].UserID != "1" || res.Content[0].Score != 0 {
+		t.Errorf("Expected the single unanswered attempt of the user but got %v", res.Content)
+		return
+	}
+}
+
+func generateQuiz(t *testing.T, s *services.QuizService) entities.Quiz {
+	userID := "1"
+	created, err := s.Generate(quiz.GenerateQuizRequest{ContextID: "1", UserID: &userID})
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	return created
+}
+
+// Answers every request with the current value of reply
+func getQuizServer(received *recordedChatRequest, reply *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(received)
+		content, _ := json.Marshal(*reply)
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":` + string(content) + `}}],"usage":{"prompt_tokens":40,"completion_tokens":20}}`))
+	}))
+}
+
+func getMockedQuizService(aiUrl string) *services.QuizService {
+	logger := getTestLogger()
+	registry := implementations.NewConfiguredAiProviderRegistry(getMultiProviderConfiguration(aiUrl, aiUrl), logger)
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	contextRepo.Create(&entities.Context{UserID: "1"})
+	contextRepo.Create(&entities.Context{UserID: "1"})
+	contextRepo.Create(&entities.Context{UserID: "1"})
+	noteRepo := mocks.NewMockRepo[entities.Note]()
+	noteRepo.Create(&entities.Note{Header: "Linear algebra", Payload: "An eigenvector keeps its direction when the matrix is applied.", ContextID: "1"})
+	noteRepo.Create(&entities.Note{Header: "Unrelated", Payload: "Material of the other context.", ContextID: "2"})
+	ps := services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(nil), registry, getMockedRetrievalService(), getMockedUsageService(nil), 0)
+	return services.NewQuizService(mocks.NewMockRepo[entities.Quiz](), mocks.NewMockRepo[entities.Question](), mocks.NewMockRepo[entities.QuizAttempt](), mocks.NewMockRepo[entities.AttemptAnswer](), noteRepo, mocks.NewMockRepo[entities.Document](), logger, ps)
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitCoversTextWithOverlappingChunks(t *testing.T) {
+	m := implementations.NewLocalChunkingManager(50)
+	text := strings.Repeat("matrix vector scalar tensor ", 20)
+	chunks := m.Split(text)
+
+	if len(chunks) < 2 {
+		t.Errorf("Expected multiple chunks but got %d", len(chunks))
+		return
+	}
+	for i, chunk := range chunks {
+		if len(chunk.Content) > 50 {
+			t.Errorf("Expected chunks of at most 50 bytes but got %d", len(chunk.Content))
+			return
+		}
+		if text[chunk.StartOffset:chunk.EndOffset] != chunk.Content {
+			t.Errorf("Expected offsets to point at the chunk content but got %s", text[chunk.StartOffset:chunk.EndOffset])
+			return
+		}
+		if i > 0 && chunk.StartOffset >= chunks[i-1].EndOffset {
+			t.Errorf("Expected chunk %d to overlap with the previous one", i)
+			return
+		}
+	}
+	if chunks[len(chunks)-1].EndOffset != len(strings.TrimSpace(text)) {
+		t.Errorf("Expected the last chunk to end with the text but got %d", chunks[len(chunks)-1].EndOffset)
+		return
+	}
+}
+
+func TestEmbedIsDeterministic(t *testing.T) {
+	first, _ := implementations.NewLocalEmbeddingManager().Embed([]string{"Eigenvalues of a symmetric matrix"})
+	second, _ := implementations.NewLocalEmbeddingManager().Embed([]string{"Eigenvalues of a symmetric matrix"})
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Expected the same vector for the same text")
+		return
+	}
+}
+
+func TestRetrieveRanksRelevantChunksFirst(t *testing.T) {
+	s := getMockedRetrievalService()
+	notes := []entities.Note{
+		{Base: entities.Base{ID: "photosynthesis"}, Payload: "Photosynthesis converts light energy into chemical energy inside chloroplasts."},
+		{Base: entities.Base{ID: "eigen"}, Payload: "An eigenvector of a matrix keeps its direction, the eigenvalue scales it."},
+		{Base: entities.Base{ID: "revolution"}, Payload: "The French Revolution started in 1789 with the storming of the Bastille."},
+	}
+	for _, note := range notes {
+		_, err := s.IndexNote("ctx", note)
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+	_, err := s.IndexNote("other", entities.Note{Base: entities.Base{ID: "other"}, Payload: "Eigenvector eigenvalue matrix"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	res, err := s.Retrieve("ctx", "How does a matrix change an eigenvector?")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(res) == 0 || res[0].SourceID != "eigen" {
+		t.Errorf("Expected the eigenvector note first but got %v", res)
+		return
+	}
+	for _, chunk := range res {
+		if chunk.ContextID != "ctx" {
+			t.Errorf("Expected %s but got %s", "ctx", chunk.ContextID)
+			return
+		}
+	}
+}
+
+func TestIndexNoteReplacesPreviousChunks(t *testing.T) {
+	s := getMockedRetrievalService()
+	note := entities.Note{Base: entities.Base{ID: "note"}, Payload: "first version"}
+	_, err := s.IndexNote("ctx", note)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	note.Payload = "second version"
+	_, err = s.IndexNote("ctx", note)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	res, err := s.Retrieve("ctx", "version")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(res) != 1 || res[0].Content != "second version" {
+		t.Errorf("Expected only the second version but got %v", res)
+		return
+	}
+}
+
+func TestIndexNoteEmbedsSameContentOnlyOnce(t *testing.T) {
+	embeddingManager := mocks.NewMockEmbeddingManager(implementations.NewLocalEmbeddingManager())
+	s := getRetrievalServiceWith(embeddingManager)
+	for _, id := range []string{"first", "second"} {
+		_, err := s.IndexNote("ctx", entities.Note{Base: entities.Base{ID: id}, Payload: "same content"})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	if len(embeddingManager.Embedded) != 1 {
+		t.Errorf("Expected the content to be embedded once but got %d", len(embeddingManager.Embedded))
+		return
+	}
+}
+
+func TestIndexDocumentKeepsSectionOfChunks(t *testing.T) {
+	s := getMockedRetrievalService()
+	doc := entities.Document{
+		Base: entities.Base{ID: "doc"},
+		Text: "Kinematics describes motion\n\nDynamics explains forces",
+		Sections: []entities.DocumentSection{
+			{Title: "Page 1", StartOffset: 0, EndOffset: 27},
+			{Title: "Page 2", StartOffset: 29, EndOffset: 53},
+		},
+	}
+	_, err := s.IndexDocument("ctx", doc)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	res, err := s.Retrieve("ctx", "forces")
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(res) == 0 || res[0].Section != "Page 1" {
+		t.Errorf("Expected the chunk to start on the first page but got %v", res)
+		return
+	}
+}
+
+func getMockedRetrievalService() *services.RetrievalService {
+	return getRetrievalServiceWith(implementations.NewLocalEmbeddingManager())
+}
+
+func getRetrievalServiceWith(embeddingManager managers.EmbeddingManager) *services.RetrievalService {
+	logger := util.NewLogger(map[string]string{}, os.Stdout)
+	chunkingManager := implementations.NewLocalChunkingManager(0)
+	return services.NewRetrievalService(mocks.NewMockRepo[entities.Chunk](), mocks.NewMockRepo[entities.CachedEmbedding](), logger, chunkingManager, embeddingManager, 0)
+}
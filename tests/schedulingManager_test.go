@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"testing"
+	"time"
+)
+
+func TestSm2GrowsIntervalOfRecalledCards(t *testing.T) {
+	m := implementations.NewSm2SchedulingManager()
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	state := m.Initial()
+	expected := []int{1, 6, 15, 38}
+	for i, interval := range expected {
+		var err error
+		state, err = m.Schedule(state, 4, now)
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+		if state.IntervalDays != interval {
+			t.Errorf("Expected interval %d after review %d but got %d", interval, i+1, state.IntervalDays)
+			return
+		}
+	}
+
+	if state.EaseFactor != 2.5 {
+		t.Errorf("Expected grade 4 to keep the ease factor but got %f", state.EaseFactor)
+		return
+	}
+	if !state.DueAt.Equal(now.AddDate(0, 0, 38)) {
+		t.Errorf("Expected the card to be due in 38 days but got %s", state.DueAt)
+		return
+	}
+}
+
+func TestSm2RestartsForgottenCards(t *testing.T) {
+	m := implementations.NewSm2SchedulingManager()
+	now := time.Now()
+	state := m.Initial()
+	for range 3 {
+		state, _ = m.Schedule(state, 5, now)
+	}
+	state, err := m.Schedule(state, 1, now)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if state.Repetitions != 0 || state.IntervalDays != 1 {
+		t.Errorf("Expected the card to start over but got %v", state)
+		return
+	}
+	if state.EaseFactor >= 2.8 {
+		t.Errorf("Expected a failed review to lower the ease factor but got %f", state.EaseFactor)
+		return
+	}
+}
+
+func TestSm2KeepsMinimumEaseFactor(t *testing.T) {
+	m := implementations.NewSm2SchedulingManager()
+	state := m.Initial()
+	for range 10 {
+		state, _ = m.Schedule(state, 0, time.Now())
+	}
+
+	if state.EaseFactor != 1.3 {
+		t.Errorf("Expected %f but got %f", 1.3, state.EaseFactor)
+		return
+	}
+}
+
+func TestSm2RejectsGradeOutOfRange(t *testing.T) {
+	m := implementations.NewSm2SchedulingManager()
+	for _, grade := range []int{-1, 6} {
+		_, err := m.Schedule(m.Initial(), grade, time.Now())
+		if err == nil || err.Error() != "argumentErrorInvalidGrade" {
+			t.Errorf("Expected \"argumentErrorInvalidGrade\" for %d but got %v", grade, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestTokensSignedWithRotatedKeyStillVerify(t *testing.T) {
+	oldKey := writeEcKey(t, "old.pem")
+	newKey := writeEcKey(t, "new.pem")
+	before := getTokenServiceWithKeys(t, &util.Configuration{SigningKeys: []util.SigningKeyConfig{{ID: "old", KeyFile: oldKey}}})
+	issued, err := before.Issue(entities.User{Base: entities.Base{ID: "1"}, Role: entities.Customer})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	after := getTokenServiceWithKeys(t, &util.Configuration{ActiveSigningKey: "new", SigningKeys: []util.SigningKeyConfig{{ID: "new", KeyFile: newKey}, {ID: "old", KeyFile: oldKey}}})
+	_, err = after.ParseAccessToken(issued.AccessToken)
+	if err != nil {
+		t.Errorf("Expected the token of the old key to verify but got %s", err.Error())
+		return
+	}
+	rotated, _ := after.Issue(entities.User{Base: entities.Base{ID: "1"}, Role: entities.Customer})
+	token, _, _ := new(jwt.Parser).ParseUnverified(rotated.AccessToken, jwt.MapClaims{})
+	if token.Header["kid"] != "new" || token.Method.Alg() != "ES256" {
+		t.Errorf("Expected new tokens to be signed by the new key but got %v", token.Header)
+		return
+	}
+	removed := getTokenServiceWithKeys(t, &util.Configuration{SigningKeys: []util.SigningKeyConfig{{ID: "new", KeyFile: newKey}}})
+	_, err = removed.ParseAccessToken(issued.AccessToken)
+	if err == nil {
+		t.Errorf("Expected the token of a removed key to be rejected")
+		return
+	}
+}
+
+func TestPublicKeysOnlyListAsymmetricKeys(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaPath := filepath.Join(dir, "rsa.pem")
+	os.WriteFile(rsaPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), 0600)
+	c := &util.Configuration{JwtSecret: testSigningSecret, SigningKeys: []util.SigningKeyConfig{{ID: "rsa", KeyFile: rsaPath}, {ID: "ec", KeyFile: writeEcKey(t, "ec.pem")}}}
+	m, err := implementations.NewConfiguredSigningKeyManager(c, getTestLogger())
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	keys := m.PublicKeys().Keys
+	if len(keys) != 2 || keys[0].KeyID != "ec" || keys[0].Curve != "P-256" || keys[1].KeyID != "rsa" || keys[1].Algorithm != "RS256" || keys[1].E != "AQAB" {
+		t.Errorf("Expected the EC and RSA keys but got %v", keys)
+		return
+	}
+	if m.ActiveKey().ID != "env" {
+		t.Errorf("Expected the key from the environment to be active but got %s", m.ActiveKey().ID)
+		return
+	}
+}
+
+func TestParseAccessTokenRejectsAlgorithmOfOtherKey(t *testing.T) {
+	s := getTokenServiceWithKeys(t, &util.Configuration{SigningKeys: []util.SigningKeyConfig{{ID: "ec", KeyFile: writeEcKey(t, "ec.pem")}}})
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userID": "1", "jti": "forged"})
+	token.Header["kid"] = "ec"
+	forged, _ := token.SignedString([]byte("anything"))
+
+	_, err := s.ParseAccessToken(forged)
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+}
+
+func TestSigningKeyManagerRejectsShortSecret(t *testing.T) {
+	_, err := implementations.NewConfiguredSigningKeyManager(&util.Configuration{JwtSecret: "short"}, getTestLogger())
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "configErrorWeakSigningKey" {
+		t.Errorf("Expected \"configErrorWeakSigningKey\" but got %s", err.Error())
+		return
+	}
+}
+
+func writeEcKey(t *testing.T, name string) string {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(key)
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	return path
+}
+
+func getTokenServiceWithKeys(t *testing.T, c *util.Configuration) *services.TokenService {
+	t.Helper()
+	keys, err := implementations.NewConfiguredSigningKeyManager(c, getTestLogger())
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	return services.NewTokenService(mocks.NewMockRepo[entities.RefreshToken](), mocks.NewMockRepo[entities.RevokedToken](), mocks.NewMockRepo[entities.User](), getTestLogger(), keys, 0, 0)
+}
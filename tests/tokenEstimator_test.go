@@ -0,0 +1,30 @@
+package tests
+
+import (
+	"echo-api/util"
+	"testing"
+)
+
+func TestEstimateTokensCountsCharactersAndWords(t *testing.T) {
+	cases := map[string]int{
+		"":                  0,
+		"eigenvector":       3,
+		"a b c d e f":       6,
+		"What is a matrix?": 5,
+		"özdeğer nedir":     4,
+	}
+	for text, expected := range cases {
+		res := util.EstimateTokens(text)
+		if res != expected {
+			t.Errorf("Expected %d tokens for %q but got %d", expected, text, res)
+		}
+	}
+}
+
+func TestEstimateMessageTokensAddsOverheadPerMessage(t *testing.T) {
+	res := util.EstimateMessageTokens("eigenvector", "")
+	if res != 3+4+4 {
+		t.Errorf("Expected %d but got %d", 3+4+4, res)
+		return
+	}
+}
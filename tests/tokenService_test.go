@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"testing"
+	"time"
+)
+
+func TestRefreshRotatesRefreshToken(t *testing.T) {
+	s, user := getMockedTokenService()
+	issued, err := s.Issue(user)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	refreshed, err := s.Refresh(issued.RefreshToken)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if refreshed.RefreshToken == issued.RefreshToken || refreshed.AccessToken == issued.AccessToken {
+		t.Errorf("Expected a new token pair but got the same tokens")
+		return
+	}
+	claims, err := s.ParseAccessToken(refreshed.AccessToken)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if claims["userID"] != user.ID || claims["sid"] == "" {
+		t.Errorf("Expected the claims of the user's session but got %v", claims)
+		return
+	}
+}
+
+func TestRefreshWithRotatedTokenEndsSession(t *testing.T) {
+	s, user := getMockedTokenService()
+	issued, _ := s.Issue(user)
+	refreshed, _ := s.Refresh(issued.RefreshToken)
+	_, err := s.Refresh(issued.RefreshToken)
+	if err == nil || err.Error() != "tokenErrorRefreshReused" {
+		t.Errorf("Expected \"tokenErrorRefreshReused\" but got %v", err)
+		return
+	}
+
+	_, err = s.Refresh(refreshed.RefreshToken)
+	if err == nil || err.Error() != "tokenErrorRefreshReused" {
+		t.Errorf("Expected the newest refresh token to be revoked too but got %v", err)
+		return
+	}
+	_, err = s.ParseAccessToken(refreshed.AccessToken)
+	if err == nil || err.Error() != "tokenErrorRevoked" {
+		t.Errorf("Expected \"tokenErrorRevoked\" but got %v", err)
+		return
+	}
+}
+
+func TestRevokeSessionOnlyEndsThatSession(t *testing.T) {
+	s, user := getMockedTokenService()
+	first, _ := s.Issue(user)
+	second, _ := s.Issue(user)
+	claims, _ := s.ParseAccessToken(first.AccessToken)
+	err := s.RevokeSession(user.ID, claims["sid"].(string))
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	_, err = s.ParseAccessToken(first.AccessToken)
+	if err == nil || err.Error() != "tokenErrorRevoked" {
+		t.Errorf("Expected \"tokenErrorRevoked\" but got %v", err)
+		return
+	}
+	_, err = s.ParseAccessToken(second.AccessToken)
+	if err != nil {
+		t.Errorf("Expected the other session to stay valid but got %s", err.Error())
+		return
+	}
+}
+
+func TestRevokeAllEndsEverySession(t *testing.T) {
+	s, user := getMockedTokenService()
+	sessions := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		issued, _ := s.Issue(user)
+		sessions = append(sessions, issued.RefreshToken)
+	}
+	err := s.RevokeAll(user.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	for _, refreshToken := range sessions {
+		_, err = s.Refresh(refreshToken)
+		if err == nil {
+			t.Errorf("Expected errors but got none")
+			return
+		}
+	}
+}
+
+func TestRefreshRejectsExpiredToken(t *testing.T) {
+	refreshRepo := mocks.NewMockRepo[entities.RefreshToken]()
+	s, user := getMockedTokenServiceWith(refreshRepo)
+	issued, _ := s.Issue(user)
+	stored, _ := refreshRepo.First("1", false)
+	stored.ExpiresAt = time.Now().Add(-time.Minute)
+	refreshRepo.Update(&stored)
+
+	_, err := s.Refresh(issued.RefreshToken)
+	if err == nil || err.Error() != "tokenErrorRefreshExpired" {
+		t.Errorf("Expected \"tokenErrorRefreshExpired\" but got %v", err)
+		return
+	}
+}
+
+const testSigningSecret = "a-test-secret-that-is-long-enough"
+
+func getMockedTokenService() (*services.TokenService, entities.User) {
+	return getMockedTokenServiceWith(mocks.NewMockRepo[entities.RefreshToken]())
+}
+
+func getMockedTokenServiceWith(refreshRepo *mocks.MockRepository[entities.RefreshToken]) (*services.TokenService, entities.User) {
+	userRepo := mocks.NewMockRepo[entities.User]()
+	user, _ := userRepo.Create(&entities.User{Name: "Ada", Email: "ada@example.com", Role: entities.Customer})
+	keys, _ := implementations.NewConfiguredSigningKeyManager(&util.Configuration{JwtSecret: testSigningSecret}, getTestLogger())
+	s := services.NewTokenService(refreshRepo, mocks.NewMockRepo[entities.RevokedToken](), userRepo, getTestLogger(), keys, 0, 0)
+	return s, user
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"echo-api/managers/implementations"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret of the SHA-1 test vectors of RFC 6238, "12345678901234567890" in base32
+const rfcTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpMatchesRfcVectors(t *testing.T) {
+	vectors := map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924", 2000000000: "279037"}
+	m := implementations.NewRfc6238TotpManager("Echo")
+	for at, code := range vectors {
+		step, ok := m.Validate(rfcTotpSecret, code, time.Unix(at, 0))
+		if !ok || step != at/30 {
+			t.Errorf("Expected %s to be valid in step %d but got %d %v", code, at/30, step, ok)
+		}
+	}
+}
+
+func TestTotpAcceptsOnlyNeighbouringSteps(t *testing.T) {
+	m := implementations.NewRfc6238TotpManager("Echo")
+	if _, ok := m.Validate(rfcTotpSecret, "287082", time.Unix(59+30, 0)); !ok {
+		t.Errorf("Expected the code of the previous step to be accepted")
+		return
+	}
+	if _, ok := m.Validate(rfcTotpSecret, "287082", time.Unix(59+90, 0)); ok {
+		t.Errorf("Expected the code of three steps ago to be rejected")
+		return
+	}
+	if _, ok := m.Validate(rfcTotpSecret, "28708", time.Unix(59, 0)); ok {
+		t.Errorf("Expected a code with a missing digit to be rejected")
+		return
+	}
+}
+
+func TestTotpProvisioningUri(t *testing.T) {
+	m := implementations.NewRfc6238TotpManager("Echo App")
+	secret, err := m.GenerateSecret()
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	uri, err := url.Parse(m.ProvisioningUri(secret, "ada@example.com"))
+	if err != nil {
+		t.Errorf("Expected a valid URI but got %s", err.Error())
+		return
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Echo App:ada@example.com" {
+		t.Errorf("Expected a totp URI labelled with issuer and account but got %s", uri.String())
+		return
+	}
+	if uri.Query().Get("secret") != secret || uri.Query().Get("issuer") != "Echo App" || len(secret) != 32 || strings.Contains(secret, "=") {
+		t.Errorf("Expected the unpadded secret and issuer but got %s", uri.RawQuery)
+		return
+	}
+}
@@ -0,0 +1,212 @@
+package tests
+
+import (
+	"echo-api/mocks"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"strings"
+	"testing"
+)
+
+const testTotpCode = "123456"
+
+func TestActivateTwoFactorReturnsRecoveryCodes(t *testing.T) {
+	s, user := getMockedTwoFactorService(entities.Customer, false)
+	enrollment, err := s.Enroll(user.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if enrollment.Secret != "SECRET" || !strings.Contains(enrollment.OtpauthUri, user.Email) {
+		t.Errorf("Expected the secret and URI of the user but got %v", enrollment)
+		return
+	}
+	if enabled, _ := s.IsEnabled(user.ID); enabled {
+		t.Errorf("Expected two-factor authentication to stay off until activated")
+		return
+	}
+
+	codes, err := s.Activate(user.ID, testTotpCode)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	if len(codes.Codes) != 10 || len(codes.Codes[0]) != 11 || codes.Codes[0][5] != '-' {
+		t.Errorf("Expected ten recovery codes but got %v", codes.Codes)
+		return
+	}
+	if enabled, _ := s.IsEnabled(user.ID); !enabled {
+		t.Errorf("Expected two-factor authentication to be on")
+		return
+	}
+}
+
+func TestVerifyCodeRefusesReplayedCode(t *testing.T) {
+	s, user, _ := getEnabledTwoFactorService(t, entities.Customer, false)
+	// Activation used the code of the current step already
+	err := s.VerifyCode(user.ID, testTotpCode)
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "twoFactorErrorInvalidCode" {
+		t.Errorf("Expected \"twoFactorErrorInvalidCode\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestVerifyCodeUsesUpRecoveryCode(t *testing.T) {
+	s, user, codes := getEnabledTwoFactorService(t, entities.Customer, false)
+	typed := strings.ToUpper(strings.ReplaceAll(codes[3], "-", " "))
+	err := s.VerifyCode(user.ID, typed)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	err = s.VerifyCode(user.ID, codes[3])
+	if err == nil || err.Error() != "twoFactorErrorInvalidCode" {
+		t.Errorf("Expected a used recovery code to be refused but got %v", err)
+		return
+	}
+}
+
+func TestVerifyCodeLocksAfterWrongCodes(t *testing.T) {
+	s, user, codes := getEnabledTwoFactorService(t, entities.Customer, false)
+	for i := 0; i < 5; i++ {
+		s.VerifyCode(user.ID, "000000")
+	}
+
+	err := s.VerifyCode(user.ID, codes[0])
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+	if err.Error() != "twoFactorErrorLocked" {
+		t.Errorf("Expected \"twoFactorErrorLocked\" but got %s", err.Error())
+		return
+	}
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	s, user, codes := getEnabledTwoFactorService(t, entities.Customer, false)
+	err := s.Disable(user.ID, codes[0])
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if enabled, _ := s.IsEnabled(user.ID); enabled {
+		t.Errorf("Expected two-factor authentication to be off")
+		return
+	}
+	err = s.VerifyCode(user.ID, codes[1])
+	if err == nil || err.Error() != "twoFactorErrorNotEnrolled" {
+		t.Errorf("Expected \"twoFactorErrorNotEnrolled\" but got %v", err)
+		return
+	}
+}
+
+func TestDisableTwoFactorRefusedForRequiredAdmin(t *testing.T) {
+	s, user, codes := getEnabledTwoFactorService(t, entities.Admin, true)
+	err := s.Disable(user.ID, codes[0])
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "twoFactorErrorRequired" {
+		t.Errorf("Expected \"twoFactorErrorRequired\" but got %s", err.Error())
+		return
+	}
+	if !s.IsRequiredFor(entities.Admin) || s.IsRequiredFor(entities.Customer) {
+		t.Errorf("Expected two-factor authentication to be required for admins only")
+		return
+	}
+}
+
+func TestChallengeIsNotAnAccessToken(t *testing.T) {
+	tokens, user := getMockedTokenService()
+	challenge, err := tokens.IssueChallenge(user)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	_, err = tokens.ParseAccessToken(challenge.ChallengeToken)
+	if err == nil {
+		t.Errorf("Expected a challenge to be refused as access token")
+		return
+	}
+	claims, err := tokens.ParseChallenge(challenge.ChallengeToken)
+	if err != nil || claims["userID"] != user.ID {
+		t.Errorf("Expected the challenge of the user but got %v %v", claims, err)
+		return
+	}
+	issued, _ := tokens.Issue(user)
+	_, err = tokens.ParseChallenge(issued.AccessToken)
+	if err == nil || err.Error() != "tokenErrorChallengeInvalid" {
+		t.Errorf("Expected an access token to be refused as challenge but got %v", err)
+		return
+	}
+}
+
+func TestUsedChallengeIsRefused(t *testing.T) {
+	tokens, user := getMockedTokenService()
+	challenge, _ := tokens.IssueChallenge(user)
+	claims, err := tokens.ParseChallenge(challenge.ChallengeToken)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	err = tokens.UseChallenge(claims)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	_, err = tokens.ParseChallenge(challenge.ChallengeToken)
+	if err == nil || err.Error() != "tokenErrorChallengeInvalid" {
+		t.Errorf("Expected \"tokenErrorChallengeInvalid\" for a used challenge but got %v", err)
+		return
+	}
+}
+
+func TestSecondFactorClaimSurvivesRefresh(t *testing.T) {
+	tokens, user := getMockedTokenService()
+	issued, err := tokens.IssueAfterSecondFactor(user)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	refreshed, err := tokens.Refresh(issued.RefreshToken)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+	claims, _ := tokens.ParseAccessToken(refreshed.AccessToken)
+	if claims["mfa"] != true {
+		t.Errorf("Expected the mfa claim to be kept but got %v", claims)
+		return
+	}
+}
+
+func getEnabledTwoFactorService(t *testing.T, role entities.Role, requireForAdmins bool) (*services.TwoFactorService, entities.User, []string) {
+	t.Helper()
+	s, user := getMockedTwoFactorService(role, requireForAdmins)
+	s.Enroll(user.ID)
+	codes, err := s.Activate(user.ID, testTotpCode)
+	if err != nil {
+		t.Fatalf("Expected no errors but got %s", err.Error())
+	}
+	return s, user, codes.Codes
+}
+
+func getMockedTwoFactorService(role entities.Role, requireForAdmins bool) (*services.TwoFactorService, entities.User) {
+	users := mocks.NewMockRepo[entities.User]()
+	user, _ := users.Create(&entities.User{Name: "Ada", Email: "ada@example.com", Role: role})
+	s := services.NewTwoFactorService(mocks.NewMockRepo[entities.TwoFactor](), mocks.NewMockRepo[entities.RecoveryCode](), users, getTestLogger(), mocks.NewMockTotpManager(testTotpCode), mocks.NewMockHashingManager(), requireForAdmins)
+	return s, user
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"echo-api/managers"
+	"echo-api/managers/implementations"
+	"echo-api/mocks"
+	"echo-api/models/dtos/requests/prompt"
+	"echo-api/models/dtos/requests/user"
+	"echo-api/models/entities"
+	"echo-api/services"
+	"echo-api/util"
+	"testing"
+	"time"
+)
+
+func TestRecordedUsageIsSummarizedPerModel(t *testing.T) {
+	s := getMockedUsageService(nil)
+	calls := []managers.Completion{
+		{Model: "gpt-small", PromptTokens: 10, CompletionTokens: 5},
+		{Model: "claude-test", PromptTokens: 100, CompletionTokens: 50},
+		{Model: "gpt-small", PromptTokens: 20, CompletionTokens: 5},
+	}
+	for _, completion := range calls {
+		err := s.Record("1", "ctx", entities.MessageUsage, completion)
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+	err := s.Record("2", "ctx", entities.PromptUsage, managers.Completion{Model: "gpt-small", PromptTokens: 1})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	res, err := s.GetUserUsage(user.FilterUsageRequest{UserID: "1"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Calls != 3 || res.TotalTokens != 190 || res.UsedToday != 190 || res.UsedThisMonth != 190 {
+		t.Errorf("Expected 3 calls with 190 tokens but got %v", res)
+		return
+	}
+	if len(res.Models) != 2 || res.Models[1].Model != "gpt-small" || res.Models[1].TotalTokens != 40 || res.Models[1].Calls != 2 {
+		t.Errorf("Expected usage split per model but got %v", res.Models)
+		return
+	}
+}
+
+func TestUsageOutsidePeriodIsNotSummarized(t *testing.T) {
+	s := getMockedUsageService(nil)
+	err := s.Record("1", "ctx", entities.MessageUsage, managers.Completion{Model: "gpt-small", PromptTokens: 10})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	to := time.Now().Add(-time.Hour)
+	res, err := s.GetUserUsage(user.FilterUsageRequest{UserID: "1", To: &to})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if res.Calls != 0 || res.UsedToday != 10 {
+		t.Errorf("Expected nothing in the period while today's usage counts but got %v", res)
+		return
+	}
+}
+
+func TestReportOrdersBiggestSpenderFirst(t *testing.T) {
+	s := getMockedUsageService(nil)
+	usage := map[string]int{"1": 10, "2": 30}
+	for userID, tokens := range usage {
+		err := s.Record(userID, "ctx", entities.MessageUsage, managers.Completion{Model: "gpt-small", CompletionTokens: tokens})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	res, err := s.Report(user.FilterUsageRequest{})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(res) != 2 || res[0].UserID != "2" || res[1].TotalTokens != 10 {
+		t.Errorf("Expected user 2 first but got %v", res)
+		return
+	}
+}
+
+func TestCheckBudgetUsesBudgetOfRole(t *testing.T) {
+	s := getMockedUsageService(map[string]util.TokenBudget{"Customer": {Daily: 100}})
+	for _, userID := range []string{"1", "2"} {
+		err := s.Record(userID, "ctx", entities.MessageUsage, managers.Completion{Model: "gpt-small", PromptTokens: 80, CompletionTokens: 20})
+		if err != nil {
+			t.Errorf("Expected no errors but got %s", err.Error())
+			return
+		}
+	}
+
+	err := s.CheckBudget("1")
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+	if err.Error() != "budgetErrorDailyExceeded" {
+		t.Errorf("Expected \"budgetErrorDailyExceeded\" but got %s", err.Error())
+		return
+	}
+	err = s.CheckBudget("2")
+	if err != nil {
+		t.Errorf("Expected no budget for admins but got %s", err.Error())
+		return
+	}
+}
+
+func TestMessagesStopOnceMonthlyBudgetIsUsed(t *testing.T) {
+	logger := getTestLogger()
+	usage := getMockedUsageService(map[string]util.TokenBudget{"Customer": {Monthly: 1}})
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	conversation, _ := contextRepo.Create(&entities.Context{UserID: "1"})
+	providers := implementations.NewConfiguredAiProviderRegistry(&util.Configuration{}, logger)
+	s := services.NewPromptService(mocks.NewMockRepo[entities.Prompt](), mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(nil), providers, getMockedRetrievalService(), usage, 0)
+	err := usage.Record("1", conversation.ID, entities.MessageUsage, managers.Completion{Model: "echo", PromptTokens: 1})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	_, err = s.GenerateAndSendMessage(prompt.CreateMessageRequest{ContextID: conversation.ID, Value: "hi"})
+	if err == nil {
+		t.Errorf("Expected errors but got none")
+		return
+	}
+
+	if err.Error() != "budgetErrorMonthlyExceeded" {
+		t.Errorf("Expected \"budgetErrorMonthlyExceeded\" but got %s", err.Error())
+		return
+	}
+}
+
+func getMockedUsageService(budgets map[string]util.TokenBudget) *services.UsageService {
+	userRepo := mocks.NewMockRepo[entities.User]()
+	userRepo.Create(&entities.User{Name: "customer", Role: entities.Customer})
+	userRepo.Create(&entities.User{Name: "admin", Role: entities.Admin})
+	return services.NewUsageService(mocks.NewMockRepo[entities.UsageRecord](), userRepo, getTestLogger(), budgets)
+}
+
+func TestForgetIsSentOnceMonthlyBudgetIsUsed(t *testing.T) {
+	logger := getTestLogger()
+	usage := getMockedUsageService(map[string]util.TokenBudget{"Customer": {Monthly: 1}})
+	contextRepo := mocks.NewMockRepo[entities.Context]()
+	conversation, _ := contextRepo.Create(&entities.Context{UserID: "1"})
+	promptRepo := mocks.NewMockRepo[entities.Prompt]()
+	remembered, _ := promptRepo.Create(&entities.Prompt{ContextID: conversation.ID, Subject: "Linear algebra", Value: "An eigenvector keeps its direction."})
+	recorder := mocks.NewMockAiCommunicationManager(implementations.NewEchoCommunicationManager())
+	s := services.NewPromptService(promptRepo, mocks.NewMockRepo[entities.Message](), contextRepo, logger, implementations.NewLocalPromptGenManager(nil), &mocks.MockAiProviderRegistry{Manager: recorder}, getMockedRetrievalService(), usage, 0)
+	err := usage.Record("1", conversation.ID, entities.MessageUsage, managers.Completion{Model: "echo", PromptTokens: 1})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	_, err = s.DeleteAndSend(remembered.ID)
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if len(recorder.Prompts) != 1 || recorder.Prompts[0] != "Prompt(Forget(Linear algebra))" {
+		t.Errorf("Expected the subject to be forgotten but got %v", recorder.Prompts)
+		return
+	}
+	if count, _ := promptRepo.Query().Count(); count != 0 {
+		t.Errorf("Expected no prompts but got %d", count)
+		return
+	}
+}
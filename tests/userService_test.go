@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"echo-api/managers/implementations"
 	"echo-api/mocks"
 	"echo-api/models/dtos/requests/base"
 	"echo-api/models/dtos/requests/user"
@@ -8,6 +9,7 @@ import (
 	"echo-api/services"
 	"echo-api/util"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -196,9 +198,76 @@ func TestDeleteNotExistingRecord(t *testing.T) {
 	}
 }
 
+func TestCreateUserSendsVerificationMail(t *testing.T) {
+	s, mails := getMockedUserServiceWithMails()
+	created, err := s.CreateOne(user.CreateUserRequest{Name: "XXX YYY", Email: " example@mail.com ", Password: "!testPass_4251"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	if created.Email != "example@mail.com" || created.EmailVerifiedAt != nil {
+		t.Errorf("Expected an unverified user with the trimmed email but got %s %v", created.Email, created.EmailVerifiedAt)
+		return
+	}
+	if len(mails.Sent) != 1 || mails.Sent[0].To != "example@mail.com" || !strings.Contains(mails.Sent[0].Body, "/verify-email?token=") {
+		t.Errorf("Expected a verification mail but got %v", mails.Sent)
+		return
+	}
+}
+
+func TestCreateUserSucceedsWhenMailFails(t *testing.T) {
+	s, mails := getMockedUserServiceWithMails()
+	mails.Fail = true
+	_, err := s.CreateOne(user.CreateUserRequest{Name: "XXX YYY", Email: "example@mail.com", Password: "!testPass_4251"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+}
+
+func TestCreateUserRejectsInvalidRequests(t *testing.T) {
+	cases := []struct {
+		request  user.CreateUserRequest
+		expected string
+	}{
+		{user.CreateUserRequest{Name: "XXX YYY", Email: "not an email", Password: "!testPass_4251"}, "userErrorInvalidEmail"},
+		{user.CreateUserRequest{Name: "XXX YYY", Email: "XXX <example2@mail.com>", Password: "!testPass_4251"}, "userErrorInvalidEmail"},
+		{user.CreateUserRequest{Name: "XXX YYY", Email: "example@mail.com", Password: "!testPass_4251"}, "userErrorEmailTaken"},
+		{user.CreateUserRequest{Name: "XXX YYY", Email: "example2@mail.com", Password: "password"}, "passwordErrorTooShort"},
+		{user.CreateUserRequest{Name: "Margaret", Email: "example2@mail.com", Password: "Margaret_4251"}, "passwordErrorContainsPersonalInfo"},
+	}
+	s := getMockedUserService()
+	_, err := s.CreateOne(user.CreateUserRequest{Name: "XXX YYY", Email: "example@mail.com", Password: "!testPass_4251"})
+	if err != nil {
+		t.Errorf("Expected no errors but got %s", err.Error())
+		return
+	}
+
+	for _, c := range cases {
+		_, err := s.CreateOne(c.request)
+		if err == nil {
+			t.Errorf("Expected %s for %v but got none", c.expected, c.request)
+			continue
+		}
+		if err.Error() != c.expected {
+			t.Errorf("Expected %s for %v but got %s", c.expected, c.request, err.Error())
+		}
+	}
+}
+
 func getMockedUserService() *services.UserService {
+	s, _ := getMockedUserServiceWithMails()
+	return s
+}
+
+func getMockedUserServiceWithMails() (*services.UserService, *mocks.MockMailManager) {
 	mockRepo := mocks.NewMockRepo[entities.User]()
 	logger := util.NewLogger(map[string]string{}, os.Stdout)
 	hasher := mocks.NewMockHashingManager()
-	return services.NewUserService(mockRepo, logger, hasher)
+	policy := implementations.NewConfiguredPasswordPolicyManager(&util.Configuration{})
+	mails := mocks.NewMockMailManager()
+	tokens, _ := getMockedTokenService()
+	accounts := services.NewAccountService(mocks.NewMockRepo[entities.AccountToken](), mockRepo, mocks.NewMockRepo[entities.Password](), logger, mails, policy, hasher, tokens, "https://app.test")
+	return services.NewUserService(mockRepo, logger, hasher, policy, accounts), mails
 }
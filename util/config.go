@@ -7,17 +7,98 @@ import (
 )
 
 type Configuration struct {
-	Version              string   `json:"version"`
-	DbConnectionString   string   `json:"dbConnectionString"`
-	SwaggerUrl           string   `json:"swaggerUrl"`
-	Title                string   `json:"title"`
-	Salt                 string   `json:"passwordSalt"`
-	AcceptedExtensions   []string `json:"acceptedExtensions"`
-	SaveLocations        []string `json:"saveLocations"`
-	IsAiAssistantEnabled bool     `json:"isAiAssistantEnabled"`
-	secretKey            string
+	Version               string                 `json:"version"`
+	DbConnectionString    string                 `json:"dbConnectionString"`
+	SwaggerUrl            string                 `json:"swaggerUrl"`
+	Title                 string                 `json:"title"`
+	Salt                  string                 `json:"passwordSalt"`
+	AcceptedExtensions    []string               `json:"acceptedExtensions"`
+	SaveLocations         []string               `json:"saveLocations"`
+	IsAiAssistantEnabled  bool                   `json:"isAiAssistantEnabled"`
+	AiBaseUrl             string                 `json:"aiBaseUrl"`
+	AiModel               string                 `json:"aiModel"`
+	AiApiKey              string                 `json:"aiApiKey"`
+	AiTimeoutSeconds      int                    `json:"aiTimeoutSeconds"`
+	AiProviders           []AiProvider           `json:"aiProviders"`
+	AiDefaultProvider     string                 `json:"aiDefaultProvider"`
+	RetrievalChunkSize    int                    `json:"retrievalChunkSize"`
+	RetrievalTopK         int                    `json:"retrievalTopK"`
+	EmbeddingProvider     string                 `json:"embeddingProvider"`
+	EmbeddingBaseUrl      string                 `json:"embeddingBaseUrl"`
+	EmbeddingModel        string                 `json:"embeddingModel"`
+	EmbeddingApiKey       string                 `json:"embeddingApiKey"`
+	EmbeddingBatchSize    int                    `json:"embeddingBatchSize"`
+	TokenBudgets          map[string]TokenBudget `json:"tokenBudgets"`
+	AiContextWindowTokens int                    `json:"aiContextWindowTokens"`
+	OcrProvider           string                 `json:"ocrProvider"`
+	OcrBinary             string                 `json:"ocrBinary"`
+	OcrLanguages          string                 `json:"ocrLanguages"`
+	ThumbnailSize         int                    `json:"thumbnailSize"`
+	AccessTokenMinutes    int                    `json:"accessTokenMinutes"`
+	RefreshTokenDays      int                    `json:"refreshTokenDays"`
+	Argon2MemoryKiB       int                    `json:"argon2MemoryKiB"`
+	Argon2Iterations      int                    `json:"argon2Iterations"`
+	Argon2Parallelism     int                    `json:"argon2Parallelism"`
+	SigningKeys           []SigningKeyConfig     `json:"signingKeys"`
+	ActiveSigningKey      string                 `json:"activeSigningKey"`
+	JwtSecret             string                 `json:"jwtSecret"`
+	JwtKeyFile            string                 `json:"jwtKeyFile"`
+	JwtKeyID              string                 `json:"jwtKeyId"`
+	MailProvider          string                 `json:"mailProvider"`
+	MailFrom              string                 `json:"mailFrom"`
+	SmtpHost              string                 `json:"smtpHost"`
+	SmtpPort              int                    `json:"smtpPort"`
+	SmtpUsername          string                 `json:"smtpUsername"`
+	SmtpPassword          string                 `json:"smtpPassword"`
+	PublicUrl             string                 `json:"publicUrl"`
+	PasswordMinLength     int                    `json:"passwordMinLength"`
+	RequireAdminTwoFactor bool                   `json:"requireAdminTwoFactor"`
+	OidcProviders         []OidcProvider         `json:"oidcProviders"`
+	secretKey             string
 }
 
+// Type is one of "openai", "anthropic" or "ollama", an empty Models accepts any model the provider serves
+type AiProvider struct {
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	BaseUrl        string   `json:"baseUrl"`
+	ApiKey         string   `json:"apiKey"`
+	DefaultModel   string   `json:"defaultModel"`
+	Models         []string `json:"models"`
+	TimeoutSeconds int      `json:"timeoutSeconds"`
+	MaxTokens      int      `json:"maxTokens"`
+	// Images of documents are given to the model when it can look at them
+	SupportsImages bool `json:"supportsImages"`
+}
+
+// Secrets are for HS256, key files hold a PEM key for RS256 or ES256. A public key only verifies tokens signed before a rotation
+type SigningKeyConfig struct {
+	ID        string `json:"id"`
+	Algorithm string `json:"algorithm"`
+	Secret    string `json:"secret"`
+	KeyFile   string `json:"keyFile"`
+}
+
+// Issuer is the url the discovery document is found under, RedirectUrl has to be registered at the provider and lead to the callback.
+// Emails only count as verified when the provider says so, TrustEmails is for providers that only hand out addresses they own but do not send email_verified
+type OidcProvider struct {
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"clientId"`
+	ClientSecret string   `json:"clientSecret"`
+	RedirectUrl  string   `json:"redirectUrl"`
+	Scopes       []string `json:"scopes"`
+	TrustEmails  bool     `json:"trustEmails"`
+}
+
+// Token limits of a role counted from the start of the UTC day and month, zero means unlimited
+type TokenBudget struct {
+	Daily   int `json:"daily"`
+	Monthly int `json:"monthly"`
+}
+
+const legacyAiProviderName = "default"
+
 func NewConfiguration(logger *Logger) (*Configuration, error) {
 	config := new(Configuration)
 	//Start filling config with reads
@@ -37,15 +118,94 @@ func NewConfiguration(logger *Logger) (*Configuration, error) {
 	return config, nil
 }
 
+// Only keys the legacy BLAKE3 password hashes, tokens are signed with the configured signing keys
 func (c *Configuration) GetSecretKey() string {
 	return c.secretKey
 }
 
+// Returns the configured providers, aiBaseUrl, aiModel and aiApiKey still work as an OpenAI compatible provider named "default"
+func (c *Configuration) GetAiProviders() []AiProvider {
+	res := append(make([]AiProvider, 0, len(c.AiProviders)+1), c.AiProviders...)
+	if c.AiBaseUrl == "" {
+		return res
+	}
+	for _, p := range res {
+		if p.Name == legacyAiProviderName {
+			return res
+		}
+	}
+	return append(res, AiProvider{
+		Name:           legacyAiProviderName,
+		Type:           "openai",
+		BaseUrl:        c.AiBaseUrl,
+		ApiKey:         c.AiApiKey,
+		DefaultModel:   c.AiModel,
+		TimeoutSeconds: c.AiTimeoutSeconds,
+	})
+}
+
+// Falls back to the first provider when no default is configured
+func (c *Configuration) GetDefaultAiProvider() string {
+	if c.AiDefaultProvider != "" {
+		return c.AiDefaultProvider
+	}
+	providers := c.GetAiProviders()
+	if len(providers) == 0 {
+		return ""
+	}
+	return providers[0].Name
+}
+
+// Returns the configured keys, jwtSecret or jwtKeyFile add one more named by jwtKeyId or "env" in front of them
+func (c *Configuration) GetSigningKeys() []SigningKeyConfig {
+	res := make([]SigningKeyConfig, 0, len(c.SigningKeys)+1)
+	if c.JwtSecret != "" || c.JwtKeyFile != "" {
+		id := c.JwtKeyID
+		if id == "" {
+			id = "env"
+		}
+		res = append(res, SigningKeyConfig{ID: id, Secret: c.JwtSecret, KeyFile: c.JwtKeyFile})
+	}
+	return append(res, c.SigningKeys...)
+}
+
+// Falls back to the first key when no active key is configured
+func (c *Configuration) GetActiveSigningKey() string {
+	if c.ActiveSigningKey != "" {
+		return c.ActiveSigningKey
+	}
+	keys := c.GetSigningKeys()
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0].ID
+}
+
 func ReadConfigFromEnv(config *Configuration, logger *Logger) *Configuration {
 	c := new(Configuration)
 	c.DbConnectionString = os.Getenv("APP_DB_CONN_STR")
 	c.Version = os.Getenv("APP_VERSION")
 	c.Salt = os.Getenv("APP_PASSWORD_SALT")
+	c.AiBaseUrl = os.Getenv("APP_AI_BASE_URL")
+	c.AiModel = os.Getenv("APP_AI_MODEL")
+	c.AiApiKey = os.Getenv("APP_AI_API_KEY")
+	c.AiDefaultProvider = os.Getenv("APP_AI_DEFAULT_PROVIDER")
+	c.EmbeddingProvider = os.Getenv("APP_EMBEDDING_PROVIDER")
+	c.EmbeddingBaseUrl = os.Getenv("APP_EMBEDDING_BASE_URL")
+	c.EmbeddingModel = os.Getenv("APP_EMBEDDING_MODEL")
+	c.EmbeddingApiKey = os.Getenv("APP_EMBEDDING_API_KEY")
+	c.OcrProvider = os.Getenv("APP_OCR_PROVIDER")
+	c.OcrBinary = os.Getenv("APP_OCR_BINARY")
+	c.JwtSecret = os.Getenv("APP_JWT_SECRET")
+	c.JwtKeyFile = os.Getenv("APP_JWT_KEY_FILE")
+	c.JwtKeyID = os.Getenv("APP_JWT_KEY_ID")
+	c.ActiveSigningKey = os.Getenv("APP_JWT_ACTIVE_KEY")
+	c.MailProvider = os.Getenv("APP_MAIL_PROVIDER")
+	c.MailFrom = os.Getenv("APP_MAIL_FROM")
+	c.SmtpHost = os.Getenv("APP_SMTP_HOST")
+	c.SmtpUsername = os.Getenv("APP_SMTP_USERNAME")
+	c.SmtpPassword = os.Getenv("APP_SMTP_PASSWORD")
+	c.PublicUrl = os.Getenv("APP_PUBLIC_URL")
 	config = copyConfigVals(config, c)
 	return config
 }
@@ -88,6 +248,132 @@ func copyConfigVals(c1 *Configuration, c2 *Configuration) *Configuration {
 	if c2.Salt != "" {
 		c1.Salt = c2.Salt
 	}
+	if len(c2.AcceptedExtensions) > 0 {
+		c1.AcceptedExtensions = c2.AcceptedExtensions
+	}
+	if len(c2.SaveLocations) > 0 {
+		c1.SaveLocations = c2.SaveLocations
+	}
+	if c2.IsAiAssistantEnabled {
+		c1.IsAiAssistantEnabled = c2.IsAiAssistantEnabled
+	}
+	if c2.AiBaseUrl != "" {
+		c1.AiBaseUrl = c2.AiBaseUrl
+	}
+	if c2.AiModel != "" {
+		c1.AiModel = c2.AiModel
+	}
+	if c2.AiApiKey != "" {
+		c1.AiApiKey = c2.AiApiKey
+	}
+	if c2.AiTimeoutSeconds != 0 {
+		c1.AiTimeoutSeconds = c2.AiTimeoutSeconds
+	}
+	if len(c2.AiProviders) > 0 {
+		c1.AiProviders = c2.AiProviders
+	}
+	if c2.AiDefaultProvider != "" {
+		c1.AiDefaultProvider = c2.AiDefaultProvider
+	}
+	if c2.RetrievalChunkSize != 0 {
+		c1.RetrievalChunkSize = c2.RetrievalChunkSize
+	}
+	if c2.RetrievalTopK != 0 {
+		c1.RetrievalTopK = c2.RetrievalTopK
+	}
+	if c2.EmbeddingProvider != "" {
+		c1.EmbeddingProvider = c2.EmbeddingProvider
+	}
+	if c2.EmbeddingBaseUrl != "" {
+		c1.EmbeddingBaseUrl = c2.EmbeddingBaseUrl
+	}
+	if c2.EmbeddingModel != "" {
+		c1.EmbeddingModel = c2.EmbeddingModel
+	}
+	if c2.EmbeddingApiKey != "" {
+		c1.EmbeddingApiKey = c2.EmbeddingApiKey
+	}
+	if c2.EmbeddingBatchSize != 0 {
+		c1.EmbeddingBatchSize = c2.EmbeddingBatchSize
+	}
+	if len(c2.TokenBudgets) > 0 {
+		c1.TokenBudgets = c2.TokenBudgets
+	}
+	if c2.AiContextWindowTokens != 0 {
+		c1.AiContextWindowTokens = c2.AiContextWindowTokens
+	}
+	if c2.OcrProvider != "" {
+		c1.OcrProvider = c2.OcrProvider
+	}
+	if c2.OcrBinary != "" {
+		c1.OcrBinary = c2.OcrBinary
+	}
+	if c2.OcrLanguages != "" {
+		c1.OcrLanguages = c2.OcrLanguages
+	}
+	if c2.ThumbnailSize != 0 {
+		c1.ThumbnailSize = c2.ThumbnailSize
+	}
+	if c2.AccessTokenMinutes != 0 {
+		c1.AccessTokenMinutes = c2.AccessTokenMinutes
+	}
+	if c2.RefreshTokenDays != 0 {
+		c1.RefreshTokenDays = c2.RefreshTokenDays
+	}
+	if c2.Argon2MemoryKiB != 0 {
+		c1.Argon2MemoryKiB = c2.Argon2MemoryKiB
+	}
+	if c2.Argon2Iterations != 0 {
+		c1.Argon2Iterations = c2.Argon2Iterations
+	}
+	if c2.Argon2Parallelism != 0 {
+		c1.Argon2Parallelism = c2.Argon2Parallelism
+	}
+	if len(c2.SigningKeys) > 0 {
+		c1.SigningKeys = c2.SigningKeys
+	}
+	if c2.ActiveSigningKey != "" {
+		c1.ActiveSigningKey = c2.ActiveSigningKey
+	}
+	if c2.JwtSecret != "" {
+		c1.JwtSecret = c2.JwtSecret
+	}
+	if c2.JwtKeyFile != "" {
+		c1.JwtKeyFile = c2.JwtKeyFile
+	}
+	if c2.JwtKeyID != "" {
+		c1.JwtKeyID = c2.JwtKeyID
+	}
+	if c2.MailProvider != "" {
+		c1.MailProvider = c2.MailProvider
+	}
+	if c2.MailFrom != "" {
+		c1.MailFrom = c2.MailFrom
+	}
+	if c2.SmtpHost != "" {
+		c1.SmtpHost = c2.SmtpHost
+	}
+	if c2.SmtpPort != 0 {
+		c1.SmtpPort = c2.SmtpPort
+	}
+	if c2.SmtpUsername != "" {
+		c1.SmtpUsername = c2.SmtpUsername
+	}
+	if c2.SmtpPassword != "" {
+		c1.SmtpPassword = c2.SmtpPassword
+	}
+	if c2.PublicUrl != "" {
+		c1.PublicUrl = c2.PublicUrl
+	}
+	if c2.PasswordMinLength != 0 {
+		c1.PasswordMinLength = c2.PasswordMinLength
+	}
+	if c2.RequireAdminTwoFactor {
+		c1.RequireAdminTwoFactor = c2.RequireAdminTwoFactor
+	}
+	if len(c2.OidcProviders) > 0 {
+		c1.OidcProviders = c2.OidcProviders
+	}
 
 	return c1
 }
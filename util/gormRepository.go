@@ -68,6 +68,18 @@ func (r *GormRepository[T]) Create(val *T) (T, error) {
 	return *val, nil
 }
 
+func (r *GormRepository[T]) CreateMany(vals []T) ([]T, error) {
+	if len(vals) == 0 {
+		return vals, nil
+	}
+	res := r.db.CreateInBatches(&vals, 100)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+
+	return vals, nil
+}
+
 func (r *GormRepository[T]) Delete(id string) error {
 	var temp T
 	res := r.db.Select(clause.Associations).Delete(&temp, id)
@@ -78,6 +90,16 @@ func (r *GormRepository[T]) Delete(id string) error {
 	return nil
 }
 
+func (r *GormRepository[T]) DeleteWhere(query string, args ...any) error {
+	var temp T
+	res := r.db.Where(query, args...).Delete(&temp)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	return nil
+}
+
 func (r *GormRepository[T]) Update(val *T) (T, error) {
 	res := r.db.Save(val)
 	if res.Error != nil {
@@ -87,6 +109,14 @@ func (r *GormRepository[T]) Update(val *T) (T, error) {
 	return *val, nil
 }
 
+func (r *GormRepository[T]) UpdateColumn(column string, value any) (int64, error) {
+	res := r.db.Update(column, value)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
 func (r *GormRepository[T]) Count() (int64, error) {
 	var count int64
 	res := r.db.Count(&count)
@@ -107,7 +137,9 @@ func (r *GormRepository[T]) Limit(limit int) Repository[T] {
 	return r
 }
 func (r *GormRepository[T]) Order(args ...any) Repository[T] {
-	r.db = r.db.Order(args)
+	for _, v := range args {
+		r.db = r.db.Order(v)
+	}
 	return r
 }
 func (r *GormRepository[T]) Clauses(conds ...clause.Expression) Repository[T] {
@@ -12,19 +12,117 @@ type Logger struct {
 }
 
 var defaultErrorMap = map[string]string{
-	"argumentError":                  "An argument given to this functionality has either missing or wrong.",
-	"passwordIncorrect":              "Entered password is not correct.",
-	"argumentErrorMissing":           "An argument is missing from the call",
-	"argumentErrorUnknownStartPoint": "Given start point is not implemented or possible.",
-	"ioErrorReadWriteMismatch":       "Read byte count is not matching written byte count",
-	"notImplementedOwnerType":        "Given owner type is not implemented",
-	"argumentErrorKeyEmpty":          "The argument \"Key\" is missing from the call",
-	"argumentErrorKeyNotFound":       "The given key is not found.",
-	"argumentErrorIDNotFound":        "The given id is not found",
-	"argumentErrorMissingFromID":     "The language id for \"From\" is missing from the call",
-	"argumentErrorNote":              "Note argument is missing from the call",
-	"argumentErrorLanguage":          "Language argument is missing from the call",
-	"configNotLoadedProperly":        "App config is not read or loaded correctly.\n Terminating",
+	"argumentError":                       "An argument given to this functionality has either missing or wrong.",
+	"passwordIncorrect":                   "Entered password is not correct.",
+	"argumentErrorMissing":                "An argument is missing from the call",
+	"argumentErrorUnknownStartPoint":      "Given start point is not implemented or possible.",
+	"ioErrorReadWriteMismatch":            "Read byte count is not matching written byte count",
+	"notImplementedOwnerType":             "Given owner type is not implemented",
+	"argumentErrorKeyEmpty":               "The argument \"Key\" is missing from the call",
+	"argumentErrorKeyNotFound":            "The given key is not found.",
+	"argumentErrorIDNotFound":             "The given id is not found",
+	"argumentErrorMissingFromID":          "The language id for \"From\" is missing from the call",
+	"argumentErrorNote":                   "Note argument is missing from the call",
+	"argumentErrorLanguage":               "Language argument is missing from the call",
+	"configNotLoadedProperly":             "App config is not read or loaded correctly.\n Terminating",
+	"configErrorUnknownEmbeddingProvider": "Configured embedding provider is not supported, use local, openai or ollama.",
+	"argumentErrorReplyInProgress":        "A reply is still being written, wait for it before sending another message.",
+	"argumentErrorUnknownEventType":       "The given websocket event type is not supported.",
+	"aiErrorNotConfigured":                "AI assistant is enabled but its base url or model is not configured.",
+	"aiErrorUnknownProvider":              "The given AI provider is not configured.",
+	"aiErrorUnknownModel":                 "The given model is not offered by the AI provider.",
+	"aiErrorUnknownProviderType":          "Configured AI provider type is not supported, use openai, anthropic or ollama.",
+	"aiErrorContextNotFound":              "The given context has no conversation with the AI assistant.",
+	"aiErrorProviderUnreachable":          "AI provider could not be reached.",
+	"aiErrorProviderUnauthorized":         "AI provider rejected the configured credentials.",
+	"aiErrorProviderRateLimited":          "AI provider is rate limiting the requests.",
+	"aiErrorProviderRejected":             "AI provider rejected the request.",
+	"aiErrorProviderInvalidResponse":      "AI provider returned a response that could not be understood.",
+	"vectorErrorUnsupportedType":          "Stored vector could not be read.",
+	"argumentErrorUnsupportedExtension":   "Uploaded file type is not accepted.",
+	"extractionErrorUnsupportedExtension": "Text cannot be extracted from the given file type.",
+	"documentErrorTooLarge":               "Uploaded file is too large.",
+	"extractionErrorInvalidDocument":      "Uploaded file is damaged or does not match its extension.",
+	"extractionErrorInvalidEncoding":      "Uploaded text file is not UTF-8 encoded.",
+	"budgetErrorDailyExceeded":            "Daily AI token budget of your role is used up, try again tomorrow.",
+	"budgetErrorMonthlyExceeded":          "Monthly AI token budget of your role is used up, try again next month.",
+	"templateErrorUnknownName":            "There is no prompt template with the given name.",
+	"templateErrorInvalidBody":            "Prompt template body is not a valid template or refers to unknown fields.",
+	"argumentErrorUnknownResetMode":       "Reset mode must be either soft or hard.",
+	"argumentErrorInvalidGrade":           "Review grade must be between 0 and 5.",
+	"argumentErrorUnknownSourceType":      "Source type must be either note or document.",
+	"argumentErrorSourceOutsideContext":   "Given note or document belongs to another context.",
+	"flashcardErrorInvalidReply":          "Assistant did not answer with flashcards, try again.",
+	"quizErrorNoMaterial":                 "Context has no notes or documents to make a quiz from.",
+	"quizErrorInvalidReply":               "Assistant did not answer with questions or a grade, try again.",
+	"argumentErrorUnknownQuestion":        "Answer was given to a question that is not part of the quiz.",
+	"stringListErrorUnsupportedType":      "Stored list could not be read.",
+	"equationErrorEmpty":                  "Equation has no LaTeX.",
+	"equationErrorUnbalancedBraces":       "Equation has a brace without its pair.",
+	"equationErrorUnbalancedDelimiters":   "Equation has a \\left without its \\right or the other way around.",
+	"equationErrorUnbalancedEnvironment":  "Equation has a \\begin without its matching \\end or the other way around.",
+	"equationErrorUnknownEnvironment":     "Equation uses an environment other than matrix, pmatrix, bmatrix, Bmatrix, vmatrix, Vmatrix, cases, aligned, align or gathered.",
+	"equationErrorUnknownCommand":         "Equation uses an unknown or unsupported LaTeX command.",
+	"equationErrorUnexpectedSymbol":       "Equation has a symbol that is not valid in LaTeX math.",
+	"equationErrorMissingArgument":        "Equation has a command or script without its argument.",
+	"equationErrorDoubleScript":           "Equation has two subscripts or two superscripts on the same symbol.",
+	"equationErrorTooDeep":                "Equation nests groups, scripts or commands too deeply.",
+	"equationErrorTooLong":                "Equation is too long.",
+	"equationErrorMisplacedAlignment":     "Equation uses & or \\\\ outside of an environment.",
+	"imageErrorUnsupportedExtension":      "Image format is not supported, use JPEG, PNG or GIF.",
+	"imageErrorInvalidImage":              "Image could not be read, the file may be damaged.",
+	"imageErrorTooLarge":                  "Image has too many pixels to be processed.",
+	"imageErrorNoThumbnail":               "Document is not an image, it has no thumbnail.",
+	"ocrErrorFailed":                      "Text in the image could not be recognized.",
+	"configErrorUnknownOcrProvider":       "Configured OCR provider is unknown, use tesseract or none.",
+	"tokenErrorClaimsNotValid":            "Token is missing claims or they are not valid.",
+	"tokenErrorRevoked":                   "Token has been revoked, log in again.",
+	"tokenErrorRefreshInvalid":            "Refresh token is not known.",
+	"tokenErrorRefreshExpired":            "Refresh token has expired, log in again.",
+	"tokenErrorRefreshReused":             "Refresh token was already used, the session has been ended for safety.",
+	"hashingErrorInvalidHash":             "Stored password hash is damaged.",
+	"tokenErrorUnknownKey":                "Token is signed with a key that is not known or was rotated out.",
+	"tokenErrorUnexpectedAlgorithm":       "Token is signed with an algorithm other than the one of its key.",
+	"configErrorInvalidSigningKey":        "Configured signing key could not be read, use an HS256 secret or an RSA or P-256 PEM key.",
+	"configErrorWeakSigningKey":           "Configured signing key is too weak, secrets need 32 characters and RSA keys 2048 bits.",
+	"configErrorDuplicateSigningKey":      "Two configured signing keys have the same id.",
+	"configErrorUnknownSigningKey":        "Active signing key is not one of the configured keys.",
+	"configErrorSigningKeyCannotSign":     "Active signing key is a public key, it can only verify tokens.",
+	"userErrorInvalidEmail":               "Email address is not valid.",
+	"userErrorEmailTaken":                 "An account with this email address already exists.",
+	"passwordErrorTooShort":               "Password is too short.",
+	"passwordErrorTooLong":                "Password is too long, use at most 256 characters.",
+	"passwordErrorTooWeak":                "Password needs at least three of lowercase letters, uppercase letters, digits and symbols, or has to be a passphrase of 20 characters.",
+	"passwordErrorContainsPersonalInfo":   "Password must not contain your email address or name.",
+	"accountErrorTokenInvalid":            "Link is not valid or was already used.",
+	"accountErrorTokenExpired":            "Link has expired, request a new one.",
+	"mailErrorInvalidAddress":             "Mail address could not be read.",
+	"mailErrorInvalidHeader":              "Mail subject must be a single line.",
+	"mailErrorDeliveryFailed":             "Mail could not be delivered, try again later.",
+	"configErrorUnknownMailProvider":      "Configured mail provider is unknown, use smtp or log.",
+	"configErrorMailNotConfigured":        "SMTP mail needs smtpHost and mailFrom to be configured.",
+	"twoFactorErrorInvalidCode":           "Code is wrong or was already used.",
+	"twoFactorErrorNotEnrolled":           "Two-factor authentication is not set up.",
+	"twoFactorErrorAlreadyEnabled":        "Two-factor authentication is already turned on, turn it off to set it up again.",
+	"twoFactorErrorLocked":                "Too many wrong codes, try again in 15 minutes.",
+	"twoFactorErrorRequired":              "Two-factor authentication is required for admins and can not be turned off.",
+	"tokenErrorChallengeInvalid":          "Login challenge is not valid or has expired, log in again.",
+	"oidcErrorUnknownProvider":            "Login provider is not known.",
+	"oidcErrorProviderUnreachable":        "Login provider could not be reached, try again later.",
+	"oidcErrorDenied":                     "Login was cancelled at the provider.",
+	"oidcErrorStateInvalid":               "Login has expired or was already finished, start it again.",
+	"oidcErrorExchangeFailed":             "Login provider did not accept the login, start it again.",
+	"oidcErrorInvalidIdToken":             "Login provider sent an identity that could not be verified.",
+	"oidcErrorEmailMissing":               "Login provider did not share an email address.",
+	"oidcErrorEmailNotVerified":           "Email address is not verified at the login provider.",
+	"oidcErrorAccountNotVerified":         "An account with this email exists but its email is not verified, confirm it with the link of the verification mail first.",
+	"apiKeyErrorNameRequired":             "API key needs a name.",
+	"apiKeyErrorInvalidScope":             "Scopes have to be a resource and an access like \"notes:write\" or \"documents:read\".",
+	"apiKeyErrorLimitReached":             "Too many API keys, revoke one that is no longer used.",
+	"apiKeyErrorNotFound":                 "API key does not exist.",
+	"apiKeyErrorInvalid":                  "API key is not valid.",
+	"citationErrorSourceChanged":          "Cited note or document has changed since it was cited.",
+	"citationErrorUnknownSource":          "Citation points at an unknown kind of source.",
 }
 
 func NewLogger(errorMap map[string]string, w io.Writer) *Logger {
@@ -41,6 +139,10 @@ func (l *Logger) Debug() *zerolog.Event {
 	return l.logger.Debug()
 }
 
+func (l *Logger) Warn() *zerolog.Event {
+	return l.logger.Warn()
+}
+
 func (l *Logger) Error() *zerolog.Event {
 	return l.logger.Error()
 }
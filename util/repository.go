@@ -10,8 +10,12 @@ type Repository[T any] interface {
 	Count() (int64, error)
 
 	Create(val *T) (T, error)
+	CreateMany(vals []T) ([]T, error)
 	Update(val *T) (T, error)
 	Delete(id string) error
+	DeleteWhere(query string, args ...any) error
+	// Sets the column on every row matching the where clauses in one statement and returns how many were changed
+	UpdateColumn(column string, value any) (int64, error)
 
 	Where(query string, args ...any) Repository[T]
 	Offset(offset int) Repository[T]
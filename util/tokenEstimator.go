@@ -0,0 +1,28 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Every message of a chat costs a few tokens for its role and separators
+const messageTokenOverhead = 4
+
+// Rough token count of a text without the tokenizer of a provider, about four characters per token but at least one per word
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	byRunes := (utf8.RuneCountInString(text) + 3) / 4
+	byWords := len(strings.Fields(text))
+	return max(byRunes, byWords)
+}
+
+// Estimate of the texts sent as separate chat messages
+func EstimateMessageTokens(texts ...string) int {
+	res := 0
+	for _, text := range texts {
+		res += EstimateTokens(text) + messageTokenOverhead
+	}
+	return res
+}